@@ -1,21 +1,54 @@
 package main
 
 import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
 	"io"
 	"log"
+	"math"
+	mathrand "math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path"
+	"path/filepath"
 	"regexp"
+	"runtime/debug"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
+	"golang.org/x/crypto/nacl/secretbox"
 )
 
 // AutoReply represents a single auto-reply rule
@@ -23,6 +56,77 @@ type AutoReply struct {
 	Trigger  string `json:"trigger"`
 	Response string `json:"response"`
 	AuthorID string `json:"author_id,omitempty"`
+
+	// Usage, for /replies audit
+	CreatedAt time.Time `json:"created_at"`
+	LastFired time.Time `json:"last_fired"`
+	FireCount int       `json:"fire_count,omitempty"`
+
+	History []ReplyVersion `json:"history,omitempty"` // previous responses, oldest first
+
+	// Responses are alternatives to Response added with /reply append. When a rule
+	// fires, one of Response and Responses is picked at random.
+	Responses []string `json:"responses,omitempty"`
+
+	ChannelIDs []string `json:"channel_ids,omitempty"` // channels the rule fires in, every channel when empty
+
+	// RoleIDs and UserIDs limit who the rule fires for: members with one of the roles or
+	// on the list, everyone when both are empty. IgnoredUserIDs never set it off.
+	RoleIDs        []string `json:"role_ids,omitempty"`
+	UserIDs        []string `json:"user_ids,omitempty"`
+	IgnoredUserIDs []string `json:"ignored_user_ids,omitempty"`
+
+	// Cooldown is how many seconds a message text rule waits before firing again in the
+	// server: 0 for defaultReplyCooldown, -1 for no cooldown
+	Cooldown int `json:"cooldown,omitempty"`
+
+	// ResponseType is "" to reply with the response, "reaction" to react to the
+	// triggering message with the emoji in the response instead, or "dm" to send the
+	// response to the author privately, in the channel when their DMs are closed
+	ResponseType string `json:"response_type,omitempty"`
+
+	Attachments []ReplyAttachment `json:"attachments,omitempty"` // sent with the response
+
+	// Match is what the trigger is looked for in: "" for the message text, "nickname" for
+	// the author's nickname or "role" for their role names. Nickname and role rules fire
+	// once per member, who are then remembered in Greeted (up to maxGreetedMembers).
+	Match   string   `json:"match,omitempty"`
+	Greeted []string `json:"greeted,omitempty"`
+
+	// Regex rules match Trigger as a regular expression anywhere in the message text,
+	// ignoring case unless CaseSensitive is set. pattern is compiled on load and save.
+	Regex         bool `json:"regex,omitempty"`
+	CaseSensitive bool `json:"case_sensitive,omitempty"`
+	pattern       *regexp.Regexp
+
+	// ExpiresAt removes the rule once it passes, zero for rules that never expire.
+	// ExpiryDM tells the author when that happens.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	ExpiryDM  bool      `json:"expiry_dm,omitempty"`
+
+	// DeleteTrigger deletes the triggering message once the rule replied, for rules that
+	// moderate banned phrases. Only moderators can turn it on.
+	DeleteTrigger bool `json:"delete_trigger,omitempty"`
+}
+
+// expired reports whether the rule's expiry has passed
+func (rule AutoReply) expired(now time.Time) bool {
+	return !rule.ExpiresAt.IsZero() && !now.Before(rule.ExpiresAt)
+}
+
+// ReplyAttachment is an image or file sent with an auto-reply: a file uploaded with /reply
+// and kept under replyMediaDir, or a link to media elsewhere
+type ReplyAttachment struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"` // stored file, uploaded with the reply
+	URL  string `json:"url,omitempty"`  // linked in the reply instead
+}
+
+// ReplyVersion is a response an auto-reply rule had before it was edited
+type ReplyVersion struct {
+	Response string    `json:"response"`
+	EditedBy string    `json:"edited_by"` // who replaced it
+	EditedAt time.Time `json:"edited_at"`
 }
 
 // RSS feed structures
@@ -38,10 +142,30 @@ type Channel struct {
 }
 
 type Item struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string `xml:"title" json:"title"`
+	Link        string `xml:"link" json:"link"`
+	Description string `xml:"description" json:"description,omitempty"`
+	PubDate     string `xml:"pubDate" json:"pub_date,omitempty"`
+
+	// Podcast episode fields
+	Enclosure *Enclosure `xml:"enclosure" json:"enclosure,omitempty"`
+	Duration  string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration" json:"duration,omitempty"`
+	Summary   string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary" json:"summary,omitempty"`
+
+	// Earthquake fields
+	Magnitude float64 `xml:"-" json:"magnitude,omitempty"`
+
+	// Free game fields
+	EndsAt time.Time `xml:"-" json:"ends_at,omitempty"`
+	Image  string    `xml:"-" json:"image,omitempty"`
+	Worth  string    `xml:"-" json:"worth,omitempty"`
+}
+
+// Enclosure is the media file attached to an RSS item, e.g. a podcast episode's audio
+type Enclosure struct {
+	URL    string `xml:"url,attr" json:"url,omitempty"`
+	Type   string `xml:"type,attr" json:"type,omitempty"`
+	Length int64  `xml:"length,attr" json:"length,omitempty"`
 }
 
 // Currency conversion response structure
@@ -50,6 +174,8 @@ type CurrencyResponse struct {
 	Query   CurrencyQuery `json:"query"`
 	Info    CurrencyInfo  `json:"info"`
 	Result  float64       `json:"result"`
+
+	Provider string `json:"provider,omitempty"` // where the rate came from, shown in the embed footer
 }
 
 type CurrencyQuery struct {
@@ -66,1036 +192,22786 @@ type CurrencyInfo struct {
 // ServerAutoReplies stores auto-reply rules per server
 type ServerAutoReplies map[string][]AutoReply // map[guildID][]AutoReply
 
-const (
-	dataFile   = "auto_replies.json"
-	embedColor = 0x00ff00
-)
+// PendingReply is an auto-reply rule waiting for a moderator's approval
+type PendingReply struct {
+	GuildID     string    `json:"guild_id"`
+	ChannelID   string    `json:"channel_id"` // review channel the approval message is in
+	Rule        AutoReply `json:"rule"`
+	AuthorID    string    `json:"author_id"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
 
-var (
-	serverAutoReplies ServerAutoReplies
-	session           *discordgo.Session
-)
+// PendingReplies stores rules awaiting review by the ID of their approval message
+type PendingReplies map[string]PendingReply // map[messageID]PendingReply
 
-// containsWholeWord checks if the trigger exists as a whole word in the message
-func containsWholeWord(message, trigger string) bool {
-	words := strings.Fields(message)
-	for _, word := range words {
-		// Remove common punctuation from the word
-		cleanWord := strings.Trim(word, ".,!?;:\"'()[]{}*")
-		if cleanWord == trigger {
-			return true
-		}
-	}
-	return false
+// replyDraft holds a /reply rule whose preview is waiting for Confirm
+type replyDraft struct {
+	GuildID   string
+	UserID    string
+	Rule      AutoReply
+	CreatedAt time.Time
 }
 
-// RSS topic mapping based on Investing.com RSS structure
-var rssTopics = map[string]string{
-	"ringkasan pasar":      "https://id.investing.com/rss/news_25.rss",
-	"analisis teknikal":    "https://id.investing.com/rss/news_25.rss",
-	"analisis fundamental": "https://id.investing.com/rss/news_25.rss",
-	"opini":                "https://id.investing.com/rss/news_25.rss",
-	"ide investasi":        "https://id.investing.com/rss/news_25.rss",
-	"mata uang kripto":     "https://id.investing.com/rss/news_301.rss",
-	"forex":                "https://id.investing.com/rss/news_1.rss",
-	"saham":                "https://id.investing.com/rss/news_25.rss",
-	"komoditas":            "https://id.investing.com/rss/news_49.rss",
-	"berita":               "https://id.investing.com/rss/news.rss",
-	"breaking news":        "https://id.investing.com/rss/news.rss",
+// replyPurge holds the rules a /reply_purge preview is waiting to remove
+type replyPurge struct {
+	GuildID   string
+	UserID    string
+	Triggers  []string
+	CreatedAt time.Time
 }
 
-// fetchRSSFeed fetches and parses RSS feed from the given URL
-func fetchRSSFeed(url string) (*RSS, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+// GuildSettings holds per-server bot configuration
+type GuildSettings struct {
+	PrefixCommands  bool     `json:"prefix_commands,omitempty"`
+	Timezone        string   `json:"timezone,omitempty"`         // IANA name or shorthand, WIB when empty
+	QuietStart      string   `json:"quiet_start,omitempty"`      // HH:MM in the server timezone, empty when quiet hours are off
+	QuietEnd        string   `json:"quiet_end,omitempty"`        // HH:MM in the server timezone
+	DefaultCurrency string   `json:"default_currency,omitempty"` // /convert target when none is given
+	AutoReplies     bool     `json:"auto_replies,omitempty"`     // /reply rules are created and fired
+	MaxReplies      int      `json:"max_replies,omitempty"`      // rule cap, defaultMaxReplies when 0
+	ReplyApproval   bool     `json:"reply_approval,omitempty"`   // new rules wait for a moderator in ReviewChannel
+	ReviewChannel   string   `json:"review_channel,omitempty"`
+	ReplyRoles      []string `json:"reply_roles,omitempty"`     // roles that may create rules, everyone when empty
+	ProfanityMode   string   `json:"profanity_mode,omitempty"`  // reject (default), mask or off
+	ProfanityWords  []string `json:"profanity_words,omitempty"` // added to the built-in lists
+	OCRChannels     []string `json:"ocr_channels,omitempty"`    // where image text is matched against auto-replies
+	Shortener       string   `json:"shortener,omitempty"`       // isgd (default), bitly or shlink
+	ShortenerURL    string   `json:"shortener_url,omitempty"`   // base URL of a self-hosted Shlink
+	Slang           bool     `json:"slang,omitempty"`           // /slang outside age-restricted channels
+	Search          bool     `json:"search,omitempty"`          // /search is available
+	SafeSearch      string   `json:"safe_search,omitempty"`     // strict, moderate (default) or off
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch RSS feed: %v", err)
-	}
-	defer resp.Body.Close()
+	LongWeekendChannel  string `json:"long_weekend_channel,omitempty"`  // where upcoming long weekends are announced
+	LongWeekendNotified string `json:"long_weekend_notified,omitempty"` // start date of the last one announced
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
-	}
+	TriviaChannel  string `json:"trivia_channel,omitempty"`  // where the weekly quiz starts
+	TriviaWeekday  int    `json:"trivia_weekday,omitempty"`  // time.Weekday of the weekly quiz
+	TriviaHour     int    `json:"trivia_hour,omitempty"`     // local hour of the weekly quiz
+	TriviaCategory string `json:"trivia_category,omitempty"` // empty for any category
+	TriviaLastRun  string `json:"trivia_last_run,omitempty"` // local date of the last weekly quiz
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
+	WordChannel string `json:"word_channel,omitempty"` // where the word of the day is posted
+	WordHour    int    `json:"word_hour,omitempty"`    // local hour of the daily post
+	WordPosted  string `json:"word_posted,omitempty"`  // local date of the last post
 
-	var rss RSS
-	err = xml.Unmarshal(body, &rss)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse XML: %v", err)
-	}
+	StandupChannel  string    `json:"standup_channel,omitempty"`  // where the daily standup prompt is posted
+	StandupHour     int       `json:"standup_hour,omitempty"`     // local hour of the prompt
+	StandupWindow   int       `json:"standup_window,omitempty"`   // hours answers are collected
+	StandupWeekdays bool      `json:"standup_weekdays,omitempty"` // skip Saturday and Sunday
+	StandupPosted   string    `json:"standup_posted,omitempty"`   // local date of the last prompt
+	StandupThread   string    `json:"standup_thread,omitempty"`   // thread still collecting answers
+	StandupCloses   time.Time `json:"standup_closes,omitempty"`   // when that thread is summarized
 
-	return &rss, nil
-}
+	ConfessChannel       string `json:"confess_channel,omitempty"`        // where /confess posts, empty when off
+	ConfessReviewChannel string `json:"confess_review_channel,omitempty"` // where moderators approve them first, if set
 
-// convertCurrency converts an amount from one currency to another using exchangerate-api.com
-func convertCurrency(amount float64, from, to string) (*CurrencyResponse, error) {
-	// Convert currency codes to uppercase for API
-	from = strings.ToUpper(from)
-	to = strings.ToUpper(to)
+	FAQAutoAnswer bool `json:"faq_auto_answer,omitempty"` // question-like messages get the closest FAQ entry
 
-	apiKey := os.Getenv("EXCHANGERATE_API_KEY") // Not used in this example, but can be set for paid plans
+	Retention []RetentionPolicy `json:"retention,omitempty"` // channels whose old messages are cleaned up
 
-	// Use exchangerate-api.com free tier (no API key required for basic usage)
-	// url := fmt.Sprintf("https://api.exchangerate-api.com/v4/latest/%s", from)
+	Onboarding        bool   `json:"onboarding,omitempty"`         // new members get the checklist
+	OnboardingChannel string `json:"onboarding_channel,omitempty"` // where it's posted, by DM when empty
+	OnboardingMessage string `json:"onboarding_message,omitempty"` // shown above the steps
+	RulesChannel      string `json:"rules_channel,omitempty"`
+	RolesChannel      string `json:"roles_channel,omitempty"`
+	IntroChannel      string `json:"intro_channel,omitempty"`
+	IntroThreads      bool   `json:"intro_threads,omitempty"` // introductions get a thread and become profiles
+	IntroEmoji        string `json:"intro_emoji,omitempty"`   // reaction on introductions, defaultIntroEmoji when empty
 
-	//example request Example Request: https://v6.exchangerate-api.com/v6/cb11520a7b456009f84a5da1/latest/USD
-	url := fmt.Sprintf("https://v6.exchangerate-api.com/v6/%s/latest/%s", apiKey, from)
+	Decancer bool `json:"decancer,omitempty"` // unreadable or hoisted nicknames are fixed on join
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	Watchwords []string `json:"watchwords,omitempty"`  // lowercase words and phrases that alert moderators
+	ModChannel string   `json:"mod_channel,omitempty"` // where watchword alerts go
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch exchange rates: %v", err)
-	}
-	defer resp.Body.Close()
+	NoCommandChannels []string `json:"no_command_channels,omitempty"` // where members can't use the bot's commands
+	BotChannel        string   `json:"bot_channel,omitempty"`         // where members are pointed to run them
+	RedirectOutput    bool     `json:"redirect_output,omitempty"`     // conversions and fun commands post in BotChannel
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
-	}
+	NoReplyChannels []string `json:"no_reply_channels,omitempty"` // where auto-replies never fire, whatever the rules say
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
+	MusicChannels []string `json:"music_channels,omitempty"` // where Spotify and Apple Music links get a song.link card
+}
 
-	// Parse the response
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
-	}
+// RetentionPolicy deletes a channel's messages once they are older than Days. With an
+// ArchiveChannel, they are posted there as a text file first, once a month.
+type RetentionPolicy struct {
+	ChannelID      string `json:"channel_id"`
+	Days           int    `json:"days"`
+	ArchiveChannel string `json:"archive_channel,omitempty"`
+	LastRun        string `json:"last_run,omitempty"` // local date the policy last ran
+}
 
-	// Check if the request was successful
-	result, ok := response["result"].(string)
-	if !ok || result != "success" {
-		return nil, fmt.Errorf("API request failed: %v", response)
-	}
+// ServerSettings stores settings per server
+type ServerSettings map[string]*GuildSettings // map[guildID]*GuildSettings
 
-	// Get conversion rates from the response
-	conversionRates, ok := response["conversion_rates"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response format: conversion_rates not found")
-	}
+// StoredSecret is an API key encrypted with the master key, with a masked form for display
+type StoredSecret struct {
+	Value  string    `json:"value"`  // base64 nonce and secretbox
+	Masked string    `json:"masked"` // e.g. "••••••••abcd"
+	SetBy  string    `json:"set_by"`
+	SetAt  time.Time `json:"set_at"`
+}
 
-	rate, ok := conversionRates[to].(float64)
-	if !ok {
-		return nil, fmt.Errorf("currency %s not found", to)
-	}
+// ServerSecrets stores encrypted API keys per server
+type ServerSecrets map[string]map[string]StoredSecret // map[guildID]map[service]StoredSecret
 
-	convertedAmount := amount * rate
+// APIToken authenticates inbound API calls for one server; only its hash is stored
+type APIToken struct {
+	Hash      string    `json:"hash"`   // hex SHA-256 of the token
+	Masked    string    `json:"masked"` // e.g. "••••••••abcd"
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
 
-	return &CurrencyResponse{
-		Success: true,
-		Query: CurrencyQuery{
-			From:   from,
-			To:     to,
-			Amount: amount,
-		},
-		Info: CurrencyInfo{
-			Timestamp: time.Now().Unix(),
-			Rate:      rate,
-		},
-		Result: convertedAmount,
-	}, nil
+// ServerAPITokens stores the inbound API token per server
+type ServerAPITokens map[string]APIToken // map[guildID]APIToken
+
+// AuditEntry records one use of a privileged command
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	UserID  string    `json:"user_id"`
+	User    string    `json:"user"`
+	Command string    `json:"command"` // e.g. "/settings apikey set"
+	Options string    `json:"options,omitempty"`
+	Result  string    `json:"result"`
 }
 
-// parseCurrencyInput parses currency conversion input like "$500 idr" or "500jpy idr"
-func parseCurrencyInput(input string) (amount float64, from, to string, err error) {
-	// Remove extra spaces and convert to lowercase
-	input = strings.TrimSpace(strings.ToLower(input))
+// ServerAuditLogs stores the privileged command audit trail per server, oldest first
+type ServerAuditLogs map[string][]AuditEntry // map[guildID][]AuditEntry
 
-	// Split by spaces
-	parts := strings.Fields(input)
-	if len(parts) != 2 {
-		return 0, "", "", fmt.Errorf("invalid format. Use format like '$500 idr' or '500jpy idr'")
-	}
+// BlockEntry records why and by whom a user or server was blocked
+type BlockEntry struct {
+	Reason  string    `json:"reason,omitempty"`
+	AddedBy string    `json:"added_by"`
+	AddedAt time.Time `json:"added_at"`
+}
 
-	fromPart := parts[0]
-	to = parts[1]
+// Blocklist holds the bot-wide blocked users and servers, managed by the bot owners
+type Blocklist struct {
+	Users  map[string]BlockEntry `json:"users"`  // map[userID]BlockEntry
+	Guilds map[string]BlockEntry `json:"guilds"` // map[guildID]BlockEntry
+}
 
-	// Handle different formats for the amount and source currency
-	var amountStr string
+// CustomCommand is an admin-defined slash command registered only in one server
+type CustomCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Response    string `json:"response"`
+	CommandID   string `json:"command_id"`
+	AuthorID    string `json:"author_id,omitempty"`
+}
 
-	// Check if it starts with a currency symbol
-	if strings.HasPrefix(fromPart, "$") {
-		from = "usd"
-		amountStr = strings.TrimPrefix(fromPart, "$")
-	} else if strings.HasPrefix(fromPart, "€") || strings.HasPrefix(fromPart, "eur") {
-		from = "eur"
-		amountStr = strings.TrimPrefix(fromPart, "€")
-		amountStr = strings.TrimPrefix(amountStr, "eur")
-	} else if strings.HasPrefix(fromPart, "£") || strings.HasPrefix(fromPart, "gbp") {
-		from = "gbp"
-		amountStr = strings.TrimPrefix(fromPart, "£")
-		amountStr = strings.TrimPrefix(amountStr, "gbp")
-	} else if strings.HasPrefix(fromPart, "¥") || strings.HasPrefix(fromPart, "jpy") {
-		from = "jpy"
-		amountStr = strings.TrimPrefix(fromPart, "¥")
-		amountStr = strings.TrimPrefix(amountStr, "jpy")
-	} else {
-		// Try to extract currency code from the end
-		re := regexp.MustCompile(`^(\d+(?:\.\d+)?)(.*?)$`)
-		matches := re.FindStringSubmatch(fromPart)
-		if len(matches) != 3 {
-			return 0, "", "", fmt.Errorf("invalid amount format")
-		}
-		amountStr = matches[1]
-		currencyCode := strings.TrimSpace(matches[2])
-		if currencyCode == "" {
-			return 0, "", "", fmt.Errorf("source currency not specified")
-		}
-		from = currencyCode
-	}
+// ServerCustomCommands stores custom slash commands per server
+type ServerCustomCommands map[string][]CustomCommand // map[guildID][]CustomCommand
 
-	// Parse the amount
-	amount, err = strconv.ParseFloat(amountStr, 64)
-	if err != nil {
-		return 0, "", "", fmt.Errorf("invalid amount: %s", amountStr)
-	}
+// Confession is an anonymous /confess message. Its author is kept for abuse handling
+// and only shown to server managers through /confessions author.
+type Confession struct {
+	Number      int       `json:"number"`
+	AuthorID    string    `json:"author_id"`
+	Text        string    `json:"text"`
+	Status      string    `json:"status"`               // pending, approved, posted or denied
+	MessageID   string    `json:"message_id,omitempty"` // review message while pending, then the posted one
+	ReviewedBy  string    `json:"reviewed_by,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
 
-	if amount <= 0 {
-		return 0, "", "", fmt.Errorf("amount must be positive")
-	}
+// ServerConfessions stores confessions per server, oldest first
+type ServerConfessions map[string][]Confession // map[guildID][]Confession
 
-	return amount, from, to, nil
+// OnboardingProgress is how far a new member got through the onboarding checklist
+type OnboardingProgress struct {
+	Step        int       `json:"step"` // steps done
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"` // zero until the last step is done
 }
 
-// loadAutoReplies loads auto-reply rules from JSON file
-func loadAutoReplies() {
-	serverAutoReplies = make(ServerAutoReplies)
+// Profile is a member's latest post in the introductions channel, shown by /profile
+type Profile struct {
+	Intro     string    `json:"intro"`
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	PostedAt  time.Time `json:"posted_at"`
+}
 
-	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
-		return
-	}
+// ServerProfiles stores profiles per server and member
+type ServerProfiles map[string]map[string]Profile // map[guildID]map[userID]Profile
 
-	data, err := os.ReadFile(dataFile)
-	if err != nil {
-		log.Printf("Error reading auto-replies file: %v", err)
-		return
-	}
+// Reminder is a /remind reminder, sent once or repeating
+type Reminder struct {
+	ID        int         `json:"id"`
+	UserID    string      `json:"user_id"`
+	GuildID   string      `json:"guild_id,omitempty"` // empty for reminders set in DMs
+	ChannelID string      `json:"channel_id"`         // where it is delivered, the channel it was set in
+	Message   string      `json:"message"`
+	NextAt    time.Time   `json:"next_at"`
+	Every     *Recurrence `json:"every,omitempty"` // nil for one-time reminders
+	CreatedAt time.Time   `json:"created_at"`
+	// DeliveredAt is set once a one-time reminder has been sent; it stays around for a
+	// day so it can still be snoozed
+	DeliveredAt time.Time `json:"delivered_at,omitempty"`
+}
 
-	if err := json.Unmarshal(data, &serverAutoReplies); err != nil {
-		log.Printf("Error parsing auto-replies file: %v", err)
-		return
-	}
+// waiting reports whether the reminder has yet to be sent
+func (r Reminder) waiting() bool {
+	return r.DeliveredAt.IsZero()
+}
 
-	totalRules := 0
-	for _, replies := range serverAutoReplies {
-		totalRules += len(replies)
-	}
-	log.Printf("Loaded %d auto-reply rules across %d servers", totalRules, len(serverAutoReplies))
+// Recurrence repeats a reminder at a local time of day: every day, every weekday, on a
+// day of the week or on a day of the month
+type Recurrence struct {
+	Unit    string       `json:"unit"` // day, weekday, week or month
+	Weekday time.Weekday `json:"weekday,omitempty"`
+	Day     int          `json:"day,omitempty"` // day of the month, the last day in shorter months
+	Minute  int          `json:"minute"`        // minutes after midnight
 }
 
-// saveAutoReplies saves auto-reply rules to JSON file
-func saveAutoReplies() {
-	data, err := json.MarshalIndent(serverAutoReplies, "", "  ")
-	if err != nil {
-		log.Printf("Error marshaling auto-replies: %v", err)
-		return
-	}
+// ReminderStore is everything /remind keeps: the reminders and members' own timezones
+type ReminderStore struct {
+	NextID    int               `json:"next_id"`
+	Reminders []Reminder        `json:"reminders"`
+	Timezones map[string]string `json:"timezones,omitempty"` // map[userID]timezone name
+}
 
-	if err := os.WriteFile(dataFile, data, 0644); err != nil {
-		log.Printf("Error saving auto-replies: %v", err)
-		return
-	}
+// Task is one item on a channel's /task list
+type Task struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	AssigneeID string    `json:"assignee_id,omitempty"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	Due        time.Time `json:"due,omitempty"`
+	Reminded   bool      `json:"reminded,omitempty"` // the due-date ping was sent
+	DoneAt     time.Time `json:"done_at,omitempty"`
+	DoneBy     string    `json:"done_by,omitempty"`
 }
 
-// addAutoReply adds a new auto-reply rule for a specific server
-func addAutoReply(trigger, response, authorID, guildID string) (bool, string, string) {
-	// Initialize server replies if not exists
-	if serverAutoReplies[guildID] == nil {
-		serverAutoReplies[guildID] = make([]AutoReply, 0)
-	}
+// TaskList is a channel's tasks and the board message that shows them
+type TaskList struct {
+	NextID  int    `json:"next_id"`
+	Tasks   []Task `json:"tasks"`
+	BoardID string `json:"board_id,omitempty"`
+}
 
-	// Check if trigger already exists in this server
-	for i, reply := range serverAutoReplies[guildID] {
-		if strings.EqualFold(reply.Trigger, trigger) {
-			// Check if the current user is the author
-			if reply.AuthorID != "" && reply.AuthorID != authorID {
-				return false, fmt.Sprintf("you can't change this you bartard <@%s>", authorID), ""
-			}
-			// Update existing reply
-			serverAutoReplies[guildID][i].Response = response
-			serverAutoReplies[guildID][i].AuthorID = authorID
-			saveAutoReplies()
-			return true, "Auto-reply updated successfully!", ""
-		}
-	}
+// ServerTasks stores task lists per server and channel
+type ServerTasks map[string]map[string]*TaskList // map[guildID]map[channelID]*TaskList
 
-	// Add new auto-reply
-	serverAutoReplies[guildID] = append(serverAutoReplies[guildID], AutoReply{
-		Trigger:  strings.ToLower(trigger),
-		Response: response,
-		AuthorID: authorID,
-	})
-	saveAutoReplies()
-	return true, "Auto-reply created successfully!", ""
+// MemeTemplate is an image a server added for /meme, stored under memesDir
+type MemeTemplate struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	AddedBy string    `json:"added_by"`
+	AddedAt time.Time `json:"added_at"`
 }
 
-// removeAutoReply removes an auto-reply rule from a specific server
-func removeAutoReply(trigger, authorID, guildID string) (bool, string, string) {
-	if serverAutoReplies[guildID] == nil {
-		return false, "No auto-reply found for that trigger.", ""
-	}
+// ServerMemeTemplates stores custom meme templates per server and name
+type ServerMemeTemplates map[string]map[string]MemeTemplate // map[guildID]map[name]MemeTemplate
 
-	for i, reply := range serverAutoReplies[guildID] {
-		if strings.EqualFold(reply.Trigger, trigger) {
-			// Check if the current user is the author
-			if reply.AuthorID != "" && reply.AuthorID != authorID {
-				return false, fmt.Sprintf("you can't change this you bartard <@%s>", authorID), ""
-			}
+// ServerOnboarding stores checklist progress per server and member
+type ServerOnboarding map[string]map[string]*OnboardingProgress // map[guildID]map[userID]*OnboardingProgress
 
-			// Remove the element
-			serverAutoReplies[guildID] = append(serverAutoReplies[guildID][:i], serverAutoReplies[guildID][i+1:]...)
+// FAQEntry is one question and answer from a server's FAQ document
+type FAQEntry struct {
+	Question string    `json:"question"`
+	Answer   string    `json:"answer"`
+	Vector   []float64 `json:"vector"` // unit-length embedding of the question and answer
+}
 
-			// Clean up empty server entries
-			if len(serverAutoReplies[guildID]) == 0 {
-				delete(serverAutoReplies, guildID)
-			}
+// FAQ is a server's uploaded FAQ with the embedder its vectors came from
+type FAQ struct {
+	Entries   []FAQEntry `json:"entries"`
+	Embedder  string     `json:"embedder"` // local or api
+	UpdatedAt time.Time  `json:"updated_at"`
+}
 
-			saveAutoReplies()
-			return true, "Auto-reply removed successfully!", ""
-		}
-	}
-	return false, "No auto-reply found for that trigger.", ""
+// ServerFAQs stores the FAQ per server
+type ServerFAQs map[string]FAQ // map[guildID]FAQ
+
+// ServerTriviaScores stores correct trivia answers per member, per server
+type ServerTriviaScores map[string]map[string]int // map[guildID]map[userID]points
+
+// ScheduledMessage is a message queued to be posted in a channel at a given time
+type ScheduledMessage struct {
+	ID        int       `json:"id"`
+	GuildID   string    `json:"guild_id"`
+	ChannelID string    `json:"channel_id"`
+	Message   string    `json:"message"`
+	SendAt    time.Time `json:"send_at"`
+	AuthorID  string    `json:"author_id,omitempty"`
+
+	// Market posts are skipped or annotated when the market is closed that day
+	Market    string `json:"market,omitempty"`     // marketIDX or marketUS
+	OnHoliday string `json:"on_holiday,omitempty"` // holidaySkip or holidayAnnotate
 }
 
-// handleReplyCommand handles the /reply slash command
-func handleReplyCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
+// FeedSubscription posts new items of an RSS topic to a channel, either one by one or as a daily digest
+type FeedSubscription struct {
+	ID         int          `json:"id"`
+	GuildID    string       `json:"guild_id"`
+	ChannelID  string       `json:"channel_id"`
+	Topic      string       `json:"topic"` // RSS topic, or the podcast name
+	URL        string       `json:"url"`
+	Kind       string       `json:"kind,omitempty"` // "" for Investing.com news, feedKindPodcast or feedKindQuake
+	Digest     bool         `json:"digest,omitempty"`
+	DigestHour int          `json:"digest_hour,omitempty"` // hour of day in the server's timezone
+	Crosspost  bool         `json:"crosspost,omitempty"`   // publish posts when the channel is an Announcement channel
+	Threads    bool         `json:"threads,omitempty"`     // open a discussion thread under each article
+	Routes     []FeedRoute  `json:"routes,omitempty"`      // keyword rules sending items to other channels
+	Translate  string       `json:"translate,omitempty"`   // language code to translate items into before posting
+	Webhook    *FeedWebhook `json:"webhook,omitempty"`     // post through a webhook with a custom identity
+	LastDigest string       `json:"last_digest,omitempty"` // date of the last digest, YYYY-MM-DD
+	Pending    []Item       `json:"pending,omitempty"`     // items collected for the next digest
+	Seen       []string     `json:"seen,omitempty"`        // canonical links already handled
+	SeenTitles []string     `json:"seen_titles,omitempty"` // normalized titles already handled
+	HeldDigest bool         `json:"held_digest,omitempty"` // digest came due during quiet hours
+	HeldNotice string       `json:"held_notice,omitempty"` // failure notices raised during quiet hours
+	CreatedBy  string       `json:"created_by,omitempty"`
 
-	// Get guild ID - only work in servers, not DMs
-	guildID := i.GuildID
-	if guildID == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Auto-reply commands only work in servers, not in DMs!",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+	MinMagnitude float64 `json:"min_magnitude,omitempty"` // smallest earthquake posted by feedKindQuake
 
-	// Get user ID - handle both guild and DM interactions
-	var userID string
-	if i.Member != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	}
+	// Feed health tracking
+	Failures int       `json:"failures,omitempty"` // consecutive fetch failures
+	RetryAt  time.Time `json:"retry_at,omitempty"` // skip polling until this time (backoff)
+	Disabled bool      `json:"disabled,omitempty"` // auto-disabled after too many failures
+}
 
-	trigger := options[0].StringValue()
+// StatusMonitor is an external service's health URL checked by the feed poller, with
+// up/down changes posted to a status channel
+type StatusMonitor struct {
+	ID        int       `json:"id"`
+	GuildID   string    `json:"guild_id"`
+	ChannelID string    `json:"channel_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Down      bool      `json:"down,omitempty"`
+	ChangedAt time.Time `json:"changed_at,omitempty"` // when it last went down or came back
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
 
-	var response string
-	var mode string = "add"
+	// Same backoff as feeds
+	Failures int       `json:"failures,omitempty"`
+	RetryAt  time.Time `json:"retry_at,omitempty"`
+}
 
-	if len(options) > 1 {
-		response = options[1].StringValue()
-	}
-	if len(options) > 2 {
-		mode = options[2].StringValue()
-	}
+// LiveSubscription announces in a channel when a streamer goes live, and edits the
+// announcement when the stream ends
+type LiveSubscription struct {
+	ID        int    `json:"id"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	Platform  string `json:"platform"` // twitch
+	Login     string `json:"login"`    // channel name on the platform
+	RoleID    string `json:"role_id,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
 
-	if strings.ToLower(mode) == "remove" {
-		success, message, _ := removeAutoReply(trigger, userID, guildID)
-		var responseType string
-		var flags discordgo.MessageFlags = discordgo.MessageFlagsEphemeral
+	// The current or last stream
+	Live      bool      `json:"live,omitempty"`
+	StreamID  string    `json:"stream_id,omitempty"`
+	Name      string    `json:"name,omitempty"` // display name
+	Title     string    `json:"title,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	MessageID string    `json:"message_id,omitempty"` // the announcement, edited when the stream ends
+}
 
-		if success {
-			responseType = "✅ " + message
-		} else {
-			responseType = message
-			// If it's the custom bartard message, make it public
-			if strings.Contains(message, "bartard") {
-				flags = 0
-			} else {
-				responseType = "❌ " + message
-			}
-		}
+// FeedWebhook is a bot-created channel webhook used to post with a custom name and avatar.
+// Its token is stored encrypted like API keys, Token only holds ones saved before that.
+type FeedWebhook struct {
+	ID        string `json:"id"`
+	Token     string `json:"token,omitempty"`
+	Sealed    string `json:"sealed_token,omitempty"` // base64 nonce and secretbox
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: responseType,
-				Flags:   flags,
-			},
-		})
-		return
+// token returns the webhook's decrypted token
+func (webhook *FeedWebhook) token() (string, error) {
+	if webhook.Sealed == "" {
+		return webhook.Token, nil
 	}
+	return decryptSecret(webhook.Sealed)
+}
 
-	if response == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Please provide a response message!",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
+// seal encrypts a token left in plain text by an older version
+func (webhook *FeedWebhook) seal() error {
+	if webhook.Token == "" {
+		return nil
+	}
+	sealed, err := encryptSecret(webhook.Token)
+	if err != nil {
+		return err
 	}
+	webhook.Sealed, webhook.Token = sealed, ""
+	return nil
+}
 
-	success, message, _ := addAutoReply(trigger, response, userID, guildID)
+// FeedRoute sends subscription items mentioning a keyword to a different channel
+type FeedRoute struct {
+	Keyword   string `json:"keyword"`
+	ChannelID string `json:"channel_id"`
+}
 
-	if !success {
-		var flags discordgo.MessageFlags = discordgo.MessageFlagsEphemeral
-		var responseContent string = "❌ " + message
+// PostedArticle is a feed item the bot posted, kept for /news search
+type PostedArticle struct {
+	GuildID     string    `json:"guild_id"`
+	ChannelID   string    `json:"channel_id"`
+	MessageID   string    `json:"message_id,omitempty"`
+	Topic       string    `json:"topic"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at,omitempty"`
+	PostedAt    time.Time `json:"posted_at"`
+}
 
-		// If it's the custom bartard message, make it public
-		if strings.Contains(message, "bartard") {
-			flags = 0
-			responseContent = message
-		}
+// Bookmark is a message or news article a user saved for later
+type Bookmark struct {
+	Title    string    `json:"title"`
+	Link     string    `json:"link,omitempty"`
+	JumpLink string    `json:"jump_link"`
+	SavedAt  time.Time `json:"saved_at"`
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: responseContent,
-				Flags:   flags,
-			},
-		})
-		return
-	}
+	// Watchlist marks a movie or series saved from /movie
+	Watchlist bool `json:"watchlist,omitempty"`
+}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "✅ Auto-Reply Set Up Successfully!",
-		Description: fmt.Sprintf("**Trigger:** %s\n**Response:** %s", trigger, response),
-		Color:       embedColor,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "The bot will now automatically reply when someone sends the trigger message. Only you can modify this auto-reply.",
-		},
-	}
+// UserBookmarks stores saved bookmarks per user
+type UserBookmarks map[string][]Bookmark // map[userID][]Bookmark
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-			Flags:  discordgo.MessageFlagsEphemeral,
-		},
-	})
+// ConversionRecord is a currency conversion a user ran, with the rate at that moment
+type ConversionRecord struct {
+	Amount    float64   `json:"amount"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Result    float64   `json:"result"`
+	Rate      float64   `json:"rate"`
+	Converted time.Time `json:"converted_at"`
 }
 
-// handleListRepliesCommand handles the /list_replies slash command
-func handleListRepliesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Get guild ID - only work in servers, not DMs
-	guildID := i.GuildID
-	if guildID == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Auto-reply commands only work in servers, not in DMs!",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// UserConversions stores recent conversions per user
+type UserConversions map[string][]ConversionRecord // map[userID][]ConversionRecord
 
-	// Check if this server has any auto-replies
-	serverReplies := serverAutoReplies[guildID]
-	if len(serverReplies) == 0 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "📝 No auto-reply rules set up for this server.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// pendingScheduleImport holds a validated CSV import waiting for confirmation
+type pendingScheduleImport struct {
+	GuildID   string
+	UserID    string
+	Messages  []ScheduledMessage
+	CreatedAt time.Time
+}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "📋 Server Auto-Reply Rules",
-		Description: "Active rules for this server",
-		Color:       0x3498db,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Total rules: %d", len(serverReplies)),
-		},
-	}
+// Hardcoded server and channel restriction for /analisis
+const (
+	analisisServerID  = "910866740567748628"
+	analisisChannelID = "910881680867348530"
+)
 
-	for _, reply := range serverReplies {
-		displayResponse := reply.Response
-		if len(displayResponse) > 100 {
-			displayResponse = displayResponse[:100] + "..."
-		}
+const (
+	dataFile      = "auto_replies.json"
+	settingsFile  = "guild_settings.json"
+	commandsFile  = "custom_commands.json"
+	scheduleFile  = "scheduled_messages.json"
+	feedsFile     = "feed_subscriptions.json"
+	monitorsFile  = "monitors.json"
+	liveFile      = "live_subscriptions.json"
+	articlesFile  = "posted_articles.json"
+	bookmarksFile = "bookmarks.json"
+	historyFile   = "conversion_history.json"
+	secretsFile   = "guild_secrets.json"
+	tokensFile    = "api_tokens.json"
+	auditFile     = "audit_log.json"
+	blocklistFile = "blocklist.json"
+	reviewsFile   = "pending_replies.json"
+	triviaFile    = "trivia_scores.json"
+	streaksFile   = "word_streaks.json"
+	confessFile   = "confessions.json"
+	faqFile       = "faq.json"
+	onboardFile   = "onboarding.json"
+	profilesFile  = "profiles.json"
+	remindersFile = "reminders.json"
+	tasksFile     = "tasks.json"
+	memesFile     = "meme_templates.json"
+	versionFile   = "data_version.json"
+	backupsDir    = "backups"
+	replyMediaDir = "reply_media"
+	memesDir      = "meme_templates"
+	embedColor    = 0x00ff00
+	commandPrefix = "!"
+)
 
-		authorInfo := ""
-		if reply.AuthorID != "" {
-			authorInfo = fmt.Sprintf(" (by <@%s>)", reply.AuthorID)
-		}
+// Scheduler limits
+const (
+	scheduleInterval      = 30 * time.Second
+	scheduleTimeLayout    = "2006-01-02 15:04"
+	maxScheduleImportRows = 100
+	maxScheduleImportSize = 1 << 20
+	pendingImportTTL      = 15 * time.Minute
+	replyDraftTTL         = 15 * time.Minute
+)
 
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   fmt.Sprintf("Trigger: %s", reply.Trigger),
-			Value:  fmt.Sprintf("Response: %s%s", displayResponse, authorInfo),
-			Inline: false,
-		})
-	}
+// Background job restart backoff
+const (
+	minJobBackoff = 5 * time.Second
+	maxJobBackoff = 5 * time.Minute
+)
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-			Flags:  discordgo.MessageFlagsEphemeral,
-		},
-	})
-}
+// Send queue tuning
+const (
+	backgroundSendReserve  = 2                      // requests left in a channel bucket for replies
+	interactiveQuietPeriod = 500 * time.Millisecond // background sends pause this long after a user command
+	maxSendYields          = 10                     // rounds a background send waits before going anyway
+)
 
-// handleHelpCommand handles the /help_reply slash command
-func handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	embed := &discordgo.MessageEmbed{
-		Title:       "🤖 Auto-Reply Bot Help",
-		Description: "Smart auto-reply system for Discord servers",
-		Color:       0x9b59b6,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "📝 `/reply [trigger] [response]`",
-				Value:  "Set up a new auto-reply rule for this server. When someone sends a message containing the trigger word, the bot will automatically respond.",
-				Inline: false,
-			},
-			{
-				Name:   "🗑️ `/reply [trigger] [response] remove`",
-				Value:  "Remove an existing auto-reply rule for the specified trigger in this server.",
-				Inline: false,
-			},
-			{
-				Name:   "📋 `/list_replies`",
-				Value:  "Show all active auto-reply rules for this server.",
-				Inline: false,
-			},
-			{
-				Name:   "ℹ️ How it works:",
-				Value:  "• Triggers are case-insensitive and match whole words only\n• Bot only works in servers where auto-replies have been set up\n• Anyone can create new rules\n• Only the original author can modify/delete their rules\n• Rules are server-specific",
-				Inline: false,
-			},
-			{
-				Name:   "⚠️ Note:",
-				Value:  "• Commands only work in servers, not in DMs\n• The bot needs 'Send Messages' permission in channels where you want auto-replies to work\n• Auto-replies only work in servers that have at least one rule set up",
-				Inline: false,
-			},
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /reply to set up smart auto-replies for this server! Only you can modify rules you create.",
-		},
-	}
+// Discord limits for batching several embeds into one message
+const (
+	maxEmbedsPerMessage = 10
+	maxEmbedChars       = 6000
+)
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-			Flags:  discordgo.MessageFlagsEphemeral,
-		},
-	})
-}
+// Feed kinds
+const (
+	feedKindPodcast = "podcast"
+	feedKindQuake   = "quake"
+	feedKindGames   = "free_games"
 
-// handleCommandsCommand handles the /commands slash command
-func handleCommandsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	embed := &discordgo.MessageEmbed{
-		Title:       "🎛️ Bot Commands",
-		Description: "All available commands for this bot",
-		Color:       0x3498db,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "🤖 **Auto-Reply Commands**",
-				Value:  "`/reply` - Set up auto-reply rules\n`/list_replies` - Show server's auto-reply rules\n`/help_reply` - Help for auto-reply system",
-				Inline: false,
-			},
-			{
-				Name:   "📰 **News & Analysis Commands**",
-				Value:  "`/analisis` - Get latest financial news (restricted to specific server/channel)\n`/trendingx` - Get top 5 trending topics with links and previews",
-				Inline: false,
-			},
-			{
-				Name:   "💱 **Currency Commands**",
-				Value:  "`/convert` - Convert currency amounts (e.g., `/convert $500 idr`)",
-				Inline: false,
-			},
-			{
-				Name:   "ℹ️ **Information Commands**",
-				Value:  "`/commands` - Show this list of all commands",
-				Inline: false,
-			},
-			{
-				Name:   "📖 **Quick Usage Examples:**",
-				Value:  "• `/reply kerja working hard!` - Create auto-reply\n• `/analisis ringkasan pasar` - Get market news (if authorized)\n• `/convert $500 idr` - Convert $500 to Indonesian Rupiah\n• `/convert 1000jpy usd` - Convert 1000 Japanese Yen to USD\n• `/list_replies` - See all server replies\n• `/help_reply` - Detailed auto-reply help",
-				Inline: false,
-			},
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "💡 Tip: Use /help_reply for detailed auto-reply instructions",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
-	}
+	bmkgQuakeFeed         = "https://data.bmkg.go.id/DataMKG/TEWS/gempaterkini.json"   // latest M5+ quakes
+	bmkgFeltFeed          = "https://data.bmkg.go.id/DataMKG/TEWS/gempadirasakan.json" // latest felt quakes of any size
+	defaultQuakeMagnitude = 5.0
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
-			Flags:  discordgo.MessageFlagsEphemeral,
-		},
-	})
-}
+	epicFreeGamesFeed  = "https://store-site-backend-static.ak.epicgames.com/freeGamesPromotions"
+	steamFreeGamesFeed = "https://www.gamerpower.com/api/giveaways?platform=steam&type=game"
 
-// handleConvertCommand handles the /convert slash command for currency conversion
-func handleConvertCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	if len(options) == 0 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Please provide the conversion details. Examples:\n• `/convert $500 idr`\n• `/convert 1000jpy usd`\n• `/convert 100eur gbp`",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+	defaultWebhookName = "Investing.com News"
+)
 
-	input := options[0].StringValue()
+// Feed poller limits
+const (
+	feedPollInterval         = 10 * time.Minute
+	maxItemsPerPoll          = 5
+	maxSeenLinks             = 200
+	titleSimilarityThreshold = 0.8
+	defaultDigestHour        = 8
+	threadAutoArchive        = 24 * 60 // minutes before article threads auto-archive
+	maxFeedRoutes            = 10
+	maxTranslationCache      = 1000
+	digestHeadlines          = 3
+	feedFailureNotify        = 3  // consecutive failures before warning the channel
+	feedFailureLimit         = 10 // consecutive failures before auto-disabling
+	maxFeedBackoff           = 6 * time.Hour
+)
 
-	// Parse the input
-	amount, from, to, err := parseCurrencyInput(input)
-	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("❌ %s\n\n**Examples:**\n• `/convert $500 idr`\n• `/convert 1000jpy usd`\n• `/convert 100eur gbp`", err.Error()),
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// maxLivePerGuild is how many streamers a server can follow with /feed live
+const maxLivePerGuild = 20
 
-	// Defer the response since currency conversion might take a moment
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
-	if err != nil {
-		log.Printf("Error deferring interaction: %v", err)
-		return
-	}
+// Status monitor limits, checked by the feed poller
+const (
+	maxMonitorsPerGuild = 10
+	monitorDownAfter    = 2 // failed checks in a row before a service is reported down
+	maxMonitorBackoff   = time.Hour
+)
 
-	// Perform currency conversion
-	result, err := convertCurrency(amount, from, to)
-	if err != nil {
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("❌ Failed to convert currency: %v", err),
-			Flags:   discordgo.MessageFlagsEphemeral,
-		})
-		return
-	}
+// News search limits
+const (
+	articleRetention  = 90 * 24 * time.Hour
+	defaultSearchDays = 7
+	maxSearchResults  = 10
+	maxBookmarks      = 50
+)
 
-	// Create embed with conversion result
-	embed := &discordgo.MessageEmbed{
-		Title: "💱 Currency Conversion",
-		Color: 0x2ecc71,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "From",
-				Value:  fmt.Sprintf("%.2f %s", result.Query.Amount, strings.ToUpper(result.Query.From)),
-				Inline: true,
-			},
-			{
-				Name:   "To",
-				Value:  fmt.Sprintf("%.2f %s", result.Result, strings.ToUpper(result.Query.To)),
-				Inline: true,
-			},
-			{
-				Name:   "Exchange Rate",
-				Value:  fmt.Sprintf("1 %s = %.4f %s", strings.ToUpper(result.Query.From), result.Info.Rate, strings.ToUpper(result.Query.To)),
-				Inline: false,
-			},
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Exchange rates provided by exchangerate-api.com",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
-	}
+// maxConversionHistory is how many conversions are kept per user for /convert history
+const maxConversionHistory = 10
 
-	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Embeds: []*discordgo.MessageEmbed{embed},
-	})
-}
+// movieWatchRegion is the country /movie shows streaming services for
+const movieWatchRegion = "ID"
 
-// handleAnalisisCommand handles the /analisis slash command for RSS feeds
-func handleAnalisisCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Hardcoded server and channel restriction
-	allowedServerID := "910866740567748628"
-	allowedChannelID := "910881680867348530"
+// maxReplyVersions is how many previous responses are kept per auto-reply rule
+const maxReplyVersions = 10
 
-	// Check if command is used in the allowed server
-	if i.GuildID != allowedServerID {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ The `/analisis` command is only available in specific servers. This command is restricted to authorized servers only.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// maxGreetedMembers is how many members a nickname or role rule remembers, the ones greeted
+// longest ago are forgotten first
+const maxGreetedMembers = 5000
 
-	// Check if command is used in the allowed channel
-	if i.ChannelID != allowedChannelID {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ The `/analisis` command can only be used in the designated channel. Please use it in the correct channel.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// Auto-reply cooldowns, see AutoReply.Cooldown
+const (
+	defaultReplyCooldown = 30 * time.Second
+	maxReplyCooldown     = 3600 // seconds
+)
 
-	options := i.ApplicationCommandData().Options
+// Rules per server, admins can change the cap with /settings auto_replies. It keeps the
+// rules file and the match loop in handleAutoReplies small.
+const (
+	defaultMaxReplies = 100
+	maxMaxReplies     = 1000
+)
 
-	if len(options) == 0 {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Please provide a topic! Example: `/analisis ringkasan pasar`",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// maxRepliesPerUser is how many auto-replies one member can set off per minute, so
+// nobody can make the bot spam by repeating triggers
+const maxRepliesPerUser = 5
 
-	topic := strings.ToLower(options[0].StringValue())
+// editedReplyWindow is how long after it was sent an edited message can still set off an
+// auto-reply, older messages being edited stay quiet
+const editedReplyWindow = 15 * time.Minute
 
-	// Find matching RSS URL
-	var rssURL string
-	var foundTopic string
-	for key, url := range rssTopics {
-		if strings.Contains(topic, key) || key == topic {
-			rssURL = url
-			foundTopic = key
-			break
-		}
-	}
+// Music link expansion, song.link's free API allows 10 lookups a minute
+const (
+	maxMusicLinks     = 3 // cards per message
+	songLinkPerMinute = 10
+)
 
-	if rssURL == "" {
-		// Show available topics
-		availableTopics := make([]string, 0, len(rssTopics))
-		for topic := range rssTopics {
-			availableTopics = append(availableTopics, topic)
-		}
+// Reminder limits
+const (
+	maxRemindersPerUser = 25             // reminders one member can have waiting
+	reminderSnoozeTime  = 24 * time.Hour // how long a sent reminder can still be snoozed
+	reminderRetryWindow = 24 * time.Hour // how long a reminder that fails to send is retried
+)
 
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("❌ Topic not found! Available topics:\n• %s", strings.Join(availableTopics, "\n• ")),
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-		return
-	}
+// Task list limits
+const (
+	maxTasksPerChannel = 50                 // open tasks in one channel
+	taskBoardDone      = 5                  // finished tasks still shown on the board
+	taskDoneKeep       = 7 * 24 * time.Hour // how long finished tasks are kept
+	taskReminderRetry  = 24 * time.Hour     // how long a due reminder that fails to send is retried
+)
 
-	// Defer the response since fetching RSS might take time
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
+// Watchword limits
+const (
+	maxWatchwords     = 50
+	watchwordCooldown = 10 * time.Minute // between alerts for the same member and word
+)
 
-	// Fetch RSS feed
-	rss, err := fetchRSSFeed(rssURL)
-	if err != nil {
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("❌ Failed to fetch RSS feed: %v", err),
-			Flags:   discordgo.MessageFlagsEphemeral,
-		})
-		return
-	}
+// maxIntroLength is how much of an introduction a profile keeps
+const maxIntroLength = 1500
 
-	if len(rss.Channel.Items) == 0 {
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "📰 No news articles found for this topic.",
-			Flags:   discordgo.MessageFlagsEphemeral,
-		})
-		return
-	}
+// maxReplyMediaSize is the largest file /reply stores, under Discord's upload limit
+const maxReplyMediaSize = 8 << 20
 
-	// Create embed with latest news (limit to 5 articles)
-	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("📰 %s - %s", strings.ToUpper(string(foundTopic[0]))+foundTopic[1:], rss.Channel.Title),
-		Description: "Latest news from Investing.com",
-		Color:       0x1f8b4c,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Source: Investing.com",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
-	}
+// maxReplyReactions is how many emoji a reaction rule can add to one message
+const maxReplyReactions = 5
 
-	maxItems := 5
-	if len(rss.Channel.Items) < maxItems {
-		maxItems = len(rss.Channel.Items)
-	}
+// maxReplyChannels is how many channels a rule can be limited to
+const maxReplyChannels = 25
 
-	for i := 0; i < maxItems; i++ {
-		item := rss.Channel.Items[i]
+// maxReplyTargets is how many roles or users a rule's roles, users and ignore_users can list
+const maxReplyTargets = 25
 
-		// Clean up description (remove HTML tags and limit length)
-		description := strings.ReplaceAll(item.Description, "<![CDATA[", "")
-		description = strings.ReplaceAll(description, "]]>", "")
-		description = strings.ReplaceAll(description, "<p>", "")
-		description = strings.ReplaceAll(description, "</p>", "")
-		description = strings.ReplaceAll(description, "<br>", "\n")
-		description = strings.ReplaceAll(description, "<br/>", "\n")
+// maxReplyResponses is how many responses, including the main one, a rule can pick from
+const maxReplyResponses = 10
 
-		if len(description) > 200 {
-			description = description[:200] + "..."
-		}
+// maxReplyExpiry is the furthest ahead a rule's expiry can be set
+const maxReplyExpiry = 366 * 24 * time.Hour
 
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:   item.Title,
-			Value:  fmt.Sprintf("%s\n\n[Read More](%s)", description, item.Link),
-			Inline: false,
-		})
-	}
+// maxAutocompleteChoices is the most suggestions Discord shows for an option
+const maxAutocompleteChoices = 25
 
-	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Embeds: []*discordgo.MessageEmbed{embed},
-	})
-}
+// Meme generator limits
+const (
+	maxMemeTemplates = 25 // custom templates per server
+	maxMemeImageSize = 8 << 20
+	maxMemeCaption   = 100
+	maxMemeDimension = 4096 // pixels wide or high, decoding allocates 4 bytes a pixel
+)
 
-// messageCreate handles incoming messages for auto-replies and manual bot triggers
-func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Ignore bot messages
-	if m.Author.Bot {
-		return
-	}
+// Image OCR limits for auto-reply triggers, kept low since every image is an API call
+const (
+	ocrPerGuildHour = 20
+	ocrUserCooldown = time.Minute
+	ocrMaxImageSize = 1024 * 1024 // OCR.space free tier limit
+	ocrImagesPerMsg = 1
+)
 
-	// Only work in servers, not DMs
-	if m.GuildID == "" {
-		return
-	}
+// Holiday lookups and long weekend announcements
+const (
+	upcomingHolidays  = 10
+	longWeekendHour   = 9 // server time
+	longWeekendNotice = 7 * 24 * time.Hour
+)
 
-	log.Printf("Received message in guild %s from %s: %s", m.GuildID, m.Author.Username, m.Content)
-	// Check for manual bot trigger when user replies to a message and mentions the bot
-	if m.ReferencedMessage != nil && len(m.Mentions) > 0 {
-		// Check if the bot is mentioned
-		for _, mention := range m.Mentions {
-			if mention.ID == s.State.User.ID {
-				// Get the ORIGINAL message content to check for specific trigger words
-				originalMessageContent := strings.ToLower(strings.TrimSpace(m.ReferencedMessage.Content))
-				log.Printf("Manual trigger detected, checking original message: %s", originalMessageContent)
-				var response string
+// /check limits
+const (
+	maxCheckRedirects = 5
+	certWarningDays   = 14
+)
 
-				// Split original message into words to check for exact matches
-				words := strings.Fields(originalMessageContent)
-				triggerFound := false
+// /run go limits
+const (
+	maxRunCodeSize = 4000 // the most a modal text input takes
+	maxRunOutput   = 1500
+	runCooldown    = 30 * time.Second
+)
 
-				log.Printf("Original message words: %v", words)
-				for _, word := range words {
-					// Remove common punctuation from the word
-					cleanWord := strings.Trim(word, ".,!?;:\"'()[]{}*<>@")
+// /run go cooldowns, in memory only
+var (
+	runMu       sync.Mutex
+	runLastUser = make(map[string]time.Time) // map[userID]last run
+)
 
-					log.Printf("Checking word from original message: %s", cleanWord)
+// Trivia rounds
+const (
+	defaultTriviaRounds   = 5
+	maxTriviaRounds       = 15
+	triviaRoundTime       = 20 * time.Second
+	triviaRevealPause     = 5 * time.Second // between the reveal and the next question
+	triviaLeaderboardSize = 10
+)
 
-					switch cleanWord {
-					case "ai":
-						response = "AI IS DUMB BRO!"
-						triggerFound = true
-					case "kerja":
-						response = "cerdas"
-						triggerFound = true
-					case "btc":
-						response = "SIAP, ALL IN BTC!!!!!"
-						triggerFound = true
-					case "aji":
-						response = "ganteng"
-						triggerFound = true
-					}
+// FAQ limits
+const (
+	maxFAQSize         = 256 * 1024
+	maxFAQEntries      = 200
+	maxFAQMatches      = 3
+	localEmbeddingSize = 1024
+	faqAutoCooldown    = time.Minute // per channel
+)
 
-					if triggerFound {
-						break
-					}
-				}
+// faqAutoAnswers holds channels that just had a question looked up for an auto-answer
+var faqAutoAnswers = newExpiringKeys() // keyed by channel ID
 
-				// Only respond if a trigger was found in the original message
-				if triggerFound {
-					// Reply to the original message with the appropriate response
-					_, err := s.ChannelMessageSendReply(m.ChannelID, response, &discordgo.MessageReference{
-						MessageID: m.ReferencedMessage.ID,
-						ChannelID: m.ChannelID,
-						GuildID:   m.GuildID,
-					})
-					if err != nil {
-						log.Printf("Error sending manual trigger reply: %v", err)
-						// Fallback to regular message if reply fails
-						s.ChannelMessageSend(m.ChannelID, response)
-					}
-				} else {
-					// No trigger found in original message, send default response
-					log.Printf("No trigger found in original message")
-					s.ChannelMessageSend(m.ChannelID, "belum ada yang pas ganteng, coba izin dulu ke kak aji ganteng!")
-				}
-				return // Exit early after handling manual trigger
-			}
-		}
-	}	
-
-	// //below is the auto-reply logic, no need again
-	// // Check if this server has any auto-replies set up
-	// serverReplies := serverAutoReplies[m.GuildID]
-	// if len(serverReplies) == 0 {
-	// 	return
-	// }
-
-	// // Note: If MESSAGE_CONTENT_INTENT is not enabled, m.Content will be empty
-	// // for messages from users who are not the bot owner
-	// messageContent := strings.ToLower(strings.TrimSpace(m.Content))
-
-	// // If content is empty due to missing intent, skip auto-reply
-	// if messageContent == "" {
-	// 	return
-	// }
-
-	// // Check for matching triggers - search for whole word matches only
-	// for _, reply := range serverReplies {
-	// 	if containsWholeWord(messageContent, reply.Trigger) {
-	// 		// Send reply immediately with message reference to show "replying to" context
-	// 		_, err := s.ChannelMessageSendReply(m.ChannelID, reply.Response, &discordgo.MessageReference{
-	// 			MessageID: m.ID,
-	// 			ChannelID: m.ChannelID,
-	// 			GuildID:   m.GuildID,
-	// 		})
-	// 		if err != nil {
-	// 			log.Printf("Error sending auto-reply: %v", err)
-	// 			// Fallback to regular message if reply fails
-	// 			s.ChannelMessageSend(m.ChannelID, reply.Response)
-	// 		}
-	// 		break // Only respond to the first matching trigger
-	// 	}
-	// }
-}
+// summarizeCooldowns and transcriptCooldowns hold members who just ran /summarize or
+// /transcript, keyed by user ID
+var (
+	summarizeCooldowns  = newExpiringKeys()
+	transcriptCooldowns = newExpiringKeys()
+)
 
-// interactionCreate handles slash command interactions
-func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type != discordgo.InteractionApplicationCommand {
-		return
-	}
+// Retention limits
+const (
+	retentionHour       = 3 // server time, when channels are quiet
+	maxRetentionDays    = 365
+	maxRetentionDeletes = 1000                        // per channel per run, the rest go next time
+	bulkDeleteMaxAge    = 14*24*time.Hour - time.Hour // Discord only bulk deletes messages under 14 days old
+	discordEpoch        = 1420070400000               // milliseconds, the start of Discord snowflake IDs
+)
 
-	switch i.ApplicationCommandData().Name {
-	// case "reply":
-	// 	handleReplyCommand(s, i)
-	case "list_replies":
-		handleListRepliesCommand(s, i)
-	case "help_reply":
-		handleHelpCommand(s, i)
-	case "analisis":
-		handleAnalisisCommand(s, i)
-	case "commands":
-		handleCommandsCommand(s, i)
-	case "convert":
-		handleConvertCommand(s, i)
+// /summarize limits
+const (
+	defaultSummaryMessages = 50
+	maxSummaryMessages     = 300
+	maxSummaryInput        = 12000 // transcript characters sent to the LLM
+	summarizeCooldown      = time.Minute
+)
+
+// /transcript limits
+const (
+	defaultTranscriptMessages = 100
+	maxTranscriptMessages     = 1000
+	transcriptCooldown        = time.Minute
+)
+
+// maxConfessions is how many confessions per server are kept on record
+const maxConfessions = 500
+
+// defaultWordHour is when the word of the day is posted unless the server picks an hour
+const defaultWordHour = 7
+
+// Standup defaults and limits
+const (
+	defaultStandupHour   = 9
+	defaultStandupWindow = 4   // hours
+	maxStandupWindow     = 12  // hours, so the summary lands the same day
+	maxStandupMessages   = 300 // thread messages read for the summary
+)
+
+// standupRetryWindow is how long a standup summary that keeps failing is retried
+const standupRetryWindow = 24 * time.Hour
+
+// /roll limits
+const (
+	maxDice      = 100
+	maxDiceSides = 1000
+)
+
+// maxSearchHits is how many results /search shows
+const maxSearchHits = 3
+
+// maxGitHubIssues is how many search results /github issues shows
+const maxGitHubIssues = 10
+
+// maxWordSenses is how many meanings /kbbi and /define show before summarizing the rest
+const maxWordSenses = 8
+
+// listRepliesPageSize is how many rules a /list_replies page shows
+const listRepliesPageSize = 10
+
+// Auto-reply audit thresholds
+const (
+	staleReplyAge      = 90 * 24 * time.Hour
+	nearDuplicateRatio = 0.85
+)
+
+// Audit trail limits
+const (
+	maxAuditEntries = 1000 // per server, oldest are dropped first
+	auditPageSize   = 15
+)
+
+var (
+	serverAutoReplies ServerAutoReplies
+	repliesMu         sync.Mutex // rules are also changed by approval buttons
+	repliesDirty      bool       // fire counts changed since the last save
+	pendingReplies    PendingReplies
+	replyDrafts       = make(map[string]*replyDraft) // keyed by the /reply interaction ID
+	replyPurges       = make(map[string]*replyPurge) // keyed by the /reply_purge interaction ID
+	replyCooldowns    = newExpiringKeys()            // keyed by guildID|trigger while a rule cools down
+	watchwordAlerts   = newExpiringKeys()            // keyed by guildID|userID|watchword after an alert
+	answeredMessages  = newExpiringKeys()            // keyed by message ID after an auto-reply
+
+	// Auto-replies each member set off, keyed by guildID|userID
+	replyUserLimits = newSlidingWindow(maxRepliesPerUser, time.Minute)
+
+	// song.link lookups for every server together
+	songLinkLimit = newSlidingWindow(songLinkPerMinute, time.Minute)
+
+	// OCR usage for rate limiting, in memory only
+	ocrMu             sync.Mutex
+	ocrRecent         = make(map[string][]time.Time) // map[guildID]recent OCR calls
+	ocrLastUser       = make(map[string]time.Time)   // map[guildID|userID]last OCR call
+	serverSettings    ServerSettings
+	settingsMu        sync.Mutex // settings are also read by the scheduler and feed poller
+	guildSecrets      ServerSecrets
+	secretsMu         sync.Mutex // API keys may be read by background jobs
+	apiTokens         ServerAPITokens
+	tokensMu          sync.Mutex // tokens are checked by the inbound API server
+	auditLogs         ServerAuditLogs
+	auditMu           sync.Mutex
+	blocklist         Blocklist
+	blocklistMu       sync.Mutex
+	triviaScores      ServerTriviaScores
+	triviaScoresMu    sync.Mutex
+	wordStreaks       ServerWordStreaks
+	wordStreaksMu     sync.Mutex
+	confessions       ServerConfessions
+	confessMu         sync.Mutex
+	serverFAQs        ServerFAQs
+	faqMu             sync.Mutex
+	onboarding        ServerOnboarding
+	onboardingMu      sync.Mutex
+	profiles          ServerProfiles
+	profilesMu        sync.Mutex
+	reminders         ReminderStore
+	remindersMu       sync.Mutex
+	tasks             ServerTasks
+	tasksMu           sync.Mutex
+	memeTemplates     ServerMemeTemplates
+	memesMu           sync.Mutex
+	botOwners         = make(map[string]bool) // filled from the application info on ready
+	customCommands    ServerCustomCommands
+	userBookmarks     UserBookmarks
+	bookmarksMu       sync.Mutex // bookmarks are saved from buttons and commands at once
+	conversionHistory UserConversions
+	conversionsMu     sync.Mutex // conversions are recorded from commands and buttons at once
+	session           *discordgo.Session
+
+	// Scheduled messages are shared with the scheduler goroutine
+	scheduleMu        sync.Mutex
+	scheduledMessages []ScheduledMessage
+	nextScheduleID    = 1
+	pendingImports    = make(map[string]*pendingScheduleImport)
+
+	// Feed subscriptions are shared with the feed poller goroutine
+	feedMu            sync.Mutex
+	feedSubscriptions []*FeedSubscription
+	nextFeedID        = 1
+
+	// Status monitors are checked by the feed poller too
+	monitorsMu     sync.Mutex
+	statusMonitors []*StatusMonitor
+	nextMonitorID  = 1
+
+	// Live stream subscriptions are polled by the feed poller as well
+	liveMu            sync.Mutex
+	liveSubscriptions []*LiveSubscription
+	nextLiveID        = 1
+
+	// Posted articles are appended by the feed poller and read by /news search
+	articleMu      sync.Mutex
+	postedArticles []PostedArticle
+
+	// Background sends wait here so interactive replies go first
+	backgroundSends = make(chan backgroundSend, 100)
+	lastInteractive atomic.Int64 // unix nanoseconds of the last user command
+
+	// Panics recovered per background job
+	jobPanicsMu sync.Mutex
+	jobPanics   = make(map[string]int)
+
+	// botLocation is the timezone used to read schedule times (WIB)
+	botLocation = loadBotLocation()
+)
+
+// loadBotLocation loads the Asia/Jakarta timezone, falling back to a fixed UTC+7 zone
+func loadBotLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
 	}
+	return loc
 }
 
-// ready handles the ready event
-func ready(s *discordgo.Session, event *discordgo.Ready) {
-	log.Printf("Bot is ready! Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
-	log.Printf("Bot is in %d servers", len(event.Guilds))
+// containsWholeWord checks if the trigger exists as a whole word in the message
+func containsWholeWord(message, trigger string) bool {
+	words := strings.Fields(message)
+	for _, word := range words {
+		// Remove common punctuation from the word
+		cleanWord := strings.Trim(word, ".,!?;:\"'()[]{}*")
+		if cleanWord == trigger {
+			return true
+		}
+	}
+	return false
+}
 
-	// Register slash commands
-	commands := []*discordgo.ApplicationCommand{
+// matchesTrigger checks a message text rule against a message. Regex rules see the
+// original text, word rules the lowercased text, see containsWholeWord.
+func matchesTrigger(rule AutoReply, original, lowered string) bool {
+	if rule.Regex {
+		return rule.pattern != nil && rule.pattern.MatchString(original)
+	}
+	return containsWholeWord(lowered, rule.Trigger)
+}
+
+// sameTrigger reports whether two rules have the same trigger. Word triggers ignore case
+// like their matching does, regex triggers are only the same when written exactly alike.
+// Lookups by name pass the name as b's trigger.
+func sameTrigger(a, b AutoReply) bool {
+	if a.Regex || a.CaseSensitive || b.Regex || b.CaseSensitive {
+		return a.Trigger == b.Trigger
+	}
+	return strings.EqualFold(a.Trigger, b.Trigger)
+}
+
+// compileTrigger compiles a regex rule's trigger, ignoring case unless the rule is case-sensitive
+func compileTrigger(rule *AutoReply) error {
+	rule.pattern = nil
+	if !rule.Regex {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(rule.Trigger)
+	if err != nil {
+		return invalidInput("`%s` is not a valid regex: %v", rule.Trigger, strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	if !rule.CaseSensitive {
+		pattern = regexp.MustCompile("(?i)" + rule.Trigger)
+	}
+	rule.pattern = pattern
+	return nil
+}
+
+// RSS topic mapping based on Investing.com RSS structure
+var rssTopics = map[string]string{
+	"ringkasan pasar":      "https://id.investing.com/rss/news_25.rss",
+	"analisis teknikal":    "https://id.investing.com/rss/news_25.rss",
+	"analisis fundamental": "https://id.investing.com/rss/news_25.rss",
+	"opini":                "https://id.investing.com/rss/news_25.rss",
+	"ide investasi":        "https://id.investing.com/rss/news_25.rss",
+	"mata uang kripto":     "https://id.investing.com/rss/news_301.rss",
+	"forex":                "https://id.investing.com/rss/news_1.rss",
+	"saham":                "https://id.investing.com/rss/news_25.rss",
+	"komoditas":            "https://id.investing.com/rss/news_49.rss",
+	"berita":               "https://id.investing.com/rss/news.rss",
+	"breaking news":        "https://id.investing.com/rss/news.rss",
+}
+
+// Error kinds returned by the currency, crypto and RSS services
+var (
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUpstreamDown = errors.New("upstream service unavailable")
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// ServiceError is a service failure of a given kind, with a detail that is safe to show users.
+// Check the kind with errors.Is(err, ErrNotFound) and friends.
+type ServiceError struct {
+	Kind   error  // one of the Err* kinds above
+	Detail string // user-facing detail, e.g. "currency XYZ not found"
+	Err    error  // underlying cause, only logged
+}
+
+func (e *ServiceError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Detail, e.Err)
+	}
+	return e.Detail
+}
+
+func (e *ServiceError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// invalidInput returns an ErrInvalidInput error with a formatted user-facing detail
+func invalidInput(format string, args ...interface{}) error {
+	return &ServiceError{Kind: ErrInvalidInput, Detail: fmt.Sprintf(format, args...)}
+}
+
+// notFound returns an ErrNotFound error with a formatted user-facing detail
+func notFound(format string, args ...interface{}) error {
+	return &ServiceError{Kind: ErrNotFound, Detail: fmt.Sprintf(format, args...)}
+}
+
+// upstreamDown returns an ErrUpstreamDown error for a service, keeping the cause for the logs
+func upstreamDown(service string, err error) error {
+	return &ServiceError{Kind: ErrUpstreamDown, Detail: service, Err: err}
+}
+
+// httpStatusError classifies a non-200 response from a service
+func httpStatusError(service string, status int) error {
+	err := fmt.Errorf("HTTP error: %d", status)
+	switch {
+	case status == http.StatusTooManyRequests:
+		return &ServiceError{Kind: ErrRateLimited, Detail: service, Err: err}
+	case status == http.StatusNotFound:
+		return &ServiceError{Kind: ErrNotFound, Detail: service + " could not find what was requested", Err: err}
+	default:
+		return upstreamDown(service, err)
+	}
+}
+
+// userErrorMessage maps an error to a friendly, emoji-prefixed message for replies.
+// Unexpected errors are logged instead of being shown to users.
+func userErrorMessage(err error) string {
+	var serviceErr *ServiceError
+	detail := ""
+	if errors.As(err, &serviceErr) {
+		detail = serviceErr.Detail
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		return "❌ " + upperFirst(detail)
+	case errors.Is(err, ErrNotFound):
+		return "🔍 " + upperFirst(detail) + "."
+	case errors.Is(err, ErrRateLimited):
+		return fmt.Sprintf("⏳ %s is getting too many requests right now. Please try again in a minute.", detail)
+	case errors.Is(err, ErrUpstreamDown):
+		log.Printf("Upstream error: %v", err)
+		return fmt.Sprintf("🔌 %s is not responding right now. Please try again later.", detail)
+	default:
+		log.Printf("Unexpected error: %v", err)
+		return "⚠️ Something went wrong. Please try again later."
+	}
+}
+
+// upperFirst capitalizes the first letter of a message
+func upperFirst(message string) string {
+	if message == "" {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:]
+}
+
+// lruCache is a size-limited, concurrency-safe cache that evicts the least recently used entry
+// when full and optionally expires entries after a TTL
+type lruCache[V any] struct {
+	name    string
+	maxSize int
+	ttl     time.Duration // 0 keeps entries until they are evicted
+
+	mu        sync.Mutex
+	order     *list.List // most recently used at the front
+	entries   map[string]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// cacheStats is a snapshot of a cache's counters for /admin cache stats
+type cacheStats struct {
+	Name      string
+	Size      int
+	MaxSize   int
+	TTL       time.Duration
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// caches lists every cache created with newLRUCache so their stats can be shown
+var caches []interface{ stats() cacheStats }
+
+// newLRUCache creates a cache holding at most maxSize entries and registers it for stats
+func newLRUCache[V any](name string, maxSize int, ttl time.Duration) *lruCache[V] {
+	c := &lruCache[V]{
+		name:    name,
+		maxSize: maxSize,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+	caches = append(caches, c)
+	return c
+}
+
+// Get returns the cached value for key, if present and not expired
+func (c *lruCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry[V])
+		if c.ttl == 0 || time.Now().Before(entry.expires) {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return entry.value, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Set stores a value, evicting the least recently used entry when the cache is full
+func (c *lruCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+		c.evictions++
+	}
+}
+
+// expiringKeys remembers keys until their own deadline, for cooldowns. Expired keys are
+// swept once a minute on writes, so the map only holds the cooldowns still running.
+type expiringKeys struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	nextSweep time.Time
+}
+
+func newExpiringKeys() *expiringKeys {
+	return &expiringKeys{expires: make(map[string]time.Time)}
+}
+
+// Claim reports whether key is free and, if it is, holds it for ttl
+func (k *expiringKeys) Claim(key string, ttl time.Duration, now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if now.After(k.nextSweep) {
+		for key, expires := range k.expires {
+			if !now.Before(expires) {
+				delete(k.expires, key)
+			}
+		}
+		k.nextSweep = now.Add(time.Minute)
+	}
+
+	if expires, ok := k.expires[key]; ok && now.Before(expires) {
+		return false
+	}
+	k.expires[key] = now.Add(ttl)
+	return true
+}
+
+// Held reports whether key is claimed, without claiming it
+func (k *expiringKeys) Held(key string, now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	expires, ok := k.expires[key]
+	return ok && now.Before(expires)
+}
+
+// slidingWindow allows each key at most limit events in any window, for rate limits.
+// Keys without recent events are swept once a minute on writes.
+type slidingWindow struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	events    map[string][]time.Time
+	nextSweep time.Time
+}
+
+func newSlidingWindow(limit int, window time.Duration) *slidingWindow {
+	return &slidingWindow{limit: limit, window: window, events: make(map[string][]time.Time)}
+}
+
+// Allow reports whether key is under its limit and, if it is, records an event
+func (w *slidingWindow) Allow(key string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if now.After(w.nextSweep) {
+		for key, events := range w.events {
+			if len(events) == 0 || now.Sub(events[len(events)-1]) >= w.window {
+				delete(w.events, key)
+			}
+		}
+		w.nextSweep = now.Add(time.Minute)
+	}
+
+	recent := slices.DeleteFunc(w.events[key], func(t time.Time) bool { return now.Sub(t) >= w.window })
+	if len(recent) >= w.limit {
+		w.events[key] = recent
+		return false
+	}
+	w.events[key] = append(recent, now)
+	return true
+}
+
+func (c *lruCache[V]) stats() cacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return cacheStats{
+		Name:      c.name,
+		Size:      c.order.Len(),
+		MaxSize:   c.maxSize,
+		TTL:       c.ttl,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// Shared caches for external services
+var (
+	rssCache         = newLRUCache[*RSS]("RSS feeds", 50, 2*time.Minute)
+	exchangeCache    = newLRUCache[map[string]interface{}]("Exchange rates", 50, 10*time.Minute)
+	cryptoCache      = newLRUCache[map[string]float64]("Crypto prices", 100, time.Minute)
+	translationCache = newLRUCache[string]("Translations", maxTranslationCache, 0) // feed items are only translated once
+	dictionaryCache  = newLRUCache[*wordEntry]("Dictionary", 200, 24*time.Hour)
+	holidayCache     = newLRUCache[[]Holiday]("Holidays", 10, 24*time.Hour)
+	searchCache      = newLRUCache[[]searchResult]("Web searches", 200, time.Hour)
+)
+
+// fetchRSSFeed fetches and parses RSS feed from the given URL
+func fetchRSSFeed(url string) (*RSS, error) {
+	if rss, ok := rssCache.Get(url); ok {
+		return rss, nil
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, upstreamDown("The news feed", fmt.Errorf("failed to fetch RSS feed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError("The news feed", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, upstreamDown("The news feed", fmt.Errorf("failed to read response body: %v", err))
+	}
+
+	var rss RSS
+	err = xml.Unmarshal(body, &rss)
+	if err != nil {
+		return nil, upstreamDown("The news feed", fmt.Errorf("failed to parse XML: %v", err))
+	}
+
+	// Podcast episodes don't always have a link, fall back to the audio file
+	for idx := range rss.Channel.Items {
+		if rss.Channel.Items[idx].Link == "" && rss.Channel.Items[idx].Enclosure != nil {
+			rss.Channel.Items[idx].Link = rss.Channel.Items[idx].Enclosure.URL
+		}
+	}
+
+	rssCache.Set(url, &rss)
+	return &rss, nil
+}
+
+// convertCurrency converts an amount from one currency to another using exchangerate-api.com
+func convertCurrency(amount float64, from, to string) (*CurrencyResponse, error) {
+	// Crypto pairs are priced by CoinGecko instead of the exchange rate API
+	if isCryptoTicker(from) || isCryptoTicker(to) {
+		from = strings.ToLower(from)
+		to = strings.ToLower(to)
+		rate, err := cryptoRate(from, to)
+		if err != nil {
+			return nil, err
+		}
+		return &CurrencyResponse{
+			Success: true,
+			Query: CurrencyQuery{
+				From:   strings.ToUpper(from),
+				To:     strings.ToUpper(to),
+				Amount: amount,
+			},
+			Info: CurrencyInfo{
+				Timestamp: time.Now().Unix(),
+				Rate:      rate,
+			},
+			Result:   amount * rate,
+			Provider: "CoinGecko",
+		}, nil
+	}
+
+	// Convert currency codes to uppercase for API
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	conversionRates, err := fetchExchangeRates(from)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, ok := conversionRates[to].(float64)
+	if !ok {
+		return nil, notFound("currency %s not found", to)
+	}
+
+	convertedAmount := amount * rate
+
+	return &CurrencyResponse{
+		Success: true,
+		Query: CurrencyQuery{
+			From:   from,
+			To:     to,
+			Amount: amount,
+		},
+		Info: CurrencyInfo{
+			Timestamp: time.Now().Unix(),
+			Rate:      rate,
+		},
+		Result:   convertedAmount,
+		Provider: "exchangerate-api.com",
+	}, nil
+}
+
+// fetchExchangeRates fetches the conversion rates from a base currency, cached for a few minutes
+func fetchExchangeRates(from string) (map[string]interface{}, error) {
+	if rates, ok := exchangeCache.Get(from); ok {
+		return rates, nil
+	}
+
+	apiKey := os.Getenv("EXCHANGERATE_API_KEY") // Not used in this example, but can be set for paid plans
+
+	// Use exchangerate-api.com free tier (no API key required for basic usage)
+	// url := fmt.Sprintf("https://api.exchangerate-api.com/v4/latest/%s", from)
+
+	//example request Example Request: https://v6.exchangerate-api.com/v6/cb11520a7b456009f84a5da1/latest/USD
+	url := fmt.Sprintf("https://v6.exchangerate-api.com/v6/%s/latest/%s", apiKey, from)
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, upstreamDown("The exchange rate service", fmt.Errorf("failed to fetch exchange rates: %v", err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, upstreamDown("The exchange rate service", fmt.Errorf("failed to read response body: %v", err))
+	}
+
+	// Parse the response, error responses carry an error type in the body
+	var response map[string]interface{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, httpStatusError("The exchange rate service", resp.StatusCode)
+		}
+		return nil, upstreamDown("The exchange rate service", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	// Check if the request was successful
+	result, ok := response["result"].(string)
+	if !ok || result != "success" {
+		switch response["error-type"] {
+		case "unsupported-code", "malformed-request":
+			return nil, notFound("currency %s not found", from)
+		case "quota-reached":
+			return nil, &ServiceError{Kind: ErrRateLimited, Detail: "The exchange rate service", Err: fmt.Errorf("API request failed: %v", response)}
+		}
+		return nil, upstreamDown("The exchange rate service", fmt.Errorf("API request failed: %v", response))
+	}
+
+	// Get conversion rates from the response
+	conversionRates, ok := response["conversion_rates"].(map[string]interface{})
+	if !ok {
+		return nil, upstreamDown("The exchange rate service", fmt.Errorf("invalid response format: conversion_rates not found"))
+	}
+
+	exchangeCache.Set(from, conversionRates)
+	return conversionRates, nil
+}
+
+// cryptoCoins maps supported crypto tickers to their CoinGecko IDs
+var cryptoCoins = map[string]string{
+	"btc":  "bitcoin",
+	"eth":  "ethereum",
+	"sol":  "solana",
+	"bnb":  "binancecoin",
+	"xrp":  "ripple",
+	"ada":  "cardano",
+	"doge": "dogecoin",
+	"trx":  "tron",
+	"ton":  "the-open-network",
+	"dot":  "polkadot",
+	"ltc":  "litecoin",
+	"avax": "avalanche-2",
+	"link": "chainlink",
+	"shib": "shiba-inu",
+	"usdt": "tether",
+	"usdc": "usd-coin",
+}
+
+// isCryptoTicker checks if a currency code is a supported crypto ticker
+func isCryptoTicker(code string) bool {
+	_, ok := cryptoCoins[strings.ToLower(code)]
+	return ok
+}
+
+// fetchCryptoPrices fetches the prices of CoinGecko coin IDs in a quote currency
+func fetchCryptoPrices(ids []string, quote string) (map[string]float64, error) {
+	cacheKey := strings.Join(ids, ",") + "|" + strings.ToLower(quote)
+	if prices, ok := cryptoCache.Get(cacheKey); ok {
+		return prices, nil
+	}
+
+	url := fmt.Sprintf("https://api.coingecko.com/api/v3/simple/price?ids=%s&vs_currencies=%s", strings.Join(ids, ","), strings.ToLower(quote))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if apiKey := os.Getenv("COINGECKO_API_KEY"); apiKey != "" {
+		req.Header.Set("x-cg-demo-api-key", apiKey)
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, upstreamDown("CoinGecko", fmt.Errorf("failed to fetch crypto prices: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError("CoinGecko", resp.StatusCode)
+	}
+
+	var response map[string]map[string]float64
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, upstreamDown("CoinGecko", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	prices := make(map[string]float64)
+	for _, id := range ids {
+		price, ok := response[id][strings.ToLower(quote)]
+		if !ok || price <= 0 {
+			return nil, notFound("no %s price for %s", strings.ToUpper(quote), id)
+		}
+		prices[id] = price
+	}
+
+	cryptoCache.Set(cacheKey, prices)
+	return prices, nil
+}
+
+// cryptoRate returns how much one unit of from is worth in to when either side is crypto.
+// Crypto-to-crypto pairs are priced through USD.
+func cryptoRate(from, to string) (float64, error) {
+	fromCoin, fromCrypto := cryptoCoins[from]
+	toCoin, toCrypto := cryptoCoins[to]
+
+	switch {
+	case fromCrypto && toCrypto:
+		prices, err := fetchCryptoPrices([]string{fromCoin, toCoin}, "usd")
+		if err != nil {
+			return 0, err
+		}
+		return prices[fromCoin] / prices[toCoin], nil
+	case fromCrypto:
+		prices, err := fetchCryptoPrices([]string{fromCoin}, to)
+		if errors.Is(err, ErrNotFound) {
+			return 0, notFound("currency %s not found", strings.ToUpper(to))
+		}
+		if err != nil {
+			return 0, err
+		}
+		return prices[fromCoin], nil
+	default:
+		prices, err := fetchCryptoPrices([]string{toCoin}, from)
+		if errors.Is(err, ErrNotFound) {
+			return 0, notFound("currency %s not found", strings.ToUpper(from))
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 1 / prices[toCoin], nil
+	}
+}
+
+// formatAmount formats an amount with precision suited to the currency:
+// up to 8 decimals for crypto and tiny values, 2 decimals for fiat
+func formatAmount(value float64, currency string) string {
+	if isCryptoTicker(currency) || (value != 0 && math.Abs(value) < 0.01) {
+		formatted := strconv.FormatFloat(value, 'f', 8, 64)
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+		if formatted == "0" || formatted == "-0" {
+			// Still too small for 8 decimals, e.g. IDR to BTC
+			return strconv.FormatFloat(value, 'g', 4, 64)
+		}
+		return formatted
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// formatRate formats an exchange rate, keeping significant digits for very small rates
+func formatRate(rate float64) string {
+	if rate != 0 && math.Abs(rate) < 0.0001 {
+		return strconv.FormatFloat(rate, 'g', 4, 64)
+	}
+	return fmt.Sprintf("%.4f", rate)
+}
+
+// amountMultipliers are the Indonesian and English shorthand suffixes accepted after an amount
+var amountMultipliers = map[string]float64{
+	"k":       1e3,
+	"rb":      1e3,
+	"ribu":    1e3,
+	"jt":      1e6,
+	"juta":    1e6,
+	"m":       1e6,
+	"b":       1e9,
+	"bn":      1e9,
+	"miliar":  1e9,
+	"milyar":  1e9,
+	"t":       1e12,
+	"triliun": 1e12,
+}
+
+// currencyCode matches a three-letter currency code like "idr"
+var currencyCode = regexp.MustCompile(`^[a-z]{3}$`)
+
+// amountPattern splits an amount like "1,5jt" or "500kidr" into its number and the letters after it
+var amountPattern = regexp.MustCompile(`^(\d[\d.,]*)([a-z]*)$`)
+
+// parseNumber parses a number using either comma or point as the thousand or decimal separator,
+// e.g. "1.000.000", "1,000,000", "1,5" and "2.50"
+func parseNumber(value string, hasMultiplier bool) (float64, error) {
+	commas := strings.Count(value, ",")
+	points := strings.Count(value, ".")
+
+	switch {
+	case commas > 0 && points > 0:
+		// The separator used last is the decimal one: "1.234,56" or "1,234.56"
+		if strings.LastIndex(value, ",") > strings.LastIndex(value, ".") {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case commas > 1:
+		value = strings.ReplaceAll(value, ",", "")
+	case points > 1:
+		value = strings.ReplaceAll(value, ".", "")
+	case commas == 1 || points == 1:
+		// A single separator followed by exactly three digits is a thousand separator
+		// ("1.000", "25,000"), unless a multiplier follows ("1,500jt")
+		sep := ","
+		if points == 1 {
+			sep = "."
+		}
+		_, decimals, _ := strings.Cut(value, sep)
+		if len(decimals) == 3 && !hasMultiplier {
+			value = strings.Replace(value, sep, "", 1)
+		} else {
+			value = strings.Replace(value, sep, ".", 1)
+		}
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// splitAmount parses an amount with an optional shorthand multiplier and currency code,
+// e.g. "500k", "1,5jt", "2m" or "500kidr". The currency is "" when none is attached.
+func splitAmount(value string) (amount float64, currency string, err error) {
+	matches := amountPattern.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, "", invalidInput("invalid amount: %s", value)
+	}
+	number, rest := matches[1], matches[2]
+
+	multiplier := 1.0
+	if m, ok := amountMultipliers[rest]; ok {
+		multiplier = m
+	} else if len(rest) != 3 && !isCryptoTicker(rest) {
+		// Letters other than a plain currency code must start with a multiplier, e.g. "kidr"
+		for suffix, m := range amountMultipliers {
+			code := strings.TrimPrefix(rest, suffix)
+			if strings.HasPrefix(rest, suffix) && (len(code) == 3 || isCryptoTicker(code)) {
+				multiplier = m
+				currency = code
+				break
+			}
+		}
+		if currency == "" && rest != "" {
+			return 0, "", invalidInput("invalid amount: %s", value)
+		}
+	} else {
+		currency = rest
+	}
+
+	amount, err = parseNumber(strings.TrimRight(number, ".,"), multiplier != 1)
+	if err != nil {
+		return 0, "", invalidInput("invalid amount: %s", number)
+	}
+	return amount * multiplier, currency, nil
+}
+
+// parseConversionQuery parses /convert input, including the question forms
+// "?usd 1000000idr" (how many USD is 1,000,000 IDR) and "budget 100usd idr"
+// (how much IDR buys 100 USD). A budget query converts the same way as
+// "100usd idr" and only sets budget, so the result is labeled as the amount
+// needed. When the input has no target currency, defaultTo is used if set.
+func parseConversionQuery(input, defaultTo string) (amount float64, from, to string, budget bool, err error) {
+	input = strings.TrimSpace(strings.ToLower(input))
+
+	if rest, ok := strings.CutPrefix(input, "?"); ok {
+		// "?usd 1000000idr" asks the same as "1000000idr usd"
+		target, amountPart, found := strings.Cut(strings.TrimSpace(rest), " ")
+		if !found || target == "" {
+			return 0, "", "", false, invalidInput("invalid question. Use format like '?usd 1jt idr' or '?usd 1000000idr'")
+		}
+		amount, from, to, err = parseCurrencyInput(amountPart + " " + target)
+		return amount, from, to, false, err
+	}
+
+	if rest, ok := strings.CutPrefix(input, "budget "); ok {
+		// "budget 100usd idr": 100 USD is what we want to end up with, IDR is what we pay with
+		amount, from, to, err = parseCurrencyInputWithDefault(rest, defaultTo)
+		return amount, from, to, true, err
+	}
+
+	amount, from, to, err = parseCurrencyInputWithDefault(input, defaultTo)
+	return amount, from, to, false, err
+}
+
+// parseCurrencyInputWithDefault parses the input, retrying with the default target currency
+// appended when the input on its own is incomplete, e.g. "$500"
+func parseCurrencyInputWithDefault(input, defaultTo string) (amount float64, from, to string, err error) {
+	amount, from, to, err = parseCurrencyInput(input)
+	if err != nil && defaultTo != "" {
+		if amount, from, to, retryErr := parseCurrencyInput(input + " " + defaultTo); retryErr == nil {
+			return amount, from, to, nil
+		}
+	}
+	return amount, from, to, err
+}
+
+// parseCurrencyInput parses currency conversion input like "$500 idr", "500jpy idr" or "1,5jt idr usd"
+func parseCurrencyInput(input string) (amount float64, from, to string, err error) {
+	// Remove extra spaces and convert to lowercase
+	input = strings.TrimSpace(strings.ToLower(input))
+
+	// Split by spaces, the source currency may be attached to the amount or follow it
+	parts := strings.Fields(input)
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, "", "", invalidInput("invalid format. Use format like '$500 idr', '500jpy idr' or '1,5jt idr usd'")
+	}
+
+	fromPart := parts[0]
+	to = parts[len(parts)-1]
+
+	// Handle different formats for the amount and source currency
+	var amountStr string
+
+	// Check if it starts with a currency symbol
+	if strings.HasPrefix(fromPart, "$") {
+		from = "usd"
+		amountStr = strings.TrimPrefix(fromPart, "$")
+	} else if strings.HasPrefix(fromPart, "€") || strings.HasPrefix(fromPart, "eur") {
+		from = "eur"
+		amountStr = strings.TrimPrefix(fromPart, "€")
+		amountStr = strings.TrimPrefix(amountStr, "eur")
+	} else if strings.HasPrefix(fromPart, "£") || strings.HasPrefix(fromPart, "gbp") {
+		from = "gbp"
+		amountStr = strings.TrimPrefix(fromPart, "£")
+		amountStr = strings.TrimPrefix(amountStr, "gbp")
+	} else if strings.HasPrefix(fromPart, "¥") || strings.HasPrefix(fromPart, "jpy") {
+		from = "jpy"
+		amountStr = strings.TrimPrefix(fromPart, "¥")
+		amountStr = strings.TrimPrefix(amountStr, "jpy")
+	} else if strings.HasPrefix(fromPart, "rp") {
+		from = "idr"
+		amountStr = strings.TrimPrefix(fromPart, "rp")
+	} else {
+		// The currency code may be attached at the end, e.g. "500kidr"
+		amountStr = fromPart
+	}
+
+	// Parse the amount and any shorthand multiplier
+	amount, currencyCode, err := splitAmount(amountStr)
+	if err != nil {
+		return 0, "", "", err
+	}
+	if currencyCode != "" {
+		if from != "" {
+			return 0, "", "", invalidInput("invalid amount: %s", amountStr)
+		}
+		from = currencyCode
+	}
+
+	if len(parts) == 3 {
+		if from != "" {
+			return 0, "", "", invalidInput("too many currencies. Use format like '500k idr usd' or '500kidr usd'")
+		}
+		from = parts[1]
+	}
+	if from == "" {
+		return 0, "", "", invalidInput("source currency not specified")
+	}
+
+	if amount <= 0 {
+		return 0, "", "", invalidInput("amount must be positive")
+	}
+
+	return amount, from, to, nil
+}
+
+// dataMigration upgrades the stored JSON files from the previous schema version to Version
+type dataMigration struct {
+	Version     int
+	Description string
+	Apply       func() error
+}
+
+// dataMigrations run in order on startup. Append new ones with the next version number,
+// never edit or remove a migration that has shipped.
+var dataMigrations = []dataMigration{
+	{1, "baseline schema", func() error { return nil }},
+	{2, "canonicalize seen links of feed subscriptions", migrateCanonicalSeenLinks},
+	{3, "stamp auto-reply rules with a creation time", migrateAutoReplyCreatedAt},
+}
+
+// DataVersion is stored in versionFile and records which migrations have run
+type DataVersion struct {
+	Version    int       `json:"version"`
+	MigratedAt time.Time `json:"migrated_at"`
+}
+
+// loadDataVersion reads the schema version, 0 when the data predates versioning
+func loadDataVersion() DataVersion {
+	var version DataVersion
+	data, err := os.ReadFile(versionFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading data version file: %v", err)
+		}
+		return version
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		log.Printf("Error parsing data version file: %v", err)
+	}
+	return version
+}
+
+// saveDataVersion records the schema version
+func saveDataVersion(version int) {
+	data, err := json.MarshalIndent(DataVersion{Version: version, MigratedAt: time.Now()}, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling data version: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(versionFile, data, 0644); err != nil {
+		log.Printf("Error saving data version: %v", err)
+		return
+	}
+}
+
+// runMigrations upgrades old data files to the current schema before they are loaded.
+// All data files are backed up first; if the backup fails nothing is migrated.
+func runMigrations() {
+	latest := dataMigrations[len(dataMigrations)-1].Version
+	current := loadDataVersion().Version
+	if current >= latest {
+		return
+	}
+
+	// A fresh install has nothing to migrate
+	var existing []string
+	for _, file := range dataFiles {
+		if _, err := os.Stat(file); err == nil {
+			existing = append(existing, file)
+		}
+	}
+	if len(existing) == 0 {
+		saveDataVersion(latest)
+		return
+	}
+
+	backupDir := filepath.Join(backupsDir, time.Now().Format("20060102-150405"))
+	if err := backupDataFiles(backupDir, existing); err != nil {
+		log.Printf("Error backing up data before migration, skipping migrations: %v", err)
+		return
+	}
+	log.Printf("Backed up %d data files to %s", len(existing), backupDir)
+
+	for _, migration := range dataMigrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err := migration.Apply(); err != nil {
+			log.Printf("Error running data migration %d (%s), stopping at version %d: %v", migration.Version, migration.Description, current, err)
+			return
+		}
+		current = migration.Version
+		saveDataVersion(current)
+		log.Printf("Migrated data to version %d: %s", migration.Version, migration.Description)
+	}
+}
+
+// backupDataFiles copies data files into dir
+func backupDataFiles(dir string, files []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteJSONFile decodes a data file into raw JSON objects, lets update change them and writes
+// the file back. Working on raw objects lets migrations rename keys the current structs no longer know.
+func rewriteJSONFile(file string, target interface{}, update func() error) error {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	if err := update(); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(target, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// migrateCanonicalSeenLinks rewrites feed subscriptions' seen links in canonical form,
+// so articles seen before deduplication compared canonical links are not posted again
+func migrateCanonicalSeenLinks() error {
+	var subs []map[string]json.RawMessage
+	return rewriteJSONFile(feedsFile, &subs, func() error {
+		for _, sub := range subs {
+			raw, ok := sub["seen"]
+			if !ok {
+				continue
+			}
+			var seen []string
+			if err := json.Unmarshal(raw, &seen); err != nil {
+				return err
+			}
+
+			unique := make(map[string]bool)
+			var canonical []string
+			for _, link := range seen {
+				link = canonicalLink(link)
+				if !unique[link] {
+					unique[link] = true
+					canonical = append(canonical, link)
+				}
+			}
+
+			data, err := json.Marshal(canonical)
+			if err != nil {
+				return err
+			}
+			sub["seen"] = data
+		}
+		return nil
+	})
+}
+
+// migrateAutoReplyCreatedAt gives rules created before usage tracking a creation time of
+// now, so /replies audit only calls them stale once they go unused for the full period
+func migrateAutoReplyCreatedAt() error {
+	var replies map[string][]map[string]interface{}
+	return rewriteJSONFile(dataFile, &replies, func() error {
+		now := time.Now().Format(time.RFC3339)
+		for _, rules := range replies {
+			for _, rule := range rules {
+				if _, ok := rule["created_at"]; !ok {
+					rule["created_at"] = now
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// loadAutoReplies loads auto-reply rules from JSON file
+func loadAutoReplies() {
+	serverAutoReplies = make(ServerAutoReplies)
+
+	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		log.Printf("Error reading auto-replies file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &serverAutoReplies); err != nil {
+		log.Printf("Error parsing auto-replies file: %v", err)
+		return
+	}
+
+	totalRules := 0
+	for guildID, replies := range serverAutoReplies {
+		totalRules += len(replies)
+		for idx := range replies {
+			if err := compileTrigger(&replies[idx]); err != nil {
+				log.Printf("Regex auto-reply %q in guild %s never fires: %v", replies[idx].Trigger, guildID, err)
+			}
+		}
+	}
+	log.Printf("Loaded %d auto-reply rules across %d servers", totalRules, len(serverAutoReplies))
+}
+
+// saveAutoReplies saves auto-reply rules to JSON file
+func saveAutoReplies() {
+	repliesDirty = false
+	data, err := json.MarshalIndent(serverAutoReplies, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling auto-replies: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(dataFile, data, 0644); err != nil {
+		log.Printf("Error saving auto-replies: %v", err)
+		return
+	}
+}
+
+// flushAutoReplies saves the rules if a reply fired since they were last saved
+func flushAutoReplies() {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+	if repliesDirty {
+		saveAutoReplies()
+	}
+}
+
+// addAutoReply adds a new auto-reply rule for a specific server, or changes the response
+// of an existing one and keeps its other options
+func addAutoReply(trigger, response, authorID, guildID string) (bool, string, string) {
+	return addAutoReplyRule(guildID, draftRule(guildID, trigger, response), authorID)
+}
+
+// draftRule returns the server's rule for trigger with a new response, or a new rule
+func draftRule(guildID, trigger, response string) AutoReply {
+	rule, ok := findAutoReply(guildID, AutoReply{Trigger: trigger})
+	if !ok {
+		rule = AutoReply{Trigger: trigger}
+	}
+	rule.Response = response
+	return rule
+}
+
+// addAutoReplyRule saves a rule's response and options, creating it or replacing the
+// options of the rule with the same trigger. Usage and history are kept on updates.
+func addAutoReplyRule(guildID string, rule AutoReply, authorID string) (bool, string, string) {
+	if err := compileTrigger(&rule); err != nil {
+		return false, userErrorMessage(err), ""
+	}
+	limit := getGuildSettings(guildID).replyLimit()
+
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	// Initialize server replies if not exists
+	if serverAutoReplies[guildID] == nil {
+		serverAutoReplies[guildID] = make([]AutoReply, 0)
+	}
+
+	// Word triggers are stored lowercase, regex triggers keep their case since \D isn't \d
+	if !rule.Regex {
+		rule.Trigger = strings.ToLower(rule.Trigger)
+	}
+
+	// Check if trigger already exists in this server
+	for i, reply := range serverAutoReplies[guildID] {
+		if sameTrigger(reply, rule) {
+			// Check if the current user is the author
+			if reply.AuthorID != "" && reply.AuthorID != authorID {
+				return false, fmt.Sprintf("you can't change this you bartard <@%s>", authorID), ""
+			}
+
+			// Update existing reply, keeping the old response for /replies history
+			updated := rule
+			updated.AuthorID = authorID
+			updated.CreatedAt = reply.CreatedAt
+			updated.LastFired = reply.LastFired
+			updated.FireCount = reply.FireCount
+			updated.History = reply.History
+			if updated.Match == reply.Match {
+				updated.Greeted = reply.Greeted
+			}
+			if reply.Response != rule.Response {
+				updated.History = append(updated.History, ReplyVersion{Response: reply.Response, EditedBy: authorID, EditedAt: time.Now()})
+				if len(updated.History) > maxReplyVersions {
+					updated.History = updated.History[len(updated.History)-maxReplyVersions:]
+				}
+			}
+			serverAutoReplies[guildID][i] = updated
+			removeUnusedMedia(guildID, reply.Attachments)
+			saveAutoReplies()
+			return true, "Auto-reply updated successfully!", ""
+		}
+	}
+
+	if len(serverAutoReplies[guildID]) >= limit {
+		return false, fmt.Sprintf("This server has reached its limit of %d auto-replies. Remove rules nobody uses (`/replies audit` finds them), or ask an admin to raise the limit with `/settings auto_replies`.", limit), ""
+	}
+
+	// Add new auto-reply
+	rule.AuthorID = authorID
+	rule.CreatedAt = time.Now()
+	rule.LastFired = time.Time{}
+	rule.FireCount = 0
+	rule.History = nil
+	rule.Greeted = nil
+	serverAutoReplies[guildID] = append(serverAutoReplies[guildID], rule)
+	saveAutoReplies()
+	return true, "Auto-reply created successfully!", ""
+}
+
+// matchLabels describe AutoReply.Match values in previews and lists
+var matchLabels = map[string]string{
+	"":         "message text",
+	"nickname": "nickname",
+	"role":     "role names",
+}
+
+// channelMentions lists channels as <#mentions>
+func channelMentions(channelIDs []string) string {
+	mentions := make([]string, len(channelIDs))
+	for n, channelID := range channelIDs {
+		mentions[n] = "<#" + channelID + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// parseReplyChannels reads the /reply channels option: channel mentions or IDs separated
+// by spaces or commas, or "all" to let the rule fire everywhere again
+func parseReplyChannels(s *discordgo.Session, guildID, value string) ([]string, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "all") {
+		return nil, nil
+	}
+
+	var channelIDs []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		channelID, err := resolveGuildChannel(s, guildID, field)
+		if err != nil {
+			return nil, invalidInput("%v", err)
+		}
+		if !slices.Contains(channelIDs, channelID) {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	switch {
+	case len(channelIDs) == 0:
+		return nil, invalidInput("list channels like `#general #memes`, or `all` for every channel")
+	case len(channelIDs) > maxReplyChannels:
+		return nil, invalidInput("a rule can be limited to at most %d channels", maxReplyChannels)
+	}
+	return channelIDs, nil
+}
+
+// parseReplyRoles reads the /reply roles option: role mentions or IDs separated by spaces
+// or commas, or "all" to let everyone set the rule off again
+func parseReplyRoles(s *discordgo.Session, guildID, value string) ([]string, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "all") {
+		return nil, nil
+	}
+
+	var roleIDs []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		roleID := strings.TrimSuffix(strings.TrimPrefix(field, "<@&"), ">")
+		if _, err := s.State.Role(guildID, roleID); err != nil {
+			return nil, invalidInput("role %s not found in this server", field)
+		}
+		if !slices.Contains(roleIDs, roleID) {
+			roleIDs = append(roleIDs, roleID)
+		}
+	}
+	switch {
+	case len(roleIDs) == 0:
+		return nil, invalidInput("list roles like `@Member @VIP`, or `all` for everyone")
+	case len(roleIDs) > maxReplyTargets:
+		return nil, invalidInput("a rule can be limited to at most %d roles", maxReplyTargets)
+	}
+	return roleIDs, nil
+}
+
+// parseReplyUsers reads the /reply users and ignore_users options: user mentions or IDs
+// separated by spaces or commas, or "none" to clear the list
+func parseReplyUsers(option, value string) ([]string, error) {
+	if strings.EqualFold(strings.TrimSpace(value), "none") {
+		return nil, nil
+	}
+
+	var userIDs []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		userID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(field, "<@"), "!"), ">")
+		if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+			return nil, invalidInput("`%s` isn't a member, mention them like `@someone`", field)
+		}
+		if !slices.Contains(userIDs, userID) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	switch {
+	case len(userIDs) == 0:
+		return nil, invalidInput("list members for `%s` like `@alice @bob`, or `none` to clear it", option)
+	case len(userIDs) > maxReplyTargets:
+		return nil, invalidInput("`%s` can list at most %d members", option, maxReplyTargets)
+	}
+	return userIDs, nil
+}
+
+// firesFor reports whether a message by the user, who has the roles, can set the rule off
+func (reply AutoReply) firesFor(userID string, roleIDs []string) bool {
+	if slices.Contains(reply.IgnoredUserIDs, userID) {
+		return false
+	}
+	if len(reply.RoleIDs) == 0 && len(reply.UserIDs) == 0 {
+		return true
+	}
+	return slices.Contains(reply.UserIDs, userID) || slices.ContainsFunc(roleIDs, func(id string) bool { return slices.Contains(reply.RoleIDs, id) })
+}
+
+// ruleAudience describes who can set a rule off, "" for everyone
+func ruleAudience(rule AutoReply) string {
+	var who []string
+	if len(rule.RoleIDs) > 0 {
+		who = append(who, "members with "+roleMentions(rule.RoleIDs))
+	}
+	if len(rule.UserIDs) > 0 {
+		who = append(who, userMentions(rule.UserIDs))
+	}
+	audience := strings.Join(who, " or ")
+	if len(rule.IgnoredUserIDs) > 0 {
+		if audience == "" {
+			audience = "everyone"
+		}
+		audience += " except " + userMentions(rule.IgnoredUserIDs)
+	}
+	return audience
+}
+
+// userMentions lists users as <@mentions>
+func userMentions(userIDs []string) string {
+	mentions := make([]string, len(userIDs))
+	for n, id := range userIDs {
+		mentions[n] = "<@" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// customEmoji matches a custom emoji as it is typed in a message, like <:pepe:123> or <a:dance:456>
+var customEmoji = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+
+// parseReactions reads a reaction rule's response into the emoji MessageReactionAdd takes:
+// unicode emoji as they are and custom emoji as name:id
+func parseReactions(response string) ([]string, error) {
+	var emoji []string
+	for _, field := range strings.Fields(response) {
+		if match := customEmoji.FindStringSubmatch(field); match != nil {
+			emoji = append(emoji, match[1]+":"+match[2])
+			continue
+		}
+		if !isEmoji(field) {
+			return nil, invalidInput("`%s` isn't an emoji, separate emoji with spaces like `👍 🎉`", field)
+		}
+		emoji = append(emoji, field)
+	}
+	switch {
+	case len(emoji) == 0:
+		return nil, invalidInput("list the emoji to react with, like `👍 🎉`")
+	case len(emoji) > maxReplyReactions:
+		return nil, invalidInput("a rule can react with at most %d emoji", maxReplyReactions)
+	}
+	return emoji, nil
+}
+
+// pictographRanges are the code points an emoji can be built on, besides keycaps and flags
+var pictographRanges = []struct{ lo, hi rune }{
+	{0x00a9, 0x00a9}, {0x00ae, 0x00ae}, {0x203c, 0x203c}, {0x2049, 0x2049},
+	{0x2122, 0x2139}, {0x2194, 0x21aa}, {0x231a, 0x23ff}, {0x24c2, 0x24c2},
+	{0x25aa, 0x25fe}, {0x2600, 0x27bf}, {0x2934, 0x2935}, {0x2b05, 0x2b55},
+	{0x3030, 0x3030}, {0x303d, 0x303d}, {0x3297, 0x3299}, {0x1f000, 0x1faff},
+}
+
+// isPictograph reports whether r can stand on its own as an emoji
+func isPictograph(r rune) bool {
+	for _, span := range pictographRanges {
+		if r >= span.lo && r <= span.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// isRegionalIndicator reports whether r is one of the letters country flags are made of
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isEmoji reports whether text is exactly one emoji: a keycap like 1️⃣, a flag, or
+// pictographs joined by zero width joiners, each with an optional skin tone and
+// variation selector
+func isEmoji(text string) bool {
+	runes := []rune(text)
+	switch {
+	case len(runes) == 0:
+		return false
+	case strings.ContainsRune("0123456789#*", runes[0]):
+		rest := runes[1:]
+		if len(rest) > 0 && rest[0] == 0xfe0f {
+			rest = rest[1:]
+		}
+		return len(rest) == 1 && rest[0] == 0x20e3
+	case isRegionalIndicator(runes[0]):
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	case runes[0] == 0x1f3f4 && len(runes) > 2 && runes[len(runes)-1] == 0xe007f:
+		// Subdivision flags like Scotland's spell the region in tag characters
+		for _, r := range runes[1 : len(runes)-1] {
+			if r < 0xe0020 || r > 0xe007e {
+				return false
+			}
+		}
+		return true
+	}
+
+	needPictograph := true
+	for _, r := range runes {
+		switch {
+		case needPictograph:
+			if !isPictograph(r) {
+				return false
+			}
+			needPictograph = false
+		case r == 0x200d:
+			needPictograph = true
+		case r != 0xfe0f && (r < 0x1f3fb || r > 0x1f3ff):
+			return false
+		}
+	}
+	return !needPictograph
+}
+
+// storeReplyMedia saves an uploaded file for an auto-reply under replyMediaDir, named by
+// its content so uploading the same file twice keeps one copy
+func storeReplyMedia(guildID, name string, data []byte) (string, error) {
+	dir := filepath.Join(replyMediaDir, guildID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, filepath.Base(name))
+	file := filepath.Join(dir, hex.EncodeToString(sum[:6])+"-"+name)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// removeUnusedMedia deletes stored files of attachments no rule in the server uses anymore.
+// The caller must hold repliesMu.
+func removeUnusedMedia(guildID string, attachments []ReplyAttachment) {
+	for _, attachment := range attachments {
+		if attachment.Path == "" {
+			continue
+		}
+		used := false
+		for _, rule := range serverAutoReplies[guildID] {
+			for _, other := range rule.Attachments {
+				used = used || other.Path == attachment.Path
+			}
+		}
+		if !used {
+			if err := os.Remove(attachment.Path); err != nil && !os.IsNotExist(err) {
+				log.Printf("Error removing auto-reply media %s: %v", attachment.Path, err)
+			}
+		}
+	}
+}
+
+// sendReplyWithMedia sends an auto-reply with its attachments to a channel, uploading stored
+// files and linking URLs so Discord shows their preview
+func sendReplyWithMedia(s *discordgo.Session, channelID string, reference *discordgo.MessageReference, response string, attachments []ReplyAttachment) error {
+	var files []*discordgo.File
+	var links []string
+	for _, attachment := range attachments {
+		if attachment.URL != "" {
+			links = append(links, attachment.URL)
+			continue
+		}
+		f, err := os.Open(attachment.Path)
+		if err != nil {
+			log.Printf("Error opening auto-reply media %s: %v", attachment.Path, err)
+			continue
+		}
+		defer f.Close()
+		files = append(files, &discordgo.File{Name: attachment.Name, Reader: f})
+	}
+
+	content := strings.TrimSpace(strings.Join(append([]string{response}, links...), "\n"))
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:   content,
+		Files:     files,
+		Reference: reference,
+	})
+	return err
+}
+
+// sendReplyDM sends a "dm" rule's response to the author of the triggering message, with a
+// link back to it. It fails when the member doesn't accept DMs from the server.
+func sendReplyDM(s *discordgo.Session, m *discordgo.MessageCreate, response string, attachments []ReplyAttachment) error {
+	dm, err := s.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		return err
+	}
+	guildName := "the server"
+	if guild, err := s.State.Guild(m.GuildID); err == nil {
+		guildName = guild.Name
+	}
+	// A response near the 2000 character limit is shortened to keep the link
+	footer := fmt.Sprintf("\n-# Auto-reply to [your message](https://discord.com/channels/%s/%s/%s) in %s", m.GuildID, m.ChannelID, m.ID, guildName)
+	response = truncate(response, 2000-len(footer)) + footer
+	if len(attachments) > 0 {
+		return sendReplyWithMedia(s, dm.ID, nil, response, attachments)
+	}
+	_, err = s.ChannelMessageSend(dm.ID, response)
+	return err
+}
+
+// replyCooldown is how long a rule waits between firing, see AutoReply.Cooldown
+func replyCooldown(rule AutoReply) time.Duration {
+	switch {
+	case rule.Cooldown < 0:
+		return 0
+	case rule.Cooldown == 0:
+		return defaultReplyCooldown
+	}
+	return time.Duration(rule.Cooldown) * time.Second
+}
+
+// pickResponse returns one of a rule's responses at random
+func pickResponse(rule AutoReply) string {
+	if len(rule.Responses) == 0 {
+		return rule.Response
+	}
+	n := mathrand.IntN(len(rule.Responses) + 1)
+	if n == 0 {
+		return rule.Response
+	}
+	return rule.Responses[n-1]
+}
+
+// regexLabel describes how a regex rule treats case
+func regexLabel(rule AutoReply) string {
+	if rule.CaseSensitive {
+		return "case-sensitive"
+	}
+	return "ignores case"
+}
+
+// ruleSummary describes a rule's trigger, response and options for embeds
+func ruleSummary(rule AutoReply) string {
+	summary := fmt.Sprintf("**Trigger:** %s\n**Response:** %s", rule.Trigger, rule.Response)
+	if rule.Match != "" {
+		summary += fmt.Sprintf("\n**Matches:** %s, once per member", matchLabels[rule.Match])
+	}
+	if rule.Regex {
+		summary += "\n**Regex:** " + regexLabel(rule)
+	}
+	for n, response := range rule.Responses {
+		summary += fmt.Sprintf("\n**Response %d:** %s", n+2, response)
+	}
+	if len(rule.ChannelIDs) > 0 {
+		summary += "\n**Channels:** " + channelMentions(rule.ChannelIDs)
+	}
+	if audience := ruleAudience(rule); audience != "" {
+		summary += "\n**Fires for:** " + audience
+	}
+	switch rule.ResponseType {
+	case "reaction":
+		summary += "\n**Responds with:** reactions"
+	case "dm":
+		summary += "\n**Responds with:** a DM"
+	}
+	for _, attachment := range rule.Attachments {
+		if attachment.URL != "" {
+			summary += "\n**Media:** " + attachment.URL
+		} else {
+			summary += fmt.Sprintf("\n**Media:** `%s` (uploaded)", attachment.Name)
+		}
+	}
+	if rule.Cooldown != 0 && rule.Match == "" {
+		summary += "\n**Cooldown:** " + replyCooldown(rule).String()
+	}
+	if !rule.ExpiresAt.IsZero() {
+		summary += fmt.Sprintf("\n**Expires:** <t:%d:f> (<t:%d:R>)", rule.ExpiresAt.Unix(), rule.ExpiresAt.Unix())
+	}
+	if rule.DeleteTrigger {
+		summary += "\n**Deletes the message:** yes"
+	}
+	return summary
+}
+
+// removeAutoReply removes an auto-reply rule from a specific server
+func removeAutoReply(trigger, authorID, guildID string, moderator bool) (bool, string, string) {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	if serverAutoReplies[guildID] == nil {
+		return false, "No auto-reply found for that trigger.", ""
+	}
+
+	for i, reply := range serverAutoReplies[guildID] {
+		if sameTrigger(reply, AutoReply{Trigger: trigger}) {
+			// Check if the current user is the author, moderators may clean up anyone's rules
+			othersRule := reply.AuthorID != "" && reply.AuthorID != authorID
+			if othersRule && !moderator {
+				return false, fmt.Sprintf("you can't change this you bartard <@%s>", authorID), ""
+			}
+
+			// Remove the element
+			serverAutoReplies[guildID] = append(serverAutoReplies[guildID][:i], serverAutoReplies[guildID][i+1:]...)
+			removeUnusedMedia(guildID, reply.Attachments)
+
+			// Clean up empty server entries
+			if len(serverAutoReplies[guildID]) == 0 {
+				delete(serverAutoReplies, guildID)
+			}
+
+			saveAutoReplies()
+			if othersRule {
+				return true, fmt.Sprintf("Auto-reply by <@%s> removed successfully!", reply.AuthorID), reply.AuthorID
+			}
+			return true, "Auto-reply removed successfully!", ""
+		}
+	}
+	return false, "No auto-reply found for that trigger.", ""
+}
+
+// builtinProfanity are the words filtered from auto-reply responses in every server,
+// Indonesian and English, matched as whole words after undoing common letter swaps
+var builtinProfanity = map[string]bool{
+	// Indonesian
+	"anjing": true, "anjg": true, "anjink": true, "bangsat": true, "bajingan": true, "kontol": true,
+	"memek": true, "ngentot": true, "entot": true, "jancok": true, "jancuk": true, "jembut": true,
+	"pepek": true, "perek": true, "lonte": true, "pelacur": true, "kampret": true, "keparat": true,
+	"goblok": true, "tolol": true, "brengsek": true, "bego": true, "titit": true, "ngewe": true,
+	// English
+	"fuck": true, "fucking": true, "fucker": true, "fucked": true, "motherfucker": true, "shit": true,
+	"bullshit": true, "bitch": true, "asshole": true, "bastard": true, "cunt": true, "dick": true,
+	"pussy": true, "slut": true, "whore": true, "faggot": true, "fag": true, "nigger": true,
+	"nigga": true, "retard": true, "retarded": true,
+}
+
+// leetReplacer undoes digit and symbol swaps people use to slip past word filters
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// profanityWord matches the words checked by the filter, including leetspeak characters
+var profanityWord = regexp.MustCompile(`[\p{L}\p{N}@$]+`)
+
+// profanityRejectedMessage is the reply when a new rule's response contains a blocked word
+const profanityRejectedMessage = "❌ That response contains words that aren't allowed in this server. Please rephrase it."
+
+// isProfane reports whether a single word is on the built-in or server word list
+func isProfane(word string, custom map[string]bool) bool {
+	word = leetReplacer.Replace(strings.ToLower(word))
+	return builtinProfanity[word] || custom[word]
+}
+
+// maskProfanity replaces blocked words in text, keeping their first letter, and reports
+// whether any were found
+func maskProfanity(text string, customWords []string) (string, bool) {
+	custom := make(map[string]bool, len(customWords))
+	for _, word := range customWords {
+		custom[word] = true
+	}
+
+	found := false
+	masked := profanityWord.ReplaceAllStringFunc(text, func(word string) string {
+		if !isProfane(word, custom) {
+			return word
+		}
+		found = true
+		runes := []rune(word)
+		return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	})
+	return masked, found
+}
+
+// screenReplyResponse applies the server's profanity mode to a response. It returns the
+// response to use, masked if needed, and false if the response must be rejected.
+func screenReplyResponse(guildID, response string) (string, bool) {
+	settings := getGuildSettings(guildID)
+	if settings.ProfanityMode == "off" {
+		return response, true
+	}
+
+	masked, found := maskProfanity(response, settings.ProfanityWords)
+	if !found {
+		return response, true
+	}
+	if settings.ProfanityMode == "mask" {
+		return masked, true
+	}
+	return "", false
+}
+
+// profanitySetting handles /settings profanity mode|add|remove|list and returns the reply
+func profanitySetting(guildID string, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	var value string
+	if len(subcommand.Options) > 0 {
+		value = strings.ToLower(strings.TrimSpace(subcommand.Options[0].StringValue()))
+	}
+
+	switch subcommand.Name {
+	case "mode":
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.ProfanityMode = value
+		})
+		switch value {
+		case "off":
+			return "✅ Profanity filter turned off for auto-replies."
+		case "mask":
+			return "✅ Blocked words in auto-replies will be masked, e.g. `f***`."
+		default:
+			return "✅ Auto-replies containing blocked words will be rejected."
+		}
+
+	case "add":
+		word := leetReplacer.Replace(value)
+		if word == "" || strings.ContainsAny(word, " \t") {
+			return "❌ Add one word at a time."
+		}
+		if isProfane(word, nil) {
+			return fmt.Sprintf("📝 `%s` is already on the built-in list.", word)
+		}
+		added := true
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			for _, existing := range settings.ProfanityWords {
+				if existing == word {
+					added = false
+					return
+				}
+			}
+			settings.ProfanityWords = toggleID(settings.ProfanityWords, word, true)
+		})
+		if !added {
+			return fmt.Sprintf("📝 `%s` is already blocked.", word)
+		}
+		return fmt.Sprintf("✅ `%s` added to this server's blocked words.", word)
+
+	case "remove":
+		word := leetReplacer.Replace(value)
+		removed := false
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			var kept []string
+			for _, existing := range settings.ProfanityWords {
+				if existing == word {
+					removed = true
+					continue
+				}
+				kept = append(kept, existing)
+			}
+			settings.ProfanityWords = kept
+		})
+		if !removed {
+			return fmt.Sprintf("❌ `%s` isn't on this server's list. Built-in words can't be removed, but `/settings profanity mode off` disables the filter.", word)
+		}
+		return fmt.Sprintf("✅ `%s` removed from this server's blocked words.", word)
+
+	default:
+		settings := getGuildSettings(guildID)
+		mode := settings.ProfanityMode
+		if mode == "" {
+			mode = "reject"
+		}
+		content := fmt.Sprintf("🧼 Profanity filter: **%s**, with %d built-in words.", mode, len(builtinProfanity))
+		if len(settings.ProfanityWords) > 0 {
+			content += "\nServer additions: ||" + strings.Join(settings.ProfanityWords, ", ") + "||"
+		}
+		return content
+	}
+}
+
+// watchwordsSetting handles /settings watchwords add|remove|list|channel and returns the reply
+func watchwordsSetting(guildID string, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	var value string
+	if len(subcommand.Options) > 0 && subcommand.Name != "channel" {
+		value = strings.ToLower(strings.Join(strings.Fields(subcommand.Options[0].StringValue()), " "))
+	}
+
+	switch subcommand.Name {
+	case "add":
+		if value == "" {
+			return "❌ Give the word or phrase to watch for."
+		}
+		var problem string
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			switch {
+			case slices.Contains(settings.Watchwords, value):
+				problem = fmt.Sprintf("📝 `%s` is already watched.", value)
+			case len(settings.Watchwords) >= maxWatchwords:
+				problem = fmt.Sprintf("❌ A server can watch at most %d words.", maxWatchwords)
+			default:
+				settings.Watchwords = toggleID(settings.Watchwords, value, true)
+			}
+		})
+		if problem != "" {
+			return problem
+		}
+		content := fmt.Sprintf("✅ Messages containing `%s` now alert moderators, the message stays up.", value)
+		if getGuildSettings(guildID).ModChannel == "" {
+			content += "\n⚠️ Pick where alerts go with `/settings watchwords channel`."
+		}
+		return content
+
+	case "remove":
+		removed := false
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			kept := slices.DeleteFunc(slices.Clone(settings.Watchwords), func(word string) bool { return word == value })
+			removed = len(kept) < len(settings.Watchwords)
+			settings.Watchwords = kept
+		})
+		if !removed {
+			return fmt.Sprintf("❌ `%s` isn't watched.", value)
+		}
+		return fmt.Sprintf("✅ `%s` is no longer watched.", value)
+
+	case "channel":
+		channelID := subcommand.Options[0].ChannelValue(nil).ID
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.ModChannel = channelID
+		})
+		return fmt.Sprintf("✅ Watchword alerts now go to <#%s>.", channelID)
+
+	default:
+		settings := getGuildSettings(guildID)
+		if len(settings.Watchwords) == 0 {
+			return "👀 No watchwords yet. Add one with `/settings watchwords add`."
+		}
+		where := "nowhere yet, pick a channel with `/settings watchwords channel`"
+		if settings.ModChannel != "" {
+			where = "<#" + settings.ModChannel + ">"
+		}
+		return fmt.Sprintf("👀 Watching for ||%s||, alerts go to %s.", strings.Join(settings.Watchwords, ", "), where)
+	}
+}
+
+// checkWatchwords alerts moderators when a message contains one of the server's
+// watchwords. The message itself is left alone.
+func checkWatchwords(s *discordgo.Session, m *discordgo.MessageCreate, settings *GuildSettings) {
+	if len(settings.Watchwords) == 0 || settings.ModChannel == "" || m.ChannelID == settings.ModChannel {
+		return
+	}
+	content := strings.ToLower(m.Content)
+	var matched string
+	for _, word := range settings.Watchwords {
+		if strings.Contains(content, word) {
+			matched = word
+			break
+		}
+	}
+	if matched == "" || !watchwordAlerts.Claim(m.GuildID+"|"+m.Author.ID+"|"+matched, watchwordCooldown, time.Now()) {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "👀 Watchword: " + matched,
+		URL:         fmt.Sprintf("https://discord.com/channels/%s/%s/%s", m.GuildID, m.ChannelID, m.ID),
+		Description: truncate(m.Content, 1000),
+		Color:       0xe67e22,
+		Author:      &discordgo.MessageEmbedAuthor{Name: m.Author.Username, IconURL: m.Author.AvatarURL("64")},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Member", Value: fmt.Sprintf("<@%s> (`%s`)", m.Author.ID, m.Author.ID), Inline: true},
+			{Name: "Channel", Value: "<#" + m.ChannelID + ">", Inline: true},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	_, err := s.ChannelMessageSendComplex(settings.ModChannel, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Jump to message", Style: discordgo.LinkButton, URL: embed.URL},
+			}},
+		},
+	})
+	if err != nil {
+		log.Printf("Error sending watchword alert to %s: %v", settings.ModChannel, err)
+	}
+}
+
+// replyRolesSetting handles /settings reply_roles add|remove|list and returns the reply
+func replyRolesSetting(guildID string, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	var roleID string
+	if len(subcommand.Options) > 0 {
+		roleID = subcommand.Options[0].RoleValue(nil, "").ID
+	}
+
+	switch subcommand.Name {
+	case "add":
+		added := true
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			if slices.Contains(settings.ReplyRoles, roleID) {
+				added = false
+				return
+			}
+			settings.ReplyRoles = toggleID(settings.ReplyRoles, roleID, true)
+		})
+		if !added {
+			return fmt.Sprintf("📝 <@&%s> can already create auto-replies.", roleID)
+		}
+		return fmt.Sprintf("✅ Only members with %s (and moderators) can create auto-replies now.", roleMentions(getGuildSettings(guildID).ReplyRoles))
+
+	case "remove":
+		removed := false
+		var remaining []string
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			remaining = slices.DeleteFunc(slices.Clone(settings.ReplyRoles), func(id string) bool { return id == roleID })
+			removed = len(remaining) < len(settings.ReplyRoles)
+			settings.ReplyRoles = remaining
+		})
+		switch {
+		case !removed:
+			return fmt.Sprintf("❌ <@&%s> isn't on the list.", roleID)
+		case len(remaining) == 0:
+			return "✅ Everyone can create auto-replies again."
+		default:
+			return fmt.Sprintf("✅ <@&%s> removed, auto-replies are limited to %s.", roleID, roleMentions(remaining))
+		}
+
+	default:
+		roles := getGuildSettings(guildID).ReplyRoles
+		if len(roles) == 0 {
+			return "👥 Everyone can create auto-replies. Limit it to roles with `/settings reply_roles add`."
+		}
+		return fmt.Sprintf("👥 Auto-replies can be created by members with %s, and by moderators.", roleMentions(roles))
+	}
+}
+
+// roleMentions lists roles as <@&mentions>
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for n, id := range roleIDs {
+		mentions[n] = "<@&" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// canCreateReplies reports whether a member may create or edit auto-replies: anyone unless
+// the server limited it to roles with /settings reply_roles, and moderators always
+func canCreateReplies(settings *GuildSettings, memberRoles []string, manager bool) bool {
+	if len(settings.ReplyRoles) == 0 || manager {
+		return true
+	}
+	return slices.ContainsFunc(memberRoles, func(id string) bool { return slices.Contains(settings.ReplyRoles, id) })
+}
+
+// replyRolesMessage is the reply to members without a role that may create auto-replies
+func replyRolesMessage(settings *GuildSettings) string {
+	return fmt.Sprintf("🔒 Only members with %s can create auto-replies in this server.", roleMentions(settings.ReplyRoles))
+}
+
+// shadowedReply is a rule that can never fire, and why
+type shadowedReply struct {
+	Trigger string
+	Reason  string
+}
+
+// replyAudit is the result of analyzing a server's rule set for /replies audit
+type replyAudit struct {
+	Duplicates     [][]string  // triggers sharing the exact same response
+	NearDuplicates [][2]string // trigger pairs whose responses are almost the same
+	Shadowed       []shadowedReply
+	Stale          []string
+	Total          int
+}
+
+// normalizeResponse lowercases a response and drops punctuation and repeated spaces
+func normalizeResponse(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the longer one
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// shadowReason explains why a trigger can never match, or returns "" if it can. Messages
+// are matched one lowercased word at a time with punctuation trimmed, see containsWholeWord.
+func shadowReason(trigger string, seen map[string]bool) string {
+	switch {
+	case seen[strings.ToLower(trigger)]:
+		return "same trigger as an earlier rule, which always fires first"
+	case strings.TrimSpace(trigger) == "":
+		return "empty trigger"
+	case strings.ContainsAny(trigger, " \t\n"):
+		return "has spaces, but messages are matched one word at a time"
+	case strings.Trim(trigger, ".,!?;:\"'()[]{}*") != trigger:
+		return "starts or ends with punctuation that is stripped from messages"
+	case strings.ToLower(trigger) != trigger:
+		return "has capital letters, but messages are lowercased before matching"
+	}
+	return ""
+}
+
+// auditAutoReplies finds duplicate, near-duplicate, unreachable and stale rules in a server
+func auditAutoReplies(guildID string, now time.Time) replyAudit {
+	repliesMu.Lock()
+	rules := append([]AutoReply(nil), serverAutoReplies[guildID]...)
+	repliesMu.Unlock()
+
+	audit := replyAudit{Total: len(rules)}
+	seen := make(map[string]bool)
+	byResponse := make(map[string][]string)
+	var responseOrder []string
+	for _, rule := range rules {
+		if rule.Match != "" || rule.Regex {
+			// Nickname, role and regex rules match anywhere, so the word rules don't apply
+		} else if reason := shadowReason(rule.Trigger, seen); reason != "" {
+			audit.Shadowed = append(audit.Shadowed, shadowedReply{Trigger: rule.Trigger, Reason: reason})
+		}
+		seen[strings.ToLower(rule.Trigger)] = true
+
+		if !rule.CreatedAt.IsZero() && now.Sub(rule.CreatedAt) > staleReplyAge && now.Sub(rule.LastFired) > staleReplyAge {
+			audit.Stale = append(audit.Stale, rule.Trigger)
+		}
+
+		if byResponse[rule.Response] == nil {
+			responseOrder = append(responseOrder, rule.Response)
+		}
+		byResponse[rule.Response] = append(byResponse[rule.Response], rule.Trigger)
+	}
+
+	for _, response := range responseOrder {
+		if triggers := byResponse[response]; len(triggers) > 1 {
+			audit.Duplicates = append(audit.Duplicates, triggers)
+		}
+	}
+
+	// Compare each distinct response once, skipping pairs whose lengths are too far apart
+	for a := 0; a < len(responseOrder); a++ {
+		na := normalizeResponse(responseOrder[a])
+		for b := a + 1; b < len(responseOrder); b++ {
+			nb := normalizeResponse(responseOrder[b])
+			longer, shorter := max(len(na), len(nb)), min(len(na), len(nb))
+			if longer == 0 || float64(shorter)/float64(longer) < nearDuplicateRatio {
+				continue
+			}
+			if na == nb || similarity(na, nb) >= nearDuplicateRatio {
+				audit.NearDuplicates = append(audit.NearDuplicates, [2]string{byResponse[responseOrder[a]][0], byResponse[responseOrder[b]][0]})
+			}
+		}
+	}
+
+	return audit
+}
+
+// replyAuditEmbed renders an audit with one field per finding type
+func replyAuditEmbed(audit replyAudit) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔍 Auto-Reply Audit",
+		Description: fmt.Sprintf("Checked %d rules", audit.Total),
+		Color:       0x3498db,
+	}
+
+	addField := func(name string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s (%d)", name, len(lines)),
+			Value: truncate(strings.Join(lines, "\n"), 1024),
+		})
+	}
+
+	var lines []string
+	for _, triggers := range audit.Duplicates {
+		lines = append(lines, "• `"+strings.Join(triggers, "`, `")+"` send the same response")
+	}
+	addField("📑 Duplicates", lines)
+
+	lines = nil
+	for _, pair := range audit.NearDuplicates {
+		lines = append(lines, fmt.Sprintf("• `%s` and `%s` have almost the same response", pair[0], pair[1]))
+	}
+	addField("🪞 Near-duplicates", lines)
+
+	lines = nil
+	for _, rule := range audit.Shadowed {
+		lines = append(lines, fmt.Sprintf("• `%s`: %s", rule.Trigger, rule.Reason))
+	}
+	addField("🚫 Never fire", lines)
+
+	lines = nil
+	for _, trigger := range audit.Stale {
+		lines = append(lines, "• `"+trigger+"`")
+	}
+	addField(fmt.Sprintf("💤 Unused for %d days", int(staleReplyAge.Hours()/24)), lines)
+
+	if len(embed.Fields) == 0 {
+		embed.Description += "\n✅ No problems found."
+		embed.Color = embedColor
+	}
+	return embed
+}
+
+// replyAuditComponents returns cleanup buttons for the findings that can be removed safely
+func replyAuditComponents(audit replyAudit) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	if len(audit.Shadowed) > 0 {
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Remove %d that never fire", len(audit.Shadowed)),
+			Style:    discordgo.DangerButton,
+			CustomID: "replies_cleanup:shadowed",
+		})
+	}
+	if len(audit.Stale) > 0 {
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Remove %d unused", len(audit.Stale)),
+			Style:    discordgo.DangerButton,
+			CustomID: "replies_cleanup:stale",
+		})
+	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// replyExport is the file /reply_export attaches and /reply_import reads back
+type replyExport struct {
+	Version    int         `json:"version"`
+	GuildID    string      `json:"guild_id"`
+	ExportedAt time.Time   `json:"exported_at"`
+	Rules      []AutoReply `json:"rules"`
+}
+
+// replyExportVersion is written to exports so the format can change later
+const replyExportVersion = 1
+
+// maxReplyImportSize is the largest file /reply_import reads
+const maxReplyImportSize = 2 << 20
+
+// handleReplyExportCommand handles /reply_export, attaching the server's rules as JSON
+func handleReplyExportCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Auto-reply commands only work in servers, not in DMs!")
+		return
+	}
+	if !hasManageGuild(i) {
+		respond("❌ You need the Manage Server permission to export auto-replies.")
+		return
+	}
+
+	repliesMu.Lock()
+	export := replyExport{
+		Version:    replyExportVersion,
+		GuildID:    i.GuildID,
+		ExportedAt: time.Now().UTC(),
+		Rules:      slices.Clone(serverAutoReplies[i.GuildID]),
+	}
+	repliesMu.Unlock()
+	if len(export.Rules) == 0 {
+		respond("📝 No auto-reply rules set up for this server.")
+		return
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling auto-reply export: %v", err)
+		respond(userErrorMessage(err))
+		return
+	}
+
+	name := fmt.Sprintf("auto-replies-%s-%s.json", i.GuildID, export.ExportedAt.Format("2006-01-02"))
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📦 %d auto-reply rules. Restore them with `/reply_import`.\n-# Uploaded media isn't in the file, it only comes back when imported into this server.", len(export.Rules)),
+			Files:   []*discordgo.File{{Name: name, ContentType: "application/json", Reader: bytes.NewReader(data)}},
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleReplyImportCommand handles /reply_import, merging the rules of an exported file
+// into the server's or replacing them, and reports the rules it couldn't take
+func handleReplyImportCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Auto-reply commands only work in servers, not in DMs!")
+		return
+	}
+	if !hasManageGuild(i) {
+		respond("❌ You need the Manage Server permission to import auto-replies.")
+		return
+	}
+	if !getGuildSettings(i.GuildID).AutoReplies {
+		respond(autoRepliesOffMessage)
+		return
+	}
+
+	var file *discordgo.MessageAttachment
+	mode := "merge"
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "file":
+			file = i.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+		case "mode":
+			mode = opt.StringValue()
+		}
+	}
+	if file == nil || file.Size > maxReplyImportSize {
+		respond(fmt.Sprintf("❌ Please upload a `/reply_export` file of at most %d MB.", maxReplyImportSize>>20))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	followup := func(content string) {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         truncate(content, 2000),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+			Flags:           discordgo.MessageFlagsEphemeral,
+		})
+	}
+
+	data, err := fetchAttachment(file.URL)
+	if err != nil {
+		log.Printf("Error downloading auto-reply import: %v", err)
+		followup("❌ Couldn't download that file, please try again.")
+		return
+	}
+	rules, err := parseReplyExport(data)
+	if err != nil {
+		followup(userErrorMessage(err))
+		return
+	}
+
+	// Check every rule first so a bad file changes nothing it reports as skipped
+	var valid []AutoReply
+	var skipped []string
+	canDelete := i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
+	undeleting := 0
+	for _, rule := range rules {
+		if rule.DeleteTrigger && !canDelete {
+			rule.DeleteTrigger = false
+			undeleting++
+		}
+		if reason := validateImportedRule(i.GuildID, &rule); reason != "" {
+			skipped = append(skipped, fmt.Sprintf("`%s`: %s", truncate(rule.Trigger, 80), reason))
+			continue
+		}
+		if slices.ContainsFunc(valid, func(other AutoReply) bool { return sameTrigger(other, rule) }) {
+			skipped = append(skipped, fmt.Sprintf("`%s`: appears more than once in the file", truncate(rule.Trigger, 80)))
+			continue
+		}
+		valid = append(valid, rule)
+	}
+
+	var conflicts, overLimit []string
+	limit := getGuildSettings(i.GuildID).replyLimit()
+	repliesMu.Lock()
+	previous := serverAutoReplies[i.GuildID]
+	if mode == "replace" {
+		for _, rule := range valid[min(len(valid), limit):] {
+			overLimit = append(overLimit, fmt.Sprintf("`%s`", truncate(rule.Trigger, 80)))
+		}
+		valid = valid[:min(len(valid), limit)]
+		serverAutoReplies[i.GuildID] = valid
+		removeUnusedMedia(i.GuildID, allAttachments(previous))
+	} else {
+		merged := slices.Clone(previous)
+		for _, rule := range valid {
+			if slices.ContainsFunc(previous, func(existing AutoReply) bool { return sameTrigger(existing, rule) }) {
+				conflicts = append(conflicts, fmt.Sprintf("`%s`", truncate(rule.Trigger, 80)))
+				continue
+			}
+			if len(merged) >= limit {
+				overLimit = append(overLimit, fmt.Sprintf("`%s`", truncate(rule.Trigger, 80)))
+				continue
+			}
+			merged = append(merged, rule)
+		}
+		serverAutoReplies[i.GuildID] = merged
+	}
+	saveAutoReplies()
+	repliesMu.Unlock()
+
+	var report strings.Builder
+	if mode == "replace" {
+		fmt.Fprintf(&report, "✅ Replaced this server's %d rules with %d from the file.", len(previous), len(valid))
+	} else {
+		fmt.Fprintf(&report, "✅ Imported %d of %d rules.", len(valid)-len(conflicts)-len(overLimit), len(rules))
+	}
+	if len(overLimit) > 0 {
+		fmt.Fprintf(&report, "\n\n**Not imported, the server's limit of %d rules was reached (%d):**\n%s", limit, len(overLimit), strings.Join(overLimit, ", "))
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(&report, "\n\n**Kept the server's rule, it already has these triggers (%d):**\n%s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	if undeleting > 0 {
+		fmt.Fprintf(&report, "\n\n🗑️ %d rules were set to delete the triggering message. Only moderators with Manage Messages can import that, so they only reply.", undeleting)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&report, "\n\n**Skipped (%d):**\n• %s", len(skipped), strings.Join(skipped, "\n• "))
+	}
+	followup(report.String())
+}
+
+// parseReplyExport reads a /reply_export file, or a plain list of rules
+func parseReplyExport(data []byte) ([]AutoReply, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		var rules []AutoReply
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, invalidInput("that file isn't valid JSON: %v", err)
+		}
+		return rules, nil
+	}
+
+	var export replyExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, invalidInput("that file isn't valid JSON: %v", err)
+	}
+	if export.Version > replyExportVersion {
+		return nil, invalidInput("that file is from a newer version of the bot")
+	}
+	if len(export.Rules) == 0 {
+		return nil, invalidInput("that file has no auto-reply rules")
+	}
+	return export.Rules, nil
+}
+
+// validateImportedRule checks an imported rule the way /reply would and returns why it
+// can't be imported, or "" when it can. Profanity is masked when the server masks it.
+// The caller drops DeleteTrigger when the importer may not set it.
+func validateImportedRule(guildID string, rule *AutoReply) string {
+	rule.Trigger = strings.TrimSpace(rule.Trigger)
+	if rule.Trigger == "" {
+		return "no trigger"
+	}
+	if !rule.Regex {
+		rule.Trigger = strings.ToLower(rule.Trigger)
+	}
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
+	if _, ok := matchLabels[rule.Match]; !ok {
+		return fmt.Sprintf("unknown match `%s`", rule.Match)
+	}
+	if rule.Regex && rule.Match != "" {
+		return "regex triggers only match message text"
+	}
+	if err := compileTrigger(rule); err != nil {
+		return strings.TrimPrefix(userErrorMessage(err), "❌ ")
+	}
+	if len(rule.Responses)+1 > maxReplyResponses {
+		return fmt.Sprintf("more than %d responses", maxReplyResponses)
+	}
+	// Stored cooldowns are -1 for none, 0 for the default or up to maxReplyCooldown seconds
+	if rule.Cooldown < -1 || rule.Cooldown > maxReplyCooldown {
+		return fmt.Sprintf("a cooldown over %d seconds", maxReplyCooldown)
+	}
+	switch {
+	case len(rule.ChannelIDs) > maxReplyChannels:
+		return fmt.Sprintf("limited to more than %d channels", maxReplyChannels)
+	case len(rule.RoleIDs) > maxReplyTargets:
+		return fmt.Sprintf("limited to more than %d roles", maxReplyTargets)
+	case len(rule.UserIDs) > maxReplyTargets, len(rule.IgnoredUserIDs) > maxReplyTargets:
+		return fmt.Sprintf("lists more than %d members", maxReplyTargets)
+	}
+
+	// Stored files only exist on this bot, and only for the server they were uploaded in
+	media := rule.Attachments[:0:0]
+	for _, attachment := range rule.Attachments {
+		if attachment.Path != "" {
+			if filepath.Dir(attachment.Path) != filepath.Join(replyMediaDir, guildID) {
+				continue
+			}
+			if _, err := os.Stat(attachment.Path); err != nil {
+				continue
+			}
+		}
+		media = append(media, attachment)
+	}
+	rule.Attachments = media
+	if rule.Response == "" && len(rule.Attachments) == 0 {
+		return "no response"
+	}
+
+	switch rule.ResponseType {
+	case "", "dm":
+	case "reaction":
+		if rule.DeleteTrigger {
+			return "reaction rules can't delete the message they react to"
+		}
+		for _, response := range append([]string{rule.Response}, rule.Responses...) {
+			if _, err := parseReactions(response); err != nil {
+				return strings.TrimPrefix(userErrorMessage(err), "❌ ")
+			}
+		}
+	default:
+		return fmt.Sprintf("unknown response type `%s`", rule.ResponseType)
+	}
+
+	for idx, response := range append([]string{rule.Response}, rule.Responses...) {
+		screened, ok := screenReplyResponse(guildID, response)
+		if !ok {
+			return "the response contains blocked words"
+		}
+		if idx == 0 {
+			rule.Response = screened
+		} else {
+			rule.Responses[idx-1] = screened
+		}
+	}
+	return ""
+}
+
+// allAttachments lists the media of every rule
+func allAttachments(rules []AutoReply) []ReplyAttachment {
+	var attachments []ReplyAttachment
+	for _, rule := range rules {
+		attachments = append(attachments, rule.Attachments...)
+	}
+	return attachments
+}
+
+// handleRepliesCommand handles /replies audit and /replies history
+func handleRepliesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if subcommand := i.ApplicationCommandData().Options[0]; subcommand.Name == "history" {
+		handleReplyHistory(s, i, subcommand.Options[0].StringValue())
+		return
+	}
+
+	if i.GuildID == "" || !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to audit auto-replies.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	audit := auditAutoReplies(i.GuildID, time.Now())
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{replyAuditEmbed(audit)},
+			Components: replyAuditComponents(audit),
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// replyHistoryMessage lists a rule's versions, newest first, with a rollback menu
+func replyHistoryMessage(rule AutoReply) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🕘 History of `%s`", rule.Trigger),
+		Description: "**Current:** " + truncate(rule.Response, 500),
+		Color:       0x3498db,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Keeping the last %d versions", maxReplyVersions),
+		},
+	}
+	if len(rule.History) == 0 {
+		embed.Description += "\n\nThis rule hasn't been edited yet."
+		return embed, nil
+	}
+
+	var options []discordgo.SelectMenuOption
+	for idx := len(rule.History) - 1; idx >= 0; idx-- {
+		version := rule.History[idx]
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("v%d · replaced <t:%d:R>", idx+1, version.EditedAt.Unix()),
+			Value: truncate(version.Response, 300) + fmt.Sprintf("\n-# replaced by <@%s>", version.EditedBy),
+		})
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       fmt.Sprintf("v%d", idx+1),
+			Value:       strconv.FormatInt(version.EditedAt.UnixNano(), 10),
+			Description: truncate(version.Response, 100),
+		})
+	}
+
+	// The trigger travels in the custom ID, which Discord caps at 100 characters
+	customID := "reply_rollback:" + rule.Trigger
+	if len(customID) > 100 {
+		return embed, nil
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    customID,
+				Placeholder: "Roll back to an earlier version…",
+				Options:     options,
+			},
+		}},
+	}
+}
+
+// handleReplyHistory handles /replies history
+func handleReplyHistory(s *discordgo.Session, i *discordgo.InteractionCreate, trigger string) {
+	rule, ok := findAutoReply(i.GuildID, AutoReply{Trigger: trigger})
+	if !ok {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ No auto-reply found for that trigger.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	embed, components := replyHistoryMessage(rule)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleReplyRollback restores the version picked from the /replies history menu. The
+// current response becomes the newest version, so a rollback can itself be undone.
+func handleReplyRollback(s *discordgo.Session, i *discordgo.InteractionCreate, trigger string) {
+	rule, ok := findAutoReply(i.GuildID, AutoReply{Trigger: trigger})
+	values := i.MessageComponentData().Values
+	var target *ReplyVersion
+	for idx := range rule.History {
+		if len(values) > 0 && strconv.FormatInt(rule.History[idx].EditedAt.UnixNano(), 10) == values[0] {
+			target = &rule.History[idx]
+		}
+	}
+	if !ok || target == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ That version no longer exists. Run `/replies history` again.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	success, message, _ := addAutoReply(rule.Trigger, target.Response, interactionUserID(i), i.GuildID)
+	if !success {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	rule, _ = findAutoReply(i.GuildID, AutoReply{Trigger: trigger})
+	embed, components := replyHistoryMessage(rule)
+	embed.Description = "✅ Rolled back.\n" + embed.Description
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// handleRepliesCleanup removes the rules an audit flagged, re-running the audit first so
+// rules changed since the report was shown are judged on their current state
+func handleRepliesCleanup(s *discordgo.Session, i *discordgo.InteractionCreate, kind string) {
+	if !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to remove auto-replies.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	audit := auditAutoReplies(i.GuildID, time.Now())
+	var removed []AutoReply
+	switch kind {
+	case "shadowed":
+		// Only the later copy of a repeated trigger is dropped, the one that fires stays
+		seen := make(map[string]bool)
+		repliesMu.Lock()
+		var kept []AutoReply
+		for _, rule := range serverAutoReplies[i.GuildID] {
+			if rule.Match == "" && !rule.Regex && shadowReason(rule.Trigger, seen) != "" {
+				removed = append(removed, rule)
+			} else {
+				kept = append(kept, rule)
+			}
+			seen[strings.ToLower(rule.Trigger)] = true
+		}
+		serverAutoReplies[i.GuildID] = kept
+	case "stale":
+		repliesMu.Lock()
+		var kept []AutoReply
+		for _, rule := range serverAutoReplies[i.GuildID] {
+			if slices.Contains(audit.Stale, rule.Trigger) {
+				removed = append(removed, rule)
+			} else {
+				kept = append(kept, rule)
+			}
+		}
+		serverAutoReplies[i.GuildID] = kept
+	default:
+		return
+	}
+	for _, rule := range removed {
+		removeUnusedMedia(i.GuildID, rule.Attachments)
+	}
+	if len(serverAutoReplies[i.GuildID]) == 0 {
+		delete(serverAutoReplies, i.GuildID)
+	}
+	saveAutoReplies()
+	repliesMu.Unlock()
+
+	log.Printf("Removed %d %s auto-replies in guild %s", len(removed), kind, i.GuildID)
+
+	audit = auditAutoReplies(i.GuildID, time.Now())
+	embed := replyAuditEmbed(audit)
+	embed.Description += fmt.Sprintf("\n🗑️ Removed %d rules.", len(removed))
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: replyAuditComponents(audit),
+		},
+	})
+}
+
+// purgeMatches returns the triggers of the rules a /reply_purge removes: every rule with
+// all, otherwise those by author and whose trigger contains text. Members who can't
+// manage the server only ever match their own rules. The caller must hold repliesMu.
+func purgeMatches(guildID, userID, author, text string, all, moderator bool) []string {
+	text = strings.ToLower(text)
+	var triggers []string
+	for _, rule := range serverAutoReplies[guildID] {
+		switch {
+		case !moderator && rule.AuthorID != userID:
+			continue
+		case all:
+		case author != "" && rule.AuthorID != author:
+			continue
+		case text != "" && !strings.Contains(strings.ToLower(rule.Trigger), text):
+			continue
+		}
+		triggers = append(triggers, rule.Trigger)
+	}
+	return triggers
+}
+
+// handleReplyPurgeCommand handles /reply_purge, previewing the rules it would remove with
+// a button to confirm
+func handleReplyPurgeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if i.GuildID == "" {
+		respond("❌ Auto-reply commands only work in servers, not in DMs!")
+		return
+	}
+
+	var author, text string
+	var all bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "author":
+			author = opt.UserValue(nil).ID
+		case "contains":
+			text = strings.TrimSpace(opt.StringValue())
+		case "all":
+			all = opt.BoolValue()
+		}
+	}
+
+	userID := interactionUserID(i)
+	moderator := hasManageGuild(i)
+	switch {
+	case !all && author == "" && text == "":
+		respond("❌ Pick the rules to remove with `author`, `contains` or `all:true`.")
+		return
+	case all && (author != "" || text != ""):
+		respond("❌ `all:true` removes every rule, leave out `author` and `contains`.")
+		return
+	case all && !moderator:
+		respond("❌ Only members with Manage Server can remove every rule in the server.")
+		return
+	case author != "" && author != userID && !moderator:
+		respond("❌ Only members with Manage Server can remove someone else's rules.")
+		return
+	}
+
+	repliesMu.Lock()
+	triggers := purgeMatches(i.GuildID, userID, author, text, all, moderator)
+	if len(triggers) > 0 {
+		replyPurges[i.ID] = &replyPurge{GuildID: i.GuildID, UserID: userID, Triggers: triggers, CreatedAt: time.Now()}
+	}
+	repliesMu.Unlock()
+	if len(triggers) == 0 {
+		respond("🔍 No auto-replies match that, nothing to remove.")
+		return
+	}
+
+	shown := make([]string, 0, len(triggers))
+	for _, trigger := range triggers {
+		shown = append(shown, "`"+strings.ReplaceAll(truncate(trigger, 60), "`", "'")+"`")
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🗑️ Remove %d auto-replies?", len(triggers)),
+		Description: truncate(strings.Join(shown, ", "), 4000),
+		Color:       0xe74c3c,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Removed rules can't be restored, back them up with /reply_export first. This preview expires in 15 minutes.",
+		},
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: fmt.Sprintf("Remove %d rules", len(triggers)), Style: discordgo.DangerButton, CustomID: "reply_purge_confirm:" + i.ID},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "reply_purge_cancel:" + i.ID},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleReplyPurgeButton removes or keeps the rules a /reply_purge previewed. Only the
+// rules in the preview go, even if more would match by now.
+func handleReplyPurgeButton(s *discordgo.Session, i *discordgo.InteractionCreate, purgeID string, confirm bool) {
+	repliesMu.Lock()
+	purge := replyPurges[purgeID]
+	delete(replyPurges, purgeID)
+	repliesMu.Unlock()
+
+	var content string
+	switch {
+	case purge == nil || time.Since(purge.CreatedAt) > replyDraftTTL:
+		content = "❌ This preview has expired. Please run `/reply_purge` again."
+	case !confirm:
+		content = "👍 Cancelled, no rules were removed."
+	default:
+		repliesMu.Lock()
+		var kept, removed []AutoReply
+		for _, rule := range serverAutoReplies[purge.GuildID] {
+			if slices.ContainsFunc(purge.Triggers, func(trigger string) bool { return sameTrigger(rule, AutoReply{Trigger: trigger}) }) {
+				removed = append(removed, rule)
+			} else {
+				kept = append(kept, rule)
+			}
+		}
+		serverAutoReplies[purge.GuildID] = kept
+		for _, rule := range removed {
+			removeUnusedMedia(purge.GuildID, rule.Attachments)
+		}
+		if len(kept) == 0 {
+			delete(serverAutoReplies, purge.GuildID)
+		}
+		saveAutoReplies()
+		repliesMu.Unlock()
+
+		log.Printf("Purged %d auto-replies in guild %s for %s", len(removed), purge.GuildID, purge.UserID)
+		entry := AuditEntry{
+			Time:    time.Now(),
+			UserID:  purge.UserID,
+			Command: "/reply_purge",
+			Result:  fmt.Sprintf("removed %d rules", len(removed)),
+		}
+		if i.Member != nil && i.Member.User != nil {
+			entry.User = i.Member.User.Username
+		}
+		appendAudit(purge.GuildID, entry)
+		content = fmt.Sprintf("✅ Removed %d auto-replies.", len(removed))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// sweepReplyPurges drops /reply_purge previews nobody confirmed or cancelled
+func sweepReplyPurges(now time.Time) {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	for purgeID, purge := range replyPurges {
+		if now.Sub(purge.CreatedAt) > replyDraftTTL {
+			delete(replyPurges, purgeID)
+		}
+	}
+}
+
+// autoRepliesOffMessage is the reply to /reply in servers that haven't enabled auto-replies
+const autoRepliesOffMessage = "❌ Auto-replies are turned off in this server. A server manager can enable them with `/settings auto_replies`."
+
+// loadPendingReplies loads auto-reply rules awaiting approval from JSON file
+func loadPendingReplies() {
+	pendingReplies = make(PendingReplies)
+
+	if _, err := os.Stat(reviewsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(reviewsFile)
+	if err != nil {
+		log.Printf("Error reading pending replies file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &pendingReplies); err != nil {
+		log.Printf("Error parsing pending replies file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded %d auto-reply rules awaiting review", len(pendingReplies))
+}
+
+// savePendingReplies saves auto-reply rules awaiting approval to JSON file
+func savePendingReplies() {
+	data, err := json.MarshalIndent(pendingReplies, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling pending replies: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(reviewsFile, data, 0644); err != nil {
+		log.Printf("Error saving pending replies: %v", err)
+		return
+	}
+}
+
+// ruleOwnedByOther reports whether rule's trigger already belongs to someone other than authorID
+func ruleOwnedByOther(guildID string, rule AutoReply, authorID string) bool {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	for _, reply := range serverAutoReplies[guildID] {
+		if sameTrigger(reply, rule) {
+			return reply.AuthorID != "" && reply.AuthorID != authorID
+		}
+	}
+	return false
+}
+
+// findAutoReply returns a copy of the server's rule with the same trigger as key, see
+// sameTrigger. Lookups by name pass just the trigger.
+func findAutoReply(guildID string, key AutoReply) (AutoReply, bool) {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	for _, reply := range serverAutoReplies[guildID] {
+		if sameTrigger(reply, key) {
+			reply.History = append([]ReplyVersion(nil), reply.History...)
+			reply.Responses = append([]string(nil), reply.Responses...)
+			reply.ChannelIDs = append([]string(nil), reply.ChannelIDs...)
+			reply.RoleIDs = append([]string(nil), reply.RoleIDs...)
+			reply.UserIDs = append([]string(nil), reply.UserIDs...)
+			reply.IgnoredUserIDs = append([]string(nil), reply.IgnoredUserIDs...)
+			return reply, true
+		}
+	}
+	return AutoReply{}, false
+}
+
+// lineDiff renders the change from old to new as a diff code block, line by line
+func lineDiff(old, new string) string {
+	a, b := strings.Split(old, "\n"), strings.Split(new, "\n")
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "+ "+b[j])
+			j++
+		default:
+			lines = append(lines, "- "+a[i])
+			i++
+		}
+	}
+	return "```diff\n" + strings.ReplaceAll(strings.Join(lines, "\n"), "```", "'''") + "\n```"
+}
+
+// submitReplyForReview posts a new or changed rule to the review channel with approve/deny
+// buttons and returns the message for its author
+func submitReplyForReview(s *discordgo.Session, settings *GuildSettings, guildID string, rule AutoReply, authorID string) string {
+	if ruleOwnedByOther(guildID, rule, authorID) {
+		return fmt.Sprintf("you can't change this you bartard <@%s>", authorID)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📝 Auto-Reply Awaiting Approval",
+		Description: ruleSummary(rule),
+		Color:       0xf1c40f,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Submitted by", Value: fmt.Sprintf("<@%s>", authorID), Inline: true},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	msg, err := s.ChannelMessageSendComplex(settings.ReviewChannel, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Approve", Style: discordgo.SuccessButton, CustomID: "reply_review:approve"},
+				discordgo.Button{Label: "Deny", Style: discordgo.DangerButton, CustomID: "reply_review:deny"},
+			}},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("Error posting auto-reply for review in channel %s: %v", settings.ReviewChannel, err)
+		return "❌ Couldn't send your rule for review. Ask a moderator to check the review channel in `/settings reply_approval`."
+	}
+
+	repliesMu.Lock()
+	pendingReplies[msg.ID] = PendingReply{
+		GuildID:     guildID,
+		ChannelID:   msg.ChannelID,
+		Rule:        rule,
+		AuthorID:    authorID,
+		SubmittedAt: time.Now(),
+	}
+	savePendingReplies()
+	repliesMu.Unlock()
+
+	return "🕐 Your auto-reply was sent to the moderators for approval. It goes live once approved."
+}
+
+// handleReplyReviewButton approves or denies a pending rule from its review message
+func handleReplyReviewButton(s *discordgo.Session, i *discordgo.InteractionCreate, action string) {
+	if i.Member == nil || i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Only moderators can review auto-replies.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	repliesMu.Lock()
+	pending, ok := pendingReplies[i.Message.ID]
+	delete(pendingReplies, i.Message.ID)
+	savePendingReplies()
+	repliesMu.Unlock()
+	if !ok {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ This rule was already reviewed.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	reviewer := i.Member.User.ID
+	var status, notice string
+	var color int
+	saved := false
+	if action == "approve" {
+		success, message, _ := addAutoReplyRule(pending.GuildID, pending.Rule, pending.AuthorID)
+		saved = success
+		if success {
+			status, color = fmt.Sprintf("✅ Approved by <@%s>", reviewer), embedColor
+			notice = fmt.Sprintf("✅ Your auto-reply for **%s** was approved and is now live.", pending.Rule.Trigger)
+		} else {
+			status, color = fmt.Sprintf("⚠️ Approved by <@%s> but not saved: %s", reviewer, message), 0xe67e22
+		}
+	} else {
+		status, color = fmt.Sprintf("❌ Denied by <@%s>", reviewer), 0xe74c3c
+		notice = fmt.Sprintf("❌ Your auto-reply for **%s** was not approved by the moderators.", pending.Rule.Trigger)
+	}
+	// Media uploaded for a rule that didn't go live is only kept if another rule uses it
+	if !saved {
+		repliesMu.Lock()
+		removeUnusedMedia(pending.GuildID, pending.Rule.Attachments)
+		repliesMu.Unlock()
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📝 Auto-Reply Reviewed",
+		Description: ruleSummary(pending.Rule),
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Submitted by", Value: fmt.Sprintf("<@%s>", pending.AuthorID), Inline: true},
+			{Name: "Result", Value: status, Inline: true},
+		},
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+
+	// Let the author know, if they accept DMs
+	if notice != "" {
+		if dm, err := s.UserChannelCreate(pending.AuthorID); err == nil {
+			s.ChannelMessageSend(dm.ID, notice)
+		}
+	}
+}
+
+// ocrSetting handles /settings ocr and returns the reply
+func ocrSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID string
+	var enabled bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.OCRChannels = toggleID(settings.OCRChannels, channelID, enabled)
+	})
+
+	if !enabled {
+		return fmt.Sprintf("✅ Images in <#%s> are no longer read for auto-reply triggers.", channelID)
+	}
+	content := fmt.Sprintf("✅ Text in images posted in <#%s> now counts for auto-reply triggers (up to %d images an hour for the server, one per member per minute).", channelID, ocrPerGuildHour)
+	if _, ok := guildAPIKey(guildID, "ocr"); !ok && os.Getenv("OCR_SPACE_API_KEY") == "" {
+		content += "\n⚠️ No OCR.space key is set up yet. Add one with `/settings apikey set service:OCR.space`."
+	}
+	return content
+}
+
+// musicLink matches Spotify and Apple Music track and album links
+var musicLink = regexp.MustCompile(`https://(?:open\.spotify\.com/(?:intl-[a-z]+/)?(?:track|album)/[A-Za-z0-9]+|music\.apple\.com/[a-z]{2}/(?:album|song)/[^\s<>]+)`)
+
+// songLinkPlatforms are the services listed under an expanded music link, in order
+var songLinkPlatforms = []struct{ key, name string }{
+	{"spotify", "Spotify"},
+	{"appleMusic", "Apple Music"},
+	{"youtubeMusic", "YouTube Music"},
+	{"youtube", "YouTube"},
+	{"deezer", "Deezer"},
+	{"tidal", "Tidal"},
+	{"soundcloud", "SoundCloud"},
+}
+
+// songLinkResult is the part of a song.link (Odesli) lookup the bot uses
+type songLinkResult struct {
+	PageURL         string `json:"pageUrl"`
+	EntityUniqueID  string `json:"entityUniqueId"`
+	EntitiesByUniID map[string]struct {
+		Type         string `json:"type"` // "song" or "album"
+		Title        string `json:"title"`
+		ArtistName   string `json:"artistName"`
+		ThumbnailURL string `json:"thumbnailUrl"`
+	} `json:"entitiesByUniqueId"`
+	LinksByPlatform map[string]struct {
+		URL string `json:"url"`
+	} `json:"linksByPlatform"`
+}
+
+// lookupSongLink finds a song or album on every streaming service with song.link
+func lookupSongLink(link string) (*songLinkResult, error) {
+	body, err := fetchPage("song.link", "https://api.song.link/v1-alpha.1/links?userCountry="+movieWatchRegion+"&url="+url.QueryEscape(link))
+	if err != nil {
+		return nil, err
+	}
+	var result songLinkResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, upstreamDown("song.link", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if _, ok := result.EntitiesByUniID[result.EntityUniqueID]; !ok || result.PageURL == "" {
+		return nil, notFound("song.link doesn't know %s", link)
+	}
+	return &result, nil
+}
+
+// songLinkEmbed builds the card for an expanded music link, with links to each service
+func songLinkEmbed(result *songLinkResult) *discordgo.MessageEmbed {
+	entity := result.EntitiesByUniID[result.EntityUniqueID]
+	emoji := "🎵"
+	if entity.Type == "album" {
+		emoji = "💿"
+	}
+
+	var links []string
+	for _, platform := range songLinkPlatforms {
+		if link, ok := result.LinksByPlatform[platform.key]; ok && link.URL != "" {
+			links = append(links, fmt.Sprintf("[%s](%s)", platform.name, link.URL))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(emoji+" "+entity.Title, 256),
+		URL:         result.PageURL,
+		Description: "by **" + truncate(entity.ArtistName, 200) + "**",
+		Color:       0x1db954,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Listen anywhere with song.link",
+		},
+	}
+	if len(links) > 0 {
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Listen on", Value: truncate(strings.Join(links, " • "), 1024), Inline: false}}
+	}
+	if entity.ThumbnailURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: entity.ThumbnailURL}
+	}
+	return embed
+}
+
+// expandMusicLinks replies to a message with song.link cards for the Spotify and Apple Music
+// links in it, so members on other services can listen too
+func expandMusicLinks(s *discordgo.Session, m *discordgo.MessageCreate) {
+	var embeds []*discordgo.MessageEmbed
+	for _, link := range musicLink.FindAllString(m.Content, maxMusicLinks) {
+		if !songLinkLimit.Allow("song.link", time.Now()) {
+			break
+		}
+		result, err := lookupSongLink(link)
+		if err != nil {
+			log.Printf("Error expanding music link %s: %v", link, err)
+			continue
+		}
+		embeds = append(embeds, songLinkEmbed(result))
+	}
+	if len(embeds) == 0 {
+		return
+	}
+
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:          embeds,
+		Reference:       &discordgo.MessageReference{MessageID: m.ID, ChannelID: m.ChannelID, GuildID: m.GuildID},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("Error sending music link cards: %v", err)
+	}
+}
+
+// musicLinksSetting handles /settings music_links and returns the reply
+func musicLinksSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID string
+	var enabled bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.MusicChannels = toggleID(settings.MusicChannels, channelID, enabled)
+	})
+
+	if !enabled {
+		return fmt.Sprintf("✅ Music links in <#%s> are left as they are.", channelID)
+	}
+	return fmt.Sprintf("✅ Spotify and Apple Music links posted in <#%s> now get a song.link card with the other streaming services.", channelID)
+}
+
+// replyBlacklistSetting handles /settings reply_blacklist and returns the reply. Without
+// options it lists the channels where auto-replies never fire.
+func replyBlacklistSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID string
+	blocked := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "blocked":
+			blocked = opt.BoolValue()
+		}
+	}
+
+	if channelID == "" {
+		channels := getGuildSettings(guildID).NoReplyChannels
+		if len(channels) == 0 {
+			return "📝 Auto-replies can fire in every channel. Block one with `/settings reply_blacklist channel:#announcements`."
+		}
+		return "🔇 Auto-replies never fire in " + channelMentions(channels) + "."
+	}
+
+	changed := false
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		channels := toggleID(settings.NoReplyChannels, channelID, blocked)
+		changed = len(channels) != len(settings.NoReplyChannels)
+		settings.NoReplyChannels = channels
+	})
+
+	switch {
+	case blocked && !changed:
+		return fmt.Sprintf("📝 Auto-replies are already blocked in <#%s>.", channelID)
+	case blocked:
+		return fmt.Sprintf("✅ Auto-replies will never fire in <#%s>, whatever their channels.", channelID)
+	case !changed:
+		return fmt.Sprintf("❌ <#%s> isn't on the auto-reply blacklist.", channelID)
+	default:
+		return fmt.Sprintf("✅ Auto-replies can fire in <#%s> again.", channelID)
+	}
+}
+
+// commandChannelsSetting handles /settings command_channels and returns the reply
+func commandChannelsSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID, botChannel string
+	var allowed bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "allowed":
+			allowed = opt.BoolValue()
+		case "bot_channel":
+			botChannel = opt.ChannelValue(nil).ID
+		}
+	}
+	if botChannel != "" && botChannel == channelID && !allowed {
+		return "❌ The bot channel can't be one where commands are refused."
+	}
+
+	var settings GuildSettings
+	updateGuildSettings(guildID, func(current *GuildSettings) {
+		current.NoCommandChannels = toggleID(current.NoCommandChannels, channelID, !allowed)
+		if botChannel != "" {
+			current.BotChannel = botChannel
+		}
+		settings = *current
+	})
+
+	if allowed {
+		return fmt.Sprintf("✅ Commands work in <#%s> again.", channelID)
+	}
+	content := fmt.Sprintf("✅ The bot now refuses commands in <#%s>. Moderators can still use them there.", channelID)
+	if settings.BotChannel != "" {
+		content += fmt.Sprintf("\nMembers are pointed to <#%s> instead.", settings.BotChannel)
+	} else {
+		content += "\n-# Pick a channel to point members to with `bot_channel`."
+	}
+	return content
+}
+
+// botChannelSetting handles /settings bot_channel and returns the reply
+func botChannelSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID string
+	var redirect bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "redirect":
+			redirect = opt.BoolValue()
+		}
+	}
+	if commandsRefused(getGuildSettings(guildID), channelID) {
+		return fmt.Sprintf("❌ Commands are refused in <#%s>. Allow them with `/settings command_channels` first.", channelID)
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.BotChannel = channelID
+		settings.RedirectOutput = redirect
+	})
+	if !redirect {
+		return fmt.Sprintf("✅ The bot channel is <#%s>. Command results stay in the channel they're used in.", channelID)
+	}
+	return fmt.Sprintf("✅ Conversions and fun commands now post their results in <#%s>, the member gets a link to them.", channelID)
+}
+
+// botChannelFor returns the channel a command's public output goes to instead of the one
+// it was used in, or "" when it stays where it is
+func botChannelFor(i *discordgo.InteractionCreate) string {
+	if i.GuildID == "" {
+		return ""
+	}
+	settings := getGuildSettings(i.GuildID)
+	if !settings.RedirectOutput || settings.BotChannel == "" || settings.BotChannel == i.ChannelID {
+		return ""
+	}
+	return settings.BotChannel
+}
+
+// postInBotChannel posts a command's output in the bot channel, crediting the member who
+// used it, and returns the reply pointing them to it
+func postInBotChannel(s *discordgo.Session, i *discordgo.InteractionCreate, channelID string, msg *discordgo.MessageSend) string {
+	credit := fmt.Sprintf("<@%s> used `/%s`", interactionUserID(i), i.ApplicationCommandData().Name)
+	if msg.Content != "" {
+		credit += ":\n" + msg.Content
+	}
+	msg.Content = truncate(credit, 2000)
+	msg.AllowedMentions = &discordgo.MessageAllowedMentions{}
+
+	posted, err := s.ChannelMessageSendComplex(channelID, msg)
+	if err != nil {
+		log.Printf("Error posting to bot channel %s: %v", channelID, err)
+		return fmt.Sprintf("❌ Couldn't post in <#%s>, the bot may not have access to it.", channelID)
+	}
+	return fmt.Sprintf("📨 Posted in <#%s>: https://discord.com/channels/%s/%s/%s", channelID, i.GuildID, channelID, posted.ID)
+}
+
+// commandsRefused reports whether the server turned commands off in a channel
+func commandsRefused(settings *GuildSettings, channelID string) bool {
+	return slices.Contains(settings.NoCommandChannels, channelID)
+}
+
+// refuseCommandHere turns away slash commands in channels where the server doesn't want
+// them, pointing the member to the bot channel. Moderators are let through so they can
+// still change the setting from there.
+func refuseCommandHere(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if i.GuildID == "" || hasManageGuild(i) {
+		return false
+	}
+	settings := getGuildSettings(i.GuildID)
+	if !commandsRefused(settings, i.ChannelID) {
+		return false
+	}
+
+	content := "🤫 Commands are turned off in this channel."
+	if settings.BotChannel != "" {
+		content = fmt.Sprintf("🤫 Commands are turned off in this channel, please use <#%s> instead.", settings.BotChannel)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	return true
+}
+
+// replyApprovalSetting handles /settings reply_approval and returns the reply
+func replyApprovalSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	var channelID string
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.ReplyApproval = false
+		})
+		return "✅ Reply approval disabled. New auto-replies go live immediately."
+	}
+
+	if channelID == "" {
+		channelID = getGuildSettings(guildID).ReviewChannel
+	}
+	if channelID == "" {
+		return "❌ Pick a review channel where moderators will approve new rules."
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.ReplyApproval = true
+		settings.ReviewChannel = channelID
+	})
+	return fmt.Sprintf("✅ New auto-replies from members now need a moderator's approval in <#%s>. Server managers' rules skip review.", channelID)
+}
+
+// loadGuildSettings loads per-server settings from JSON file
+func loadGuildSettings() {
+	serverSettings = make(ServerSettings)
+
+	if _, err := os.Stat(settingsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		log.Printf("Error reading guild settings file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &serverSettings); err != nil {
+		log.Printf("Error parsing guild settings file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded settings for %d servers", len(serverSettings))
+}
+
+// saveGuildSettings saves per-server settings to JSON file
+func saveGuildSettings() {
+	data, err := json.MarshalIndent(serverSettings, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling guild settings: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(settingsFile, data, 0644); err != nil {
+		log.Printf("Error saving guild settings: %v", err)
+		return
+	}
+}
+
+// getGuildSettings returns the settings for a server, or defaults if none are stored
+func getGuildSettings(guildID string) *GuildSettings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if settings, ok := serverSettings[guildID]; ok {
+		copied := *settings
+		return &copied
+	}
+	return &GuildSettings{}
+}
+
+// updateGuildSettings applies a change to a server's settings and saves them
+func updateGuildSettings(guildID string, update func(settings *GuildSettings)) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	settings, ok := serverSettings[guildID]
+	if !ok {
+		settings = &GuildSettings{}
+		serverSettings[guildID] = settings
+	}
+	update(settings)
+	saveGuildSettings()
+}
+
+// hasManageGuild checks if the interacting member can manage the server
+func hasManageGuild(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
+}
+
+// memberHasManageGuild is hasManageGuild for message authors, whose permissions aren't sent with the message
+func memberHasManageGuild(s *discordgo.Session, userID, channelID string) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		log.Printf("Error checking permissions of %s: %v", userID, err)
+		return false
+	}
+	return perms&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
+}
+
+// parseTimezone resolves an IANA timezone name like "Asia/Makassar", the Indonesian
+// shorthands WIB/WITA/WIT, or a fixed offset like "UTC+7"
+func parseTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch strings.ToUpper(name) {
+	case "WIB":
+		return time.FixedZone("WIB", 7*60*60), nil
+	case "WITA":
+		return time.FixedZone("WITA", 8*60*60), nil
+	case "WIT":
+		return time.FixedZone("WIT", 9*60*60), nil
+	}
+
+	upper := strings.ToUpper(name)
+	if strings.HasPrefix(upper, "UTC") || strings.HasPrefix(upper, "GMT") {
+		offset := upper[3:]
+		if offset == "" {
+			return time.UTC, nil
+		}
+		hours, err := strconv.Atoi(offset)
+		if err == nil && hours >= -12 && hours <= 14 {
+			return time.FixedZone(upper, hours*60*60), nil
+		}
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || strings.EqualFold(name, "local") {
+		return nil, fmt.Errorf("unknown timezone %q, use a name like 'Asia/Jakarta', 'WITA' or 'UTC+8'", name)
+	}
+	return loc, nil
+}
+
+// replyLimit is how many auto-reply rules the server can have
+func (settings *GuildSettings) replyLimit() int {
+	if settings.MaxReplies == 0 {
+		return defaultMaxReplies
+	}
+	return settings.MaxReplies
+}
+
+// toggleID returns a copy of ids with id added or removed. Settings slices are never changed
+// in place, since readers may still hold the previous GuildSettings.
+func toggleID(ids []string, id string, present bool) []string {
+	updated := slices.DeleteFunc(slices.Clone(ids), func(existing string) bool { return existing == id })
+	if present {
+		updated = append(updated, id)
+	}
+	return updated
+}
+
+// location returns the server's timezone, WIB when none is set
+func (settings *GuildSettings) location() *time.Location {
+	if settings.Timezone == "" {
+		return botLocation
+	}
+	loc, err := parseTimezone(settings.Timezone)
+	if err != nil {
+		return botLocation
+	}
+	return loc
+}
+
+// parseClock parses a "HH:MM" time of day into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use 24-hour format like '22:00'", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// inQuietHours reports whether t falls within the server's quiet hours, which may wrap past midnight
+func (settings *GuildSettings) inQuietHours(t time.Time) bool {
+	if settings.QuietStart == "" || settings.QuietEnd == "" {
+		return false
+	}
+	start, err := parseClock(settings.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(settings.QuietEnd)
+	if err != nil {
+		return false
+	}
+
+	local := t.In(settings.location())
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// isQuietTime reports whether posts to a server should be held at time t
+func isQuietTime(guildID string, t time.Time) bool {
+	return getGuildSettings(guildID).inQuietHours(t)
+}
+
+// handleReplyCommand handles the /reply slash command
+func handleReplyCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+
+	// Get guild ID - only work in servers, not DMs
+	guildID := i.GuildID
+	if guildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Auto-reply commands only work in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if !getGuildSettings(guildID).AutoReplies {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: autoRepliesOffMessage,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Get user ID - handle both guild and DM interactions
+	var userID string
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	var trigger, response, match string
+	var mode string = "add"
+	if i.ApplicationCommandData().Name == "reply_edit" {
+		mode = "edit"
+	}
+	var regex, caseSensitive *bool
+	var channels, roles, users, ignoreUsers *string
+	var cooldown *int
+	var responseType string
+	var upload *discordgo.MessageAttachment
+	var mediaURL, expires *string
+	var expiryDM, deleteTrigger *bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "trigger":
+			trigger = opt.StringValue()
+		case "response":
+			response = opt.StringValue()
+		case "mode":
+			mode = opt.StringValue()
+		case "match":
+			match = opt.StringValue()
+		case "regex":
+			value := opt.BoolValue()
+			regex = &value
+		case "case_sensitive":
+			value := opt.BoolValue()
+			caseSensitive = &value
+		case "channels":
+			value := opt.StringValue()
+			channels = &value
+		case "roles":
+			value := opt.StringValue()
+			roles = &value
+		case "users":
+			value := opt.StringValue()
+			users = &value
+		case "ignore_users":
+			value := opt.StringValue()
+			ignoreUsers = &value
+		case "cooldown":
+			value := int(opt.IntValue())
+			cooldown = &value
+		case "response_type":
+			responseType = opt.StringValue()
+		case "attachment":
+			upload = i.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+		case "media_url":
+			value := strings.TrimSpace(opt.StringValue())
+			mediaURL = &value
+		case "expires":
+			value := opt.StringValue()
+			expires = &value
+		case "expiry_dm":
+			value := opt.BoolValue()
+			expiryDM = &value
+		case "delete_trigger":
+			value := opt.BoolValue()
+			deleteTrigger = &value
+		}
+	}
+
+	rule, exists := findAutoReply(guildID, AutoReply{Trigger: trigger})
+	appending := strings.ToLower(mode) == "append"
+	if strings.ToLower(mode) == "edit" || appending {
+		if !exists {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ No auto-reply found for that trigger. Create it with `/reply`.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+
+	if strings.ToLower(mode) == "remove" {
+		success, message, removedFor := removeAutoReply(trigger, userID, guildID, hasManageGuild(i))
+		var responseType string
+		var flags discordgo.MessageFlags = discordgo.MessageFlagsEphemeral
+
+		if success {
+			responseType = "✅ " + message
+		} else {
+			responseType = message
+			// If it's the custom bartard message, make it public
+			if strings.Contains(message, "bartard") {
+				flags = 0
+			} else {
+				responseType = "❌ " + message
+			}
+		}
+
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: responseType,
+				Flags:   flags,
+			},
+		})
+
+		// A moderator removing someone else's rule goes in the audit trail like other overrides
+		if removedFor != "" {
+			recordAudit(i, "removed a rule by "+removedFor)
+		}
+		return
+	}
+
+	// Removing stays open to the rule's author, creating and editing may be limited to roles
+	if settings := getGuildSettings(guildID); !canCreateReplies(settings, i.Member.Roles, hasManageGuild(i)) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         replyRolesMessage(settings),
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Options that aren't given keep their current value when editing
+	if !exists {
+		rule = AutoReply{Trigger: trigger}
+	}
+	if response != "" && !appending {
+		rule.Response = response
+	}
+	if match != "" {
+		rule.Match = strings.TrimPrefix(match, "text")
+	}
+	if regex != nil {
+		rule.Regex = *regex
+	}
+	if caseSensitive != nil {
+		rule.CaseSensitive = *caseSensitive
+	}
+	if responseType != "" {
+		rule.ResponseType = strings.TrimPrefix(responseType, "message")
+	}
+	if cooldown != nil {
+		rule.Cooldown = *cooldown
+		if rule.Cooldown == 0 {
+			rule.Cooldown = -1
+		}
+	}
+	if channels != nil {
+		channelIDs, err := parseReplyChannels(s, guildID, *channels)
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: userErrorMessage(err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		rule.ChannelIDs = channelIDs
+	}
+	if roles != nil || users != nil || ignoreUsers != nil {
+		var err error
+		if roles != nil {
+			rule.RoleIDs, err = parseReplyRoles(s, guildID, *roles)
+		}
+		if users != nil && err == nil {
+			rule.UserIDs, err = parseReplyUsers("users", *users)
+		}
+		if ignoreUsers != nil && err == nil {
+			rule.IgnoredUserIDs, err = parseReplyUsers("ignore_users", *ignoreUsers)
+		}
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: userErrorMessage(err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+	if expires != nil {
+		expiresAt, err := parseReplyExpiry(*expires, getGuildSettings(guildID).location(), time.Now())
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: userErrorMessage(err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		// Authors hear about the expiry unless they said otherwise
+		rule.ExpiresAt = expiresAt
+		rule.ExpiryDM = !expiresAt.IsZero()
+	}
+	if expiryDM != nil {
+		rule.ExpiryDM = *expiryDM && !rule.ExpiresAt.IsZero()
+	}
+	if deleteTrigger != nil {
+		if *deleteTrigger && i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) == 0 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ Only moderators with Manage Messages can make rules that delete messages.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		rule.DeleteTrigger = *deleteTrigger
+	}
+
+	// A new file or link replaces the media the rule sends, media_url:none removes it
+	if upload != nil || mediaURL != nil {
+		var problem string
+		rule.Attachments = nil
+		if mediaURL != nil && !strings.EqualFold(*mediaURL, "none") {
+			if u, err := url.Parse(*mediaURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				problem = "❌ `media_url` must be an http(s) link, or `none` to remove the media."
+			} else {
+				rule.Attachments = append(rule.Attachments, ReplyAttachment{Name: path.Base(u.Path), URL: *mediaURL})
+			}
+		}
+		if upload != nil && upload.Size > maxReplyMediaSize {
+			problem = fmt.Sprintf("❌ Files for auto-replies can be at most %d MB.", maxReplyMediaSize>>20)
+		}
+		if problem != "" {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: problem,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+
+	// Regex triggers are checked here so a bad pattern never reaches the preview
+	var ruleErr error
+	switch {
+	case rule.Regex && rule.Match != "":
+		ruleErr = invalidInput("regex triggers only match message text")
+	case rule.CaseSensitive && !rule.Regex:
+		ruleErr = invalidInput("`case_sensitive` only applies to regex triggers, word triggers always ignore case")
+	case rule.ResponseType == "reaction" && (upload != nil || len(rule.Attachments) > 0):
+		ruleErr = invalidInput("reaction rules can't send media, use `response_type:message`")
+	case rule.ResponseType == "reaction" && rule.DeleteTrigger:
+		ruleErr = invalidInput("reaction rules can't delete the message they react to, use `response_type:message`")
+	default:
+		ruleErr = compileTrigger(&rule)
+	}
+	if ruleErr != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(ruleErr),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if rule.Response == "" && upload == nil && len(rule.Attachments) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please provide a response message, file or media link!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	screened, ok := screenReplyResponse(guildID, rule.Response)
+	if !ok {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: profanityRejectedMessage,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	rule.Response = screened
+
+	// append keeps the current responses and adds another to pick from
+	if appending {
+		var problem string
+		extra, ok := screenReplyResponse(guildID, response)
+		switch {
+		case response == "":
+			problem = "❌ Please provide the response to add!"
+		case !ok:
+			problem = profanityRejectedMessage
+		case extra == rule.Response || slices.Contains(rule.Responses, extra):
+			problem = "❌ That trigger already has this response."
+		case len(rule.Responses)+1 >= maxReplyResponses:
+			problem = fmt.Sprintf("❌ A trigger can have at most %d responses.", maxReplyResponses)
+		}
+		if problem != "" {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: problem,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		rule.Responses = append(rule.Responses, extra)
+	}
+
+	// Reaction rules must only hold emoji the bot can react with
+	if rule.ResponseType == "reaction" {
+		for _, response := range append([]string{rule.Response}, rule.Responses...) {
+			if _, err := parseReactions(response); err != nil {
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: userErrorMessage(err),
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+				return
+			}
+		}
+	}
+
+	// Someone else's rule is refused before the preview, publicly as it always was
+	if ruleOwnedByOther(guildID, rule, userID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("you can't change this you bartard <@%s>", userID),
+			},
+		})
+		return
+	}
+
+	// Uploaded files are downloaded and kept locally, Discord's attachment links expire
+	if upload != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		data, err := fetchAttachment(upload.URL)
+		var stored string
+		if err == nil {
+			stored, err = storeReplyMedia(guildID, upload.Filename, data)
+		}
+		if err != nil {
+			log.Printf("Error storing auto-reply media: %v", err)
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: "❌ Couldn't save that file, please try again.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return
+		}
+		rule.Attachments = append([]ReplyAttachment{{Name: upload.Filename, Path: stored}}, rule.Attachments...)
+	}
+
+	// Show exactly what the bot will send and wait for Confirm before saving
+	draftID := i.ID
+	repliesMu.Lock()
+	replyDrafts[draftID] = &replyDraft{
+		GuildID:   guildID,
+		UserID:    userID,
+		Rule:      rule,
+		CreatedAt: time.Now(),
+	}
+	repliesMu.Unlock()
+
+	preview := replyPreview(s, i, rule, draftID)
+	if upload != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         preview.Content,
+			Components:      preview.Components,
+			AllowedMentions: preview.AllowedMentions,
+			Flags:           preview.Flags,
+		})
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: preview,
+	})
+}
+
+// replyPreview renders a draft rule as the bot would send it, with {placeholders} filled
+// in from the member creating it, plus Confirm/Cancel buttons
+func replyPreview(s *discordgo.Session, i *discordgo.InteractionCreate, rule AutoReply, draftID string) *discordgo.InteractionResponseData {
+	rendered := expandReplyTemplate(s, rule.Response, i.Member.User, i.GuildID, i.ChannelID)
+
+	when := fmt.Sprintf("when someone says `%s`", rule.Trigger)
+	if rule.Regex {
+		when = fmt.Sprintf("when a message matches the regex `%s` (%s)", rule.Trigger, regexLabel(rule))
+	}
+	if rule.Match != "" {
+		when = fmt.Sprintf("the first time someone whose %s contains `%s` sends a message", matchLabels[rule.Match], rule.Trigger)
+	}
+	if len(rule.ChannelIDs) > 0 {
+		when += " in " + channelMentions(rule.ChannelIDs)
+	}
+	if audience := ruleAudience(rule); audience != "" {
+		when += ", for " + audience
+	}
+	content := fmt.Sprintf("👀 **Preview:** %s, the bot will reply:\n\n%s", when, rendered)
+	if rule.ResponseType == "dm" {
+		content = fmt.Sprintf("👀 **Preview:** %s, the bot will DM the member:\n\n%s", when, rendered)
+	}
+	if rule.ResponseType == "reaction" {
+		content = fmt.Sprintf("👀 **Preview:** %s, the bot will react with %s", when, strings.Join(append([]string{rule.Response}, rule.Responses...), " or "))
+	}
+	existing, ok := findAutoReply(i.GuildID, rule)
+	if ok && existing.Response != rule.Response {
+		content = fmt.Sprintf("✏️ **Changes** to `%s`:\n%s\n", rule.Trigger, lineDiff(existing.Response, rule.Response)) + content
+	}
+	switch {
+	case rule.ResponseType == "reaction":
+		// Emoji have no placeholders to explain
+	case ok && len(rule.Responses) > len(existing.Responses):
+		// Appending shows the new response, the one members haven't seen yet
+		added := rule.Responses[len(rule.Responses)-1]
+		rendered = expandReplyTemplate(s, added, i.Member.User, i.GuildID, i.ChannelID)
+		content = fmt.Sprintf("👀 **Preview:** %s, the bot will pick one of %d responses at random, now also:\n\n%s", when, len(rule.Responses)+1, rendered)
+		if rendered != added {
+			content += "\n\n-# Placeholders are filled in with your name and this channel as sample data."
+		}
+	case rendered != rule.Response:
+		content += "\n\n-# Placeholders are filled in with your name and this channel as sample data."
+	}
+	for _, attachment := range rule.Attachments {
+		if attachment.URL != "" {
+			content += "\n📎 " + attachment.URL
+		} else {
+			content += fmt.Sprintf("\n📎 `%s`", attachment.Name)
+		}
+	}
+	if rule.DeleteTrigger {
+		content += "\n🗑️ The triggering message is deleted after the reply."
+		if i.AppPermissions&discordgo.PermissionManageMessages == 0 {
+			content += "\n⚠️ The bot can't delete messages in this channel. Give it **Manage Messages**, until then the rule only replies."
+		}
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: truncate(content, 2000),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Confirm", Style: discordgo.SuccessButton, CustomID: "reply_confirm:" + draftID},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "reply_cancel:" + draftID},
+			}},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Flags:           discordgo.MessageFlagsEphemeral,
+	}
+}
+
+// sweepReplyDrafts drops /reply previews nobody confirmed or cancelled, and the media
+// uploaded with them
+func sweepReplyDrafts(now time.Time) {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	for draftID, draft := range replyDrafts {
+		if now.Sub(draft.CreatedAt) > replyDraftTTL {
+			delete(replyDrafts, draftID)
+			removeUnusedMedia(draft.GuildID, draft.Rule.Attachments)
+		}
+	}
+}
+
+// expandReplyTemplate fills in the placeholders a response may use: {user} mentions the
+// author, {username} is their name, {server} the server name and {channel} the channel
+func expandReplyTemplate(s *discordgo.Session, response string, user *discordgo.User, guildID, channelID string) string {
+	if !strings.Contains(response, "{") {
+		return response
+	}
+
+	serverName := "this server"
+	if guild, err := s.State.Guild(guildID); err == nil {
+		serverName = guild.Name
+	}
+
+	return strings.NewReplacer(
+		"{user}", "<@"+user.ID+">",
+		"{username}", user.Username,
+		"{server}", serverName,
+		"{channel}", "<#"+channelID+">",
+	).Replace(response)
+}
+
+// handleReplyDraftButton saves or discards a previewed rule
+func handleReplyDraftButton(s *discordgo.Session, i *discordgo.InteractionCreate, draftID string, confirm bool) {
+	repliesMu.Lock()
+	draft := replyDrafts[draftID]
+	delete(replyDrafts, draftID)
+	repliesMu.Unlock()
+
+	update := func(data *discordgo.InteractionResponseData) {
+		if data.Embeds == nil {
+			data.Embeds = []*discordgo.MessageEmbed{}
+		}
+		data.Components = []discordgo.MessageComponent{}
+		data.AllowedMentions = &discordgo.MessageAllowedMentions{}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: data,
+		})
+	}
+
+	switch {
+	case draft == nil || time.Since(draft.CreatedAt) > replyDraftTTL:
+		update(&discordgo.InteractionResponseData{Content: "❌ This preview has expired. Please run `/reply` again."})
+		return
+	case !confirm:
+		repliesMu.Lock()
+		removeUnusedMedia(draft.GuildID, draft.Rule.Attachments)
+		repliesMu.Unlock()
+		update(&discordgo.InteractionResponseData{Content: "🗑️ Cancelled, the auto-reply was not saved."})
+		return
+	}
+
+	// Servers with reply approval send new rules from non-moderators to the review channel
+	if settings := getGuildSettings(draft.GuildID); settings.ReplyApproval && !hasManageGuild(i) {
+		update(&discordgo.InteractionResponseData{Content: submitReplyForReview(s, settings, draft.GuildID, draft.Rule, draft.UserID)})
+		return
+	}
+
+	success, message, _ := addAutoReplyRule(draft.GuildID, draft.Rule, draft.UserID)
+	if !success {
+		update(&discordgo.InteractionResponseData{Content: "❌ " + message})
+		// If it's the custom bartard message, make it public
+		if strings.Contains(message, "bartard") {
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: message})
+		}
+		return
+	}
+
+	update(&discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "✅ Auto-Reply Set Up Successfully!",
+			Description: ruleSummary(draft.Rule),
+			Color:       embedColor,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: "The bot will now automatically reply when someone sends the trigger message. Only you can modify this auto-reply.",
+			},
+		}},
+	})
+}
+
+// handleListRepliesCommand handles the /list_replies slash command
+func handleListRepliesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Get guild ID - only work in servers, not DMs
+	guildID := i.GuildID
+	if guildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Auto-reply commands only work in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	var filter replyFilter
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "trigger":
+			filter.Query = strings.TrimSpace(opt.StringValue())
+		case "author":
+			filter.AuthorID = opt.UserValue(nil).ID
+		case "channel":
+			filter.ChannelID = opt.ChannelValue(nil).ID
+		}
+	}
+
+	// Check if this server has any auto-replies
+	embed, pages := listRepliesEmbed(guildID, 0, filter)
+	if embed == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: filter.emptyMessage(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: listRepliesComponents(0, pages, filter),
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// replyFilter narrows /list_replies to rules whose trigger contains Query, that AuthorID
+// created or that are limited to ChannelID. Empty fields don't filter.
+type replyFilter struct {
+	Query     string
+	AuthorID  string
+	ChannelID string
+}
+
+// matches reports whether a rule passes the filter
+func (f replyFilter) matches(rule AutoReply) bool {
+	return (f.Query == "" || strings.Contains(strings.ToLower(rule.Trigger), strings.ToLower(f.Query))) &&
+		(f.AuthorID == "" || rule.AuthorID == f.AuthorID) &&
+		(f.ChannelID == "" || slices.Contains(rule.ChannelIDs, f.ChannelID))
+}
+
+// active reports whether any filter is set
+func (f replyFilter) active() bool {
+	return f.Query != "" || f.AuthorID != "" || f.ChannelID != ""
+}
+
+// describe lists the filters for the rule list embed
+func (f replyFilter) describe() string {
+	var parts []string
+	if f.Query != "" {
+		parts = append(parts, fmt.Sprintf("trigger contains `%s`", f.Query))
+	}
+	if f.AuthorID != "" {
+		parts = append(parts, fmt.Sprintf("created by <@%s>", f.AuthorID))
+	}
+	if f.ChannelID != "" {
+		parts = append(parts, fmt.Sprintf("limited to <#%s>", f.ChannelID))
+	}
+	return "Rules whose " + strings.Join(parts, ", ")
+}
+
+// emptyMessage is the reply when no rules are listed
+func (f replyFilter) emptyMessage() string {
+	if f.active() {
+		return "🔍 No auto-reply rules match those filters."
+	}
+	return "📝 No auto-reply rules set up for this server."
+}
+
+// customID encodes a page and the filter for the list buttons. The query goes last since
+// it may contain colons.
+func (f replyFilter) customID(page int) string {
+	return fmt.Sprintf("list_replies:%d:%s:%s:%s", page, f.AuthorID, f.ChannelID, f.Query)
+}
+
+// handleListRepliesPage moves a /list_replies message to another page, custom ID
+// list_replies:<page>:<authorID>:<channelID>:<query>
+func handleListRepliesPage(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	parts := strings.SplitN(arg, ":", 4)
+	page, _ := strconv.Atoi(parts[0])
+	var filter replyFilter
+	if len(parts) == 4 {
+		filter = replyFilter{AuthorID: parts[1], ChannelID: parts[2], Query: parts[3]}
+	}
+
+	embed, pages := listRepliesEmbed(i.GuildID, page, filter)
+	data := &discordgo.InteractionResponseData{
+		Content:    filter.emptyMessage(),
+		Embeds:     []*discordgo.MessageEmbed{},
+		Components: []discordgo.MessageComponent{},
+	}
+	if embed != nil {
+		data.Content = ""
+		data.Embeds = []*discordgo.MessageEmbed{embed}
+		data.Components = listRepliesComponents(min(page, pages-1), pages, filter)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
+}
+
+// listRepliesComponents are the Previous/Next buttons under a rule list page, none when
+// everything fits on one
+func listRepliesComponents(page, pages int, filter replyFilter) []discordgo.MessageComponent {
+	if pages <= 1 {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "◀ Previous", Style: discordgo.SecondaryButton, CustomID: filter.customID(page - 1), Disabled: page == 0},
+			discordgo.Button{Label: fmt.Sprintf("%d/%d", page+1, pages), Style: discordgo.SecondaryButton, CustomID: "list_replies_page", Disabled: true},
+			discordgo.Button{Label: "Next ▶", Style: discordgo.SecondaryButton, CustomID: filter.customID(page + 1), Disabled: page >= pages-1},
+		}},
+	}
+}
+
+// listRepliesEmbed builds one page of the rule list embed for a server and returns it
+// with the number of pages, or nil if no rules pass the filter. Pages past the end show
+// the last.
+func listRepliesEmbed(guildID string, page int, filter replyFilter) (*discordgo.MessageEmbed, int) {
+	repliesMu.Lock()
+	defer repliesMu.Unlock()
+
+	var serverReplies []AutoReply
+	for _, rule := range serverAutoReplies[guildID] {
+		if filter.matches(rule) {
+			serverReplies = append(serverReplies, rule)
+		}
+	}
+	if len(serverReplies) == 0 {
+		return nil, 0
+	}
+
+	pages := (len(serverReplies) + listRepliesPageSize - 1) / listRepliesPageSize
+	page = max(0, min(page, pages-1))
+	start := page * listRepliesPageSize
+	end := min(start+listRepliesPageSize, len(serverReplies))
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📋 Server Auto-Reply Rules",
+		Description: "Active rules for this server",
+		Color:       0x3498db,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Total rules: %d", len(serverReplies)),
+		},
+	}
+	if pages > 1 {
+		embed.Footer.Text = fmt.Sprintf("Rules %d–%d of %d · Page %d/%d", start+1, end, len(serverReplies), page+1, pages)
+	}
+	if filter.active() {
+		embed.Description = filter.describe()
+	}
+
+	var names, values []string
+	for _, reply := range serverReplies[start:end] {
+		displayResponse := truncate(reply.Response, 103)
+		if len(reply.Responses) > 0 {
+			displayResponse += fmt.Sprintf(" (+%d more, picked at random)", len(reply.Responses))
+		}
+		if len(reply.Attachments) > 0 {
+			displayResponse += fmt.Sprintf(" 📎 %d file(s)", len(reply.Attachments))
+		}
+
+		authorInfo := ""
+		if reply.AuthorID != "" {
+			authorInfo = fmt.Sprintf(" (by <@%s>)", reply.AuthorID)
+		}
+
+		name := fmt.Sprintf("Trigger: %s", reply.Trigger)
+		if reply.Match != "" {
+			name += fmt.Sprintf(" (in %s)", matchLabels[reply.Match])
+		}
+		if reply.Regex {
+			name += " (regex)"
+		}
+		if len(reply.ChannelIDs) > 0 {
+			displayResponse += "\nOnly in " + channelMentions(reply.ChannelIDs)
+		}
+		if audience := ruleAudience(reply); audience != "" {
+			displayResponse += "\nOnly for " + audience
+		}
+		if !reply.ExpiresAt.IsZero() {
+			displayResponse += fmt.Sprintf("\n⌛ Expires <t:%d:R>", reply.ExpiresAt.Unix())
+		}
+		if reply.DeleteTrigger {
+			displayResponse += "\n🗑️ Deletes the message"
+		}
+
+		names = append(names, truncate(name, 256))
+		values = append(values, fmt.Sprintf("Response: %s%s", displayResponse, authorInfo))
+	}
+
+	// Embeds hold 6000 characters, the values share what the names and footer leave
+	budget := maxEmbedChars - embedsLength([]*discordgo.MessageEmbed{embed})
+	for _, name := range names {
+		budget -= len(name)
+	}
+	limit := min(1024, budget/len(values))
+	for n, name := range names {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  truncate(values[n], limit),
+			Inline: false,
+		})
+	}
+
+	return embed, pages
+}
+
+// handleHelpCommand handles the /help_reply slash command
+func handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{helpReplyEmbed()},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// helpReplyEmbed builds the auto-reply help embed
+func helpReplyEmbed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "🤖 Auto-Reply Bot Help",
+		Description: "Smart auto-reply system for Discord servers",
+		Color:       0x9b59b6,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "📝 `/reply [trigger] [response]`",
+				Value:  "Set up a new auto-reply rule for this server. When someone sends a message containing the trigger word, the bot will automatically respond.",
+				Inline: false,
+			},
+			{
+				Name:   "🗑️ `/reply [trigger] [response] remove`",
+				Value:  "Remove an existing auto-reply rule for the specified trigger in this server.",
+				Inline: false,
+			},
+			{
+				Name:   "✏️ `/reply_edit [trigger]`",
+				Value:  "Change one of your rules. Start typing the trigger to pick it, and only set the options you want to change.",
+				Inline: false,
+			},
+			{
+				Name:   "📋 `/list_replies`",
+				Value:  "Show all active auto-reply rules for this server.",
+				Inline: false,
+			},
+			{
+				Name:   "ℹ️ How it works:",
+				Value:  "• Triggers are case-insensitive and match whole words only\n• With `regex:true` the trigger is a regular expression matched anywhere, like `go+d morning`; add `case_sensitive:true` to match capitals exactly\n• Bot only works in servers where auto-replies have been set up\n• Anyone can create new rules\n• Only the original author can modify/delete their rules, members with Manage Server can delete any rule\n• Rules are server-specific\n• Responses can use `{user}`, `{username}`, `{server}` and `{channel}`\n• `/reply` shows a preview to confirm before the rule is saved\n• `/reply mode:append` adds another response, the bot picks one at random\n• `channels:#general #memes` limits where a rule fires, `roles`, `users` and `ignore_users` who sets it off\n• A rule replies at most once every 30 seconds, change it with `cooldown`\n• `response_type:reaction` reacts with the emoji in the response, like `👍 🎉`, `dm` sends it privately\n• `expires:7d` removes a rule after a week, you get a DM when it goes",
+				Inline: false,
+			},
+			{
+				Name:   "⚠️ Note:",
+				Value:  "• Commands only work in servers, not in DMs\n• The bot needs 'Send Messages' permission in channels where you want auto-replies to work\n• Auto-replies only work in servers that have at least one rule set up",
+				Inline: false,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /reply to set up smart auto-replies for this server! Only you can modify rules you create.",
+		},
+	}
+}
+
+// handleCommandsCommand handles the /commands slash command
+func handleCommandsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{commandsEmbed()},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	// Settings and admin tools only matter to those who can use them
+	if hasManageGuild(i) {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{serverCommandsEmbed()},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		})
+	}
+}
+
+// qrVersion describes the level M error correction layout of one QR code version
+type qrVersion struct {
+	ecPerBlock int
+	blocks     []int // data codewords of each block
+}
+
+// qrVersions are QR code versions 1–10 at error correction level M, enough for 213 bytes
+var qrVersions = []qrVersion{
+	{10, []int{16}},
+	{16, []int{28}},
+	{26, []int{44}},
+	{18, []int{32, 32}},
+	{24, []int{43, 43}},
+	{16, []int{27, 27, 27, 27}},
+	{18, []int{31, 31, 31, 31}},
+	{22, []int{38, 38, 39, 39}},
+	{22, []int{36, 36, 36, 37, 37}},
+	{26, []int{43, 43, 43, 43, 44}},
+}
+
+// qrCode is a square grid of modules, true for dark
+type qrCode struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// gfMultiply multiplies two elements of the QR code Galois field GF(2^8)
+func gfMultiply(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x1D)
+		z ^= ((y >> i) & 1) * x
+	}
+	return z
+}
+
+// reedSolomon returns the error correction codewords for a block of data
+func reedSolomon(data []byte, degree int) []byte {
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+	var root byte = 1
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			divisor[j] = gfMultiply(divisor[j], root)
+			if j+1 < degree {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	result := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[degree-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// encodeQR encodes text in byte mode in the smallest version that fits
+func encodeQR(text string) (*qrCode, error) {
+	data := []byte(text)
+	version := 0
+	for v := range qrVersions {
+		capacity := 0
+		for _, n := range qrVersions[v].blocks {
+			capacity += n
+		}
+		countBits := 8
+		if v+1 >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 <= capacity*8 {
+			version = v + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, invalidInput("text is too long for a QR code, keep it under 200 characters")
+	}
+	layout := qrVersions[version-1]
+
+	// Mode indicator, character count, data, terminator and padding
+	var bits []bool
+	appendBits := func(value, length int) {
+		for i := length - 1; i >= 0; i-- {
+			bits = append(bits, (value>>i)&1 == 1)
+		}
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	appendBits(0x4, 4)
+	appendBits(len(data), countBits)
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+	capacity := 0
+	for _, n := range layout.blocks {
+		capacity += n
+	}
+	appendBits(0, min(4, capacity*8-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity*8; pad ^= 0xEC ^ 0x11 {
+		appendBits(pad, 8)
+	}
+
+	codewords := make([]byte, capacity)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// Split into blocks, add error correction and interleave
+	var blocks, ecBlocks [][]byte
+	offset := 0
+	for _, n := range layout.blocks {
+		block := codewords[offset : offset+n]
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, reedSolomon(block, layout.ecPerBlock))
+		offset += n
+	}
+	var final []byte
+	for i := 0; i < layout.blocks[len(layout.blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				final = append(final, block[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			final = append(final, block[i])
+		}
+	}
+
+	qr := newQRCode(version)
+	qr.drawCodewords(final)
+
+	// Pick the mask with the lowest penalty, as the spec recommends
+	bestMask, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if penalty := qr.penalty(); penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask) // masks are their own inverse
+	}
+	qr.applyMask(bestMask)
+	qr.drawFormatBits(bestMask)
+	return qr, nil
+}
+
+// newQRCode draws the finder, timing, alignment and version patterns of a version
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	qr := &qrCode{size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for y := range qr.modules {
+		qr.modules[y] = make([]bool, size)
+		qr.isFunction[y] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	for _, center := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					dist := max(abs(dx), abs(dy))
+					qr.setFunction(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+
+	if version > 1 {
+		count := version/7 + 2
+		step := (version*4 + count*2 + 1) / (count*2 - 2) * 2
+		positions := make([]int, count)
+		positions[0] = 6
+		for k, pos := count-1, size-7; k >= 1; k, pos = k-1, pos-step {
+			positions[k] = pos
+		}
+		last := len(positions) - 1
+		for i, x := range positions {
+			for j, y := range positions {
+				if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+					continue
+				}
+				for dy := -2; dy <= 2; dy++ {
+					for dx := -2; dx <= 2; dx++ {
+						qr.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+					}
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas, then draw the version blocks for versions 7 and up
+	qr.drawFormatBits(0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := size-11+i%3, i/3
+			qr.setFunction(a, b, dark)
+			qr.setFunction(b, a, dark)
+		}
+	}
+	return qr
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (qr *qrCode) setFunction(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.isFunction[y][x] = true
+}
+
+// drawFormatBits draws both copies of the level M format information for a mask
+func (qr *qrCode) drawFormatBits(mask int) {
+	data := 0<<3 | mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.size-15+i, bit(i))
+	}
+	qr.setFunction(8, qr.size-8, true) // always dark
+}
+
+// drawCodewords places the data in the zigzag order, two columns at a time from the right
+func (qr *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if !qr.isFunction[y][x] && i < len(data)*8 {
+					qr.modules[y][x] = (data[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by one of the eight mask patterns
+func (qr *qrCode) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !qr.isFunction[y][x] {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan using the four rules of the spec
+func (qr *qrCode) penalty() int {
+	size := qr.size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qr.modules[x][y]
+		}
+		return qr.modules[y][x]
+	}
+
+	penalty := 0
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < size; y++ {
+			// Runs of five or more modules of the same color
+			run := 1
+			for x := 1; x < size; x++ {
+				if at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				penalty += run - 2
+			}
+
+			// Finder-like patterns with four light modules on either side
+			for x := 0; x+7 <= size; x++ {
+				match := true
+				for k, dark := range finderLike {
+					if at(x+k, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+				light := func(from, to int) bool {
+					for k := from; k < to; k++ {
+						if k >= 0 && k < size && at(k, y, transpose) {
+							return false
+						}
+					}
+					return true
+				}
+				if light(x-4, x) || light(x+7, x+11) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	// 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := qr.modules[y][x]
+				if c == qr.modules[y][x+1] && c == qr.modules[y+1][x] && c == qr.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	// Balance of dark and light modules
+	percent := dark * 100 / (size * size)
+	penalty += abs(percent-50) / 5 * 10
+	return penalty
+}
+
+// png renders the code with a four-module quiet zone, scale pixels per module
+func (qr *qrCode) png(scale int) ([]byte, error) {
+	border := 4
+	width := (qr.size + border*2) * scale
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for py := 0; py < width; py++ {
+		for px := 0; px < width; px++ {
+			x, y := px/scale-border, py/scale-border
+			shade := color.Gray{Y: 255}
+			if x >= 0 && x < qr.size && y >= 0 && y < qr.size && qr.modules[y][x] {
+				shade = color.Gray{Y: 0}
+			}
+			img.SetGray(px, py, shade)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handleQRCommand handles /qr
+func handleQRCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	text := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	qr, err := encodeQR(text)
+	var pngData []byte
+	if err == nil {
+		pngData, err = qr.png(8)
+	}
+	if err != nil {
+		log.Printf("Error generating QR code: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔳 QR Code",
+		Description: "```\n" + truncate(strings.ReplaceAll(text, "```", "'''"), 1000) + "\n```",
+		Color:       embedColor,
+		Image:       &discordgo.MessageEmbedImage{URL: "attachment://qr.png"},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Double-check payment details and wallet addresses before you pay",
+		},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Files: []*discordgo.File{{
+				Name:        "qr.png",
+				ContentType: "image/png",
+				Reader:      bytes.NewReader(pngData),
+			}},
+		},
+	})
+}
+
+// shortenerNames are the link shorteners a server can pick with /settings shortener
+var shortenerNames = map[string]string{
+	"isgd":   "is.gd",
+	"bitly":  "Bitly",
+	"shlink": "Shlink",
+}
+
+// shortenerProvider returns the server's link shortener, with the Bitly or Shlink key when one is needed
+func shortenerProvider(guildID string) (provider, apiKey string, settings *GuildSettings, err error) {
+	settings = getGuildSettings(guildID)
+	provider = settings.Shortener
+	if provider == "" || provider == "isgd" {
+		return "isgd", "", settings, nil
+	}
+
+	apiKey, ok := guildAPIKey(guildID, provider)
+	if !ok {
+		return "", "", settings, invalidInput("no %s key is set up yet, add one with `/settings apikey set`", shortenerNames[provider])
+	}
+	return provider, apiKey, settings, nil
+}
+
+// shortenLink shortens a URL with the server's link shortener
+func shortenLink(guildID, longURL string) (string, error) {
+	provider, apiKey, settings, err := shortenerProvider(guildID)
+	if err != nil {
+		return "", err
+	}
+
+	switch provider {
+	case "bitly":
+		var result struct {
+			Link string `json:"link"`
+		}
+		err = shortenerRequest("Bitly", http.MethodPost, "https://api-ssl.bitly.com/v4/shorten", "Bearer "+apiKey, map[string]string{"long_url": longURL}, &result)
+		return result.Link, err
+	case "shlink":
+		var result struct {
+			ShortURL string `json:"shortUrl"`
+		}
+		endpoint := strings.TrimRight(settings.ShortenerURL, "/") + "/rest/v3/short-urls"
+		err = shortenerRequest("Shlink", http.MethodPost, endpoint, apiKey, map[string]string{"longUrl": longURL}, &result)
+		return result.ShortURL, err
+	default:
+		var result struct {
+			ShortURL     string `json:"shorturl"`
+			ErrorMessage string `json:"errormessage"`
+		}
+		endpoint := "https://is.gd/create.php?format=json&url=" + url.QueryEscape(longURL)
+		if err := shortenerRequest("is.gd", http.MethodGet, endpoint, "", nil, &result); err != nil {
+			return "", err
+		}
+		if result.ShortURL == "" {
+			return "", invalidInput("is.gd could not shorten that link: %s", result.ErrorMessage)
+		}
+		return result.ShortURL, nil
+	}
+}
+
+// linkClicks returns how often a short link was opened, for shorteners that report it
+func linkClicks(guildID, shortURL string) (int, error) {
+	provider, apiKey, settings, err := shortenerProvider(guildID)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := url.Parse(shortURL)
+	if err != nil || parsed.Host == "" {
+		return 0, invalidInput("`%s` is not a short link", shortURL)
+	}
+	code := strings.Trim(parsed.Path, "/")
+
+	switch provider {
+	case "bitly":
+		var result struct {
+			TotalClicks int `json:"total_clicks"`
+		}
+		endpoint := fmt.Sprintf("https://api-ssl.bitly.com/v4/bitlinks/%s/%s/clicks/summary?unit=month&units=-1", parsed.Host, code)
+		err = shortenerRequest("Bitly", http.MethodGet, endpoint, "Bearer "+apiKey, nil, &result)
+		return result.TotalClicks, err
+	case "shlink":
+		var result struct {
+			VisitsSummary struct {
+				Total int `json:"total"`
+			} `json:"visitsSummary"`
+		}
+		endpoint := strings.TrimRight(settings.ShortenerURL, "/") + "/rest/v3/short-urls/" + url.PathEscape(code)
+		err = shortenerRequest("Shlink", http.MethodGet, endpoint, apiKey, nil, &result)
+		return result.VisitsSummary.Total, err
+	default:
+		return 0, invalidInput("is.gd doesn't report click counts, switch to Bitly or Shlink with `/settings shortener`")
+	}
+}
+
+// shortenerRequest calls a shortener API and decodes its JSON reply. Bitly takes a bearer
+// token, Shlink an X-Api-Key header.
+func shortenerRequest(service, method, endpoint, auth string, payload interface{}, target interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s request: %v", service, err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case strings.HasPrefix(auth, "Bearer "):
+		req.Header.Set("Authorization", auth)
+	case auth != "":
+		req.Header.Set("X-Api-Key", auth)
+	}
+
+	// Shlink addresses are set by server admins, so private addresses are refused like /check
+	resp, err := publicOnlyClient.Do(req)
+	if errors.Is(err, errPrivateAddress) {
+		return invalidInput("%s must be on a public address", service)
+	}
+	if err != nil {
+		return upstreamDown(service, fmt.Errorf("failed to call API: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		if resp.StatusCode == http.StatusBadRequest {
+			return invalidInput("%s rejected that link", service)
+		}
+		return httpStatusError(service, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return upstreamDown(service, fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	return nil
+}
+
+// shortenerSetting handles /settings shortener and returns the reply
+func shortenerSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var provider, endpoint string
+	for _, opt := range options {
+		switch opt.Name {
+		case "provider":
+			provider = opt.StringValue()
+		case "endpoint":
+			endpoint = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if provider == "shlink" {
+		if endpoint == "" {
+			endpoint = getGuildSettings(guildID).ShortenerURL
+		}
+		if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "❌ Shlink needs the address of your server, e.g. `endpoint:https://s.example.com`."
+		}
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.Shortener = provider
+		if provider == "shlink" {
+			settings.ShortenerURL = endpoint
+		}
+	})
+
+	content := fmt.Sprintf("✅ `/shorten` now uses **%s**.", shortenerNames[provider])
+	if provider == "isgd" {
+		return content + " is.gd needs no key but doesn't report click counts."
+	}
+	if _, ok := guildAPIKey(guildID, provider); !ok {
+		content += fmt.Sprintf("\n⚠️ No %s key is set up yet. Add one with `/settings apikey set service:%s`.", shortenerNames[provider], shortenerNames[provider])
+	}
+	return content
+}
+
+// handleShortenCommand handles /shorten url and /shorten stats
+func handleShortenCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ `/shorten` only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	link := strings.TrimSpace(subcommand.Options[0].StringValue())
+
+	if subcommand.Name == "url" {
+		if parsed, err := url.Parse(link); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ That doesn't look like a link. Include the `https://` part.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+
+	// Defer the response since the shortener might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	var content string
+	if subcommand.Name == "stats" {
+		var clicks int
+		clicks, err = linkClicks(i.GuildID, link)
+		content = fmt.Sprintf("📊 <%s> has been opened **%d** times.", link, clicks)
+	} else {
+		var short string
+		short, err = shortenLink(i.GuildID, link)
+		content = fmt.Sprintf("🔗 %s\nPoints to <%s>", short, link)
+	}
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+}
+
+// wordSense is one meaning of a word
+type wordSense struct {
+	PartOfSpeech string
+	Definition   string
+	Example      string
+}
+
+// wordEntry is a dictionary lookup shown by /kbbi and /define
+type wordEntry struct {
+	Word     string
+	Phonetic string
+	URL      string
+	Source   string
+	Senses   []wordSense
+}
+
+var (
+	htmlTag       = regexp.MustCompile(`<[^>]+>`)
+	kbbiHeading   = regexp.MustCompile(`(?s)<h2[^>]*>(.*?)</h2>`)
+	kbbiList      = regexp.MustCompile(`(?s)<(?:ol|ul class="adjusted-par")>(.*?)</(?:ol|ul)>`)
+	kbbiItem      = regexp.MustCompile(`(?s)<li>(.*?)</li>`)
+	kbbiLabel     = regexp.MustCompile(`(?s)<font color="red"><i>(.*?)</i></font>`)
+	kbbiExample   = regexp.MustCompile(`(?s)<font color="grey"><i>(.*?)</i></font>`)
+	kbbiLabelName = regexp.MustCompile(`<span title="([^":]+)`)
+)
+
+// stripHTML removes tags and decodes entities from a snippet of HTML
+func stripHTML(snippet string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(snippet, ""))), " ")
+}
+
+// fetchPage downloads a page for the dictionary lookups, mapping 404s to notFound
+func fetchPage(service, pageURL string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(pageURL)
+	if err != nil {
+		return nil, upstreamDown(service, fmt.Errorf("failed to fetch %s: %v", pageURL, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError(service, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil, upstreamDown(service, fmt.Errorf("failed to read response body: %v", err))
+	}
+	return body, nil
+}
+
+// fetchWithKey downloads an API response like fetchPage, for URLs with an API key in the
+// query string. Errors leave the URL out, since net/http repeats it in *url.Error too.
+func fetchWithKey(service, pageURL string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(pageURL)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, upstreamDown(service, fmt.Errorf("request failed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError(service, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil, upstreamDown(service, fmt.Errorf("failed to read response body: %v", err))
+	}
+	return body, nil
+}
+
+// lookupKBBI reads a word's entry from KBBI Daring. KBBI has no public API, so the entry page
+// is parsed; KBBI_URL can point at a mirror with the same layout.
+func lookupKBBI(word string) (*wordEntry, error) {
+	cacheKey := "kbbi:" + word
+	if entry, ok := dictionaryCache.Get(cacheKey); ok {
+		return entry, nil
+	}
+
+	base := os.Getenv("KBBI_URL")
+	if base == "" {
+		base = "https://kbbi.kemdikbud.go.id/entri/"
+	}
+	pageURL := base + url.PathEscape(word)
+
+	body, err := fetchPage("KBBI", pageURL)
+	if err != nil {
+		return nil, err
+	}
+	page := string(body)
+	if strings.Contains(page, "Entri tidak ditemukan") {
+		return nil, notFound("KBBI has no entry for \"%s\"", word)
+	}
+
+	entry := &wordEntry{Word: word, URL: pageURL, Source: "Kamus Besar Bahasa Indonesia (KBBI Daring)"}
+	if heading := kbbiHeading.FindStringSubmatch(page); heading != nil {
+		entry.Phonetic = strings.TrimRight(stripHTML(heading[1]), " »0123456789") // syllables, e.g. "ma.kan"
+	}
+
+	for _, list := range kbbiList.FindAllStringSubmatch(page, -1) {
+		for _, item := range kbbiItem.FindAllStringSubmatch(list[1], -1) {
+			var sense wordSense
+			text := item[1]
+			if label := kbbiLabel.FindStringSubmatch(text); label != nil {
+				var names []string
+				for _, name := range kbbiLabelName.FindAllStringSubmatch(label[1], -1) {
+					names = append(names, strings.ToLower(name[1]))
+				}
+				sense.PartOfSpeech = strings.Join(names, ", ")
+				text = strings.Replace(text, label[0], "", 1)
+			}
+			if example := kbbiExample.FindStringSubmatch(text); example != nil {
+				sense.Example = stripHTML(example[1])
+				text = strings.Replace(text, example[0], "", 1)
+			}
+			sense.Definition = strings.TrimSuffix(stripHTML(text), ":")
+			if sense.Definition != "" {
+				entry.Senses = append(entry.Senses, sense)
+			}
+		}
+	}
+	if len(entry.Senses) == 0 {
+		return nil, notFound("KBBI has no entry for \"%s\"", word)
+	}
+
+	dictionaryCache.Set(cacheKey, entry)
+	return entry, nil
+}
+
+// lookupEnglish looks a word up in the Free Dictionary API
+func lookupEnglish(word string) (*wordEntry, error) {
+	cacheKey := "en:" + word
+	if entry, ok := dictionaryCache.Get(cacheKey); ok {
+		return entry, nil
+	}
+
+	body, err := fetchPage("The dictionary", "https://api.dictionaryapi.dev/api/v2/entries/en/"+url.PathEscape(word))
+	if errors.Is(err, ErrNotFound) {
+		return nil, notFound("no definitions found for \"%s\"", word)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Word     string `json:"word"`
+		Phonetic string `json:"phonetic"`
+		Meanings []struct {
+			PartOfSpeech string `json:"partOfSpeech"`
+			Definitions  []struct {
+				Definition string `json:"definition"`
+				Example    string `json:"example"`
+			} `json:"definitions"`
+		} `json:"meanings"`
+		SourceURLs []string `json:"sourceUrls"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, upstreamDown("The dictionary", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if len(results) == 0 {
+		return nil, notFound("no definitions found for \"%s\"", word)
+	}
+
+	entry := &wordEntry{Word: results[0].Word, Phonetic: results[0].Phonetic, Source: "Free Dictionary API · Wiktionary"}
+	if len(results[0].SourceURLs) > 0 {
+		entry.URL = results[0].SourceURLs[0]
+	}
+	for _, result := range results {
+		for _, meaning := range result.Meanings {
+			for _, definition := range meaning.Definitions {
+				entry.Senses = append(entry.Senses, wordSense{
+					PartOfSpeech: meaning.PartOfSpeech,
+					Definition:   definition.Definition,
+					Example:      definition.Example,
+				})
+			}
+		}
+	}
+
+	dictionaryCache.Set(cacheKey, entry)
+	return entry, nil
+}
+
+// definitionEmbed builds the embed for a dictionary entry, keeping it within Discord's limits
+func definitionEmbed(entry *wordEntry) *discordgo.MessageEmbed {
+	title := "📖 " + entry.Word
+	if entry.Phonetic != "" && entry.Phonetic != entry.Word {
+		title += " · " + entry.Phonetic
+	}
+
+	var lines []string
+	for n, sense := range entry.Senses {
+		if n == maxWordSenses {
+			lines = append(lines, fmt.Sprintf("*…and %d more*", len(entry.Senses)-n))
+			break
+		}
+		line := fmt.Sprintf("**%d.** ", n+1)
+		if sense.PartOfSpeech != "" {
+			line += "*" + sense.PartOfSpeech + "* "
+		}
+		line += truncate(sense.Definition, 300)
+		if sense.Example != "" {
+			line += "\n> " + truncate(sense.Example, 200)
+		}
+		lines = append(lines, line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       truncate(title, 256),
+		URL:         entry.URL,
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: entry.Source,
+		},
+	}
+}
+
+// handleDictionaryCommand handles /kbbi and /define
+func handleDictionaryCommand(s *discordgo.Session, i *discordgo.InteractionCreate, lookup func(string) (*wordEntry, error)) {
+	word := strings.ToLower(strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue()))
+
+	// Defer the response since the dictionary might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	entry, err := lookup(word)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{definitionEmbed(entry)},
+	})
+}
+
+// urbanLink matches the [bracketed] cross-references in Urban Dictionary text
+var urbanLink = regexp.MustCompile(`\[([^\]]+)\]`)
+
+// lookupSlang looks a term up on Urban Dictionary, best-voted definitions first
+func lookupSlang(term string) (*wordEntry, error) {
+	cacheKey := "slang:" + term
+	if entry, ok := dictionaryCache.Get(cacheKey); ok {
+		return entry, nil
+	}
+
+	body, err := fetchPage("Urban Dictionary", "https://api.urbandictionary.com/v0/define?term="+url.QueryEscape(term))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		List []struct {
+			Word       string `json:"word"`
+			Definition string `json:"definition"`
+			Example    string `json:"example"`
+			Permalink  string `json:"permalink"`
+			ThumbsUp   int    `json:"thumbs_up"`
+		} `json:"list"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, upstreamDown("Urban Dictionary", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if len(result.List) == 0 {
+		return nil, notFound("no slang definitions found for \"%s\"", term)
+	}
+
+	sort.SliceStable(result.List, func(a, b int) bool {
+		return result.List[a].ThumbsUp > result.List[b].ThumbsUp
+	})
+	entry := &wordEntry{Word: result.List[0].Word, URL: result.List[0].Permalink, Source: "Urban Dictionary · user-submitted, may be offensive"}
+	for _, item := range result.List {
+		entry.Senses = append(entry.Senses, wordSense{
+			Definition: strings.Join(strings.Fields(urbanLink.ReplaceAllString(item.Definition, "$1")), " "),
+			Example:    strings.Join(strings.Fields(urbanLink.ReplaceAllString(item.Example, "$1")), " "),
+		})
+	}
+
+	dictionaryCache.Set(cacheKey, entry)
+	return entry, nil
+}
+
+// slangAllowed reports whether /slang may be used in a channel: age-restricted channels always,
+// other channels only when the server turned it on with /settings slang
+func slangAllowed(s *discordgo.Session, guildID, channelID string) bool {
+	if getGuildSettings(guildID).Slang {
+		return true
+	}
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+	}
+	if err != nil {
+		log.Printf("Error fetching channel %s: %v", channelID, err)
+		return false
+	}
+	return channel.NSFW
+}
+
+// handleSlangCommand handles /slang, which is gated since the definitions are unmoderated
+func handleSlangCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Whether slang is allowed is a server setting, so there's nothing to check in DMs
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ `/slang` only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	if !slangAllowed(s, i.GuildID, i.ChannelID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🔞 Slang definitions can be explicit, so `/slang` only works in age-restricted channels here. Admins can allow it everywhere with `/settings slang`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	handleDictionaryCommand(s, i, lookupSlang)
+}
+
+// errPrivateAddress is returned when /check is pointed at the bot's own network
+var errPrivateAddress = errors.New("address is not public")
+
+// publicOnlyClient makes requests for /check and the link shorteners, refusing to connect
+// to loopback, private or link-local addresses so servers can't use the bot to probe the
+// network it runs in
+var publicOnlyClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+					return errPrivateAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxCheckRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	},
+}
+
+// siteCheck is the result of /check
+type siteCheck struct {
+	URL        string
+	FinalURL   string
+	Status     int
+	Elapsed    time.Duration
+	CertExpiry time.Time // zero for plain HTTP
+	CertIssuer string
+}
+
+// checkSite requests a URL and measures the response, following a few redirects
+func checkSite(target string) (*siteCheck, error) {
+	started := time.Now()
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, invalidInput("`%s` is not a valid URL", target)
+	}
+	req.Header.Set("User-Agent", "bot-cerdas uptime check")
+
+	resp, err := publicOnlyClient.Do(req)
+	if errors.Is(err, errPrivateAddress) {
+		return nil, invalidInput("only public addresses can be checked")
+	}
+	if err != nil {
+		return nil, &ServiceError{Kind: ErrUpstreamDown, Detail: req.URL.Host, Err: err}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	result := &siteCheck{
+		URL:      target,
+		FinalURL: resp.Request.URL.String(),
+		Status:   resp.StatusCode,
+		Elapsed:  time.Since(started),
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		result.CertExpiry = cert.NotAfter
+		result.CertIssuer = cert.Issuer.CommonName
+	}
+	return result, nil
+}
+
+// siteCheckEmbed shows the status, response time and certificate of a checked site
+func siteCheckEmbed(result *siteCheck) *discordgo.MessageEmbed {
+	color := 0x2ecc71
+	icon := "🟢"
+	switch {
+	case result.Status >= 500:
+		color, icon = 0xe74c3c, "🔴"
+	case result.Status >= 400:
+		color, icon = 0xe67e22, "🟠"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: icon + " " + truncate(result.URL, 240),
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: fmt.Sprintf("%d %s", result.Status, http.StatusText(result.Status)), Inline: true},
+			{Name: "Response time", Value: fmt.Sprintf("%d ms", result.Elapsed.Milliseconds()), Inline: true},
+		},
+	}
+	if result.FinalURL != result.URL {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Redirected to", Value: truncate(result.FinalURL, 1024)})
+	}
+
+	if result.CertExpiry.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "SSL", Value: "⚠️ Not using HTTPS"})
+	} else {
+		days := int(time.Until(result.CertExpiry).Hours() / 24)
+		value := fmt.Sprintf("Expires <t:%d:D> (%d days)", result.CertExpiry.Unix(), days)
+		if days < certWarningDays {
+			value = "⚠️ " + value
+		}
+		if result.CertIssuer != "" {
+			value += "\nIssued by " + result.CertIssuer
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "SSL certificate", Value: value})
+	}
+	return embed
+}
+
+// handleCheckCommand handles /check
+func handleCheckCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	target := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	if parsed, err := url.Parse(target); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please give a website like `example.com` or `https://example.com/health`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since slow sites are exactly what this checks
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	result, err := checkSite(target)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{siteCheckEmbed(result)},
+	})
+}
+
+// lookupDNS resolves the common record types of a domain, or just the requested one
+func lookupDNS(ctx context.Context, domain, recordType string) []*discordgo.MessageEmbedField {
+	resolver := net.DefaultResolver
+	var fields []*discordgo.MessageEmbedField
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  name,
+				Value: truncate("```\n"+strings.Join(values, "\n"), 1020) + "\n```",
+			})
+		}
+	}
+	wants := func(name string) bool { return recordType == "" || recordType == name }
+
+	if wants("A") || wants("AAAA") {
+		ips, _ := resolver.LookupIPAddr(ctx, domain)
+		var v4, v6 []string
+		for _, ip := range ips {
+			if ip.IP.To4() != nil {
+				v4 = append(v4, ip.IP.String())
+			} else {
+				v6 = append(v6, ip.IP.String())
+			}
+		}
+		if wants("A") {
+			add("A", v4)
+		}
+		if wants("AAAA") {
+			add("AAAA", v6)
+		}
+	}
+	if wants("CNAME") {
+		if cname, err := resolver.LookupCNAME(ctx, domain); err == nil && strings.TrimSuffix(cname, ".") != strings.TrimSuffix(domain, ".") {
+			add("CNAME", []string{cname})
+		}
+	}
+	if wants("MX") {
+		records, _ := resolver.LookupMX(ctx, domain)
+		var values []string
+		for _, mx := range records {
+			values = append(values, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+		add("MX", values)
+	}
+	if wants("NS") {
+		records, _ := resolver.LookupNS(ctx, domain)
+		var values []string
+		for _, ns := range records {
+			values = append(values, ns.Host)
+		}
+		add("NS", values)
+	}
+	if wants("TXT") {
+		records, _ := resolver.LookupTXT(ctx, domain)
+		add("TXT", records)
+	}
+	return fields
+}
+
+// handleDNSCommand handles /dns
+func handleDNSCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var domain, recordType string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "domain":
+			domain = strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		case "type":
+			recordType = opt.StringValue()
+		}
+	}
+	if parsed, err := url.Parse(domain); err == nil && parsed.Host != "" {
+		domain = parsed.Hostname() // accept a pasted URL
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	fields := lookupDNS(ctx, domain, recordType)
+	if len(fields) == 0 {
+		kind := "DNS"
+		if recordType != "" {
+			kind = recordType
+		}
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("🔍 No %s records found for `%s`.", kind, domain),
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:  "🌐 DNS records for " + truncate(domain, 200),
+			Color:  embedColor,
+			Fields: fields,
+		}},
+	})
+}
+
+// githubRepoName matches an owner/name repository reference
+var githubRepoName = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// githubRequest calls the GitHub REST API, authenticated with the server's GitHub token or
+// GITHUB_TOKEN when one is set (unauthenticated calls are limited to 60 an hour)
+func githubRequest(guildID, path string, target interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	token, ok := guildAPIKey(guildID, "github")
+	if !ok {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return upstreamDown("GitHub", fmt.Errorf("failed to call API: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return &ServiceError{Kind: ErrRateLimited, Detail: "GitHub", Err: fmt.Errorf("rate limit reached for %s", path)}
+	}
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		// Searches in a repository that doesn't exist fail validation
+		return &ServiceError{Kind: ErrNotFound, Detail: "GitHub could not find what was requested", Err: fmt.Errorf("HTTP error: %d", resp.StatusCode)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return httpStatusError("GitHub", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return upstreamDown("GitHub", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	return nil
+}
+
+// githubRepoEmbed shows a repository's stars, language and latest release
+func githubRepoEmbed(guildID, repo string) (*discordgo.MessageEmbed, error) {
+	var info struct {
+		FullName    string    `json:"full_name"`
+		Description string    `json:"description"`
+		HTMLURL     string    `json:"html_url"`
+		Language    string    `json:"language"`
+		Stars       int       `json:"stargazers_count"`
+		Forks       int       `json:"forks_count"`
+		OpenIssues  int       `json:"open_issues_count"`
+		Archived    bool      `json:"archived"`
+		PushedAt    time.Time `json:"pushed_at"`
+		Owner       struct {
+			AvatarURL string `json:"avatar_url"`
+		} `json:"owner"`
+		License *struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	if err := githubRequest(guildID, "/repos/"+repo, &info); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, notFound("GitHub has no public repository %s", repo)
+		}
+		return nil, err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📦 " + info.FullName,
+		URL:         info.HTMLURL,
+		Description: truncate(info.Description, 500),
+		Color:       0x24292e,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: info.Owner.AvatarURL},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "⭐ Stars", Value: strconv.Itoa(info.Stars), Inline: true},
+			{Name: "🍴 Forks", Value: strconv.Itoa(info.Forks), Inline: true},
+			{Name: "🐛 Open issues", Value: strconv.Itoa(info.OpenIssues), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Last push",
+		},
+		Timestamp: info.PushedAt.Format(time.RFC3339),
+	}
+	if info.Language != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Language", Value: info.Language, Inline: true})
+	}
+	if info.License != nil && info.License.SPDXID != "" && info.License.SPDXID != "NOASSERTION" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "License", Value: info.License.SPDXID, Inline: true})
+	}
+	if info.Archived {
+		embed.Description = "🗄️ *Archived*\n" + embed.Description
+	}
+
+	var release struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	// Repositories without releases answer 404 here
+	if err := githubRequest(guildID, "/repos/"+repo+"/releases/latest", &release); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "🏷️ Latest release",
+			Value:  fmt.Sprintf("[%s](%s) <t:%d:R>", release.TagName, release.HTMLURL, release.PublishedAt.Unix()),
+			Inline: true,
+		})
+	} else if !errors.Is(err, ErrNotFound) {
+		log.Printf("Error fetching latest release of %s: %v", repo, err)
+	}
+	return embed, nil
+}
+
+// githubIssuesEmbed lists the issues and pull requests of a repository matching a search
+func githubIssuesEmbed(guildID, repo, query string) (*discordgo.MessageEmbed, error) {
+	var result struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			Number      int       `json:"number"`
+			Title       string    `json:"title"`
+			HTMLURL     string    `json:"html_url"`
+			State       string    `json:"state"`
+			Comments    int       `json:"comments"`
+			UpdatedAt   time.Time `json:"updated_at"`
+			PullRequest *struct{} `json:"pull_request"`
+		} `json:"items"`
+	}
+	q := url.QueryEscape("repo:" + repo + " " + query)
+	if err := githubRequest(guildID, fmt.Sprintf("/search/issues?q=%s&per_page=%d", q, maxGitHubIssues), &result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, notFound("GitHub has no public repository %s", repo)
+		}
+		return nil, err
+	}
+
+	var lines []string
+	for _, item := range result.Items {
+		icon := "🟢"
+		if item.State == "closed" {
+			icon = "🟣"
+		}
+		kind := "Issue"
+		if item.PullRequest != nil {
+			kind = "PR"
+		}
+		lines = append(lines, fmt.Sprintf("%s [#%d %s](%s)\n%s · 💬 %d · updated <t:%d:R>", icon, item.Number, truncate(item.Title, 120), item.HTMLURL, kind, item.Comments, item.UpdatedAt.Unix()))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No issues or pull requests match.")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔎 %s: \"%s\"", repo, truncate(query, 100)),
+		URL:         fmt.Sprintf("https://github.com/%s/issues?q=%s", repo, url.QueryEscape(query)),
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       0x24292e,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d results", result.TotalCount),
+		},
+	}, nil
+}
+
+// handleGitHubCommand handles /github repo and /github issues
+func handleGitHubCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	var repo, query string
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "repo":
+			repo = strings.Trim(strings.TrimPrefix(strings.TrimSpace(opt.StringValue()), "https://github.com/"), "/")
+		case "query":
+			query = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if !githubRepoName.MatchString(repo) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please give the repository as `owner/name`, e.g. `bwmarrin/discordgo`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since GitHub might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	if subcommand.Name == "issues" {
+		embed, err = githubIssuesEmbed(i.GuildID, repo, query)
+	} else {
+		embed, err = githubRepoEmbed(i.GuildID, repo)
+	}
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// playgroundResponse is the Go Playground compile and run result
+type playgroundResponse struct {
+	Errors string `json:"Errors"` // compile errors
+	Events []struct {
+		Message string `json:"Message"`
+		Kind    string `json:"Kind"` // stdout or stderr
+	} `json:"Events"`
+	Status    int    `json:"Status"`
+	VetErrors string `json:"VetErrors"`
+}
+
+// runGoSnippet runs code on the Go Playground. Snippets without a package clause are wrapped in
+// a main function, so one-liners like fmt.Println(1 << 10) work.
+func runGoSnippet(code string) (*playgroundResponse, error) {
+	if !strings.Contains(code, "package ") {
+		imports := ""
+		if strings.Contains(code, "fmt.") {
+			imports = "import \"fmt\"\n\n"
+		}
+		code = "package main\n\n" + imports + "func main() {\n" + code + "\n}\n"
+	}
+
+	endpoint := os.Getenv("GO_PLAYGROUND_URL")
+	if endpoint == "" {
+		endpoint = "https://go.dev/_/compile"
+	}
+	form := url.Values{
+		"version": {"2"},
+		"body":    {code},
+		"withVet": {"true"},
+	}
+
+	client := &http.Client{
+		Timeout: 20 * time.Second,
+	}
+
+	resp, err := client.PostForm(endpoint, form)
+	if err != nil {
+		return nil, upstreamDown("The Go Playground", fmt.Errorf("failed to run snippet: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError("The Go Playground", resp.StatusCode)
+	}
+
+	var result playgroundResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, upstreamDown("The Go Playground", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	return &result, nil
+}
+
+// playgroundMessage formats a run result as a code block, keeping within the message limit
+func playgroundMessage(result *playgroundResponse) string {
+	if result.Errors != "" {
+		return "❌ **Build failed**\n```\n" + truncate(strings.ReplaceAll(result.Errors, "```", "'''"), maxRunOutput) + "\n```"
+	}
+
+	var output strings.Builder
+	for _, event := range result.Events {
+		output.WriteString(event.Message)
+	}
+	text := strings.TrimRight(output.String(), "\n")
+	if text == "" {
+		text = "(no output)"
+	}
+
+	header := "✅ **Program exited**"
+	if result.Status != 0 {
+		header = fmt.Sprintf("⚠️ **Program exited with status %d**", result.Status)
+	}
+	content := header + "\n```\n" + truncate(strings.ReplaceAll(text, "```", "'''"), maxRunOutput) + "\n```"
+	if result.VetErrors != "" {
+		content += "\n🔎 **go vet**\n```\n" + truncate(strings.ReplaceAll(result.VetErrors, "```", "'''"), 300) + "\n```"
+	}
+	return content
+}
+
+// allowRun reports whether a member may run another snippet, once per runCooldown, and records it
+func allowRun(userID string, now time.Time) (time.Duration, bool) {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	if wait := runCooldown - now.Sub(runLastUser[userID]); wait > 0 {
+		return wait, false
+	}
+	runLastUser[userID] = now
+	return 0, true
+}
+
+// handleRunCommand handles /run go, running the code option directly or asking for it in a modal
+func handleRunCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	if len(subcommand.Options) > 0 {
+		runSnippet(s, i, subcommand.Options[0].StringValue())
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "run_go",
+			Title:    "Run Go code",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "code",
+						Label:       "Code",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"Halo!\")\n}",
+						Required:    true,
+						MaxLength:   maxRunCodeSize,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error opening run modal: %v", err)
+	}
+}
+
+// handleRunModal runs the code submitted in the /run go modal
+func handleRunModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	for _, row := range i.ModalSubmitData().Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == "code" {
+				runSnippet(s, i, input.Value)
+				return
+			}
+		}
+	}
+}
+
+// runSnippet runs a snippet for /run go, subject to the size limit and member cooldown
+func runSnippet(s *discordgo.Session, i *discordgo.InteractionCreate, code string) {
+	code = strings.TrimSpace(code)
+	code = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(code, "```go"), "```"), "```")
+
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if strings.TrimSpace(code) == "" {
+		respond("❌ There's no code to run.")
+		return
+	}
+	if len(code) > maxRunCodeSize {
+		respond(fmt.Sprintf("❌ Snippets are limited to %d characters.", maxRunCodeSize))
+		return
+	}
+	if wait, ok := allowRun(interactionUserID(i), time.Now()); !ok {
+		respond(fmt.Sprintf("⏳ You can run another snippet in %d seconds.", int(wait.Seconds())+1))
+		return
+	}
+
+	// Defer the response since compiling and running takes a few seconds
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	result, err := runGoSnippet(code)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: playgroundMessage(result),
+	})
+}
+
+// searchResult is one web search hit
+type searchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+var (
+	ddgResult  = regexp.MustCompile(`(?s)<a([^>]*class="result__a"[^>]*)>(.*?)</a>`)
+	ddgHref    = regexp.MustCompile(`href="([^"]+)"`)
+	ddgSnippet = regexp.MustCompile(`(?s)class="result__snippet"[^>]*>(.*?)</a>`)
+)
+
+// safeSearchParams maps /settings search safe_search modes to DuckDuckGo's kp parameter
+var safeSearchParams = map[string]string{
+	"strict":   "1",
+	"moderate": "-1",
+	"off":      "-2",
+}
+
+// webSearch returns the top results for a query from DuckDuckGo's HTML endpoint, which needs no
+// API key and doesn't track who searched
+func webSearch(query, safeSearch string) ([]searchResult, error) {
+	if safeSearch == "" {
+		safeSearch = "moderate"
+	}
+	cacheKey := safeSearch + "\x00" + query
+	if results, ok := searchCache.Get(cacheKey); ok {
+		return results, nil
+	}
+
+	form := url.Values{"q": {query}, "kp": {safeSearchParams[safeSearch]}}
+	req, err := http.NewRequest(http.MethodPost, "https://html.duckduckgo.com/html/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; bot-cerdas)")
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, upstreamDown("DuckDuckGo", fmt.Errorf("failed to search: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError("DuckDuckGo", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil, upstreamDown("DuckDuckGo", fmt.Errorf("failed to read response body: %v", err))
+	}
+	page := string(body)
+
+	var results []searchResult
+	matches := ddgResult.FindAllStringSubmatchIndex(page, -1)
+	for n, match := range matches {
+		if len(results) == maxSearchHits {
+			break
+		}
+		href := ddgHref.FindStringSubmatch(page[match[2]:match[3]])
+		if href == nil {
+			continue
+		}
+		link := html.UnescapeString(href[1])
+		if parsed, err := url.Parse(link); err == nil {
+			if strings.HasSuffix(parsed.Path, "/y.js") {
+				continue // sponsored result
+			}
+			if target := parsed.Query().Get("uddg"); target != "" {
+				link = target // DuckDuckGo's redirect wrapper
+			}
+		}
+
+		// The snippet follows the title, before the next result
+		end := len(page)
+		if n+1 < len(matches) {
+			end = matches[n+1][0]
+		}
+		result := searchResult{Title: stripHTML(page[match[4]:match[5]]), URL: link}
+		if snippet := ddgSnippet.FindStringSubmatch(page[match[1]:end]); snippet != nil {
+			result.Snippet = stripHTML(snippet[1])
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, notFound("no results for \"%s\"", query)
+	}
+
+	searchCache.Set(cacheKey, results)
+	return results, nil
+}
+
+// searchSetting handles /settings search and returns the reply
+func searchSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	safeSearch := ""
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "safe_search":
+			safeSearch = opt.StringValue()
+		}
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.Search = enabled
+		if safeSearch != "" {
+			settings.SafeSearch = safeSearch
+		}
+	})
+	if !enabled {
+		return "✅ `/search` disabled for this server."
+	}
+	if safeSearch == "" {
+		safeSearch = getGuildSettings(guildID).SafeSearch
+	}
+	if safeSearch == "" {
+		safeSearch = "moderate"
+	}
+	return fmt.Sprintf("✅ `/search` enabled with **%s** safe search.", safeSearch)
+}
+
+// handleSearchCommand handles /search in servers that enabled it
+func handleSearchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	settings := getGuildSettings(i.GuildID)
+	if i.GuildID != "" && !settings.Search {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ `/search` is not enabled in this server. An admin can turn it on with `/settings search`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	query := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	// Defer the response since searching might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	safeSearch := settings.SafeSearch
+	if i.GuildID == "" {
+		safeSearch = "strict" // DMs have no admin to choose
+	}
+	results, err := webSearch(query, safeSearch)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🔎 " + truncate(query, 200),
+		URL:   "https://duckduckgo.com/?q=" + url.QueryEscape(query),
+		Color: 0xde5833,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Results from DuckDuckGo",
+		},
+	}
+	for _, result := range results {
+		value := result.URL
+		if result.Snippet != "" {
+			value = truncate(result.Snippet, 300) + "\n" + result.URL
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(result.Title, 256),
+			Value: truncate(value, 1024),
+		})
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// namedColors are the CSS color names /color understands, plus a few Indonesian ones
+var namedColors = map[string]int{
+	"black": 0x000000, "white": 0xffffff, "red": 0xff0000, "lime": 0x00ff00, "blue": 0x0000ff,
+	"yellow": 0xffff00, "cyan": 0x00ffff, "aqua": 0x00ffff, "magenta": 0xff00ff, "fuchsia": 0xff00ff,
+	"silver": 0xc0c0c0, "gray": 0x808080, "grey": 0x808080, "maroon": 0x800000, "olive": 0x808000,
+	"green": 0x008000, "purple": 0x800080, "teal": 0x008080, "navy": 0x000080, "orange": 0xffa500,
+	"pink": 0xffc0cb, "brown": 0xa52a2a, "gold": 0xffd700, "coral": 0xff7f50, "salmon": 0xfa8072,
+	"tomato": 0xff6347, "crimson": 0xdc143c, "indigo": 0x4b0082, "violet": 0xee82ee, "orchid": 0xda70d6,
+	"lavender": 0xe6e6fa, "beige": 0xf5f5dc, "khaki": 0xf0e68c, "ivory": 0xfffff0, "turquoise": 0x40e0d0,
+	"skyblue": 0x87ceeb, "steelblue": 0x4682b4, "royalblue": 0x4169e1, "slategray": 0x708090,
+	"chocolate": 0xd2691e, "tan": 0xd2b48c, "plum": 0xdda0dd, "mintcream": 0xf5fffa, "hotpink": 0xff69b4,
+	"forestgreen": 0x228b22, "seagreen": 0x2e8b57, "darkgreen": 0x006400, "darkred": 0x8b0000,
+	"darkblue": 0x00008b, "lightgray": 0xd3d3d3, "darkgray": 0xa9a9a9, "blurple": 0x5865f2,
+	"merah": 0xff0000, "hijau": 0x008000, "biru": 0x0000ff, "kuning": 0xffff00, "hitam": 0x000000,
+	"putih": 0xffffff, "ungu": 0x800080, "jingga": 0xffa500, "oranye": 0xffa500, "coklat": 0xa52a2a,
+	"cokelat": 0xa52a2a, "abu-abu": 0x808080, "merah muda": 0xffc0cb,
+}
+
+// rgbPattern matches "rgb(1, 2, 3)", "1,2,3" or "1 2 3"
+var rgbPattern = regexp.MustCompile(`^(?:rgb\s*\()?\s*(\d{1,3})\s*[,\s]\s*(\d{1,3})\s*[,\s]\s*(\d{1,3})\s*\)?$`)
+
+// parseColor reads a color as hex (#5865f2, 5865f2, #fff), RGB or a color name
+func parseColor(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[value]; ok {
+		return c, nil
+	}
+
+	if m := rgbPattern.FindStringSubmatch(value); m != nil {
+		var rgb [3]int
+		for k := range rgb {
+			n, _ := strconv.Atoi(m[k+1])
+			if n > 255 {
+				return 0, invalidInput("RGB values go from 0 to 255")
+			}
+			rgb[k] = n
+		}
+		return rgb[0]<<16 | rgb[1]<<8 | rgb[2], nil
+	}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(value, "#"), "0x")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		if c, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return int(c), nil
+		}
+	}
+	return 0, invalidInput("`%s` is not a color, try `#5865f2`, `rgb(88, 101, 242)` or `orange`", value)
+}
+
+// colorHSL converts an RGB color to hue in degrees and saturation and lightness in percent
+func colorHSL(c int) (h, s, l float64) {
+	r, g, b := float64(c>>16&0xff)/255, float64(c>>8&0xff)/255, float64(c&0xff)/255
+	high, low := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (high + low) / 2
+	if high == low {
+		return 0, 0, l * 100
+	}
+
+	d := high - low
+	if l > 0.5 {
+		s = d / (2 - high - low)
+	} else {
+		s = d / (high + low)
+	}
+	switch high {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s * 100, l * 100
+}
+
+// colorSwatch renders a solid PNG of a color
+func colorSwatch(c int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 128))
+	fill := color.RGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 255}
+	for y := 0; y < 128; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetRGBA(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handleColorCommand handles /color
+func handleColorCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	value := i.ApplicationCommandData().Options[0].StringValue()
+
+	c, err := parseColor(value)
+	var swatch []byte
+	if err == nil {
+		swatch, err = colorSwatch(c)
+	}
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	h, sat, l := colorHSL(c)
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("🎨 #%06X", c),
+		Color: c,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Hex", Value: fmt.Sprintf("`#%06X`", c), Inline: true},
+			{Name: "RGB", Value: fmt.Sprintf("`rgb(%d, %d, %d)`", c>>16&0xff, c>>8&0xff, c&0xff), Inline: true},
+			{Name: "HSL", Value: fmt.Sprintf("`hsl(%.0f, %.0f%%, %.0f%%)`", h, sat, l), Inline: true},
+			{Name: "Integer", Value: fmt.Sprintf("`%d`", c), Inline: true},
+		},
+		Image: &discordgo.MessageEmbedImage{URL: "attachment://color.png"},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "The integer is what Discord embeds and bots use for colors",
+		},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Files: []*discordgo.File{{
+				Name:        "color.png",
+				ContentType: "image/png",
+				Reader:      bytes.NewReader(swatch),
+			}},
+		},
+	})
+}
+
+// maxTextInput is the longest text /text accepts. Modes that make the text longer take less,
+// see textModeLimits.
+const maxTextInput = 1000
+
+// textModeLimits are the most bytes of text the /text modes whose output grows accept, so
+// the result still fits in a message
+var textModeLimits = map[string]int{
+	"zalgo":         200,  // a letter gets up to three two-byte marks
+	"base64_encode": 1400, // 4 bytes out for every 3 in
+	"url_encode":    630,  // an escaped byte becomes 3
+	"binary":        210,  // a byte becomes 8 digits and a space
+}
+
+// zalgoMarks are the combining marks /text zalgo stacks on letters
+var zalgoMarks = []rune{'\u0300', '\u0301', '\u0302', '\u0303', '\u0308', '\u030a', '\u0316', '\u0317', '\u031c', '\u0323', '\u0330', '\u0353'}
+
+// transformText applies a /text mode and says whether the result should be shown as code
+func transformText(mode, text string) (string, bool, error) {
+	if limit, ok := textModeLimits[mode]; ok && len(text) > limit {
+		return "", false, invalidInput("that mode takes up to %d characters of plain text, the result wouldn't fit in a message", limit)
+	}
+	switch mode {
+	case "upper":
+		return strings.ToUpper(text), false, nil
+	case "mock":
+		var b strings.Builder
+		upper := false
+		for _, r := range text {
+			if unicode.IsLetter(r) {
+				if upper {
+					r = unicode.ToUpper(r)
+				} else {
+					r = unicode.ToLower(r)
+				}
+				upper = !upper
+			}
+			b.WriteRune(r)
+		}
+		return b.String(), false, nil
+	case "zalgo":
+		var b strings.Builder
+		for _, r := range text {
+			b.WriteRune(r)
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				for n := mathrand.IntN(3) + 1; n > 0; n-- {
+					b.WriteRune(zalgoMarks[mathrand.IntN(len(zalgoMarks))])
+				}
+			}
+		}
+		return b.String(), false, nil
+	case "base64_encode":
+		return base64.StdEncoding.EncodeToString([]byte(text)), true, nil
+	case "base64_decode":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(text), "="))
+		}
+		if err != nil || !utf8.Valid(decoded) {
+			return "", false, invalidInput("that isn't base64 encoded text")
+		}
+		return string(decoded), true, nil
+	case "url_encode":
+		return url.QueryEscape(text), true, nil
+	case "binary":
+		bits := make([]string, 0, len(text))
+		for _, c := range []byte(text) {
+			bits = append(bits, fmt.Sprintf("%08b", c))
+		}
+		return strings.Join(bits, " "), true, nil
+	}
+	return "", false, invalidInput("unknown mode %q", mode)
+}
+
+// handleTextCommand handles /text
+func handleTextCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var mode, text string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "mode":
+			mode = opt.StringValue()
+		case "content":
+			text = opt.StringValue()
+		}
+	}
+
+	result, code, err := transformText(mode, text)
+	if err == nil && strings.TrimSpace(result) == "" {
+		err = invalidInput("there's no text left after converting")
+	}
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if code {
+		result = "```\n" + truncate(strings.ReplaceAll(result, "```", "'''"), 1900) + "\n```"
+	} else {
+		result = truncate(result, 2000)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         result,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// maxUUIDs is how many UUIDs one /uuid can generate
+const maxUUIDs = 10
+
+// hashText returns the hex digest of text with md5, sha1 or sha256
+func hashText(algo, text string) (string, error) {
+	switch algo {
+	case "md5":
+		sum := md5.Sum([]byte(text))
+		return hex.EncodeToString(sum[:]), nil
+	case "sha1":
+		sum := sha1.Sum([]byte(text))
+		return hex.EncodeToString(sum[:]), nil
+	case "sha256":
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return "", invalidInput("unknown hash %q, use md5, sha1 or sha256", algo)
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// handleDevCommand handles /hash and /uuid
+func handleDevCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var content string
+	var err error
+
+	switch data.Name {
+	case "hash":
+		var algo, text string
+		for _, opt := range data.Options {
+			switch opt.Name {
+			case "algo":
+				algo = opt.StringValue()
+			case "text":
+				text = opt.StringValue()
+			}
+		}
+		var digest string
+		if digest, err = hashText(algo, text); err == nil {
+			content = fmt.Sprintf("🔐 **%s**\n```\n%s\n```", strings.ToUpper(algo), digest)
+		}
+	case "uuid":
+		count := 1
+		if len(data.Options) > 0 {
+			count = int(data.Options[0].IntValue())
+		}
+		uuids := make([]string, 0, count)
+		for len(uuids) < count && err == nil {
+			var id string
+			if id, err = newUUID(); err == nil {
+				uuids = append(uuids, id)
+			}
+		}
+		content = "🆔\n```\n" + strings.Join(uuids, "\n") + "\n```"
+	}
+
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// dicePattern matches NdM dice with an optional modifier, e.g. 2d6+3
+var dicePattern = regexp.MustCompile(`^(\d*)d(\d+)([+-]\d+)?$`)
+
+// eightBallAnswers are the classic magic 8-ball answers
+var eightBallAnswers = []string{
+	"It is certain.", "It is decidedly so.", "Without a doubt.", "Yes, definitely.", "You may rely on it.",
+	"As I see it, yes.", "Most likely.", "Outlook good.", "Yes.", "Signs point to yes.",
+	"Reply hazy, try again.", "Ask again later.", "Better not tell you now.", "Cannot predict now.", "Concentrate and ask again.",
+	"Don't count on it.", "My reply is no.", "My sources say no.", "Outlook not so good.", "Very doubtful.",
+}
+
+// rollDice rolls dice written as NdM+K and describes the result
+func rollDice(notation string) (string, error) {
+	notation = strings.ToLower(strings.ReplaceAll(notation, " ", ""))
+	m := dicePattern.FindStringSubmatch(notation)
+	if m == nil {
+		return "", invalidInput("use dice like `d20`, `2d6` or `3d8+2`")
+	}
+
+	count := 1
+	if m[1] != "" {
+		count, _ = strconv.Atoi(m[1])
+	}
+	sides, _ := strconv.Atoi(m[2])
+	modifier, _ := strconv.Atoi(m[3])
+	if count < 1 || count > maxDice || sides < 2 || sides > maxDiceSides {
+		return "", invalidInput("roll 1 to %d dice with 2 to %d sides", maxDice, maxDiceSides)
+	}
+
+	rolls := make([]string, count)
+	total := modifier
+	for k := range rolls {
+		roll := mathrand.IntN(sides) + 1
+		total += roll
+		rolls[k] = strconv.Itoa(roll)
+	}
+
+	result := fmt.Sprintf("🎲 **%s** → **%d**", notation, total)
+	if count > 1 || modifier != 0 {
+		detail := strings.Join(rolls, " + ")
+		if modifier != 0 {
+			detail += fmt.Sprintf(" %+d", modifier)
+		}
+		result += " (" + truncate(detail, 1800) + ")"
+	}
+	return result, nil
+}
+
+// handleFunCommand handles /roll, /flip, /choose and /8ball
+func handleFunCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var content string
+	var err error
+
+	switch data.Name {
+	case "roll":
+		dice := "d6"
+		if len(data.Options) > 0 {
+			dice = data.Options[0].StringValue()
+		}
+		content, err = rollDice(dice)
+	case "flip":
+		content = "🪙 **Heads!**"
+		if mathrand.IntN(2) == 1 {
+			content = "🪙 **Tails!**"
+		}
+	case "choose":
+		var choices []string
+		for _, choice := range strings.FieldsFunc(data.Options[0].StringValue(), func(r rune) bool { return r == ',' || r == '|' }) {
+			if choice = strings.TrimSpace(choice); choice != "" {
+				choices = append(choices, choice)
+			}
+		}
+		if len(choices) < 2 {
+			err = invalidInput("give at least two options separated by commas, e.g. `nasi goreng, mie ayam, bakso`")
+			break
+		}
+		content = "🤔 I choose **" + truncate(choices[mathrand.IntN(len(choices))], 500) + "**"
+	case "8ball":
+		question := truncate(strings.TrimSpace(data.Options[0].StringValue()), 500)
+		content = fmt.Sprintf("❓ %s\n🎱 %s", question, eightBallAnswers[mathrand.IntN(len(eightBallAnswers))])
+	}
+
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if channelID := botChannelFor(i); channelID != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: postInBotChannel(s, i, channelID, &discordgo.MessageSend{Content: content}),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// triviaCategories are the Open Trivia DB categories offered by /trivia
+var triviaCategories = map[string]int{
+	"general":   9,
+	"film":      11,
+	"music":     12,
+	"games":     15,
+	"science":   17,
+	"computers": 18,
+	"math":      19,
+	"sports":    21,
+	"geography": 22,
+	"history":   23,
+	"anime":     31,
+}
+
+// triviaQuestion is one multiple-choice question
+type triviaQuestion struct {
+	Category string
+	Question string
+	Choices  []string
+	Answer   int // index into Choices
+}
+
+// triviaGame is a quiz running in a channel, in memory only
+type triviaGame struct {
+	ID        string
+	GuildID   string
+	ChannelID string
+	Questions []triviaQuestion
+	Round     int
+	Answers   map[string]int // map[userID]choice for the current round
+	Points    map[string]int // map[userID]correct answers this game
+}
+
+var (
+	triviaMu    sync.Mutex
+	triviaGames = make(map[string]*triviaGame) // map[channelID]running game
+)
+
+// fetchTrivia gets multiple-choice questions from the Open Trivia DB
+func fetchTrivia(category string, amount int) ([]triviaQuestion, error) {
+	endpoint := fmt.Sprintf("https://opentdb.com/api.php?amount=%d&type=multiple&encode=url3986", amount)
+	if id, ok := triviaCategories[category]; ok {
+		endpoint += fmt.Sprintf("&category=%d", id)
+	}
+
+	body, err := fetchPage("Open Trivia DB", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		ResponseCode int `json:"response_code"`
+		Results      []struct {
+			Category         string   `json:"category"`
+			Question         string   `json:"question"`
+			CorrectAnswer    string   `json:"correct_answer"`
+			IncorrectAnswers []string `json:"incorrect_answers"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, upstreamDown("Open Trivia DB", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	switch result.ResponseCode {
+	case 0:
+	case 5:
+		return nil, &ServiceError{Kind: ErrRateLimited, Detail: "Open Trivia DB", Err: fmt.Errorf("response code 5")}
+	default:
+		return nil, upstreamDown("Open Trivia DB", fmt.Errorf("response code %d", result.ResponseCode))
+	}
+
+	unescape := func(text string) string {
+		if decoded, err := url.QueryUnescape(text); err == nil {
+			return decoded
+		}
+		return text
+	}
+	var questions []triviaQuestion
+	for _, item := range result.Results {
+		question := triviaQuestion{Category: unescape(item.Category), Question: unescape(item.Question)}
+		for _, wrong := range item.IncorrectAnswers {
+			question.Choices = append(question.Choices, unescape(wrong))
+		}
+		question.Answer = mathrand.IntN(len(question.Choices) + 1)
+		question.Choices = slices.Insert(question.Choices, question.Answer, unescape(item.CorrectAnswer))
+		questions = append(questions, question)
+	}
+	if len(questions) == 0 {
+		return nil, notFound("no trivia questions are available for that category")
+	}
+	return questions, nil
+}
+
+// startTrivia fetches questions and runs a game in a channel unless one is already running there
+func startTrivia(s *discordgo.Session, guildID, channelID, category string, rounds int) error {
+	triviaMu.Lock()
+	if _, running := triviaGames[channelID]; running {
+		triviaMu.Unlock()
+		return invalidInput("a trivia game is already running in this channel")
+	}
+	game := &triviaGame{ID: strconv.FormatInt(time.Now().UnixNano(), 36), GuildID: guildID, ChannelID: channelID, Points: make(map[string]int)}
+	triviaGames[channelID] = game // reserve the channel while questions are fetched
+	triviaMu.Unlock()
+
+	questions, err := fetchTrivia(category, rounds)
+	if err != nil {
+		triviaMu.Lock()
+		delete(triviaGames, channelID)
+		triviaMu.Unlock()
+		return err
+	}
+	game.Questions = questions
+
+	go runRecovered("trivia game", func() {
+		defer func() {
+			triviaMu.Lock()
+			delete(triviaGames, channelID)
+			triviaMu.Unlock()
+		}()
+		runTriviaGame(s, game)
+	})
+	return nil
+}
+
+// runTriviaGame posts each round, waits for answers, reveals the result and records the scores
+func runTriviaGame(s *discordgo.Session, game *triviaGame) {
+	for round, question := range game.Questions {
+		triviaMu.Lock()
+		game.Round = round
+		game.Answers = make(map[string]int)
+		triviaMu.Unlock()
+
+		var buttons []discordgo.MessageComponent
+		for idx, choice := range question.Choices {
+			buttons = append(buttons, discordgo.Button{
+				Label:    truncate(choice, 80),
+				Style:    discordgo.SecondaryButton,
+				CustomID: fmt.Sprintf("trivia:%s:%d:%d", game.ID, round, idx),
+			})
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("🧠 Question %d of %d", round+1, len(game.Questions)),
+			Description: "**" + question.Question + "**",
+			Color:       0x9b59b6,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("%s · %d seconds to answer", question.Category, int(triviaRoundTime.Seconds())),
+			},
+		}
+		msg, err := s.ChannelMessageSendComplex(game.ChannelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+		})
+		if err != nil {
+			log.Printf("Error posting trivia question in channel %s: %v", game.ChannelID, err)
+			return
+		}
+
+		time.Sleep(triviaRoundTime)
+
+		triviaMu.Lock()
+		var correct []string
+		for userID, choice := range game.Answers {
+			if choice == question.Answer {
+				correct = append(correct, "<@"+userID+">")
+				game.Points[userID]++
+			}
+		}
+		answered := len(game.Answers)
+		game.Answers = nil // closes the round
+		triviaMu.Unlock()
+
+		// Show the answer on the buttons
+		for idx := range buttons {
+			button := buttons[idx].(discordgo.Button)
+			button.Disabled = true
+			if idx == question.Answer {
+				button.Style = discordgo.SuccessButton
+			}
+			buttons[idx] = button
+		}
+		sort.Strings(correct)
+		result := fmt.Sprintf("✅ **%s**\n", question.Choices[question.Answer])
+		switch {
+		case len(correct) > 0:
+			result += fmt.Sprintf("%d of %d got it: %s", len(correct), answered, truncate(strings.Join(correct, " "), 900))
+		case answered > 0:
+			result += fmt.Sprintf("Nobody of the %d who answered got it.", answered)
+		default:
+			result += "Nobody answered."
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Answer", Value: result}}
+		embed.Footer.Text = question.Category
+		components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+		if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         msg.ID,
+			Channel:    game.ChannelID,
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		}); err != nil {
+			log.Printf("Error revealing trivia answer in channel %s: %v", game.ChannelID, err)
+		}
+
+		time.Sleep(triviaRevealPause)
+	}
+
+	triviaMu.Lock()
+	points := make(map[string]int, len(game.Points))
+	for userID, n := range game.Points {
+		points[userID] = n
+	}
+	triviaMu.Unlock()
+	recordTriviaScores(game.GuildID, points)
+
+	if _, err := s.ChannelMessageSendComplex(game.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{triviaStandingsEmbed("🏁 Trivia Results", points, len(game.Questions))},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}); err != nil {
+		log.Printf("Error posting trivia results in channel %s: %v", game.ChannelID, err)
+	}
+}
+
+// triviaStandingsEmbed ranks members by points, for game results and the leaderboard
+func triviaStandingsEmbed(title string, points map[string]int, rounds int) *discordgo.MessageEmbed {
+	userIDs := make([]string, 0, len(points))
+	for userID, n := range points {
+		if n > 0 {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Slice(userIDs, func(a, b int) bool {
+		if points[userIDs[a]] != points[userIDs[b]] {
+			return points[userIDs[a]] > points[userIDs[b]]
+		}
+		return userIDs[a] < userIDs[b]
+	})
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	var lines []string
+	for rank, userID := range userIDs {
+		if rank == triviaLeaderboardSize {
+			break
+		}
+		prefix := fmt.Sprintf("`%d.`", rank+1)
+		if rank < len(medals) {
+			prefix = medals[rank]
+		}
+		line := fmt.Sprintf("%s <@%s> · **%d**", prefix, userID, points[userID])
+		if rounds > 0 {
+			line += fmt.Sprintf("/%d", rounds)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No correct answers yet.")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: strings.Join(lines, "\n"),
+		Color:       0x9b59b6,
+	}
+}
+
+// handleTriviaAnswer records a member's answer for the current round
+func handleTriviaAnswer(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	parts := strings.Split(arg, ":")
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if len(parts) != 3 {
+		return
+	}
+	round, _ := strconv.Atoi(parts[1])
+	choice, _ := strconv.Atoi(parts[2])
+
+	triviaMu.Lock()
+	game := triviaGames[i.ChannelID]
+	if game == nil || game.ID != parts[0] || game.Round != round || game.Answers == nil {
+		triviaMu.Unlock()
+		respond("⌛ This question is closed.")
+		return
+	}
+	userID := interactionUserID(i)
+	if _, answered := game.Answers[userID]; answered {
+		triviaMu.Unlock()
+		respond("🔒 You already answered this question.")
+		return
+	}
+	game.Answers[userID] = choice
+	triviaMu.Unlock()
+
+	respond("🔒 Answer locked in! The answer is revealed when time runs out.")
+}
+
+// loadTriviaScores loads the trivia leaderboards from file
+func loadTriviaScores() {
+	triviaScores = make(ServerTriviaScores)
+
+	if _, err := os.Stat(triviaFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(triviaFile)
+	if err != nil {
+		log.Printf("Error reading trivia scores file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &triviaScores); err != nil {
+		log.Printf("Error parsing trivia scores file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded trivia scores for %d servers", len(triviaScores))
+}
+
+// saveTriviaScores saves the trivia leaderboards to file
+func saveTriviaScores() {
+	data, err := json.MarshalIndent(triviaScores, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling trivia scores: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(triviaFile, data, 0644); err != nil {
+		log.Printf("Error saving trivia scores: %v", err)
+		return
+	}
+}
+
+// recordTriviaScores adds a game's points to the server leaderboard
+func recordTriviaScores(guildID string, points map[string]int) {
+	if len(points) == 0 {
+		return
+	}
+
+	triviaScoresMu.Lock()
+	defer triviaScoresMu.Unlock()
+
+	if triviaScores[guildID] == nil {
+		triviaScores[guildID] = make(map[string]int)
+	}
+	for userID, n := range points {
+		triviaScores[guildID][userID] += n
+	}
+	saveTriviaScores()
+}
+
+// startScheduledTrivia starts the weekly quiz in servers whose slot has come, once per week
+func startScheduledTrivia(s *discordgo.Session, now time.Time) {
+	settingsMu.Lock()
+	var due []string
+	for guildID, settings := range serverSettings {
+		if settings.TriviaChannel != "" {
+			due = append(due, guildID)
+		}
+	}
+	settingsMu.Unlock()
+
+	for _, guildID := range due {
+		settings := getGuildSettings(guildID)
+		local := now.In(settings.location())
+		today := local.Format("2006-01-02")
+		if int(local.Weekday()) != settings.TriviaWeekday || local.Hour() != settings.TriviaHour || settings.TriviaLastRun == today || settings.inQuietHours(now) {
+			continue
+		}
+		triviaMu.Lock()
+		_, running := triviaGames[settings.TriviaChannel]
+		triviaMu.Unlock()
+		if running {
+			continue
+		}
+
+		// Fetching the questions can be slow, so the game starts beside the scheduler. The
+		// week only counts once it started, a failed fetch is tried again on the next tick.
+		go runRecovered("scheduled trivia", func() {
+			if err := startTrivia(s, guildID, settings.TriviaChannel, settings.TriviaCategory, defaultTriviaRounds); err != nil {
+				log.Printf("Error starting weekly trivia in channel %s: %v", settings.TriviaChannel, err)
+				return
+			}
+			updateGuildSettings(guildID, func(settings *GuildSettings) {
+				settings.TriviaLastRun = today
+			})
+		})
+	}
+}
+
+// triviaCategoryOption is the category choice shared by /trivia start and /trivia schedule
+func triviaCategoryOption() *discordgo.ApplicationCommandOption {
+	names := make([]string, 0, len(triviaCategories))
+	for name := range triviaCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	option := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "category",
+		Description: "Question category (default: any)",
+		Required:    false,
+	}
+	for _, name := range names {
+		option.Choices = append(option.Choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+	return option
+}
+
+// handleTriviaCommand handles /trivia start|leaderboard|schedule
+func handleTriviaCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Trivia only works in servers, not in DMs!")
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "start":
+		category := ""
+		rounds := defaultTriviaRounds
+		for _, opt := range subcommand.Options {
+			switch opt.Name {
+			case "category":
+				category = opt.StringValue()
+			case "rounds":
+				rounds = int(opt.IntValue())
+			}
+		}
+
+		// Defer the response since fetching the questions might take a moment
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		})
+		if err != nil {
+			log.Printf("Error deferring interaction: %v", err)
+			return
+		}
+		if err := startTrivia(s, i.GuildID, i.ChannelID, category, rounds); err != nil {
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: userErrorMessage(err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return
+		}
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("🧠 **Trivia time!** %d questions, %d seconds each. Click a button to answer, one try per question.", rounds, int(triviaRoundTime.Seconds())),
+		})
+
+	case "leaderboard":
+		triviaScoresMu.Lock()
+		points := make(map[string]int, len(triviaScores[i.GuildID]))
+		for userID, n := range triviaScores[i.GuildID] {
+			points[userID] = n
+		}
+		triviaScoresMu.Unlock()
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:          []*discordgo.MessageEmbed{triviaStandingsEmbed("🏆 Trivia Leaderboard", points, 0)},
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			},
+		})
+
+	case "schedule":
+		if !hasManageGuild(i) {
+			respond("❌ You need the Manage Server permission to schedule trivia.")
+			return
+		}
+		respond(triviaScheduleSetting(i.GuildID, subcommand.Options))
+	}
+}
+
+// triviaScheduleSetting handles /trivia schedule and returns the reply
+func triviaScheduleSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var channelID, category string
+	weekday, hour := -1, 20
+	enabled := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "day":
+			weekday = int(opt.IntValue())
+		case "hour":
+			hour = int(opt.IntValue())
+		case "category":
+			category = opt.StringValue()
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.TriviaChannel = ""
+		})
+		return "✅ Weekly trivia turned off."
+	}
+	if channelID == "" || weekday < 0 {
+		return "❌ Pick a `channel` and a `day` for the weekly quiz."
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.TriviaChannel = channelID
+		settings.TriviaWeekday = weekday
+		settings.TriviaHour = hour
+		settings.TriviaCategory = category
+	})
+	return fmt.Sprintf("✅ A %d-question trivia quiz will start in <#%s> every %s at %02d:00 server time.", defaultTriviaRounds, channelID, time.Weekday(weekday), hour)
+}
+
+// vocabWord is an Indonesian/English word pair for the word of the day
+type vocabWord struct {
+	Indonesian string
+	English    string
+	Example    string // in Indonesian
+	Meaning    string // the example in English
+}
+
+// vocabularyWords are cycled through one per day
+var vocabularyWords = []vocabWord{
+	{"rindu", "to miss (someone)", "Aku rindu masakan ibu.", "I miss my mother's cooking."},
+	{"semangat", "spirit, enthusiasm", "Semangat ya ujiannya!", "Good luck with the exam, keep your spirits up!"},
+	{"gotong royong", "working together", "Warga gotong royong membersihkan selokan.", "The residents worked together to clean the gutter."},
+	{"canggung", "awkward", "Suasananya jadi canggung setelah dia pergi.", "It got awkward after they left."},
+	{"gemas", "adorably frustrating", "Anak kucing itu bikin gemas.", "That kitten is just too cute."},
+	{"mager", "too lazy to move", "Hujan begini bikin mager.", "Rain like this makes me not want to move."},
+	{"jendela", "window", "Tolong buka jendelanya.", "Please open the window."},
+	{"pelangi", "rainbow", "Ada pelangi setelah hujan.", "There's a rainbow after the rain."},
+	{"lapar", "hungry", "Saya lapar, ayo makan.", "I'm hungry, let's eat."},
+	{"macet", "traffic jam", "Jalan ke kantor macet total.", "The road to the office is completely jammed."},
+	{"kenangan", "memory", "Foto ini penuh kenangan.", "This photo is full of memories."},
+	{"senja", "dusk", "Kami duduk di pantai menunggu senja.", "We sat on the beach waiting for dusk."},
+	{"tangguh", "resilient", "Tim kita tangguh walau kalah.", "Our team is resilient even in defeat."},
+	{"ramah", "friendly", "Penduduk desa itu sangat ramah.", "The villagers are very friendly."},
+	{"pedas", "spicy", "Sambal ini terlalu pedas untukku.", "This sambal is too spicy for me."},
+	{"sepi", "quiet, lonely", "Kantor sepi saat libur panjang.", "The office is quiet over the long weekend."},
+	{"bingung", "confused", "Saya bingung dengan petunjuknya.", "I'm confused by the instructions."},
+	{"ngabuburit", "passing time before iftar", "Kami ngabuburit di taman kota.", "We passed the time before iftar in the city park."},
+	{"mudik", "going home for the holidays", "Jutaan orang mudik saat Lebaran.", "Millions of people go home for Eid."},
+	{"santai", "relaxed", "Akhir pekan ini aku mau santai saja.", "This weekend I just want to relax."},
+	{"cerdas", "smart", "Bot ini cukup cerdas.", "This bot is quite smart."},
+	{"bangga", "proud", "Orang tuanya bangga padanya.", "Their parents are proud of them."},
+	{"jujur", "honest", "Jujur, aku belum selesai.", "Honestly, I'm not done yet."},
+	{"sabar", "patient", "Sabar, sebentar lagi sampai.", "Be patient, we're almost there."},
+	{"rajin", "diligent", "Dia rajin belajar setiap malam.", "They study diligently every night."},
+	{"hemat", "thrifty", "Kita harus hemat bulan ini.", "We have to be thrifty this month."},
+	{"kantuk", "sleepiness", "Kopi ini mengusir kantuk.", "This coffee chases away sleepiness."},
+	{"tetangga", "neighbor", "Tetangga kami membawa kue.", "Our neighbor brought cake."},
+	{"sungai", "river", "Anak-anak berenang di sungai.", "The kids are swimming in the river."},
+	{"ombak", "wave", "Ombaknya tinggi hari ini.", "The waves are high today."},
+}
+
+// WordStreak is a member's run of correct word-of-the-day answers
+type WordStreak struct {
+	Streak       int    `json:"streak"`
+	Best         int    `json:"best"`
+	LastAnswered string `json:"last_answered"` // local date of the last quiz answered
+}
+
+// ServerWordStreaks stores word-of-the-day streaks per member, per server
+type ServerWordStreaks map[string]map[string]WordStreak // map[guildID]map[userID]WordStreak
+
+// wordOfTheDay picks the word for a date (YYYY-MM-DD) with its shuffled quiz choices,
+// the same for everyone on that day
+func wordOfTheDay(date string) (vocabWord, []string, int) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		day = time.Now()
+	}
+	index := int(day.Unix()/86400) % len(vocabularyWords)
+	word := vocabularyWords[index]
+
+	picker := mathrand.New(mathrand.NewPCG(uint64(index), uint64(day.Unix())))
+	choices := []string{word.English}
+	for _, n := range picker.Perm(len(vocabularyWords)) {
+		if len(choices) == 4 {
+			break
+		}
+		if n != index {
+			choices = append(choices, vocabularyWords[n].English)
+		}
+	}
+	picker.Shuffle(len(choices), func(a, b int) {
+		choices[a], choices[b] = choices[b], choices[a]
+	})
+	return word, choices, slices.Index(choices, word.English)
+}
+
+// wordOfTheDayMessage is the word-of-the-day embed with its quiz buttons
+func wordOfTheDayMessage(date string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	word, choices, _ := wordOfTheDay(date)
+
+	var buttons []discordgo.MessageComponent
+	for idx, choice := range choices {
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncate(choice, 80),
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("word_quiz:%s:%d", date, idx),
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📚 Word of the Day: " + word.Indonesian,
+		Description: fmt.Sprintf("What does **%s** mean? Answer below to keep your streak going.", word.Indonesian),
+		Color:       embedColor,
+		Footer:      &discordgo.MessageEmbedFooter{Text: date},
+	}
+	return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// loadWordStreaks loads the word-of-the-day streaks from file
+func loadWordStreaks() {
+	wordStreaks = make(ServerWordStreaks)
+
+	if _, err := os.Stat(streaksFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(streaksFile)
+	if err != nil {
+		log.Printf("Error reading word streaks file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &wordStreaks); err != nil {
+		log.Printf("Error parsing word streaks file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded word streaks for %d servers", len(wordStreaks))
+}
+
+// saveWordStreaks saves the word-of-the-day streaks to file
+func saveWordStreaks() {
+	data, err := json.MarshalIndent(wordStreaks, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling word streaks: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(streaksFile, data, 0644); err != nil {
+		log.Printf("Error saving word streaks: %v", err)
+		return
+	}
+}
+
+// answerWordQuiz records a member's answer for a day's quiz and returns their streak.
+// answered is false when they already answered that day.
+func answerWordQuiz(guildID, userID, date string, correct bool) (streak WordStreak, answered bool) {
+	wordStreaksMu.Lock()
+	defer wordStreaksMu.Unlock()
+
+	if wordStreaks[guildID] == nil {
+		wordStreaks[guildID] = make(map[string]WordStreak)
+	}
+	streak = wordStreaks[guildID][userID]
+	if streak.LastAnswered >= date {
+		return streak, false
+	}
+
+	day, _ := time.Parse("2006-01-02", date)
+	switch {
+	case !correct:
+		streak.Streak = 0
+	case streak.LastAnswered == day.AddDate(0, 0, -1).Format("2006-01-02"):
+		streak.Streak++
+	default:
+		streak.Streak = 1
+	}
+	streak.Best = max(streak.Best, streak.Streak)
+	streak.LastAnswered = date
+	wordStreaks[guildID][userID] = streak
+	saveWordStreaks()
+	return streak, true
+}
+
+// handleWordQuizButton checks a word-of-the-day answer and replies privately with the streak
+func handleWordQuizButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	date, choice, _ := strings.Cut(arg, ":")
+	idx, _ := strconv.Atoi(choice)
+	word, _, answer := wordOfTheDay(date)
+
+	var content string
+	today := time.Now().In(getGuildSettings(i.GuildID).location()).Format("2006-01-02")
+	if date != today {
+		content = fmt.Sprintf("⌛ This quiz has closed. **%s** means *%s*.", word.Indonesian, word.English)
+	} else if streak, answered := answerWordQuiz(i.GuildID, interactionUserID(i), date, idx == answer); !answered {
+		content = fmt.Sprintf("🔒 You already answered today's quiz. Your streak is **%d** (best %d).", streak.Streak, streak.Best)
+	} else if idx == answer {
+		content = fmt.Sprintf("✅ Correct! **%s** means *%s*.\n> %s\n> *%s*\n🔥 Streak: **%d** day(s) (best %d)", word.Indonesian, word.English, word.Example, word.Meaning, streak.Streak, streak.Best)
+	} else {
+		content = fmt.Sprintf("❌ Not quite, **%s** means *%s*.\n> %s\n> *%s*\nYour streak starts again tomorrow (best %d).", word.Indonesian, word.English, word.Example, word.Meaning, streak.Best)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleWordCommand shows today's word of the day and its quiz
+func handleWordCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ The word of the day only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	today := time.Now().In(getGuildSettings(i.GuildID).location()).Format("2006-01-02")
+	embed, components := wordOfTheDayMessage(today)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// postWordOfTheDay posts the day's word in servers that scheduled it, once a day at their hour
+func postWordOfTheDay(s *discordgo.Session, now time.Time) {
+	settingsMu.Lock()
+	guildIDs := make([]string, 0, len(serverSettings))
+	for guildID, settings := range serverSettings {
+		if settings.WordChannel != "" {
+			guildIDs = append(guildIDs, guildID)
+		}
+	}
+	settingsMu.Unlock()
+
+	for _, guildID := range guildIDs {
+		settings := getGuildSettings(guildID)
+		local := now.In(settings.location())
+		today := local.Format("2006-01-02")
+		if local.Hour() != settings.WordHour || settings.WordPosted == today || settings.inQuietHours(now) {
+			continue
+		}
+
+		channelID := settings.WordChannel
+		embed, components := wordOfTheDayMessage(today)
+		if _, err := queueBackgroundSend(channelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: components,
+			})
+		}); err != nil {
+			// Tried again on the next tick, for as long as it's still the posting hour
+			log.Printf("Error posting word of the day in channel %s: %v", channelID, err)
+			continue
+		}
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.WordPosted = today
+		})
+	}
+}
+
+// handleScheduleWordOfTheDay turns the daily word post on or off for a channel
+func handleScheduleWordOfTheDay(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var channelID string
+	hour := defaultWordHour
+	enabled := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "hour":
+			hour = int(opt.IntValue())
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	content := "✅ Word of the day turned off."
+	updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+		if enabled {
+			settings.WordChannel = channelID
+			settings.WordHour = hour
+		} else {
+			settings.WordChannel = ""
+		}
+	})
+	if enabled {
+		content = fmt.Sprintf("✅ The word of the day will be posted in <#%s> every day at %02d:00 server time.", channelID, hour)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleScheduleStandup turns the daily standup prompt on or off for a channel
+func handleScheduleStandup(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var channelID string
+	hour := defaultStandupHour
+	window := defaultStandupWindow
+	weekdays := true
+	enabled := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "hour":
+			hour = int(opt.IntValue())
+		case "window":
+			window = int(opt.IntValue())
+		case "weekdays_only":
+			weekdays = opt.BoolValue()
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	content := "✅ Daily standup turned off."
+	updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+		if enabled {
+			settings.StandupChannel = channelID
+			settings.StandupHour = hour
+			settings.StandupWindow = window
+			settings.StandupWeekdays = weekdays
+		} else {
+			settings.StandupChannel = ""
+		}
+	})
+	if enabled {
+		days := "every day"
+		if weekdays {
+			days = "Monday to Friday"
+		}
+		content = fmt.Sprintf("✅ The standup prompt will be posted in <#%s> %s at %02d:00 server time. Replies in its thread are collected for %d hours and then summarized in one post.", channelID, days, hour, window)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// postStandups posts the daily standup prompt with a thread for the answers, and once the
+// collection window is over posts the summary. Called from runScheduler.
+func postStandups(s *discordgo.Session, now time.Time) {
+	settingsMu.Lock()
+	guildIDs := make([]string, 0, len(serverSettings))
+	for guildID, settings := range serverSettings {
+		if settings.StandupChannel != "" || settings.StandupThread != "" {
+			guildIDs = append(guildIDs, guildID)
+		}
+	}
+	settingsMu.Unlock()
+
+	for _, guildID := range guildIDs {
+		settings := getGuildSettings(guildID)
+
+		// A standup turned off or moved still gets its summary
+		if settings.StandupThread != "" {
+			if now.Before(settings.StandupCloses) {
+				continue
+			}
+			summarizeStandup(s, guildID, settings, now)
+			continue
+		}
+
+		local := now.In(settings.location())
+		today := local.Format("2006-01-02")
+		weekend := local.Weekday() == time.Saturday || local.Weekday() == time.Sunday
+		if settings.StandupChannel == "" || local.Hour() != settings.StandupHour || settings.StandupPosted == today || (settings.StandupWeekdays && weekend) || settings.inQuietHours(now) {
+			continue
+		}
+
+		// A prompt that fails to send is tried again on the next tick within the hour
+		channelID := settings.StandupChannel
+		embed := &discordgo.MessageEmbed{
+			Title:       "🧍 Daily standup",
+			Description: "Reply in the thread below:\n\n**1.** What did you do since the last standup?\n**2.** What do you plan to do today?\n**3.** Anything blocking you?",
+			Color:       0x3498db,
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Answers are collected for %d hours, then summarized here", settings.StandupWindow)},
+		}
+		msg, err := queueBackgroundSend(channelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendEmbed(channelID, embed)
+		})
+		if err != nil {
+			log.Printf("Error posting standup in channel %s: %v", channelID, err)
+			continue
+		}
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.StandupPosted = today
+		})
+		thread, err := s.MessageThreadStart(channelID, msg.ID, "Standup "+today, threadAutoArchive)
+		if err != nil {
+			log.Printf("Error starting standup thread in channel %s: %v", channelID, err)
+			continue
+		}
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.StandupThread = thread.ID
+			settings.StandupCloses = now.Add(time.Duration(settings.StandupWindow) * time.Hour)
+		})
+	}
+}
+
+// summarizeStandup compiles the answers in a standup thread into one post, one field per
+// member in the order they answered, and locks the thread. A summary that can't be read or
+// posted is tried again on the next tick, for up to standupRetryWindow.
+func summarizeStandup(s *discordgo.Session, guildID string, settings *GuildSettings, now time.Time) {
+	threadID := settings.StandupThread
+	done := func() {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.StandupThread = ""
+		})
+	}
+	failed := func() {
+		if now.Sub(settings.StandupCloses) > standupRetryWindow {
+			log.Printf("Giving up on the standup summary of thread %s", threadID)
+			done()
+		}
+	}
+
+	messages, err := recentMessages(s, threadID, maxStandupMessages, time.Time{})
+	if err != nil {
+		log.Printf("Error reading standup thread %s: %v", threadID, err)
+		failed()
+		return
+	}
+	var order []string
+	names := make(map[string]string)
+	answers := make(map[string][]string)
+	for _, msg := range messages {
+		if msg.Author == nil || msg.Author.Bot || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		if _, ok := answers[msg.Author.ID]; !ok {
+			order = append(order, msg.Author.ID)
+			names[msg.Author.ID] = msg.Author.Username
+			if msg.Author.GlobalName != "" {
+				names[msg.Author.ID] = msg.Author.GlobalName
+			}
+		}
+		answers[msg.Author.ID] = append(answers[msg.Author.ID], msg.Content)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     "📋 Standup summary",
+		Color:     0x3498db,
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Full answers in the thread"},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	switch len(order) {
+	case 0:
+		embed.Description = fmt.Sprintf("Nobody checked in on <#%s>.", threadID)
+	case 1:
+		embed.Description = fmt.Sprintf("1 member checked in on <#%s>.", threadID)
+	default:
+		embed.Description = fmt.Sprintf("%d members checked in on <#%s>.", len(order), threadID)
+	}
+	// Embeds hold 25 fields and 6000 characters, the answers share what is left
+	shown := order[:min(len(order), 25)]
+	limit := 1024
+	if len(shown) > 0 {
+		limit = min(limit, 5000/len(shown))
+	}
+	for _, userID := range shown {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(names[userID], 256),
+			Value: truncate(strings.Join(answers[userID], "\n"), limit),
+		})
+	}
+	if len(order) > len(shown) {
+		embed.Description += fmt.Sprintf(" Showing the first %d.", len(shown))
+	}
+
+	channelID := settings.StandupChannel
+	if channelID == "" {
+		// Turned off since: the summary goes to the thread itself
+		channelID = threadID
+	}
+	if _, err := queueBackgroundSend(channelID, func() (*discordgo.Message, error) {
+		return s.ChannelMessageSendEmbed(channelID, embed)
+	}); err != nil {
+		log.Printf("Error posting standup summary in channel %s: %v", channelID, err)
+		failed()
+		return
+	}
+	done()
+
+	locked := true
+	if _, err := s.ChannelEditComplex(threadID, &discordgo.ChannelEdit{Locked: &locked, Archived: &locked}); err != nil {
+		log.Printf("Error closing standup thread %s: %v", threadID, err)
+	}
+}
+
+// loadConfessions loads confessions and their authors from file
+func loadConfessions() {
+	confessions = make(ServerConfessions)
+
+	if _, err := os.Stat(confessFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(confessFile)
+	if err != nil {
+		log.Printf("Error reading confessions file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &confessions); err != nil {
+		log.Printf("Error parsing confessions file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded confessions for %d servers", len(confessions))
+}
+
+// saveConfessions saves confessions and their authors to file
+func saveConfessions() {
+	data, err := json.MarshalIndent(confessions, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling confessions: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(confessFile, data, 0644); err != nil {
+		log.Printf("Error saving confessions: %v", err)
+		return
+	}
+}
+
+// confessionEmbed is how a confession looks in the confession channel, with no trace of the author
+func confessionEmbed(confession Confession) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("💬 Anonymous Confession #%d", confession.Number),
+		Description: confession.Text,
+		Color:       0x5865f2,
+		Timestamp:   confession.SubmittedAt.Format(time.RFC3339),
+	}
+}
+
+// postConfession sends a confession to the server's confession channel
+func postConfession(s *discordgo.Session, channelID string, confession Confession) (*discordgo.Message, error) {
+	return s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{confessionEmbed(confession)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// addConfession numbers a new confession and stores it with its author
+func addConfession(guildID string, confession Confession) Confession {
+	confessMu.Lock()
+	defer confessMu.Unlock()
+
+	entries := confessions[guildID]
+	confession.Number = 1
+	if len(entries) > 0 {
+		confession.Number = entries[len(entries)-1].Number + 1
+	}
+	entries = append(entries, confession)
+	if len(entries) > maxConfessions {
+		entries = entries[len(entries)-maxConfessions:]
+	}
+	confessions[guildID] = entries
+	saveConfessions()
+	return confession
+}
+
+// updateConfession changes a stored confession by number and returns the updated copy
+func updateConfession(guildID string, number int, fn func(*Confession)) (Confession, bool) {
+	confessMu.Lock()
+	defer confessMu.Unlock()
+
+	for idx := range confessions[guildID] {
+		if confessions[guildID][idx].Number == number {
+			fn(&confessions[guildID][idx])
+			saveConfessions()
+			return confessions[guildID][idx], true
+		}
+	}
+	return Confession{}, false
+}
+
+// handleConfessCommand posts an anonymous confession, or sends it to the moderators first
+func handleConfessCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Confessions only work in servers, not in DMs!")
+		return
+	}
+
+	settings := getGuildSettings(i.GuildID)
+	if settings.ConfessChannel == "" {
+		respond("❌ Confessions are not set up in this server. Ask a server manager to run `/settings confessions`.")
+		return
+	}
+
+	text := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	text, ok := screenReplyResponse(i.GuildID, text)
+	if !ok {
+		respond("❌ Your confession contains words that aren't allowed in this server.")
+		return
+	}
+
+	confession := addConfession(i.GuildID, Confession{
+		AuthorID:    interactionUserID(i),
+		Text:        text,
+		Status:      "pending",
+		SubmittedAt: time.Now(),
+	})
+
+	if settings.ConfessReviewChannel == "" {
+		msg, err := postConfession(s, settings.ConfessChannel, confession)
+		if err != nil {
+			log.Printf("Error posting confession in channel %s: %v", settings.ConfessChannel, err)
+			respond("❌ Couldn't post your confession. Ask a server manager to check the channel in `/settings confessions`.")
+			return
+		}
+		updateConfession(i.GuildID, confession.Number, func(c *Confession) {
+			c.Status = "posted"
+			c.MessageID = msg.ID
+		})
+		respond(fmt.Sprintf("🤫 Confession #%d was posted anonymously in <#%s>.", confession.Number, settings.ConfessChannel))
+		return
+	}
+
+	// Reviewers see the text but not who wrote it
+	embed := confessionEmbed(confession)
+	embed.Title = fmt.Sprintf("📝 Confession #%d Awaiting Approval", confession.Number)
+	embed.Color = 0xf1c40f
+	msg, err := s.ChannelMessageSendComplex(settings.ConfessReviewChannel, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Approve", Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("confess_review:approve:%d", confession.Number)},
+				discordgo.Button{Label: "Deny", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("confess_review:deny:%d", confession.Number)},
+			}},
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("Error posting confession for review in channel %s: %v", settings.ConfessReviewChannel, err)
+		respond("❌ Couldn't send your confession for review. Ask a server manager to check the review channel in `/settings confessions`.")
+		return
+	}
+	updateConfession(i.GuildID, confession.Number, func(c *Confession) {
+		c.MessageID = msg.ID
+	})
+	respond(fmt.Sprintf("🕐 Confession #%d was sent to the moderators. It's posted anonymously once approved.", confession.Number))
+}
+
+// handleConfessReviewButton approves or denies a pending confession from its review message
+func handleConfessReviewButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.Member == nil || i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) == 0 {
+		respond("❌ Only moderators can review confessions.")
+		return
+	}
+
+	action, numberText, _ := strings.Cut(arg, ":")
+	number, _ := strconv.Atoi(numberText)
+	reviewer := i.Member.User.ID
+	var wasPending bool
+	confession, ok := updateConfession(i.GuildID, number, func(c *Confession) {
+		wasPending = c.Status == "pending"
+		if !wasPending {
+			return
+		}
+		c.Status = "denied"
+		if action == "approve" {
+			c.Status = "approved"
+		}
+		c.ReviewedBy = reviewer
+	})
+	if !ok || !wasPending {
+		respond("❌ This confession was already reviewed.")
+		return
+	}
+
+	status, color := fmt.Sprintf("❌ Denied by <@%s>", reviewer), 0xe74c3c
+	if action == "approve" {
+		channelID := getGuildSettings(i.GuildID).ConfessChannel
+		msg, err := postConfession(s, channelID, confession)
+		if err != nil {
+			log.Printf("Error posting confession in channel %s: %v", channelID, err)
+			status, color = fmt.Sprintf("⚠️ Approved by <@%s> but couldn't be posted in <#%s>", reviewer, channelID), 0xe67e22
+		} else {
+			updateConfession(i.GuildID, number, func(c *Confession) {
+				c.Status = "posted"
+				c.MessageID = msg.ID
+			})
+			status, color = fmt.Sprintf("✅ Approved by <@%s>", reviewer), embedColor
+		}
+	}
+
+	embed := confessionEmbed(confession)
+	embed.Title = fmt.Sprintf("📝 Confession #%d Reviewed", confession.Number)
+	embed.Color = color
+	embed.Fields = []*discordgo.MessageEmbedField{{Name: "Result", Value: status}}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// handleConfessionsCommand handles /confessions author, showing server managers who wrote
+// a confession. Lookups are privileged, so each one lands in the audit log.
+func handleConfessionsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to look up who wrote a confession.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	number := int(subcommand.Options[0].IntValue())
+
+	content := fmt.Sprintf("🔍 No confession #%d is on record in this server.", number)
+	confessMu.Lock()
+	for _, confession := range confessions[i.GuildID] {
+		if confession.Number != number {
+			continue
+		}
+		content = fmt.Sprintf("🔍 Confession #%d was written by <@%s> (`%s`) on %s. Status: %s",
+			number, confession.AuthorID, confession.AuthorID, confession.SubmittedAt.Format("2006-01-02 15:04"), confession.Status)
+		if confession.ReviewedBy != "" {
+			content += fmt.Sprintf(", reviewed by <@%s>", confession.ReviewedBy)
+		}
+		content += fmt.Sprintf(".\n> %s", truncate(confession.Text, 1500))
+	}
+	confessMu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// confessionsSetting handles /settings confessions and returns the reply
+func confessionsSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	var channelID, reviewChannelID string
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "review_channel":
+			reviewChannelID = opt.ChannelValue(nil).ID
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.ConfessChannel = ""
+			settings.ConfessReviewChannel = ""
+		})
+		return "✅ Confessions disabled. Past authors stay on record for `/confessions author`."
+	}
+
+	if channelID == "" {
+		channelID = getGuildSettings(guildID).ConfessChannel
+	}
+	if channelID == "" {
+		return "❌ Pick the channel where confessions are posted."
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.ConfessChannel = channelID
+		settings.ConfessReviewChannel = reviewChannelID
+	})
+	if reviewChannelID == "" {
+		return fmt.Sprintf("✅ `/confess` now posts anonymously in <#%s> right away.", channelID)
+	}
+	return fmt.Sprintf("✅ `/confess` now posts anonymously in <#%s> after a moderator approves it in <#%s>.", channelID, reviewChannelID)
+}
+
+// faqStopwords are left out of local FAQ embeddings so questions match on their subject
+var faqStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "is": true, "are": true, "do": true, "does": true, "i": true,
+	"to": true, "of": true, "in": true, "on": true, "for": true, "and": true, "or": true, "it": true,
+	"my": true, "can": true, "how": true, "what": true, "why": true, "where": true, "when": true,
+	"yang": true, "di": true, "ke": true, "dan": true, "ini": true, "itu": true, "apa": true,
+	"bagaimana": true, "gimana": true, "kenapa": true, "saya": true, "aku": true, "bisa": true,
+}
+
+// faqQuestionStart are first words that make a message look like a question for auto-answers
+var faqQuestionStart = []string{
+	"how", "what", "why", "where", "when", "who", "can", "does", "do", "is", "are",
+	"apa", "apakah", "bagaimana", "gimana", "kenapa", "mengapa", "kapan", "dimana", "di mana", "siapa", "bisa", "boleh",
+}
+
+// parseFAQ splits a FAQ document into entries. A question is a "Q:" line or a Markdown
+// heading, and its answer is everything up to the next question.
+func parseFAQ(text string) []FAQEntry {
+	var entries []FAQEntry
+	var current *FAQEntry
+	var answer []string
+	flush := func() {
+		if current != nil {
+			current.Answer = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.Join(answer, "\n")), "A:"))
+			if current.Question != "" && current.Answer != "" {
+				entries = append(entries, *current)
+			}
+		}
+		answer = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		var question string
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			question = strings.TrimLeft(trimmed, "# ")
+		case strings.HasPrefix(trimmed, "Q:"), strings.HasPrefix(trimmed, "q:"):
+			question = trimmed[2:]
+		}
+		if question != "" {
+			flush()
+			current = &FAQEntry{Question: strings.TrimSpace(question)}
+			continue
+		}
+		if current != nil {
+			answer = append(answer, line)
+		}
+	}
+	flush()
+	return entries
+}
+
+// localEmbedding turns text into a normalized vector of hashed words and word pairs, good
+// enough to match questions that share their key words without any API
+func localEmbedding(text string) []float64 {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if !faqStopwords[word] {
+			words = append(words, word)
+		}
+	}
+
+	vector := make([]float64, localEmbeddingSize)
+	add := func(term string, weight float64) {
+		h := fnv.New32a()
+		h.Write([]byte(term))
+		vector[h.Sum32()%localEmbeddingSize] += weight
+	}
+	for n, word := range words {
+		add(word, 1)
+		if n > 0 {
+			add(words[n-1]+" "+word, 0.5)
+		}
+	}
+	return normalizeVector(vector)
+}
+
+// normalizeVector scales a vector to unit length so a dot product is the cosine similarity
+func normalizeVector(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for n := range vector {
+		vector[n] /= norm
+	}
+	return vector
+}
+
+// apiEmbeddings embeds texts with an OpenAI-compatible embeddings API, using the server's
+// LLM key. EMBEDDINGS_URL and EMBEDDINGS_MODEL pick another provider.
+func apiEmbeddings(guildID string, texts []string) ([][]float64, error) {
+	apiKey, ok := llmKey(guildID)
+	if !ok {
+		return nil, invalidInput("API embeddings need an LLM key, set one with `/settings apikey` or use local embeddings")
+	}
+	endpoint := os.Getenv("EMBEDDINGS_URL")
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/embeddings"
+	}
+	model := os.Getenv("EMBEDDINGS_MODEL")
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{"model": model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, upstreamDown("Embeddings API", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpStatusError("Embeddings API", resp.StatusCode)
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, upstreamDown("Embeddings API", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	vectors := make([][]float64, len(texts))
+	for _, item := range result.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = normalizeVector(item.Embedding)
+		}
+	}
+	for _, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, upstreamDown("Embeddings API", fmt.Errorf("missing embeddings in response"))
+		}
+	}
+	return vectors, nil
+}
+
+// embedTexts embeds texts with the given embedder, "local" or "api"
+func embedTexts(guildID, embedder string, texts []string) ([][]float64, error) {
+	if embedder == "api" {
+		return apiEmbeddings(guildID, texts)
+	}
+	vectors := make([][]float64, len(texts))
+	for n, text := range texts {
+		vectors[n] = localEmbedding(text)
+	}
+	return vectors, nil
+}
+
+// faqMatch is a FAQ entry with its similarity to a question
+type faqMatch struct {
+	Entry FAQEntry
+	Score float64
+}
+
+// searchFAQ ranks the server's FAQ entries by similarity to a question, best first
+func searchFAQ(guildID, question string) ([]faqMatch, string, error) {
+	faqMu.Lock()
+	faq, ok := serverFAQs[guildID]
+	faqMu.Unlock()
+	if !ok || len(faq.Entries) == 0 {
+		return nil, "", notFound("this server has no FAQ yet, server managers can upload one with `/settings faq`")
+	}
+
+	vectors, err := embedTexts(guildID, faq.Embedder, []string{question})
+	if err != nil {
+		return nil, faq.Embedder, err
+	}
+	query := vectors[0]
+
+	var matches []faqMatch
+	for _, entry := range faq.Entries {
+		if len(entry.Vector) != len(query) {
+			continue // embedded by another model, the FAQ needs uploading again
+		}
+		var score float64
+		for n := range query {
+			score += query[n] * entry.Vector[n]
+		}
+		matches = append(matches, faqMatch{Entry: entry, Score: score})
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].Score > matches[b].Score })
+	return matches, faq.Embedder, nil
+}
+
+// faqThresholds are the lowest similarity scores shown by /faqsearch and auto-answered, per
+// embedder. API embeddings score unrelated text higher than hashed words do.
+var faqThresholds = map[string]struct{ Search, Auto float64 }{
+	"local": {Search: 0.15, Auto: 0.5},
+	"api":   {Search: 0.3, Auto: 0.6},
+}
+
+// handleFAQSearchCommand handles /faqsearch, showing the closest FAQ entries
+func handleFAQSearchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ FAQ search only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	question := i.ApplicationCommandData().Options[0].StringValue()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	matches, embedder, err := searchFAQ(i.GuildID, question)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "❓ FAQ: " + truncate(question, 200),
+		Color: embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Similarity by %s embeddings", embedder),
+		},
+	}
+	for _, match := range matches {
+		if len(embed.Fields) == maxFAQMatches || match.Score < faqThresholds[embedder].Search {
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s (%.0f%%)", truncate(match.Entry.Question, 200), match.Score*100),
+			Value: truncate(match.Entry.Answer, 1000),
+		})
+	}
+	if len(embed.Fields) == 0 {
+		embed.Description = "🔍 No FAQ entry looks like that question. Try other words, or ask the moderators."
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// looksLikeQuestion reports whether a message reads like a question worth auto-answering
+func looksLikeQuestion(content string) bool {
+	content = strings.ToLower(strings.TrimSpace(content))
+	if len(strings.Fields(content)) < 3 {
+		return false
+	}
+	if strings.HasSuffix(content, "?") {
+		return true
+	}
+	for _, start := range faqQuestionStart {
+		if strings.HasPrefix(content, start+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// answerFromFAQ replies to question-like messages with a close FAQ entry, in servers that
+// turned on auto-answers. It reports whether it replied.
+func answerFromFAQ(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if !looksLikeQuestion(m.Content) {
+		return false
+	}
+
+	// One lookup per channel per cooldown keeps busy channels readable, and bounds the
+	// embeddings calls even when questions don't match anything
+	if !faqAutoAnswers.Claim(m.ChannelID, faqAutoCooldown, time.Now()) {
+		return false
+	}
+
+	matches, embedder, err := searchFAQ(m.GuildID, m.Content)
+	if err != nil || len(matches) == 0 || matches[0].Score < faqThresholds[embedder].Auto {
+		return false
+	}
+
+	best := matches[0]
+	embed := &discordgo.MessageEmbed{
+		Title:       "❓ " + truncate(best.Entry.Question, 250),
+		Description: truncate(best.Entry.Answer, 4000),
+		Color:       embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("From the server FAQ · %.0f%% match · /faqsearch for more", best.Score*100),
+		},
+	}
+	if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Embeds:    []*discordgo.MessageEmbed{embed},
+		Reference: &discordgo.MessageReference{MessageID: m.ID, ChannelID: m.ChannelID, GuildID: m.GuildID},
+	}); err != nil {
+		log.Printf("Error sending FAQ auto-answer: %v", err)
+	}
+	return true
+}
+
+// loadFAQs loads the server FAQs from file
+func loadFAQs() {
+	serverFAQs = make(ServerFAQs)
+
+	if _, err := os.Stat(faqFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(faqFile)
+	if err != nil {
+		log.Printf("Error reading FAQ file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &serverFAQs); err != nil {
+		log.Printf("Error parsing FAQ file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded FAQs for %d servers", len(serverFAQs))
+}
+
+// saveFAQs saves the server FAQs to file
+func saveFAQs() {
+	data, err := json.Marshal(serverFAQs) // not indented, vectors make it large
+	if err != nil {
+		log.Printf("Error marshaling FAQs: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(faqFile, data, 0644); err != nil {
+		log.Printf("Error saving FAQs: %v", err)
+		return
+	}
+}
+
+// handleFAQSetting handles /settings faq: uploading a FAQ document and turning auto-answers on or off
+func handleFAQSetting(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var attachment *discordgo.MessageAttachment
+	var embedder string
+	var autoAnswer *bool
+	for _, opt := range options {
+		switch opt.Name {
+		case "file":
+			attachment = i.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+		case "embeddings":
+			embedder = opt.StringValue()
+		case "auto_answer":
+			value := opt.BoolValue()
+			autoAnswer = &value
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+	followup := func(content string) {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+	}
+
+	var lines []string
+	if attachment != nil {
+		if attachment.Size > maxFAQSize {
+			followup("❌ Please attach a text or Markdown file smaller than 256 KB.")
+			return
+		}
+		data, err := fetchAttachment(attachment.URL)
+		if err != nil {
+			followup(fmt.Sprintf("❌ %v", err))
+			return
+		}
+		entries := parseFAQ(string(data))
+		if len(entries) == 0 {
+			followup("❌ No questions found. Start each question with `Q:` or a Markdown heading like `## How do I join?`, with the answer below it.")
+			return
+		}
+		if len(entries) > maxFAQEntries {
+			followup(fmt.Sprintf("❌ The FAQ has %d questions, the limit is %d.", len(entries), maxFAQEntries))
+			return
+		}
+
+		if embedder == "" {
+			embedder = "local"
+			if _, ok := llmKey(i.GuildID); ok {
+				embedder = "api"
+			}
+		}
+		texts := make([]string, len(entries))
+		for n, entry := range entries {
+			texts[n] = entry.Question + "\n" + entry.Answer
+		}
+		vectors, err := embedTexts(i.GuildID, embedder, texts)
+		if err != nil {
+			followup(userErrorMessage(err))
+			return
+		}
+		for n := range entries {
+			entries[n].Vector = vectors[n]
+		}
+
+		faqMu.Lock()
+		serverFAQs[i.GuildID] = FAQ{Entries: entries, Embedder: embedder, UpdatedAt: time.Now()}
+		saveFAQs()
+		faqMu.Unlock()
+		lines = append(lines, fmt.Sprintf("✅ FAQ saved with **%d** questions, using %s embeddings. Members can search it with `/faqsearch`.", len(entries), embedder))
+	}
+
+	if autoAnswer != nil {
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.FAQAutoAnswer = *autoAnswer
+		})
+		if *autoAnswer {
+			lines = append(lines, "✅ Question-like messages that closely match a FAQ entry are now answered automatically.")
+		} else {
+			lines = append(lines, "✅ FAQ auto-answers turned off.")
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "❌ Attach a FAQ `file`, or set `auto_answer`.")
+	}
+	followup(strings.Join(lines, "\n"))
+}
+
+// llmKey returns the server's "llm" API key, or LLM_API_KEY when it has none
+func llmKey(guildID string) (string, bool) {
+	if apiKey, ok := guildAPIKey(guildID, "llm"); ok {
+		return apiKey, true
+	}
+	apiKey := os.Getenv("LLM_API_KEY")
+	return apiKey, apiKey != ""
+}
+
+// llmComplete asks an OpenAI-compatible chat completions API for a reply to a prompt.
+// LLM_URL and LLM_MODEL pick another provider.
+func llmComplete(guildID, system, prompt string) (string, error) {
+	apiKey, ok := llmKey(guildID)
+	if !ok {
+		return "", invalidInput("this needs an LLM key, set one with `/settings apikey`")
+	}
+	endpoint := os.Getenv("LLM_URL")
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/chat/completions"
+	}
+	model := os.Getenv("LLM_MODEL")
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", upstreamDown("LLM provider", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", httpStatusError("LLM provider", resp.StatusCode)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", upstreamDown("LLM provider", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", upstreamDown("LLM provider", fmt.Errorf("empty completion"))
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// parseSince reads a /summarize since value like "30m", "2h" or "1d"
+func parseSince(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, invalidInput("use a time like `30m`, `2h` or `1d`")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, invalidInput("use a time like `30m`, `2h` or `1d`")
+	}
+	return d, nil
+}
+
+// recentMessages fetches up to count of a channel's latest messages, stopping at messages
+// older than since when it is set. They are returned oldest first.
+func recentMessages(s *discordgo.Session, channelID string, count int, since time.Time) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	beforeID := ""
+	for len(messages) < count {
+		page, err := s.ChannelMessages(channelID, min(100, count-len(messages)), beforeID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range page {
+			if !since.IsZero() && msg.Timestamp.Before(since) {
+				slices.Reverse(messages)
+				return messages, nil
+			}
+			messages = append(messages, msg)
+		}
+		if len(page) < 100 {
+			break
+		}
+		beforeID = page[len(page)-1].ID
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// summaryTranscript renders messages as "HH:MM name: text" lines for the LLM, keeping the
+// newest lines when it runs over maxSummaryInput
+func summaryTranscript(messages []*discordgo.Message, loc *time.Location) string {
+	var lines []string
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.ContentWithMentionsReplaced())
+		if content == "" || msg.Author == nil {
+			continue
+		}
+		name := msg.Author.Username
+		if msg.Author.GlobalName != "" {
+			name = msg.Author.GlobalName
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", msg.Timestamp.In(loc).Format("15:04"), name, truncate(content, 500)))
+	}
+
+	size := 0
+	for n := len(lines) - 1; n >= 0; n-- {
+		size += len(lines[n]) + 1
+		if size > maxSummaryInput {
+			lines = lines[n+1:]
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleSummarizeCommand handles /summarize, summarizing the channel's recent messages privately
+func handleSummarizeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Summaries only work in servers, not in DMs!")
+		return
+	}
+
+	// Members only get a summary of what they could scroll back and read themselves
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionReadMessageHistory == 0 {
+		respond("❌ You need the Read Message History permission in this channel to summarize it.")
+		return
+	}
+
+	count := 0
+	var since time.Time
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "count":
+			count = int(opt.IntValue())
+		case "since":
+			d, err := parseSince(opt.StringValue())
+			if err != nil {
+				respond(userErrorMessage(err))
+				return
+			}
+			since = time.Now().Add(-d)
+		}
+	}
+	switch {
+	case count > 0:
+	case !since.IsZero():
+		count = maxSummaryMessages // since sets the range, up to the message limit
+	default:
+		count = defaultSummaryMessages
+	}
+
+	if _, ok := llmKey(i.GuildID); !ok {
+		respond("❌ Summaries need an LLM key. Ask a server manager to set one with `/settings apikey llm`.")
+		return
+	}
+	if !summarizeCooldowns.Claim(interactionUserID(i), summarizeCooldown, time.Now()) {
+		respond(fmt.Sprintf("⏳ You can summarize once every %d seconds.", int(summarizeCooldown.Seconds())))
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+	followup := func(params *discordgo.WebhookParams) {
+		params.Flags = discordgo.MessageFlagsEphemeral
+		s.FollowupMessageCreate(i.Interaction, true, params)
+	}
+
+	messages, err := recentMessages(s, i.ChannelID, count, since)
+	if err != nil {
+		log.Printf("Error reading messages in channel %s: %v", i.ChannelID, err)
+		followup(&discordgo.WebhookParams{Content: "❌ I can't read this channel's history. Check that I have the Read Message History permission here."})
+		return
+	}
+	loc := getGuildSettings(i.GuildID).location()
+	transcript := summaryTranscript(messages, loc)
+	if transcript == "" {
+		followup(&discordgo.WebhookParams{Content: "🔍 There are no messages to summarize in that range."})
+		return
+	}
+
+	summary, err := llmComplete(i.GuildID,
+		"You summarize Discord channel conversations for members catching up. Reply in the main language of the conversation with at most 8 short bullet points covering the key topics, decisions, numbers and open questions. Mention who said what only when it matters. Do not invent anything.",
+		transcript)
+	if err != nil {
+		followup(&discordgo.WebhookParams{Content: userErrorMessage(err)})
+		return
+	}
+
+	from := messages[0].Timestamp.In(loc).Format("02 Jan 15:04")
+	followup(&discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "📝 Channel Summary",
+			Description: truncate(summary, 4000),
+			Color:       embedColor,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("%d messages since %s · AI-generated, may miss details", len(messages), from),
+			},
+		}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// snowflakeAt returns the smallest Discord ID created at t, for paging messages by time
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-discordEpoch)<<22, 10)
+}
+
+// expiredMessages lists up to limit unpinned messages in a channel older than cutoff, newest first
+func expiredMessages(s *discordgo.Session, channelID string, cutoff time.Time, limit int) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	beforeID := snowflakeAt(cutoff)
+	for len(messages) < limit {
+		page, err := s.ChannelMessages(channelID, 100, beforeID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range page {
+			if !msg.Pinned && len(messages) < limit {
+				messages = append(messages, msg)
+			}
+		}
+		if len(page) < 100 {
+			break
+		}
+		beforeID = page[len(page)-1].ID
+	}
+	return messages, nil
+}
+
+// deleteMessages deletes messages, in bulk where Discord allows it (2 to 100 messages
+// younger than 14 days) and one at a time for older ones. It returns how many were deleted.
+func deleteMessages(s *discordgo.Session, channelID string, messages []*discordgo.Message) (int, error) {
+	bulkCutoff := time.Now().Add(-bulkDeleteMaxAge)
+	var recent, old []string
+	for _, msg := range messages {
+		if msg.Timestamp.After(bulkCutoff) {
+			recent = append(recent, msg.ID)
+		} else {
+			old = append(old, msg.ID)
+		}
+	}
+
+	deleted := 0
+	for len(recent) > 0 {
+		batch := recent[:min(100, len(recent))]
+		recent = recent[len(batch):]
+		if len(batch) == 1 {
+			old = append(old, batch[0])
+			continue
+		}
+		if err := s.ChannelMessagesBulkDelete(channelID, batch); err != nil {
+			return deleted, err
+		}
+		deleted += len(batch)
+	}
+	for _, messageID := range old {
+		if err := s.ChannelMessageDelete(channelID, messageID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// textTranscript renders messages, oldest first, as a plain text log with attachment links
+func textTranscript(messages []*discordgo.Message, loc *time.Location) []byte {
+	var b strings.Builder
+	for _, msg := range messages {
+		name := "unknown"
+		if msg.Author != nil {
+			name = msg.Author.Username
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", msg.Timestamp.In(loc).Format("2006-01-02 15:04"), name, msg.ContentWithMentionsReplaced())
+		for _, attachment := range msg.Attachments {
+			fmt.Fprintf(&b, "    attachment: %s\n", attachment.URL)
+		}
+		for _, embed := range msg.Embeds {
+			text := strings.TrimSpace(embed.Title + "\n" + embed.Description)
+			if text != "" {
+				fmt.Fprintf(&b, "    embed: %s\n", strings.ReplaceAll(text, "\n", "\n           "))
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+// Retention runs in progress, keyed by channel ID, so the scheduler doesn't start a policy
+// again while its last run is still deleting
+var (
+	retentionMu      sync.Mutex
+	retentionRunning = make(map[string]bool)
+)
+
+// applyRetention deletes, or archives and then deletes, a channel's expired messages and
+// reports whether it got through without errors
+func applyRetention(s *discordgo.Session, guildID string, policy RetentionPolicy, now time.Time) bool {
+	cutoff := now.AddDate(0, 0, -policy.Days)
+	messages, err := expiredMessages(s, policy.ChannelID, cutoff, maxRetentionDeletes)
+	if err != nil {
+		log.Printf("Error reading expired messages in channel %s: %v", policy.ChannelID, err)
+		return false
+	}
+	if len(messages) == 0 {
+		return true
+	}
+
+	if policy.ArchiveChannel != "" {
+		loc := getGuildSettings(guildID).location()
+		name := fmt.Sprintf("archive-%s-%s.txt", channelName(s, policy.ChannelID), now.In(loc).Format("2006-01"))
+		content := fmt.Sprintf("🗄️ Archived **%d** messages from <#%s> older than %d days.", len(messages), policy.ChannelID, policy.Days)
+		oldestFirst := slices.Clone(messages)
+		slices.Reverse(oldestFirst)
+		transcript := textTranscript(oldestFirst, loc)
+		if _, err := queueBackgroundSend(policy.ArchiveChannel, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendComplex(policy.ArchiveChannel, &discordgo.MessageSend{
+				Content: content,
+				Files:   []*discordgo.File{{Name: name, ContentType: "text/plain", Reader: bytes.NewReader(transcript)}},
+			})
+		}); err != nil {
+			// Nothing is deleted unless the archive was posted
+			log.Printf("Error posting archive of channel %s: %v", policy.ChannelID, err)
+			return false
+		}
+	}
+
+	deleted, err := deleteMessages(s, policy.ChannelID, messages)
+	if err != nil {
+		log.Printf("Error deleting expired messages in channel %s: %v", policy.ChannelID, err)
+	}
+	log.Printf("Retention removed %d messages older than %d days from channel %s", deleted, policy.Days, policy.ChannelID)
+	return err == nil
+}
+
+// channelName returns a channel's name, or its ID when it can't be looked up
+func channelName(s *discordgo.Session, channelID string) string {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		if channel, err = s.Channel(channelID); err != nil {
+			return channelID
+		}
+	}
+	return channel.Name
+}
+
+// enforceRetention runs the retention policies that are due: delete-only policies once a
+// day and archiving ones on the first of the month, both at retentionHour server time
+func enforceRetention(s *discordgo.Session, now time.Time) {
+	settingsMu.Lock()
+	guildIDs := make([]string, 0, len(serverSettings))
+	for guildID, settings := range serverSettings {
+		if len(settings.Retention) > 0 {
+			guildIDs = append(guildIDs, guildID)
+		}
+	}
+	settingsMu.Unlock()
+
+	for _, guildID := range guildIDs {
+		settings := getGuildSettings(guildID)
+		local := now.In(settings.location())
+		today := local.Format("2006-01-02")
+		if local.Hour() != retentionHour {
+			continue
+		}
+
+		var due []RetentionPolicy
+		retentionMu.Lock()
+		for _, policy := range settings.Retention {
+			if policy.LastRun == today || (policy.ArchiveChannel != "" && local.Day() != 1) || retentionRunning[policy.ChannelID] {
+				continue
+			}
+			retentionRunning[policy.ChannelID] = true
+			due = append(due, policy)
+		}
+		retentionMu.Unlock()
+		if len(due) == 0 {
+			continue
+		}
+
+		// Deleting can take a while with rate limits, so it runs beside the scheduler. Only
+		// runs that succeed count for the day, failed ones are tried again on the next tick.
+		go runRecovered("retention", func() {
+			defer func() {
+				retentionMu.Lock()
+				for _, policy := range due {
+					delete(retentionRunning, policy.ChannelID)
+				}
+				retentionMu.Unlock()
+			}()
+			for _, policy := range due {
+				if !applyRetention(s, guildID, policy, now) {
+					continue
+				}
+				updateGuildSettings(guildID, func(settings *GuildSettings) {
+					policies := append([]RetentionPolicy(nil), settings.Retention...)
+					for n := range policies {
+						if policies[n].ChannelID == policy.ChannelID {
+							policies[n].LastRun = today
+						}
+					}
+					settings.Retention = policies
+				})
+			}
+		})
+	}
+}
+
+// handleScheduleRetention adds, changes or removes a channel's retention policy
+func handleScheduleRetention(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	policy := RetentionPolicy{}
+	enabled := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			policy.ChannelID = opt.ChannelValue(nil).ID
+		case "days":
+			policy.Days = int(opt.IntValue())
+		case "archive_channel":
+			policy.ArchiveChannel = opt.ChannelValue(nil).ID
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	var content string
+	save := true
+	switch {
+	case !enabled:
+		content = fmt.Sprintf("✅ Messages in <#%s> are no longer cleaned up.", policy.ChannelID)
+	case policy.Days == 0:
+		content, save = "❌ Pick how many `days` messages are kept.", false
+	case policy.ArchiveChannel == policy.ChannelID:
+		content, save = "❌ The archive channel has to be another channel, or its archives would be deleted too.", false
+	case policy.ArchiveChannel != "":
+		content = fmt.Sprintf("✅ On the 1st of each month, messages in <#%s> older than %d days are posted as a file in <#%s> and then deleted. Pinned messages are kept.", policy.ChannelID, policy.Days, policy.ArchiveChannel)
+	default:
+		content = fmt.Sprintf("✅ Every day at %02d:00 server time, messages in <#%s> older than %d days are deleted. Pinned messages are kept.", retentionHour, policy.ChannelID, policy.Days)
+	}
+	if save {
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			var policies []RetentionPolicy
+			for _, existing := range settings.Retention {
+				if existing.ChannelID != policy.ChannelID {
+					policies = append(policies, existing)
+				}
+			}
+			if enabled {
+				policies = append(policies, policy)
+			}
+			settings.Retention = policies
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// htmlTranscript renders messages, oldest first, as a standalone HTML page
+func htmlTranscript(title string, messages []*discordgo.Message, loc *time.Location) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>%s</title>
+<style>
+body{font-family:sans-serif;background:#313338;color:#dbdee1;margin:2em}
+.msg{margin:.6em 0}.author{font-weight:bold;color:#fff}.time{color:#949ba4;font-size:.8em;margin-left:.5em}
+.content{white-space:pre-wrap}a{color:#00a8fc}
+</style></head><body>
+<h1>%s</h1>
+`, html.EscapeString(title), html.EscapeString(title))
+	for _, msg := range messages {
+		name := "unknown"
+		if msg.Author != nil {
+			name = msg.Author.Username
+		}
+		fmt.Fprintf(&b, `<div class="msg"><span class="author">%s</span><span class="time">%s</span><div class="content">%s</div>`,
+			html.EscapeString(name), msg.Timestamp.In(loc).Format("2006-01-02 15:04"), html.EscapeString(msg.ContentWithMentionsReplaced()))
+		for _, attachment := range msg.Attachments {
+			fmt.Fprintf(&b, `<div><a href="%s">%s</a></div>`, html.EscapeString(attachment.URL), html.EscapeString(attachment.Filename))
+		}
+		for _, embed := range msg.Embeds {
+			if embed.Title != "" || embed.Description != "" {
+				fmt.Fprintf(&b, `<blockquote><b>%s</b><div class="content">%s</div></blockquote>`, html.EscapeString(embed.Title), html.EscapeString(embed.Description))
+			}
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return []byte(b.String())
+}
+
+// handleTranscriptCommand handles /transcript, exporting the channel's recent messages as a file
+func handleTranscriptCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Transcripts only work in servers, not in DMs!")
+		return
+	}
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionReadMessageHistory == 0 {
+		respond("❌ You need the Read Message History permission in this channel to export it.")
+		return
+	}
+
+	count := 0
+	format := "text"
+	var since time.Time
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "count":
+			count = int(opt.IntValue())
+		case "since":
+			d, err := parseSince(opt.StringValue())
+			if err != nil {
+				respond(userErrorMessage(err))
+				return
+			}
+			since = time.Now().Add(-d)
+		case "format":
+			format = opt.StringValue()
+		}
+	}
+	switch {
+	case count > 0:
+	case !since.IsZero():
+		count = maxTranscriptMessages
+	default:
+		count = defaultTranscriptMessages
+	}
+
+	if !transcriptCooldowns.Claim(interactionUserID(i), transcriptCooldown, time.Now()) {
+		respond(fmt.Sprintf("⏳ You can export a transcript once every %d seconds.", int(transcriptCooldown.Seconds())))
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	messages, err := recentMessages(s, i.ChannelID, count, since)
+	if err != nil {
+		log.Printf("Error reading messages in channel %s: %v", i.ChannelID, err)
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "❌ I can't read this channel's history. Check that I have the Read Message History permission here.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+	if len(messages) == 0 {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "🔍 There are no messages to export in that range.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	loc := getGuildSettings(i.GuildID).location()
+	channel := channelName(s, i.ChannelID)
+	stamp := time.Now().In(loc).Format("2006-01-02-1504")
+	file := &discordgo.File{Name: fmt.Sprintf("transcript-%s-%s.txt", channel, stamp), ContentType: "text/plain"}
+	if format == "html" {
+		title := fmt.Sprintf("#%s · %s to %s", channel,
+			messages[0].Timestamp.In(loc).Format("2006-01-02 15:04"), messages[len(messages)-1].Timestamp.In(loc).Format("2006-01-02 15:04"))
+		file.Name = strings.TrimSuffix(file.Name, ".txt") + ".html"
+		file.ContentType = "text/html"
+		file.Reader = bytes.NewReader(htmlTranscript(title, messages, loc))
+	} else {
+		file.Reader = bytes.NewReader(textTranscript(messages, loc))
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprintf("📄 Transcript of the last **%d** messages in <#%s>.", len(messages), i.ChannelID),
+		Files:   []*discordgo.File{file},
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// onboardingSteps is the checklist new members go through, with the server's channels
+// filled in where it set them
+func onboardingSteps(settings *GuildSettings) []string {
+	where := func(channelID, fallback string) string {
+		if channelID == "" {
+			return fallback
+		}
+		return "in <#" + channelID + ">"
+	}
+	return []string{
+		"Read the server rules " + where(settings.RulesChannel, "in the rules channel"),
+		"Pick your roles " + where(settings.RolesChannel, "in Channels & Roles"),
+		"Introduce yourself " + where(settings.IntroChannel, "to everyone"),
+	}
+}
+
+// onboardingMessage renders a member's checklist with a button for the step they're on
+func onboardingMessage(guildID, userID string, step int) (string, []discordgo.MessageComponent) {
+	settings := getGuildSettings(guildID)
+	serverName := "the server"
+	if guild, err := session.State.Guild(guildID); err == nil {
+		serverName = guild.Name
+	}
+	steps := onboardingSteps(settings)
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "👋 **Welcome to %s, <@%s>!**\n", serverName, userID)
+	if settings.OnboardingMessage != "" {
+		content.WriteString(settings.OnboardingMessage + "\n")
+	}
+	content.WriteString("\n")
+	for n, text := range steps {
+		switch {
+		case n < step:
+			fmt.Fprintf(&content, "✅ ~~%d. %s~~\n", n+1, text)
+		case n == step:
+			fmt.Fprintf(&content, "➡️ **%d. %s**\n", n+1, text)
+		default:
+			fmt.Fprintf(&content, "⬜ %d. %s\n", n+1, text)
+		}
+	}
+	if step >= len(steps) {
+		content.WriteString("\n🎉 You're all set, enjoy your stay!")
+		return content.String(), []discordgo.MessageComponent{}
+	}
+
+	return content.String(), []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    fmt.Sprintf("Done with step %d", step+1),
+				Style:    discordgo.SuccessButton,
+				CustomID: fmt.Sprintf("onboarding:%s:%s:%d", guildID, userID, step),
+			},
+		}},
+	}
+}
+
+// guildMemberAdd cleans up the nickname of joining members and starts the onboarding
+// checklist, in servers that set them up
+func guildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.User == nil || m.User.Bot || isBlocked(m.User.ID, m.GuildID) {
+		return
+	}
+	decancerOnJoin(s, m)
+	settings := getGuildSettings(m.GuildID)
+	if !settings.Onboarding {
+		return
+	}
+
+	// Members who finished before and come back aren't asked again
+	onboardingMu.Lock()
+	if onboarding[m.GuildID] == nil {
+		onboarding[m.GuildID] = make(map[string]*OnboardingProgress)
+	}
+	progress := onboarding[m.GuildID][m.User.ID]
+	if progress != nil && !progress.CompletedAt.IsZero() {
+		onboardingMu.Unlock()
+		return
+	}
+	progress = &OnboardingProgress{StartedAt: time.Now()}
+	onboarding[m.GuildID][m.User.ID] = progress
+	saveOnboarding()
+	onboardingMu.Unlock()
+
+	content, components := onboardingMessage(m.GuildID, m.User.ID, 0)
+	msg := &discordgo.MessageSend{
+		Content:         content,
+		Components:      components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{m.User.ID}},
+	}
+
+	channelID := settings.OnboardingChannel
+	if channelID == "" {
+		dm, err := s.UserChannelCreate(m.User.ID)
+		if err != nil {
+			log.Printf("Error opening onboarding DM for %s: %v", m.User.ID, err)
+			return
+		}
+		channelID = dm.ID
+	}
+	if _, err := s.ChannelMessageSendComplex(channelID, msg); err != nil {
+		log.Printf("Error sending onboarding checklist to %s in guild %s: %v", m.User.ID, m.GuildID, err)
+	}
+}
+
+// handleOnboardingButton moves a member to the next checklist step, custom ID
+// onboarding:<guildID>:<userID>:<step>
+func handleOnboardingButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	parts := strings.Split(arg, ":")
+	if len(parts) != 3 {
+		return
+	}
+	guildID, userID := parts[0], parts[1]
+	step, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if interactionUserID(i) != userID {
+		respond("❌ This checklist belongs to someone else.")
+		return
+	}
+
+	total := len(onboardingSteps(getGuildSettings(guildID)))
+	onboardingMu.Lock()
+	progress := onboarding[guildID][userID]
+	if progress == nil {
+		onboardingMu.Unlock()
+		respond("❌ This checklist is no longer tracked.")
+		return
+	}
+	if progress.Step == step {
+		progress.Step++
+		if progress.Step >= total {
+			progress.CompletedAt = time.Now()
+		}
+		saveOnboarding()
+	}
+	current := progress.Step
+	onboardingMu.Unlock()
+
+	content, components := onboardingMessage(guildID, userID, current)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// onboardingSetting handles /settings onboarding and returns the reply
+func onboardingSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	var delivery string
+	changes := make(map[string]string)
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "delivery":
+			delivery = opt.StringValue()
+		case "channel", "rules_channel", "roles_channel", "intro_channel":
+			changes[opt.Name] = opt.ChannelValue(nil).ID
+		case "message":
+			changes["message"] = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.Onboarding = false
+		})
+		return "✅ New members no longer get the onboarding checklist. Their progress so far stays in `/onboarding`."
+	}
+	if delivery == "channel" && changes["channel"] == "" && getGuildSettings(guildID).OnboardingChannel == "" {
+		return "❌ Pick the `channel` the checklist is posted in."
+	}
+	if message, ok := changes["message"]; ok {
+		if len(message) > 500 {
+			return "❌ The welcome message can be at most 500 characters."
+		}
+		if _, ok := screenReplyResponse(guildID, message); !ok {
+			return profanityRejectedMessage
+		}
+	}
+
+	var settings GuildSettings
+	updateGuildSettings(guildID, func(current *GuildSettings) {
+		current.Onboarding = true
+		switch delivery {
+		case "dm":
+			current.OnboardingChannel = ""
+		case "channel":
+			if changes["channel"] != "" {
+				current.OnboardingChannel = changes["channel"]
+			}
+		}
+		for name, value := range changes {
+			switch name {
+			case "rules_channel":
+				current.RulesChannel = value
+			case "roles_channel":
+				current.RolesChannel = value
+			case "intro_channel":
+				current.IntroChannel = value
+			case "message":
+				current.OnboardingMessage = value
+			}
+		}
+		settings = *current
+	})
+
+	where := "by DM"
+	if settings.OnboardingChannel != "" {
+		where = "in <#" + settings.OnboardingChannel + ">"
+	}
+	content := fmt.Sprintf("✅ New members now get this checklist %s:\n", where)
+	for n, step := range onboardingSteps(&settings) {
+		content += fmt.Sprintf("%d. %s\n", n+1, step)
+	}
+	content += "-# The bot only sees members join when it runs with `SERVER_MEMBERS_INTENT` set and the intent enabled in the Developer Portal."
+	return content
+}
+
+// handleOnboardingCommand handles /onboarding, showing how far new members get
+func handleOnboardingCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to view onboarding progress.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	total := len(onboardingSteps(getGuildSettings(i.GuildID)))
+	stuck := make([]int, total)
+	var started, completed int
+	var durations []time.Duration
+
+	onboardingMu.Lock()
+	for _, progress := range onboarding[i.GuildID] {
+		started++
+		if !progress.CompletedAt.IsZero() {
+			completed++
+			durations = append(durations, progress.CompletedAt.Sub(progress.StartedAt))
+		} else if progress.Step < total {
+			stuck[progress.Step]++
+		}
+	}
+	onboardingMu.Unlock()
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🧭 Onboarding",
+		Color:       embedColor,
+		Description: "No members have started the checklist yet.",
+	}
+	if !getGuildSettings(i.GuildID).Onboarding {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "The checklist is off, turn it on with /settings onboarding"}
+	}
+	if started > 0 {
+		embed.Description = fmt.Sprintf("**%d** of **%d** members finished the checklist (%d%%).", completed, started, completed*100/started)
+		if len(durations) > 0 {
+			slices.Sort(durations)
+			embed.Description += fmt.Sprintf("\nMedian time to finish: **%s**", durations[len(durations)/2].Round(time.Second))
+		}
+		var lines []string
+		for n, step := range onboardingSteps(getGuildSettings(i.GuildID)) {
+			lines = append(lines, fmt.Sprintf("%d. %s: **%d**", n+1, truncate(step, 80), stuck[n]))
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Still on step", Value: strings.Join(lines, "\n")}}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// loadOnboarding loads onboarding progress from file
+func loadOnboarding() {
+	onboarding = make(ServerOnboarding)
+
+	if _, err := os.Stat(onboardFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(onboardFile)
+	if err != nil {
+		log.Printf("Error reading onboarding file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &onboarding); err != nil {
+		log.Printf("Error parsing onboarding file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded onboarding progress for %d servers", len(onboarding))
+}
+
+// saveOnboarding saves onboarding progress to file. The caller must hold onboardingMu.
+func saveOnboarding() {
+	data, err := json.MarshalIndent(onboarding, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling onboarding progress: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(onboardFile, data, 0644); err != nil {
+		log.Printf("Error saving onboarding progress: %v", err)
+		return
+	}
+}
+
+// defaultIntroEmoji is the reaction on introductions when the server didn't pick one
+const defaultIntroEmoji = "👋"
+
+// introField matches "Key: value" lines of an introduction, like "Hobbies: gaming"
+var introField = regexp.MustCompile(`^\s*[-*•]?\s*([\p{L}][\p{L}\p{N} /&]{0,30}?)\s*[:：]\s*(.+)$`)
+
+// handleIntroduction welcomes a member's post in the introductions channel with a reaction
+// and a thread, and keeps it as their /profile
+func handleIntroduction(s *discordgo.Session, m *discordgo.MessageCreate, settings *GuildSettings) {
+	name := m.Author.GlobalName
+	if m.Member != nil && m.Member.Nick != "" {
+		name = m.Member.Nick
+	}
+	if name == "" {
+		name = m.Author.Username
+	}
+
+	emoji := defaultIntroEmoji
+	if settings.IntroEmoji != "" {
+		emoji = settings.IntroEmoji
+	}
+	if parsed, err := parseReactions(emoji); err == nil {
+		if err := s.MessageReactionAdd(m.ChannelID, m.ID, parsed[0]); err != nil {
+			log.Printf("Error reacting to introduction %s: %v", m.ID, err)
+		}
+	}
+	if _, err := s.MessageThreadStart(m.ChannelID, m.ID, truncate("Welcome, "+name+"!", 100), threadAutoArchive); err != nil {
+		log.Printf("Error starting introduction thread for %s: %v", m.ID, err)
+	}
+
+	if strings.TrimSpace(m.Content) == "" {
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	if profiles[m.GuildID] == nil {
+		profiles[m.GuildID] = make(map[string]Profile)
+	}
+	profiles[m.GuildID][m.Author.ID] = Profile{
+		Intro:     truncate(m.Content, maxIntroLength),
+		ChannelID: m.ChannelID,
+		MessageID: m.ID,
+		PostedAt:  time.Now(),
+	}
+	saveProfiles()
+}
+
+// parseIntro splits an introduction into its "Key: value" lines and the rest of the text
+func parseIntro(text string) ([]*discordgo.MessageEmbedField, string) {
+	var fields []*discordgo.MessageEmbedField
+	var rest []string
+	for _, line := range strings.Split(text, "\n") {
+		if match := introField.FindStringSubmatch(line); match != nil && len(fields) < 10 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   upperFirst(strings.TrimSpace(match[1])),
+				Value:  truncate(strings.TrimSpace(match[2]), 1024),
+				Inline: true,
+			})
+			continue
+		}
+		rest = append(rest, line)
+	}
+	return fields, strings.TrimSpace(strings.Join(rest, "\n"))
+}
+
+// handleProfileCommand handles /profile, showing a member's card from their introduction
+func handleProfileCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Profiles only work in servers, not in DMs!")
+		return
+	}
+
+	user := i.Member.User
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		user = options[0].UserValue(s)
+	}
+
+	profilesMu.Lock()
+	profile, ok := profiles[i.GuildID][user.ID]
+	profilesMu.Unlock()
+	if !ok {
+		where := "the introductions channel"
+		if channelID := getGuildSettings(i.GuildID).IntroChannel; channelID != "" {
+			where = "<#" + channelID + ">"
+		}
+		respond(fmt.Sprintf("🔍 <@%s> hasn't introduced themselves in %s yet.", user.ID, where))
+		return
+	}
+
+	fields, text := parseIntro(profile.Intro)
+	name := user.GlobalName
+	if name == "" {
+		name = user.Username
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "👤 " + name,
+		URL:         fmt.Sprintf("https://discord.com/channels/%s/%s/%s", i.GuildID, profile.ChannelID, profile.MessageID),
+		Description: truncate(text, 2000),
+		Color:       embedColor,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")},
+		Fields:      fields,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Introduced on " + profile.PostedAt.Format("2 Jan 2006")},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// introductionsSetting handles /settings introductions and returns the reply
+func introductionsSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	var channelID, emoji string
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "emoji":
+			emoji = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.IntroThreads = false
+		})
+		return "✅ Introductions no longer get a welcome thread. Saved profiles stay available in `/profile`."
+	}
+	if channelID == "" {
+		channelID = getGuildSettings(guildID).IntroChannel
+	}
+	if channelID == "" {
+		return "❌ Pick the introductions channel."
+	}
+	if emoji != "" {
+		if parsed, err := parseReactions(emoji); err != nil {
+			return userErrorMessage(err)
+		} else if len(parsed) > 1 {
+			return "❌ Pick a single emoji."
+		}
+	}
+
+	updateGuildSettings(guildID, func(settings *GuildSettings) {
+		settings.IntroThreads = true
+		settings.IntroChannel = channelID
+		if emoji != "" {
+			settings.IntroEmoji = emoji
+		}
+	})
+	return fmt.Sprintf("✅ Introductions in <#%s> now get a reaction and a welcome thread, and show up in `/profile`.", channelID)
+}
+
+// loadProfiles loads members' introductions from file
+func loadProfiles() {
+	profiles = make(ServerProfiles)
+
+	if _, err := os.Stat(profilesFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(profilesFile)
+	if err != nil {
+		log.Printf("Error reading profiles file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		log.Printf("Error parsing profiles file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded profiles for %d servers", len(profiles))
+}
+
+// saveProfiles saves members' introductions to file. The caller must hold profilesMu.
+func saveProfiles() {
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling profiles: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(profilesFile, data, 0644); err != nil {
+		log.Printf("Error saving profiles: %v", err)
+		return
+	}
+}
+
+// Nickname clean-up by /decancer
+const (
+	decancerFallback  = "Member" // nickname when nothing readable is left of the name
+	maxCombiningMarks = 2        // marks kept on one letter, more is zalgo
+)
+
+// decancerLetters maps look-alike letters that aren't in a contiguous block to ASCII
+var decancerLetters = map[rune]rune{
+	'ᴀ': 'a', 'ʙ': 'b', 'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ꜰ': 'f', 'ɢ': 'g', 'ʜ': 'h', 'ɪ': 'i',
+	'ᴊ': 'j', 'ᴋ': 'k', 'ʟ': 'l', 'ᴍ': 'm', 'ɴ': 'n', 'ᴏ': 'o', 'ᴘ': 'p', 'ǫ': 'q', 'ʀ': 'r',
+	'ꜱ': 's', 'ᴛ': 't', 'ᴜ': 'u', 'ᴠ': 'v', 'ᴡ': 'w', 'ʏ': 'y', 'ᴢ': 'z',
+	'ℂ': 'C', 'ℍ': 'H', 'ℕ': 'N', 'ℙ': 'P', 'ℚ': 'Q', 'ℝ': 'R', 'ℤ': 'Z', 'ℬ': 'B', 'ℰ': 'E',
+	'ℱ': 'F', 'ℋ': 'H', 'ℐ': 'I', 'ℒ': 'L', 'ℳ': 'M', 'ℛ': 'R', 'ℭ': 'C', 'ℌ': 'H', 'ℑ': 'I',
+	'ℜ': 'R', 'ℨ': 'Z', 'ℯ': 'e', 'ℊ': 'g', 'ℴ': 'o', 'ℎ': 'h', 'ℓ': 'l',
+}
+
+// decancerRune maps a styled letter or digit, like 𝓐, Ａ, Ⓐ or 🅰, to plain ASCII, or
+// returns it unchanged
+func decancerRune(r rune) rune {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E: // fullwidth
+		return r - 0xFEE0
+	case r >= 0x1D400 && r <= 0x1D6A3: // mathematical bold, italic, script, fraktur...
+		n := (r - 0x1D400) % 52
+		if n < 26 {
+			return 'A' + n
+		}
+		return 'a' + n - 26
+	case r >= 0x1D7CE && r <= 0x1D7FF: // mathematical digits
+		return '0' + (r-0x1D7CE)%10
+	case r >= 0x24B6 && r <= 0x24CF: // circled capitals
+		return 'A' + r - 0x24B6
+	case r >= 0x24D0 && r <= 0x24E9: // circled small letters
+		return 'a' + r - 0x24D0
+	case r >= 0x249C && r <= 0x24B5: // parenthesized small letters
+		return 'a' + r - 0x249C
+	case r >= 0x1F130 && r <= 0x1F189 && (r-0x1F130)%32 < 26: // squared, negative circled and negative squared capitals
+		return 'A' + (r-0x1F130)%32
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators
+		return 'A' + r - 0x1F1E6
+	}
+	if plain, ok := decancerLetters[r]; ok {
+		return plain
+	}
+	return r
+}
+
+// decancer makes a nickname readable and stops it hoisting to the top of the member list:
+// styled letters become ASCII, zalgo marks and invisible characters go, and leading
+// symbols are trimmed. It returns "" when nothing readable is left.
+func decancer(name string) string {
+	var b strings.Builder
+	var base rune
+	marks := 0
+	for _, r := range name {
+		r = decancerRune(r)
+		switch {
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+			// Scripts like Thai and Devanagari need a mark or two, zalgo stacks them on
+			// Latin letters
+			if marks++; marks <= maxCombiningMarks && !unicode.Is(unicode.Latin, base) {
+				b.WriteRune(r)
+			}
+			continue
+		case unicode.Is(unicode.Cf, r) || unicode.IsControl(r):
+			continue
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+		base, marks = r, 0
+	}
+	cleaned := strings.Join(strings.Fields(b.String()), " ")
+	cleaned = strings.TrimLeftFunc(cleaned, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	if utf8.RuneCountInString(cleaned) < 2 {
+		return ""
+	}
+	return truncate(cleaned, 32)
+}
+
+// memberDisplayName is the name a member shows up as in the server
+func memberDisplayName(member *discordgo.Member) string {
+	switch {
+	case member.Nick != "":
+		return member.Nick
+	case member.User.GlobalName != "":
+		return member.User.GlobalName
+	default:
+		return member.User.Username
+	}
+}
+
+// decancerMember picks a readable nickname for a member, falling back to their username
+// and then to a placeholder. It returns "" when their name is already fine.
+func decancerMember(member *discordgo.Member) string {
+	current := memberDisplayName(member)
+	nick := decancer(current)
+	if nick == "" {
+		nick = decancer(member.User.Username)
+	}
+	if nick == "" {
+		nick = decancerFallback
+	}
+	if nick == current {
+		return ""
+	}
+	return nick
+}
+
+// decancerOnJoin fixes the nickname of a joining member in servers with /settings decancer
+// on, and records the change in the audit trail
+func decancerOnJoin(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if !getGuildSettings(m.GuildID).Decancer {
+		return
+	}
+	nick := decancerMember(m.Member)
+	if nick == "" {
+		return
+	}
+
+	old := memberDisplayName(m.Member)
+	if err := s.GuildMemberNickname(m.GuildID, m.User.ID, nick); err != nil {
+		log.Printf("Error decancering %s in guild %s: %v", m.User.ID, m.GuildID, err)
+		return
+	}
+	appendAudit(m.GuildID, AuditEntry{
+		Time:    time.Now(),
+		UserID:  s.State.User.ID,
+		User:    s.State.User.Username,
+		Command: "decancer on join",
+		Options: "user=" + m.User.ID,
+		Result:  truncate(fmt.Sprintf("%q → %q", old, nick), 150),
+	})
+}
+
+// handleDecancerCommand handles /decancer, giving a member a readable nickname
+func handleDecancerCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ This command only works in servers, not in DMs!")
+		return
+	}
+	if i.Member == nil || i.Member.Permissions&(discordgo.PermissionManageNicknames|discordgo.PermissionAdministrator) == 0 {
+		respond("❌ You need the Manage Nicknames permission to change members' names.")
+		return
+	}
+
+	user := i.ApplicationCommandData().Options[0].UserValue(s)
+	member, err := s.GuildMember(i.GuildID, user.ID)
+	if err != nil {
+		respond(fmt.Sprintf("🔍 <@%s> isn't in this server.", user.ID))
+		return
+	}
+
+	nick := decancerMember(member)
+	if nick == "" {
+		respond(fmt.Sprintf("✅ <@%s>'s name `%s` is already readable.", user.ID, memberDisplayName(member)))
+		return
+	}
+	old := memberDisplayName(member)
+	if err := s.GuildMemberNickname(i.GuildID, user.ID, nick); err != nil {
+		log.Printf("Error decancering %s in guild %s: %v", user.ID, i.GuildID, err)
+		respond("❌ Couldn't change that nickname. The bot needs Manage Nicknames and a role above the member's.")
+		return
+	}
+	respond(fmt.Sprintf("✅ Renamed <@%s> from `%s` to `%s`.", user.ID, old, nick))
+}
+
+// weekdayNames are the days /remind understands, in English and Indonesian
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "minggu": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "senin": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "selasa": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "rabu": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "kamis": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "jumat": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "sabtu": time.Saturday,
+}
+
+// timeOfDay matches "9am", "9:30 pm", "09:00" or "21.30"
+var timeOfDay = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)?$`)
+
+// dayOfMonth matches "1st", "15th" or "31"
+var dayOfMonth = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+
+// remindWhenHelp is the error detail for a /remind time the bot doesn't understand
+const remindWhenHelp = "use a time like `in 30m`, `tomorrow 9am`, `2026-11-01 08:00`, `every monday 9am` or `every 1st of month 8am`"
+
+// parseTimeOfDay reads a time of day into minutes after midnight
+func parseTimeOfDay(value string) (int, bool) {
+	match := timeOfDay.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// splitTimeOfDay separates a trailing "at 9am" from the rest of a /remind time, or
+// returns 9:00 when there is none
+func splitTimeOfDay(value string) (string, int, bool) {
+	fields := strings.Fields(value)
+	for n := len(fields); n > 0 && n >= len(fields)-2; n-- {
+		if minutes, ok := parseTimeOfDay(strings.Join(fields[n-1:], " ")); ok {
+			rest := strings.TrimSuffix(strings.TrimSpace(strings.Join(fields[:n-1], " ")), " at")
+			return strings.TrimSpace(strings.TrimPrefix(rest, "at")), minutes, true
+		}
+	}
+	return value, 9 * 60, false
+}
+
+// parseRecurrence reads what follows "every": "day", "weekday", a day name, or a day of
+// the month like "1st of month", each with an optional time of day
+func parseRecurrence(value string) (*Recurrence, error) {
+	rest, minute, _ := splitTimeOfDay(value)
+	rec := &Recurrence{Minute: minute}
+
+	rest = strings.TrimSuffix(strings.TrimSuffix(rest, " month"), " of the")
+	rest = strings.TrimSuffix(rest, " of")
+	if weekday, ok := weekdayNames[rest]; ok {
+		rec.Unit, rec.Weekday = "week", weekday
+		return rec, nil
+	}
+	switch rest {
+	case "day", "hari":
+		rec.Unit = "day"
+		return rec, nil
+	case "weekday", "workday":
+		rec.Unit = "weekday"
+		return rec, nil
+	}
+	if match := dayOfMonth.FindStringSubmatch(rest); match != nil && strings.Contains(value, "month") {
+		day, _ := strconv.Atoi(match[1])
+		if day >= 1 && day <= 31 {
+			rec.Unit, rec.Day = "month", day
+			return rec, nil
+		}
+	}
+	return nil, invalidInput(remindWhenHelp)
+}
+
+// next returns the first time after t the recurrence falls on, in loc
+func (rec *Recurrence) next(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	for days := 0; days <= 62; days++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+days, 0, rec.Minute, 0, 0, loc)
+		if !day.After(after) {
+			continue
+		}
+		switch rec.Unit {
+		case "weekday":
+			if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+				continue
+			}
+		case "week":
+			if day.Weekday() != rec.Weekday {
+				continue
+			}
+		case "month":
+			// The 31st falls on the last day of shorter months
+			last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, loc).Day()
+			if day.Day() != min(rec.Day, last) {
+				continue
+			}
+		}
+		return day
+	}
+	return after.Add(24 * time.Hour)
+}
+
+// describe says when a recurrence repeats, for /remind list
+func (rec *Recurrence) describe() string {
+	at := fmt.Sprintf("%02d:%02d", rec.Minute/60, rec.Minute%60)
+	switch rec.Unit {
+	case "weekday":
+		return "every weekday at " + at
+	case "week":
+		return fmt.Sprintf("every %s at %s", rec.Weekday, at)
+	case "month":
+		return fmt.Sprintf("on day %d of every month at %s", rec.Day, at)
+	default:
+		return "every day at " + at
+	}
+}
+
+// parseRemindWhen reads a /remind time in the member's timezone: "in 30m", "tomorrow 9am",
+// "9pm", "2026-11-01 08:00" or a recurrence starting with "every"
+func parseRemindWhen(value string, loc *time.Location, now time.Time) (time.Time, *Recurrence, error) {
+	value = strings.Join(strings.Fields(strings.ToLower(value)), " ")
+
+	if rest, ok := strings.CutPrefix(value, "every "); ok {
+		rec, err := parseRecurrence(rest)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		return rec.next(now, loc), rec, nil
+	}
+	if rest, ok := strings.CutPrefix(value, "in "); ok {
+		d, err := parseSince(strings.ReplaceAll(rest, " ", ""))
+		if err != nil {
+			return time.Time{}, nil, invalidInput(remindWhenHelp)
+		}
+		return now.Add(d), nil, nil
+	}
+	if t, err := time.ParseInLocation(scheduleTimeLayout, value, loc); err == nil {
+		if !t.After(now) {
+			return time.Time{}, nil, invalidInput("that time has already passed")
+		}
+		return t, nil, nil
+	}
+
+	// "tomorrow 9am", or a time of day that is today or else tomorrow
+	rest, minute, hasTime := splitTimeOfDay(value)
+	local := now.In(loc)
+	switch {
+	case rest == "tomorrow" || rest == "besok":
+		return time.Date(local.Year(), local.Month(), local.Day()+1, 0, minute, 0, 0, loc), nil, nil
+	case rest == "" && hasTime:
+		t := time.Date(local.Year(), local.Month(), local.Day(), 0, minute, 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil, nil
+	}
+	return time.Time{}, nil, invalidInput(remindWhenHelp)
+}
+
+// reminderLocation is the timezone a member's reminders use: their own from /remind
+// timezone, else the server's. The caller must hold remindersMu.
+func reminderLocation(userID, guildID string) *time.Location {
+	if name := reminders.Timezones[userID]; name != "" {
+		if loc, err := parseTimezone(name); err == nil {
+			return loc
+		}
+	}
+	if guildID != "" {
+		return getGuildSettings(guildID).location()
+	}
+	return botLocation
+}
+
+// handleRemindCommand handles /remind me|list|cancel|timezone
+func handleRemindCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	userID := interactionUserID(i)
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "me":
+		respond(addReminder(i, userID, subcommand.Options))
+	case "list":
+		respond(listReminders(userID))
+	case "cancel":
+		id := int(subcommand.Options[0].IntValue())
+		remindersMu.Lock()
+		kept := slices.DeleteFunc(reminders.Reminders, func(r Reminder) bool { return r.ID == id && r.UserID == userID && r.waiting() })
+		removed := len(kept) < len(reminders.Reminders)
+		reminders.Reminders = kept
+		if removed {
+			saveReminders()
+		}
+		remindersMu.Unlock()
+		if !removed {
+			respond(fmt.Sprintf("❌ You have no reminder #%d. See yours with `/remind list`.", id))
+			return
+		}
+		respond(fmt.Sprintf("✅ Reminder #%d cancelled.", id))
+	case "timezone":
+		name := subcommand.Options[0].StringValue()
+		loc, err := parseTimezone(name)
+		if err != nil {
+			respond("❌ " + err.Error())
+			return
+		}
+		remindersMu.Lock()
+		reminders.Timezones[userID] = strings.TrimSpace(name)
+		saveReminders()
+		remindersMu.Unlock()
+		respond(fmt.Sprintf("✅ Your reminders now use **%s** (currently %s). Repeating reminders keep their local time.", loc.String(), time.Now().In(loc).Format("15:04")))
+	}
+}
+
+// addReminder handles /remind me and returns the reply
+func addReminder(i *discordgo.InteractionCreate, userID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var when, message string
+	for _, opt := range options {
+		switch opt.Name {
+		case "when":
+			when = opt.StringValue()
+		case "message":
+			message = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	remindersMu.Lock()
+	defer remindersMu.Unlock()
+
+	loc := reminderLocation(userID, i.GuildID)
+	nextAt, every, err := parseRemindWhen(when, loc, time.Now())
+	if err != nil {
+		return userErrorMessage(err)
+	}
+	count := 0
+	for _, r := range reminders.Reminders {
+		if r.UserID == userID && r.waiting() {
+			count++
+		}
+	}
+	if count >= maxRemindersPerUser {
+		return fmt.Sprintf("❌ You can have at most %d reminders. Cancel one with `/remind cancel`.", maxRemindersPerUser)
+	}
+
+	reminders.NextID++
+	reminder := Reminder{
+		ID:        reminders.NextID,
+		UserID:    userID,
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Message:   message,
+		NextAt:    nextAt,
+		Every:     every,
+		CreatedAt: time.Now(),
+	}
+	reminders.Reminders = append(reminders.Reminders, reminder)
+	saveReminders()
+
+	content := fmt.Sprintf("⏰ Reminder #%d set for <t:%d:F> (<t:%d:R>)", reminder.ID, nextAt.Unix(), nextAt.Unix())
+	if every != nil {
+		content = fmt.Sprintf("🔁 Reminder #%d repeats %s (%s), first on <t:%d:F>", reminder.ID, every.describe(), loc.String(), nextAt.Unix())
+	}
+	return content + ":\n> " + message
+}
+
+// listReminders is the /remind list reply
+func listReminders(userID string) string {
+	remindersMu.Lock()
+	defer remindersMu.Unlock()
+
+	var lines []string
+	for _, r := range reminders.Reminders {
+		if r.UserID != userID || !r.waiting() {
+			continue
+		}
+		line := fmt.Sprintf("**#%d** <t:%d:f>", r.ID, r.NextAt.Unix())
+		if r.Every != nil {
+			line += " 🔁 " + r.Every.describe()
+		}
+		lines = append(lines, line+" · "+truncate(r.Message, 80))
+	}
+	if len(lines) == 0 {
+		return "📭 You have no reminders. Set one with `/remind me`."
+	}
+	zone := reminderLocation(userID, "").String()
+	if reminders.Timezones[userID] == "" {
+		zone = "the server's timezone"
+	}
+	return truncate(fmt.Sprintf("⏰ **Your reminders** (repeating ones follow %s):\n%s", zone, strings.Join(lines, "\n")), 2000)
+}
+
+// deliverReminders sends the reminders that are due and moves repeating ones to their
+// next time. Sent one-time reminders are kept until they can no longer be snoozed.
+// Called from runScheduler.
+func deliverReminders(s *discordgo.Session, now time.Time) {
+	remindersMu.Lock()
+	var due []Reminder
+	kept := reminders.Reminders[:0]
+	changed := false
+	for _, r := range reminders.Reminders {
+		if !r.waiting() {
+			if now.Sub(r.DeliveredAt) < reminderSnoozeTime {
+				kept = append(kept, r)
+			} else {
+				changed = true
+			}
+			continue
+		}
+		if r.NextAt.After(now) {
+			kept = append(kept, r)
+			continue
+		}
+		// Left due until it's sent, so a failed send is tried again on the next tick
+		due = append(due, r)
+		kept = append(kept, r)
+	}
+	reminders.Reminders = kept
+	if changed {
+		saveReminders()
+	}
+	remindersMu.Unlock()
+
+	for _, r := range due {
+		content := fmt.Sprintf("⏰ <@%s>, reminder: %s", r.UserID, r.Message)
+		_, err := queueBackgroundSend(r.ChannelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendComplex(r.ChannelID, &discordgo.MessageSend{
+				Content:         truncate(content, 1900),
+				Components:      reminderComponents(r.ID),
+				AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{r.UserID}},
+			})
+		})
+		if err != nil {
+			log.Printf("Error sending reminder %d to channel %s: %v", r.ID, r.ChannelID, err)
+			if now.Sub(r.NextAt) < reminderRetryWindow {
+				continue
+			}
+			log.Printf("Giving up on reminder %d after failing for %s", r.ID, reminderRetryWindow)
+		}
+		markReminderSent(r.ID, now)
+	}
+}
+
+// markReminderSent moves a repeating reminder to its next time, or marks a one-time
+// reminder delivered
+func markReminderSent(id int, now time.Time) {
+	remindersMu.Lock()
+	defer remindersMu.Unlock()
+
+	for n := range reminders.Reminders {
+		r := &reminders.Reminders[n]
+		if r.ID != id || !r.waiting() {
+			continue
+		}
+		if r.Every != nil {
+			r.NextAt = r.Every.next(now, reminderLocation(r.UserID, r.GuildID))
+		} else {
+			r.DeliveredAt = now
+		}
+		saveReminders()
+		return
+	}
+}
+
+// reminderSnoozes are the snooze buttons on a delivered reminder
+var reminderSnoozes = []string{"10m", "1h", "1d"}
+
+// reminderComponents are the Snooze and Done buttons under a delivered reminder
+func reminderComponents(id int) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	for _, snooze := range reminderSnoozes {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Snooze " + snooze,
+			Style:    discordgo.SecondaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: "💤"},
+			CustomID: fmt.Sprintf("remind_snooze:%d:%s", id, snooze),
+		})
+	}
+	buttons = append(buttons, discordgo.Button{
+		Label:    "Done",
+		Style:    discordgo.SuccessButton,
+		Emoji:    &discordgo.ComponentEmoji{Name: "✅"},
+		CustomID: fmt.Sprintf("remind_done:%d", id),
+	})
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// handleReminderButton handles the Snooze and Done buttons of a delivered reminder. A
+// one-time reminder is moved or removed; snoozing a repeating one adds a one-time copy.
+func handleReminderButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string, snooze bool) {
+	idText, snoozeText, _ := strings.Cut(arg, ":")
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return
+	}
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	var delay time.Duration
+	if snooze {
+		if !slices.Contains(reminderSnoozes, snoozeText) {
+			return
+		}
+		delay, _ = parseSince(snoozeText)
+	}
+
+	userID := interactionUserID(i)
+	now := time.Now()
+	remindersMu.Lock()
+	index := slices.IndexFunc(reminders.Reminders, func(r Reminder) bool { return r.ID == id })
+	if index < 0 {
+		remindersMu.Unlock()
+		respond("❌ This reminder is no longer around. Set it again with `/remind me`.")
+		return
+	}
+	reminder := reminders.Reminders[index]
+	if reminder.UserID != userID {
+		remindersMu.Unlock()
+		respond("❌ This reminder belongs to someone else.")
+		return
+	}
+
+	var status string
+	switch {
+	case !snooze:
+		if reminder.Every == nil {
+			reminders.Reminders = slices.Delete(reminders.Reminders, index, index+1)
+		}
+		status = "✅ Done"
+	case reminder.Every == nil:
+		reminders.Reminders[index].NextAt = now.Add(delay)
+		reminders.Reminders[index].DeliveredAt = time.Time{}
+		status = fmt.Sprintf("💤 Snoozed until <t:%d:t>", now.Add(delay).Unix())
+	default:
+		reminders.NextID++
+		reminders.Reminders = append(reminders.Reminders, Reminder{
+			ID:        reminders.NextID,
+			UserID:    reminder.UserID,
+			GuildID:   reminder.GuildID,
+			ChannelID: reminder.ChannelID,
+			Message:   reminder.Message,
+			NextAt:    now.Add(delay),
+			CreatedAt: now,
+		})
+		status = fmt.Sprintf("💤 Snoozed until <t:%d:t>, the series carries on as usual", now.Add(delay).Unix())
+	}
+	saveReminders()
+	remindersMu.Unlock()
+
+	content := status
+	if i.Message != nil {
+		content = i.Message.Content + "\n" + status
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         truncate(content, 2000),
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// loadReminders loads reminders and members' timezones from file
+func loadReminders() {
+	reminders = ReminderStore{Timezones: make(map[string]string)}
+
+	if _, err := os.Stat(remindersFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(remindersFile)
+	if err != nil {
+		log.Printf("Error reading reminders file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &reminders); err != nil {
+		log.Printf("Error parsing reminders file: %v", err)
+		return
+	}
+	if reminders.Timezones == nil {
+		reminders.Timezones = make(map[string]string)
+	}
+
+	log.Printf("Loaded %d reminders", len(reminders.Reminders))
+}
+
+// saveReminders saves reminders to file. The caller must hold remindersMu.
+func saveReminders() {
+	data, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling reminders: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(remindersFile, data, 0644); err != nil {
+		log.Printf("Error saving reminders: %v", err)
+		return
+	}
+}
+
+// handleTaskCommand handles /task: a small shared task list per channel, shown on a board
+// message the bot keeps up to date
+func handleTaskCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Task lists only work in servers.")
+		return
+	}
+
+	userID := interactionUserID(i)
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "add":
+		var title, assigneeID, due string
+		for _, opt := range subcommand.Options {
+			switch opt.Name {
+			case "title":
+				title = strings.TrimSpace(opt.StringValue())
+			case "assignee":
+				assigneeID = opt.UserValue(nil).ID
+			case "due":
+				due = opt.StringValue()
+			}
+		}
+		if title == "" {
+			respond("❌ Give the task a title.")
+			return
+		}
+		task := Task{Title: title, AssigneeID: assigneeID, CreatedBy: userID, CreatedAt: time.Now()}
+		if due != "" {
+			remindersMu.Lock()
+			loc := reminderLocation(userID, i.GuildID)
+			remindersMu.Unlock()
+			at, every, err := parseRemindWhen(due, loc, time.Now())
+			if err != nil {
+				respond(userErrorMessage(err))
+				return
+			}
+			if every != nil {
+				respond("❌ A task is due once. For something that repeats, use `/remind me`.")
+				return
+			}
+			task.Due = at
+		}
+
+		tasksMu.Lock()
+		list := channelTasks(i.GuildID, i.ChannelID)
+		if list.open() >= maxTasksPerChannel {
+			tasksMu.Unlock()
+			respond(fmt.Sprintf("❌ This channel already has %d open tasks. Finish some with `/task done` first.", maxTasksPerChannel))
+			return
+		}
+		list.NextID++
+		task.ID = list.NextID
+		list.Tasks = append(list.Tasks, task)
+		saveTasks()
+		tasksMu.Unlock()
+
+		content := fmt.Sprintf("✅ Added task **#%d**: %s", task.ID, title)
+		if assigneeID != "" {
+			content += fmt.Sprintf(", assigned to <@%s>", assigneeID)
+		}
+		if !task.Due.IsZero() {
+			content += fmt.Sprintf(", due <t:%d:f>", task.Due.Unix())
+		}
+		respond(content + ". " + refreshTaskBoard(s, i.GuildID, i.ChannelID, false))
+	case "list":
+		respond("📋 " + refreshTaskBoard(s, i.GuildID, i.ChannelID, true))
+	case "done":
+		id := int(subcommand.Options[0].IntValue())
+		tasksMu.Lock()
+		list := channelTasks(i.GuildID, i.ChannelID)
+		index := slices.IndexFunc(list.Tasks, func(t Task) bool { return t.ID == id && t.DoneAt.IsZero() })
+		if index < 0 {
+			tasksMu.Unlock()
+			respond(fmt.Sprintf("❌ There is no open task #%d in this channel. See them with `/task list`.", id))
+			return
+		}
+		list.Tasks[index].DoneAt = time.Now()
+		list.Tasks[index].DoneBy = userID
+		title := list.Tasks[index].Title
+		saveTasks()
+		tasksMu.Unlock()
+		respond(fmt.Sprintf("✅ Task **#%d** done: %s. %s", id, title, refreshTaskBoard(s, i.GuildID, i.ChannelID, false)))
+	}
+}
+
+// channelTasks returns a channel's task list, creating it when needed. The caller must
+// hold tasksMu.
+func channelTasks(guildID, channelID string) *TaskList {
+	if tasks[guildID] == nil {
+		tasks[guildID] = make(map[string]*TaskList)
+	}
+	if tasks[guildID][channelID] == nil {
+		tasks[guildID][channelID] = &TaskList{}
+	}
+	return tasks[guildID][channelID]
+}
+
+// open counts the tasks still to do
+func (list *TaskList) open() int {
+	count := 0
+	for _, t := range list.Tasks {
+		if t.DoneAt.IsZero() {
+			count++
+		}
+	}
+	return count
+}
+
+// taskBoardEmbed renders a channel's task list: open tasks first, then the recently done
+// ones. The caller must hold tasksMu.
+func taskBoardEmbed(list *TaskList, now time.Time) *discordgo.MessageEmbed {
+	var open, done []string
+	for _, t := range list.Tasks {
+		if !t.DoneAt.IsZero() {
+			done = append(done, fmt.Sprintf("~~#%d %s~~ · <@%s>", t.ID, truncate(t.Title, 60), t.DoneBy))
+			continue
+		}
+		line := fmt.Sprintf("**#%d** %s", t.ID, truncate(t.Title, 100))
+		if t.AssigneeID != "" {
+			line += fmt.Sprintf(" · <@%s>", t.AssigneeID)
+		}
+		if !t.Due.IsZero() {
+			mark := "📅"
+			if t.Due.Before(now) {
+				mark = "⚠️"
+			}
+			line += fmt.Sprintf(" · %s <t:%d:R>", mark, t.Due.Unix())
+		}
+		open = append(open, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📋 Tasks",
+		Description: truncate(strings.Join(open, "\n"), 4000),
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d open · /task add · /task done", len(open))},
+		Timestamp:   now.Format(time.RFC3339),
+	}
+	if len(open) == 0 {
+		embed.Description = "Nothing to do. Add a task with `/task add`."
+	}
+	if len(done) > 0 {
+		// The most recently finished ones, newest last like the list itself
+		done = done[max(0, len(done)-taskBoardDone):]
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "✅ Recently done", Value: truncate(strings.Join(done, "\n"), 1024)}}
+	}
+	return embed
+}
+
+// refreshTaskBoard edits a channel's task board to match its list and returns a short
+// note for the reply. With repost, or when the old board is gone, a new board is posted
+// and the old one deleted so the board sits at the bottom of the channel.
+func refreshTaskBoard(s *discordgo.Session, guildID, channelID string, repost bool) string {
+	tasksMu.Lock()
+	list := channelTasks(guildID, channelID)
+	embed := taskBoardEmbed(list, time.Now())
+	boardID := list.BoardID
+	tasksMu.Unlock()
+
+	if boardID != "" && !repost {
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:      boardID,
+			Channel: channelID,
+			Embeds:  &[]*discordgo.MessageEmbed{embed},
+		})
+		if err == nil {
+			return "The board is updated."
+		}
+		log.Printf("Error updating task board in channel %s: %v", channelID, err)
+	}
+	if boardID == "" && !repost {
+		return "Show the board with `/task list`."
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("Error posting task board in channel %s: %v", channelID, err)
+		return "I could not post the board here, check my permissions."
+	}
+	if boardID != "" {
+		s.ChannelMessageDelete(channelID, boardID)
+	}
+	tasksMu.Lock()
+	channelTasks(guildID, channelID).BoardID = msg.ID
+	saveTasks()
+	tasksMu.Unlock()
+	return "The board is posted below and updates as tasks change."
+}
+
+// remindDueTasks pings the assignee, or whoever added it, once a task is past due, and
+// drops tasks that were done more than taskDoneKeep ago. Called from runScheduler.
+func remindDueTasks(s *discordgo.Session, now time.Time) {
+	type dueTask struct {
+		guildID   string
+		channelID string
+		task      Task
+	}
+	var due []dueTask
+	tasksMu.Lock()
+	changed := false
+	for guildID, channels := range tasks {
+		for channelID, list := range channels {
+			kept := list.Tasks[:0]
+			for _, t := range list.Tasks {
+				if !t.DoneAt.IsZero() && now.Sub(t.DoneAt) > taskDoneKeep {
+					changed = true
+					continue
+				}
+				// Left unreminded until the ping is sent, so a failed send is tried again
+				if t.DoneAt.IsZero() && !t.Due.IsZero() && !t.Reminded && !t.Due.After(now) {
+					due = append(due, dueTask{guildID, channelID, t})
+				}
+				kept = append(kept, t)
+			}
+			list.Tasks = kept
+		}
+	}
+	if changed {
+		saveTasks()
+	}
+	tasksMu.Unlock()
+
+	for _, d := range due {
+		userID := d.task.AssigneeID
+		if userID == "" {
+			userID = d.task.CreatedBy
+		}
+		content := fmt.Sprintf("⏰ <@%s>, task **#%d** is due: %s\nMark it with `/task done id:%d`.", userID, d.task.ID, d.task.Title, d.task.ID)
+		channelID := d.channelID
+		_, err := queueBackgroundSend(channelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+				Content:         truncate(content, 2000),
+				AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+			})
+		})
+		if err != nil {
+			log.Printf("Error sending task reminder to channel %s: %v", channelID, err)
+			if now.Sub(d.task.Due) < taskReminderRetry {
+				continue
+			}
+			log.Printf("Giving up on reminder for task %d after failing for %s", d.task.ID, taskReminderRetry)
+		}
+		markTaskReminded(d.guildID, channelID, d.task.ID)
+	}
+}
+
+// markTaskReminded records that a task's due reminder went out
+func markTaskReminded(guildID, channelID string, id int) {
+	tasksMu.Lock()
+	defer tasksMu.Unlock()
+
+	list := tasks[guildID][channelID]
+	if list == nil {
+		return
+	}
+	for n := range list.Tasks {
+		if list.Tasks[n].ID == id {
+			list.Tasks[n].Reminded = true
+			saveTasks()
+			return
+		}
+	}
+}
+
+// loadTasks loads channels' task lists from file
+func loadTasks() {
+	tasks = make(ServerTasks)
+
+	if _, err := os.Stat(tasksFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(tasksFile)
+	if err != nil {
+		log.Printf("Error reading tasks file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		log.Printf("Error parsing tasks file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded task lists for %d servers", len(tasks))
+}
+
+// saveTasks saves channels' task lists to file. The caller must hold tasksMu.
+func saveTasks() {
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling tasks: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(tasksFile, data, 0644); err != nil {
+		log.Printf("Error saving tasks: %v", err)
+		return
+	}
+}
+
+// handleAutocomplete answers Discord's autocomplete requests while a member types an option
+func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	switch data.Name {
+	case "reply_edit":
+		for _, opt := range data.Options {
+			if opt.Name == "trigger" && opt.Focused {
+				choices = replyTriggerChoices(i.GuildID, interactionUserID(i), opt.StringValue(), false)
+			}
+		}
+	case "reply":
+		// New triggers are typed freely, existing ones are offered once mode is remove or append
+		var mode string
+		var trigger *discordgo.ApplicationCommandInteractionDataOption
+		for _, opt := range data.Options {
+			switch {
+			case opt.Name == "mode":
+				mode = opt.StringValue()
+			case opt.Name == "trigger" && opt.Focused:
+				trigger = opt
+			}
+		}
+		if trigger != nil && (mode == "remove" || mode == "append") {
+			// Moderators can remove anyone's rule, so they're offered every trigger
+			everyone := mode == "remove" && hasManageGuild(i)
+			choices = replyTriggerChoices(i.GuildID, interactionUserID(i), trigger.StringValue(), everyone)
+		}
+	case "meme":
+		for _, opt := range data.Options[0].Options {
+			if opt.Name == "template" && opt.Focused {
+				choices = memeTemplateChoices(i.GuildID, opt.StringValue())
+			}
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// replyTriggerChoices suggests the triggers of a member's own rules that contain typed,
+// the ones starting with it first. Only your own rules can be edited, so only those are
+// offered unless everyone is set.
+func replyTriggerChoices(guildID, userID, typed string, everyone bool) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+
+	repliesMu.Lock()
+	var prefixed, contained []AutoReply
+	for _, reply := range serverAutoReplies[guildID] {
+		trigger := strings.ToLower(reply.Trigger)
+		// Choice values are limited to 100 characters
+		if (reply.AuthorID != userID && !everyone) || len(reply.Trigger) > 100 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trigger, typed):
+			prefixed = append(prefixed, reply)
+		case strings.Contains(trigger, typed):
+			contained = append(contained, reply)
+		}
+	}
+	repliesMu.Unlock()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, reply := range append(prefixed, contained...) {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		name := reply.Trigger
+		if reply.Regex {
+			name += " (regex)"
+		}
+		if reply.Response != "" {
+			name += " → " + strings.Join(strings.Fields(reply.Response), " ")
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(name, 100),
+			Value: reply.Trigger,
+		})
+	}
+	return choices
+}
+
+// handleMonitorCommand handles /monitor add|remove|list
+func handleMonitorCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+	if i.GuildID == "" {
+		respond("❌ Status monitors only work in servers, not in DMs!")
+		return
+	}
+	if !hasManageGuild(i) {
+		respond("❌ You need the Manage Server permission to manage status monitors.")
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "add":
+		respond(addMonitor(i, subcommand.Options))
+	case "remove":
+		id := int(subcommand.Options[0].IntValue())
+		monitorsMu.Lock()
+		kept := slices.DeleteFunc(statusMonitors, func(m *StatusMonitor) bool { return m.ID == id && m.GuildID == i.GuildID })
+		removed := len(kept) < len(statusMonitors)
+		statusMonitors = kept
+		if removed {
+			saveMonitors()
+		}
+		monitorsMu.Unlock()
+		if !removed {
+			respond(fmt.Sprintf("❌ This server has no monitor #%d. See them with `/monitor list`.", id))
+			return
+		}
+		respond(fmt.Sprintf("✅ Monitor #%d removed.", id))
+	case "list":
+		respond(listMonitors(i.GuildID))
+	}
+}
+
+// addMonitor handles /monitor add and returns the reply
+func addMonitor(i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	monitor := &StatusMonitor{GuildID: i.GuildID, CreatedBy: interactionUserID(i)}
+	for _, opt := range options {
+		switch opt.Name {
+		case "name":
+			monitor.Name = strings.TrimSpace(opt.StringValue())
+		case "url":
+			monitor.URL = strings.TrimSpace(opt.StringValue())
+		case "channel":
+			monitor.ChannelID = opt.ChannelValue(nil).ID
+		}
+	}
+	if u, err := url.Parse(monitor.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "❌ The URL must be an http(s) link, like `https://status.example.com/health`."
+	}
+
+	monitorsMu.Lock()
+	defer monitorsMu.Unlock()
+	count := 0
+	for _, m := range statusMonitors {
+		if m.GuildID == i.GuildID {
+			count++
+		}
+	}
+	if count >= maxMonitorsPerGuild {
+		return fmt.Sprintf("❌ A server can have at most %d status monitors.", maxMonitorsPerGuild)
+	}
+	monitor.ID = nextMonitorID
+	nextMonitorID++
+	statusMonitors = append(statusMonitors, monitor)
+	saveMonitors()
+	return fmt.Sprintf("✅ Monitor #%d **%s** added. %s is checked every %d minutes and going down or coming back up is posted in <#%s>.", monitor.ID, monitor.Name, monitor.URL, int(feedPollInterval.Minutes()), monitor.ChannelID)
+}
+
+// listMonitors is the /monitor list reply
+func listMonitors(guildID string) string {
+	monitorsMu.Lock()
+	defer monitorsMu.Unlock()
+
+	var lines []string
+	for _, m := range statusMonitors {
+		if m.GuildID != guildID {
+			continue
+		}
+		state := "⚪ not checked yet"
+		switch {
+		case m.Down:
+			state = fmt.Sprintf("🔴 down since <t:%d:R>: %s", m.ChangedAt.Unix(), m.LastError)
+		case !m.CheckedAt.IsZero():
+			state = "🟢 up"
+			if !m.ChangedAt.IsZero() {
+				state += fmt.Sprintf(" since <t:%d:R>", m.ChangedAt.Unix())
+			}
+		}
+		if m.Failures > 0 {
+			state += fmt.Sprintf(", %d failed checks in a row, next <t:%d:R>", m.Failures, m.RetryAt.Unix())
+		}
+		lines = append(lines, fmt.Sprintf("**#%d %s** · <#%s> · %s\n%s", m.ID, m.Name, m.ChannelID, state, m.URL))
+	}
+	if len(lines) == 0 {
+		return "📝 No status monitors for this server. Add one with `/monitor add`."
+	}
+	return truncate("📡 **Status monitors**\n"+strings.Join(lines, "\n"), 2000)
+}
+
+// checkMonitor reports why a monitored URL counts as down, or "" when it is up
+func checkMonitor(target string) string {
+	result, err := checkSite(target)
+	if err != nil {
+		return userErrorMessage(err)
+	}
+	if result.Status >= 400 {
+		return fmt.Sprintf("HTTP %d %s", result.Status, http.StatusText(result.Status))
+	}
+	return ""
+}
+
+// pollMonitors checks every status monitor that isn't backing off and posts up/down
+// transitions to its channel. A service counts as down after monitorDownAfter failed
+// checks in a row, so a single timeout doesn't alert anyone. Called from runFeedPoller.
+func pollMonitors(s *discordgo.Session) {
+	now := time.Now()
+	monitorsMu.Lock()
+	urls := make(map[string]bool)
+	for _, m := range statusMonitors {
+		if !now.Before(m.RetryAt) {
+			urls[m.URL] = true
+		}
+	}
+	monitorsMu.Unlock()
+
+	// Check without holding the lock, each URL only once
+	problems := make(map[string]string, len(urls))
+	for target := range urls {
+		problems[target] = checkMonitor(target)
+	}
+
+	// Down and ChangedAt only change once the alert is posted, so a failed post is made
+	// again on the next check while the service stays in its new state
+	type monitorChange struct {
+		id   int
+		down bool
+		post feedPost
+	}
+	var changes []monitorChange
+	monitorsMu.Lock()
+	for _, m := range statusMonitors {
+		problem, ok := problems[m.URL]
+		if !ok {
+			continue
+		}
+		m.CheckedAt = now
+		if problem == "" {
+			if m.Down {
+				changes = append(changes, monitorChange{m.ID, false, feedPost{ChannelID: m.ChannelID, Embeds: []*discordgo.MessageEmbed{{
+					Title:       "🟢 " + m.Name + " is back up",
+					Description: fmt.Sprintf("%s\nDown for %s.", m.URL, now.Sub(m.ChangedAt).Round(time.Minute)),
+					Color:       0x2ecc71,
+					Timestamp:   now.Format(time.RFC3339),
+				}}}})
+			}
+			m.Failures = 0
+			m.RetryAt = time.Time{}
+			m.LastError = ""
+			continue
+		}
+
+		m.Failures++
+		m.LastError = problem
+		if !m.Down && m.Failures >= monitorDownAfter {
+			changes = append(changes, monitorChange{m.ID, true, feedPost{ChannelID: m.ChannelID, Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🔴 " + m.Name + " is down",
+				Description: fmt.Sprintf("%s\n%s, %d checks in a row.", m.URL, truncate(problem, 500), m.Failures),
+				Color:       0xe74c3c,
+				Timestamp:   now.Format(time.RFC3339),
+			}}}})
+		}
+		// Keep checking a down service at the normal pace for a while so recovery is spotted
+		// quickly, then back off like a failing feed
+		if m.Failures > monitorDownAfter {
+			m.RetryAt = now.Add(pollBackoff(m.Failures-monitorDownAfter, maxMonitorBackoff))
+		}
+	}
+	if len(problems) > 0 {
+		saveMonitors()
+	}
+	monitorsMu.Unlock()
+
+	for _, change := range changes {
+		post := change.post
+		if _, err := queueBackgroundSend(post.ChannelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendEmbeds(post.ChannelID, post.Embeds)
+		}); err != nil {
+			log.Printf("Error posting status change to channel %s: %v", post.ChannelID, err)
+			continue
+		}
+		monitorsMu.Lock()
+		for _, m := range statusMonitors {
+			if m.ID == change.id && m.Down != change.down {
+				m.Down = change.down
+				m.ChangedAt = now
+				saveMonitors()
+			}
+		}
+		monitorsMu.Unlock()
+	}
+}
+
+// loadMonitors loads status monitors from file
+func loadMonitors() {
+	statusMonitors = make([]*StatusMonitor, 0)
+
+	if _, err := os.Stat(monitorsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(monitorsFile)
+	if err != nil {
+		log.Printf("Error reading monitors file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &statusMonitors); err != nil {
+		log.Printf("Error parsing monitors file: %v", err)
+		return
+	}
+
+	for _, m := range statusMonitors {
+		if m.ID >= nextMonitorID {
+			nextMonitorID = m.ID + 1
+		}
+	}
+	log.Printf("Loaded %d status monitors", len(statusMonitors))
+}
+
+// saveMonitors saves status monitors to file. The caller must hold monitorsMu.
+func saveMonitors() {
+	data, err := json.MarshalIndent(statusMonitors, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling monitors: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(monitorsFile, data, 0644); err != nil {
+		log.Printf("Error saving monitors: %v", err)
+		return
+	}
+}
+
+// parseReplyExpiry reads when a rule expires: a duration like `7d` or `12h`, a date, a
+// date and time, or `never` to keep the rule for good (the zero time)
+func parseReplyExpiry(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "never" || value == "none" {
+		return time.Time{}, nil
+	}
+	value = strings.TrimPrefix(value, "in ")
+
+	var at time.Time
+	if d, err := parseSince(value); err == nil {
+		at = now.Add(d)
+	} else if date, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		at = date
+	} else {
+		parsed, every, err := parseRemindWhen(value, loc, now)
+		if err != nil || every != nil {
+			return time.Time{}, invalidInput("use an expiry like `7d`, `12h`, `2026-11-01`, `2026-11-01 18:00` or `never`")
+		}
+		at = parsed
+	}
+	switch {
+	case !at.After(now):
+		return time.Time{}, invalidInput("the expiry must be in the future")
+	case at.Sub(now) > maxReplyExpiry:
+		return time.Time{}, invalidInput("rules can expire at most a year from now, or `never`")
+	}
+	return at, nil
+}
+
+// expireAutoReplies removes rules whose expiry has passed and DMs their authors when
+// they asked for it. Called from runScheduler.
+func expireAutoReplies(s *discordgo.Session, now time.Time) {
+	type expiredRule struct {
+		guildID string
+		rule    AutoReply
+	}
+	var expired []expiredRule
+	repliesMu.Lock()
+	for guildID, rules := range serverAutoReplies {
+		var gone []AutoReply
+		kept := slices.DeleteFunc(rules, func(rule AutoReply) bool {
+			if rule.expired(now) {
+				gone = append(gone, rule)
+				return true
+			}
+			return false
+		})
+		if len(gone) == 0 {
+			continue
+		}
+		serverAutoReplies[guildID] = kept
+		for _, rule := range gone {
+			removeUnusedMedia(guildID, rule.Attachments)
+			expired = append(expired, expiredRule{guildID, rule})
+		}
+	}
+	if len(expired) > 0 {
+		saveAutoReplies()
+	}
+	repliesMu.Unlock()
+
+	for _, e := range expired {
+		log.Printf("Auto-reply %q in guild %s expired", e.rule.Trigger, e.guildID)
+		if !e.rule.ExpiryDM || e.rule.AuthorID == "" {
+			continue
+		}
+		server := "a server"
+		if guild, err := s.State.Guild(e.guildID); err == nil {
+			server = "**" + guild.Name + "**"
+		}
+		channel, err := s.UserChannelCreate(e.rule.AuthorID)
+		if err != nil {
+			log.Printf("Error opening DM with %s: %v", e.rule.AuthorID, err)
+			continue
+		}
+		content := fmt.Sprintf("⌛ Your auto-reply `%s` in %s expired and was removed. Set it up again with `/reply` if you still need it.", e.rule.Trigger, server)
+		if _, err := s.ChannelMessageSend(channel.ID, truncate(content, 2000)); err != nil {
+			log.Printf("Error sending expiry DM to %s: %v", e.rule.AuthorID, err)
+		}
+	}
+}
+
+// twitchLogin matches a Twitch channel name
+var twitchLogin = regexp.MustCompile(`^[a-z0-9_]{2,25}$`)
+
+// Twitch app access token, fetched with the client credentials and reused until it expires
+var (
+	twitchTokenMu     sync.Mutex
+	twitchToken       string
+	twitchTokenExpiry time.Time
+
+	// Failed polls in a row, only touched by the feed poller
+	twitchFailures int
+	twitchRetryAt  time.Time
+)
+
+// twitchConfigured reports whether Twitch client credentials are set
+func twitchConfigured() bool {
+	return os.Getenv("TWITCH_CLIENT_ID") != "" && os.Getenv("TWITCH_CLIENT_SECRET") != ""
+}
+
+// twitchAccessToken returns an app access token for the Helix API, requesting a new one
+// with TWITCH_CLIENT_ID and TWITCH_CLIENT_SECRET when needed
+func twitchAccessToken() (string, error) {
+	twitchTokenMu.Lock()
+	defer twitchTokenMu.Unlock()
+	if twitchToken != "" && time.Now().Before(twitchTokenExpiry) {
+		return twitchToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {os.Getenv("TWITCH_CLIENT_ID")},
+		"client_secret": {os.Getenv("TWITCH_CLIENT_SECRET")},
+		"grant_type":    {"client_credentials"},
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.PostForm("https://id.twitch.tv/oauth2/token", form)
+	if err != nil {
+		return "", &ServiceError{Kind: ErrUpstreamDown, Detail: "Twitch", Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &ServiceError{Kind: ErrUpstreamDown, Detail: "Twitch", Err: fmt.Errorf("token HTTP error: %d", resp.StatusCode)}
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse Twitch token: %v", err)
+	}
+	twitchToken = token.AccessToken
+	// Renew a little early so a token never expires mid-poll
+	twitchTokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return twitchToken, nil
+}
+
+// twitchStream is a live stream from the Helix streams endpoint
+type twitchStream struct {
+	ID           string    `json:"id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameName     string    `json:"game_name"`
+	Title        string    `json:"title"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	ThumbnailURL string    `json:"thumbnail_url"` // with {width} and {height} placeholders
+}
+
+// fetchTwitchStreams returns the live streams of the given channels, keyed by login.
+// Channels that are offline are left out.
+func fetchTwitchStreams(logins []string) (map[string]twitchStream, error) {
+	token, err := twitchAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	streams := make(map[string]twitchStream)
+	// Helix takes up to 100 channels per request
+	for start := 0; start < len(logins); start += 100 {
+		query := url.Values{}
+		for _, login := range logins[start:min(start+100, len(logins))] {
+			query.Add("user_login", login)
+		}
+		req, err := http.NewRequest(http.MethodGet, "https://api.twitch.tv/helix/streams?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Client-Id", os.Getenv("TWITCH_CLIENT_ID"))
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, &ServiceError{Kind: ErrUpstreamDown, Detail: "Twitch", Err: err}
+		}
+		var result struct {
+			Data []twitchStream `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			// Revoked or expired early, ask for a new token next time
+			twitchTokenMu.Lock()
+			twitchToken = ""
+			twitchTokenMu.Unlock()
+			return nil, &ServiceError{Kind: ErrUpstreamDown, Detail: "Twitch", Err: errors.New("access token rejected")}
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return nil, &ServiceError{Kind: ErrRateLimited, Detail: "Twitch"}
+		case resp.StatusCode != http.StatusOK:
+			return nil, &ServiceError{Kind: ErrUpstreamDown, Detail: "Twitch", Err: fmt.Errorf("HTTP error: %d", resp.StatusCode)}
+		case err != nil:
+			return nil, fmt.Errorf("failed to parse Twitch streams: %v", err)
+		}
+		for _, stream := range result.Data {
+			streams[strings.ToLower(stream.UserLogin)] = stream
+		}
+	}
+	return streams, nil
+}
+
+// handleFeedLive handles /feed live twitch|list|remove
+func handleFeedLive(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+				Flags:           discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	switch subcommand.Name {
+	case "twitch":
+		sub := &LiveSubscription{GuildID: i.GuildID, ChannelID: i.ChannelID, Platform: "twitch", CreatedBy: interactionUserID(i)}
+		for _, opt := range subcommand.Options {
+			switch opt.Name {
+			case "streamer":
+				login := strings.ToLower(strings.TrimSpace(opt.StringValue()))
+				login = strings.TrimPrefix(strings.TrimPrefix(login, "https://"), "www.")
+				sub.Login = strings.TrimPrefix(login, "twitch.tv/")
+			case "channel":
+				sub.ChannelID = opt.ChannelValue(nil).ID
+			case "role":
+				sub.RoleID = opt.RoleValue(nil, "").ID
+			}
+		}
+		if !twitchConfigured() {
+			respond("❌ Twitch isn't set up on this bot. The bot owner needs to set `TWITCH_CLIENT_ID` and `TWITCH_CLIENT_SECRET`.")
+			return
+		}
+		if !twitchLogin.MatchString(sub.Login) {
+			respond("❌ Use the streamer's Twitch channel name, like `shroud`.")
+			return
+		}
+
+		liveMu.Lock()
+		count := 0
+		for _, other := range liveSubscriptions {
+			if other.GuildID != i.GuildID {
+				continue
+			}
+			count++
+			if other.Login == sub.Login && other.ChannelID == sub.ChannelID {
+				other.RoleID = sub.RoleID
+				saveLiveSubscriptions()
+				liveMu.Unlock()
+				respond(fmt.Sprintf("✅ Live subscription #%d for **%s** updated.", other.ID, sub.Login))
+				return
+			}
+		}
+		if count >= maxLivePerGuild {
+			liveMu.Unlock()
+			respond(fmt.Sprintf("❌ A server can follow at most %d streamers.", maxLivePerGuild))
+			return
+		}
+		sub.ID = nextLiveID
+		nextLiveID++
+		liveSubscriptions = append(liveSubscriptions, sub)
+		saveLiveSubscriptions()
+		liveMu.Unlock()
+
+		content := fmt.Sprintf("✅ Live subscription #%d created: <#%s> will be told when **%s** goes live on Twitch", sub.ID, sub.ChannelID, sub.Login)
+		if sub.RoleID != "" {
+			content += fmt.Sprintf(", mentioning <@&%s>", sub.RoleID)
+		}
+		respond(content + ". The post is edited when the stream ends.")
+	case "remove":
+		id := int(subcommand.Options[0].IntValue())
+		liveMu.Lock()
+		kept := slices.DeleteFunc(liveSubscriptions, func(sub *LiveSubscription) bool { return sub.ID == id && sub.GuildID == i.GuildID })
+		removed := len(kept) < len(liveSubscriptions)
+		liveSubscriptions = kept
+		if removed {
+			saveLiveSubscriptions()
+		}
+		liveMu.Unlock()
+		if !removed {
+			respond(fmt.Sprintf("❌ This server has no live subscription #%d. See them with `/feed live list`.", id))
+			return
+		}
+		respond(fmt.Sprintf("✅ Live subscription #%d removed.", id))
+	case "list":
+		liveMu.Lock()
+		var lines []string
+		for _, sub := range liveSubscriptions {
+			if sub.GuildID != i.GuildID {
+				continue
+			}
+			line := fmt.Sprintf("**#%d** [%s](https://www.twitch.tv/%s) → <#%s>", sub.ID, sub.Login, sub.Login, sub.ChannelID)
+			if sub.RoleID != "" {
+				line += fmt.Sprintf(" · <@&%s>", sub.RoleID)
+			}
+			if sub.Live {
+				line += " · 🔴 live now"
+			}
+			lines = append(lines, line)
+		}
+		liveMu.Unlock()
+		if len(lines) == 0 {
+			respond("📝 No live subscriptions for this server. Add one with `/feed live twitch`.")
+			return
+		}
+		respond(truncate("📺 **Live subscriptions**\n"+strings.Join(lines, "\n"), 2000))
+	}
+}
+
+// liveEmbed announces a stream, or shows that it ended when stream is nil
+func liveEmbed(sub *LiveSubscription, stream *twitchStream, now time.Time) *discordgo.MessageEmbed {
+	if stream == nil {
+		embed := &discordgo.MessageEmbed{
+			Title:       "⚫ " + sub.Name + " was live on Twitch",
+			URL:         "https://www.twitch.tv/" + sub.Login,
+			Description: truncate(sub.Title, 300),
+			Color:       0x747f8d,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Stream ended"},
+			Timestamp:   now.Format(time.RFC3339),
+		}
+		if !sub.StartedAt.IsZero() {
+			embed.Fields = []*discordgo.MessageEmbedField{{Name: "Streamed for", Value: now.Sub(sub.StartedAt).Round(time.Minute).String(), Inline: true}}
+		}
+		return embed
+	}
+
+	thumbnail := strings.NewReplacer("{width}", "1280", "{height}", "720").Replace(stream.ThumbnailURL)
+	embed := &discordgo.MessageEmbed{
+		Title:       "🔴 " + stream.UserName + " is live on Twitch",
+		URL:         "https://www.twitch.tv/" + sub.Login,
+		Description: truncate(stream.Title, 300),
+		Color:       0x9146ff,
+		Image:       &discordgo.MessageEmbedImage{URL: fmt.Sprintf("%s?t=%d", thumbnail, now.Unix())}, // Discord caches by URL
+		Timestamp:   stream.StartedAt.Format(time.RFC3339),
+	}
+	if stream.GameName != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Game", Value: stream.GameName, Inline: true})
+	}
+	return embed
+}
+
+// pollLiveStreams checks followed Twitch channels, announces streams that went live and
+// edits the announcement once they end. Called from runFeedPoller.
+func pollLiveStreams(s *discordgo.Session) {
+	now := time.Now()
+	liveMu.Lock()
+	seen := make(map[string]bool)
+	var logins []string
+	for _, sub := range liveSubscriptions {
+		if !seen[sub.Login] {
+			seen[sub.Login] = true
+			logins = append(logins, sub.Login)
+		}
+	}
+	liveMu.Unlock()
+	if len(logins) == 0 || !twitchConfigured() || now.Before(twitchRetryAt) {
+		return
+	}
+
+	streams, err := fetchTwitchStreams(logins)
+	if err != nil {
+		// Back off like a failing feed, every subscription depends on the same API
+		twitchFailures++
+		twitchRetryAt = now.Add(pollBackoff(twitchFailures, maxFeedBackoff))
+		log.Printf("Error polling Twitch streams: %v", err)
+		return
+	}
+	twitchFailures = 0
+	twitchRetryAt = time.Time{}
+
+	type liveChange struct {
+		sub    LiveSubscription // copy taken under the lock
+		stream *twitchStream    // nil when the stream ended
+	}
+	var changes []liveChange
+	ended := false
+	liveMu.Lock()
+	for _, sub := range liveSubscriptions {
+		stream, live := streams[sub.Login]
+		switch {
+		case live && (!sub.Live || stream.ID != sub.StreamID):
+			// A new stream, also when the last one ended between two polls. It is only
+			// stored once announced, so a failed post is made again on the next poll.
+			announced := *sub
+			announced.Live = true
+			announced.StreamID = stream.ID
+			announced.Name = stream.UserName
+			announced.Title = stream.Title
+			announced.StartedAt = stream.StartedAt
+			announced.MessageID = ""
+			changes = append(changes, liveChange{announced, &stream})
+		case !live && sub.Live:
+			sub.Live = false
+			ended = true
+			changes = append(changes, liveChange{*sub, nil})
+		}
+	}
+	if ended {
+		saveLiveSubscriptions()
+	}
+	liveMu.Unlock()
+
+	for _, change := range changes {
+		sub := change.sub
+		if change.stream == nil {
+			if sub.MessageID == "" {
+				continue
+			}
+			if _, err := s.ChannelMessageEditEmbed(sub.ChannelID, sub.MessageID, liveEmbed(&sub, nil, now)); err != nil {
+				log.Printf("Error editing live post in channel %s: %v", sub.ChannelID, err)
+			}
+			continue
+		}
+
+		send := &discordgo.MessageSend{
+			Embeds:          []*discordgo.MessageEmbed{liveEmbed(&sub, change.stream, now)},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}
+		if sub.RoleID != "" {
+			send.Content = fmt.Sprintf("<@&%s>", sub.RoleID)
+			send.AllowedMentions.Roles = []string{sub.RoleID}
+		}
+		msg, err := queueBackgroundSend(sub.ChannelID, func() (*discordgo.Message, error) {
+			return s.ChannelMessageSendComplex(sub.ChannelID, send)
+		})
+		if err != nil {
+			log.Printf("Error posting live announcement in channel %s: %v", sub.ChannelID, err)
+			continue
+		}
+		liveMu.Lock()
+		for _, current := range liveSubscriptions {
+			if current.ID == sub.ID {
+				current.Live = true
+				current.StreamID = sub.StreamID
+				current.Name = sub.Name
+				current.Title = sub.Title
+				current.StartedAt = sub.StartedAt
+				current.MessageID = msg.ID
+			}
+		}
+		saveLiveSubscriptions()
+		liveMu.Unlock()
+	}
+}
+
+// loadLiveSubscriptions loads live stream subscriptions from file
+func loadLiveSubscriptions() {
+	liveSubscriptions = make([]*LiveSubscription, 0)
+
+	if _, err := os.Stat(liveFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(liveFile)
+	if err != nil {
+		log.Printf("Error reading live subscriptions file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &liveSubscriptions); err != nil {
+		log.Printf("Error parsing live subscriptions file: %v", err)
+		return
+	}
+
+	for _, sub := range liveSubscriptions {
+		if sub.ID >= nextLiveID {
+			nextLiveID = sub.ID + 1
+		}
+	}
+	log.Printf("Loaded %d live subscriptions", len(liveSubscriptions))
+}
+
+// saveLiveSubscriptions saves live stream subscriptions to file. The caller must hold liveMu.
+func saveLiveSubscriptions() {
+	data, err := json.MarshalIndent(liveSubscriptions, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling live subscriptions: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(liveFile, data, 0644); err != nil {
+		log.Printf("Error saving live subscriptions: %v", err)
+		return
+	}
+}
+
+// omdbTitle is an OMDb title lookup result
+type omdbTitle struct {
+	Title        string `json:"Title"`
+	Year         string `json:"Year"`
+	Rated        string `json:"Rated"`
+	Runtime      string `json:"Runtime"`
+	Genre        string `json:"Genre"`
+	Director     string `json:"Director"`
+	Actors       string `json:"Actors"`
+	Plot         string `json:"Plot"`
+	Poster       string `json:"Poster"`
+	IMDbRating   string `json:"imdbRating"`
+	IMDbID       string `json:"imdbID"`
+	Type         string `json:"Type"` // "movie" or "series"
+	TotalSeasons string `json:"totalSeasons"`
+	Ratings      []struct {
+		Source string `json:"Source"`
+		Value  string `json:"Value"`
+	} `json:"Ratings"`
+	Response string `json:"Response"`
+	Error    string `json:"Error"`
+}
+
+// movieAPIKey returns a server's key for OMDb or TMDB, falling back to the bot-wide
+// OMDB_API_KEY or TMDB_API_KEY
+func movieAPIKey(guildID, name string) string {
+	if key, ok := guildAPIKey(guildID, name); ok {
+		return key
+	}
+	return os.Getenv(strings.ToUpper(name) + "_API_KEY")
+}
+
+// lookupTitle finds a movie or series on OMDb by title
+func lookupTitle(apiKey, title, kind, year string) (*omdbTitle, error) {
+	params := url.Values{
+		"apikey": {apiKey},
+		"t":      {title},
+		"plot":   {"short"},
+	}
+	if kind != "" {
+		params.Set("type", kind)
+	}
+	if year != "" {
+		params.Set("y", year)
+	}
+	body, err := fetchWithKey("OMDb", "https://www.omdbapi.com/?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var result omdbTitle
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, upstreamDown("OMDb", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if result.Response != "True" {
+		if strings.Contains(result.Error, "not found") {
+			return nil, notFound("No movie or series called **%s** was found", title)
+		}
+		return nil, upstreamDown("OMDb", fmt.Errorf("lookup failed: %s", result.Error))
+	}
+	return &result, nil
+}
+
+// watchProviders returns where a title can be streamed, rented or bought in movieWatchRegion
+// according to TMDB, and the TMDB page listing them
+func watchProviders(apiKey, imdbID string) (map[string][]string, string, error) {
+	body, err := fetchWithKey("TMDB", fmt.Sprintf("https://api.themoviedb.org/3/find/%s?external_source=imdb_id&api_key=%s", url.PathEscape(imdbID), url.QueryEscape(apiKey)))
+	if err != nil {
+		return nil, "", err
+	}
+	var found struct {
+		Movies []struct {
+			ID int `json:"id"`
+		} `json:"movie_results"`
+		Shows []struct {
+			ID int `json:"id"`
+		} `json:"tv_results"`
+	}
+	if err := json.Unmarshal(body, &found); err != nil {
+		return nil, "", upstreamDown("TMDB", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	var path string
+	switch {
+	case len(found.Movies) > 0:
+		path = fmt.Sprintf("movie/%d", found.Movies[0].ID)
+	case len(found.Shows) > 0:
+		path = fmt.Sprintf("tv/%d", found.Shows[0].ID)
+	default:
+		return nil, "", nil
+	}
+
+	body, err = fetchWithKey("TMDB", fmt.Sprintf("https://api.themoviedb.org/3/%s/watch/providers?api_key=%s", path, url.QueryEscape(apiKey)))
+	if err != nil {
+		return nil, "", err
+	}
+	type provider struct {
+		Name string `json:"provider_name"`
+	}
+	var result struct {
+		Results map[string]struct {
+			Link     string     `json:"link"`
+			Flatrate []provider `json:"flatrate"`
+			Rent     []provider `json:"rent"`
+			Buy      []provider `json:"buy"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", upstreamDown("TMDB", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	region, ok := result.Results[movieWatchRegion]
+	if !ok {
+		return nil, "", nil
+	}
+	providers := make(map[string][]string)
+	for kind, list := range map[string][]provider{"Stream": region.Flatrate, "Rent": region.Rent, "Buy": region.Buy} {
+		for _, p := range list {
+			providers[kind] = append(providers[kind], p.Name)
+		}
+	}
+	return providers, region.Link, nil
+}
+
+// movieEmbed builds the /movie card with poster, ratings, synopsis and where to watch
+func movieEmbed(title *omdbTitle, providers map[string][]string, providersLink string) *discordgo.MessageEmbed {
+	emoji := "🎬"
+	if title.Type == "series" {
+		emoji = "📺"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s (%s)", emoji, title.Title, title.Year),
+		URL:         "https://www.imdb.com/title/" + title.IMDbID + "/",
+		Description: truncate(title.Plot, 1000),
+		Color:       0xf5c518,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Source: OMDb",
+		},
+	}
+	if strings.HasPrefix(title.Poster, "http") {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: title.Poster}
+	}
+
+	var ratings []string
+	for _, rating := range title.Ratings {
+		ratings = append(ratings, fmt.Sprintf("**%s:** %s", rating.Source, rating.Value))
+	}
+	if len(ratings) == 0 && title.IMDbRating != "N/A" {
+		ratings = append(ratings, "**IMDb:** "+title.IMDbRating+"/10")
+	}
+	if len(ratings) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "⭐ Ratings", Value: strings.Join(ratings, "\n"), Inline: false})
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"Genre", title.Genre},
+		{"Runtime", title.Runtime},
+		{"Rated", title.Rated},
+		{"Seasons", title.TotalSeasons},
+		{"Director", title.Director},
+		{"Cast", title.Actors},
+	} {
+		if field.value != "" && field.value != "N/A" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: field.name, Value: truncate(field.value, 1024), Inline: true})
+		}
+	}
+
+	if providers != nil {
+		var lines []string
+		for _, kind := range []string{"Stream", "Rent", "Buy"} {
+			if names := providers[kind]; len(names) > 0 {
+				lines = append(lines, fmt.Sprintf("**%s:** %s", kind, strings.Join(names, ", ")))
+			}
+		}
+		value := "Not available on streaming services in Indonesia yet."
+		if len(lines) > 0 {
+			value = truncate(strings.Join(lines, "\n"), 900)
+			if providersLink != "" {
+				value += fmt.Sprintf("\n[All options](%s)", providersLink)
+			}
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "📡 Where to watch", Value: value, Inline: false})
+		embed.Footer.Text = "Source: OMDb · Where to watch: TMDB, JustWatch"
+	}
+	return embed
+}
+
+// watchlistButtonRow returns the "🍿 Watchlist" button attached to /movie results
+func watchlistButtonRow() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Watchlist",
+					Emoji:    &discordgo.ComponentEmoji{Name: "🍿"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: "watchlist_add",
+				},
+			},
+		},
+	}
+}
+
+// handleMovieCommand handles /movie, looking a title up on OMDb and, when a TMDB key is
+// configured, where it can be watched
+func handleMovieCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var query, kind, year string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "title":
+			query = strings.TrimSpace(opt.StringValue())
+		case "type":
+			kind = opt.StringValue()
+		case "year":
+			year = strconv.Itoa(int(opt.IntValue()))
+		}
+	}
+
+	apiKey := movieAPIKey(i.GuildID, "omdb")
+	if apiKey == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Movie lookups need an OMDb API key. An admin can add one with `/settings apikey set`.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since OMDb and TMDB might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	title, err := lookupTitle(apiKey, query, kind, year)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+		})
+		return
+	}
+
+	var providers map[string][]string
+	var providersLink string
+	if tmdbKey := movieAPIKey(i.GuildID, "tmdb"); tmdbKey != "" {
+		providers, providersLink, err = watchProviders(tmdbKey, title.IMDbID)
+		if err != nil {
+			// The card is still useful without streaming info
+			log.Printf("Error fetching watch providers for %s: %v", title.IMDbID, err)
+		} else if providers == nil {
+			providers = map[string][]string{}
+		}
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds:     []*discordgo.MessageEmbed{movieEmbed(title, providers, providersLink)},
+		Components: watchlistButtonRow(),
+	})
+}
+
+// handleWatchlistButton adds the movie or series on a /movie card to the user's watchlist,
+// which is kept with their bookmarks
+func handleWatchlistButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	bookmark := bookmarkFromMessage(i.GuildID, i.Message)
+	bookmark.Title = strings.TrimSpace(strings.TrimLeft(bookmark.Title, "🎬📺"))
+	bookmark.Watchlist = true
+	content := addBookmark(interactionUserID(i), bookmark)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// memeTemplate is a built-in /meme template, downloaded from imgflip the first time it's used
+type memeTemplate struct {
+	Name  string
+	Title string
+	URL   string
+}
+
+// builtinMemes are the templates every server can use
+var builtinMemes = []memeTemplate{
+	{"drake", "Drake Hotline Bling", "https://i.imgflip.com/30b1gx.jpg"},
+	{"distracted", "Distracted Boyfriend", "https://i.imgflip.com/1ur9b0.jpg"},
+	{"buttons", "Two Buttons", "https://i.imgflip.com/1g8my4.jpg"},
+	{"changemymind", "Change My Mind", "https://i.imgflip.com/24y43o.jpg"},
+	{"onedoesnot", "One Does Not Simply", "https://i.imgflip.com/1bij.jpg"},
+	{"success", "Success Kid", "https://i.imgflip.com/1bhk.jpg"},
+	{"disaster", "Disaster Girl", "https://i.imgflip.com/23ls.jpg"},
+	{"fry", "Futurama Fry", "https://i.imgflip.com/1bgw.jpg"},
+	{"doge", "Doge", "https://i.imgflip.com/4t0m5.jpg"},
+	{"thisisfine", "This Is Fine", "https://i.imgflip.com/wxica.jpg"},
+}
+
+// memeCache holds the built-in templates once downloaded, keyed by name
+var (
+	memeCacheMu sync.Mutex
+	memeCache   = make(map[string][]byte)
+)
+
+// pixelFont is a 5x7 bitmap font for text drawn on images, like meme captions and quote
+// cards, one byte per row with the leftmost pixel in bit 4
+var pixelFont = map[rune][7]byte{
+	'A':  {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B':  {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C':  {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D':  {0x1E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x1E},
+	'E':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G':  {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H':  {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I':  {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J':  {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K':  {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L':  {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M':  {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N':  {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O':  {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P':  {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q':  {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R':  {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S':  {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T':  {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W':  {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X':  {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y':  {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'a':  {0x00, 0x00, 0x0E, 0x01, 0x0F, 0x11, 0x0F},
+	'b':  {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1E},
+	'c':  {0x00, 0x00, 0x0E, 0x10, 0x10, 0x11, 0x0E},
+	'd':  {0x01, 0x01, 0x0D, 0x13, 0x11, 0x11, 0x0F},
+	'e':  {0x00, 0x00, 0x0E, 0x11, 0x1F, 0x10, 0x0E},
+	'f':  {0x06, 0x09, 0x08, 0x1C, 0x08, 0x08, 0x08},
+	'g':  {0x00, 0x0F, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'h':  {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'i':  {0x04, 0x00, 0x0C, 0x04, 0x04, 0x04, 0x0E},
+	'j':  {0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0C},
+	'k':  {0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12},
+	'l':  {0x0C, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'm':  {0x00, 0x00, 0x1A, 0x15, 0x15, 0x11, 0x11},
+	'n':  {0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'o':  {0x00, 0x00, 0x0E, 0x11, 0x11, 0x11, 0x0E},
+	'p':  {0x00, 0x00, 0x1E, 0x11, 0x1E, 0x10, 0x10},
+	'q':  {0x00, 0x00, 0x0D, 0x13, 0x0F, 0x01, 0x01},
+	'r':  {0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10},
+	's':  {0x00, 0x00, 0x0E, 0x10, 0x0E, 0x01, 0x1E},
+	't':  {0x08, 0x08, 0x1C, 0x08, 0x08, 0x09, 0x06},
+	'u':  {0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0D},
+	'v':  {0x00, 0x00, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'w':  {0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0A},
+	'x':  {0x00, 0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11},
+	'y':  {0x00, 0x00, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'z':  {0x00, 0x00, 0x1F, 0x02, 0x04, 0x08, 0x1F},
+	'0':  {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1':  {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3':  {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4':  {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5':  {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6':  {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8':  {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9':  {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	' ':  {},
+	'.':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	',':  {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'!':  {0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04},
+	'?':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+	'"':  {0x0A, 0x0A, 0x0A, 0x00, 0x00, 0x00, 0x00},
+	'-':  {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	':':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	';':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x04, 0x08},
+	'(':  {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')':  {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'/':  {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'&':  {0x0C, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0D},
+	'+':  {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'=':  {0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00},
+	'%':  {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'#':  {0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A},
+	'@':  {0x0E, 0x11, 0x01, 0x0D, 0x15, 0x15, 0x0E},
+	'*':  {0x00, 0x04, 0x15, 0x0E, 0x15, 0x04, 0x00},
+	'_':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+	'$':  {0x04, 0x0F, 0x14, 0x0E, 0x05, 0x1E, 0x04},
+}
+
+// wrapCaption splits text into lines of at most width characters, breaking long words
+func wrapCaption(text string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		if len(line) > 0 && len(line)+1+len(runes) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, runes...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// drawCaption draws text in white capitals with a black outline across img, in the top or
+// bottom third, using the largest size at which it fits
+func drawCaption(img *image.RGBA, text string, bottom bool) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if text == "" {
+		return
+	}
+	bounds := img.Bounds()
+	margin := max(bounds.Dx()/40, 2)
+	band := bounds.Dy()/3 - margin
+
+	// A glyph takes 6x9 pixels at scale 1, with spacing between letters and lines
+	var lines []string
+	scale := max(bounds.Dx()/60, 1)
+	for ; scale > 1; scale-- {
+		lines = wrapCaption(text, (bounds.Dx()-2*margin)/(6*scale))
+		if len(lines)*9*scale <= band {
+			break
+		}
+	}
+	if scale == 1 {
+		lines = wrapCaption(text, max((bounds.Dx()-2*margin)/6, 1))
+	}
+
+	outline := max(scale/3, 1)
+	y := bounds.Min.Y + margin
+	if bottom {
+		y = bounds.Max.Y - margin - len(lines)*9*scale + 2*scale
+	}
+	black, white := image.NewUniform(color.Black), image.NewUniform(color.White)
+	for n, line := range lines {
+		x := bounds.Min.X + (bounds.Dx()-utf8.RuneCountInString(line)*6*scale+scale)/2
+		drawPixelText(img, line, x, y+n*9*scale, scale, outline, black)
+	}
+	for n, line := range lines {
+		x := bounds.Min.X + (bounds.Dx()-utf8.RuneCountInString(line)*6*scale+scale)/2
+		drawPixelText(img, line, x, y+n*9*scale, scale, 0, white)
+	}
+}
+
+// drawPixelText draws one line of text in pixelFont with its top left corner at x, y, each
+// font pixel scale pixels wide and grown by grow on every side for outlines
+func drawPixelText(img *image.RGBA, text string, x, y, scale, grow int, fill image.Image) {
+	for _, r := range text {
+		glyph, ok := pixelFont[r]
+		if !ok {
+			glyph = pixelFont['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				draw.Draw(img, image.Rect(px-grow, py-grow, px+scale+grow, py+scale+grow), fill, image.Point{}, draw.Src)
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+// renderMeme draws the captions on a template image and encodes the result as JPEG
+func renderMeme(template []byte, top, bottom string) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(template))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode template: %v", err)
+	}
+	if config.Width > maxMemeDimension || config.Height > maxMemeDimension {
+		return nil, invalidInput("that template is larger than %d×%d pixels", maxMemeDimension, maxMemeDimension)
+	}
+	src, _, err := image.Decode(bytes.NewReader(template))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode template: %v", err)
+	}
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+
+	drawCaption(img, top, false)
+	drawCaption(img, bottom, true)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// memeTemplateImage returns the image of a server's custom template or a built-in one
+func memeTemplateImage(guildID, name string) ([]byte, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	memesMu.Lock()
+	custom, ok := memeTemplates[guildID][name]
+	memesMu.Unlock()
+	if ok {
+		data, err := os.ReadFile(custom.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %v", custom.Path, err)
+		}
+		return data, nil
+	}
+
+	for _, builtin := range builtinMemes {
+		if builtin.Name != name {
+			continue
+		}
+		memeCacheMu.Lock()
+		data, ok := memeCache[name]
+		memeCacheMu.Unlock()
+		if ok {
+			return data, nil
+		}
+		data, err := fetchPage("imgflip", builtin.URL)
+		if err != nil {
+			return nil, err
+		}
+		memeCacheMu.Lock()
+		memeCache[name] = data
+		memeCacheMu.Unlock()
+		return data, nil
+	}
+	return nil, notFound("There's no meme template called `%s`, see `/meme templates`", name)
+}
+
+// memeTemplateChoices suggests the built-in and server templates whose name contains typed
+func memeTemplateChoices(guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.ToLower(strings.TrimSpace(typed))
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	memesMu.Lock()
+	names := make([]string, 0, len(memeTemplates[guildID]))
+	for name := range memeTemplates[guildID] {
+		names = append(names, name)
+	}
+	memesMu.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.Contains(name, typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name + " (this server)", Value: name})
+		}
+	}
+	for _, builtin := range builtinMemes {
+		if strings.Contains(builtin.Name, typed) || strings.Contains(strings.ToLower(builtin.Title), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: builtin.Title, Value: builtin.Name})
+		}
+	}
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+	return choices
+}
+
+// memeTemplateName is what a custom template can be called
+var memeTemplateName = regexp.MustCompile(`^[a-z0-9_-]{2,30}$`)
+
+// storeMemeTemplate saves an uploaded template image under memesDir
+func storeMemeTemplate(guildID, name string, data []byte) (string, error) {
+	dir := filepath.Join(memesDir, guildID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, name+".img")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// handleMemeCommand handles /meme make, /meme templates and the Manage Server only
+// /meme add and /meme remove for a server's own templates
+func handleMemeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := i.ApplicationCommandData().Options[0]
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	var name, top, bottom string
+	var upload *discordgo.MessageAttachment
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "template", "name":
+			name = strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		case "top":
+			top = opt.StringValue()
+		case "bottom":
+			bottom = opt.StringValue()
+		case "image":
+			upload = i.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+		}
+	}
+
+	switch subcommand.Name {
+	case "make":
+		// Defer the response since downloading and drawing the template takes a moment
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		})
+		if err != nil {
+			log.Printf("Error deferring interaction: %v", err)
+			return
+		}
+
+		template, err := memeTemplateImage(i.GuildID, name)
+		var meme []byte
+		if err == nil {
+			meme, err = renderMeme(template, top, bottom)
+		}
+		if err != nil {
+			log.Printf("Error making meme from %s: %v", name, err)
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: userErrorMessage(err),
+			})
+			return
+		}
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Files:           []*discordgo.File{{Name: "meme.jpg", ContentType: "image/jpeg", Reader: bytes.NewReader(meme)}},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+
+	case "templates":
+		var builtins []string
+		for _, builtin := range builtinMemes {
+			builtins = append(builtins, fmt.Sprintf("`%s` %s", builtin.Name, builtin.Title))
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:  "🖼️ Meme Templates",
+			Color:  0xf39c12,
+			Fields: []*discordgo.MessageEmbedField{{Name: "Built in", Value: strings.Join(builtins, "\n"), Inline: false}},
+			Footer: &discordgo.MessageEmbedFooter{Text: "Use one with /meme make template:<name> top:<text> bottom:<text>"},
+		}
+
+		memesMu.Lock()
+		var custom []string
+		for _, template := range memeTemplates[i.GuildID] {
+			custom = append(custom, fmt.Sprintf("`%s` added by <@%s>", template.Name, template.AddedBy))
+		}
+		memesMu.Unlock()
+		sort.Strings(custom)
+		if len(custom) > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "This server", Value: truncate(strings.Join(custom, "\n"), 1024), Inline: false})
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{embed},
+				Flags:  discordgo.MessageFlagsEphemeral,
+			},
+		})
+
+	case "add":
+		if !hasManageGuild(i) {
+			respond("❌ Only members with Manage Server can add meme templates.")
+			return
+		}
+		switch {
+		case !memeTemplateName.MatchString(name):
+			respond("❌ Template names are 2 to 30 lowercase letters, digits, `-` or `_`.")
+			return
+		case slices.ContainsFunc(builtinMemes, func(builtin memeTemplate) bool { return builtin.Name == name }):
+			respond(fmt.Sprintf("❌ `%s` is a built-in template, pick another name.", name))
+			return
+		case upload == nil || !strings.HasPrefix(upload.ContentType, "image/"):
+			respond("❌ Please upload a PNG or JPEG image.")
+			return
+		case upload.Size > maxMemeImageSize:
+			respond(fmt.Sprintf("❌ Templates can be at most %d MB.", maxMemeImageSize>>20))
+			return
+		}
+
+		data, err := fetchAttachment(upload.URL)
+		var config image.Config
+		if err == nil {
+			config, _, err = image.DecodeConfig(bytes.NewReader(data))
+		}
+		if err != nil {
+			log.Printf("Error reading meme template upload: %v", err)
+			respond("❌ That image couldn't be read, try a PNG or JPEG.")
+			return
+		}
+		if config.Width > maxMemeDimension || config.Height > maxMemeDimension {
+			respond(fmt.Sprintf("❌ Templates can be at most %d×%d pixels.", maxMemeDimension, maxMemeDimension))
+			return
+		}
+
+		memesMu.Lock()
+		_, replacing := memeTemplates[i.GuildID][name]
+		if !replacing && len(memeTemplates[i.GuildID]) >= maxMemeTemplates {
+			memesMu.Unlock()
+			respond(fmt.Sprintf("❌ This server already has %d templates, remove one with `/meme remove` first.", maxMemeTemplates))
+			return
+		}
+		path, err := storeMemeTemplate(i.GuildID, name, data)
+		if err != nil {
+			memesMu.Unlock()
+			log.Printf("Error storing meme template: %v", err)
+			respond("❌ The template couldn't be saved, please try again later.")
+			return
+		}
+		if memeTemplates[i.GuildID] == nil {
+			memeTemplates[i.GuildID] = make(map[string]MemeTemplate)
+		}
+		memeTemplates[i.GuildID][name] = MemeTemplate{Name: name, Path: path, AddedBy: i.Member.User.ID, AddedAt: time.Now()}
+		saveMemeTemplates()
+		memesMu.Unlock()
+		respond(fmt.Sprintf("✅ Template `%s` saved. Try it with `/meme make template:%s`.", name, name))
+
+	case "remove":
+		if !hasManageGuild(i) {
+			respond("❌ Only members with Manage Server can remove meme templates.")
+			return
+		}
+		memesMu.Lock()
+		template, ok := memeTemplates[i.GuildID][name]
+		if ok {
+			delete(memeTemplates[i.GuildID], name)
+			if len(memeTemplates[i.GuildID]) == 0 {
+				delete(memeTemplates, i.GuildID)
+			}
+			saveMemeTemplates()
+		}
+		memesMu.Unlock()
+		if !ok {
+			respond(fmt.Sprintf("❌ This server has no template called `%s`.", name))
+			return
+		}
+		if err := os.Remove(template.Path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing meme template %s: %v", template.Path, err)
+		}
+		respond(fmt.Sprintf("✅ Template `%s` removed.", name))
+	}
+}
+
+// loadMemeTemplates loads the servers' custom meme templates from file
+func loadMemeTemplates() {
+	memeTemplates = make(ServerMemeTemplates)
+
+	if _, err := os.Stat(memesFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(memesFile)
+	if err != nil {
+		log.Printf("Error reading meme templates file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &memeTemplates); err != nil {
+		log.Printf("Error parsing meme templates file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded meme templates for %d servers", len(memeTemplates))
+}
+
+// saveMemeTemplates saves the custom meme templates to file. The caller must hold memesMu.
+func saveMemeTemplates() {
+	data, err := json.MarshalIndent(memeTemplates, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling meme templates: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(memesFile, data, 0644); err != nil {
+		log.Printf("Error saving meme templates: %v", err)
+		return
+	}
+}
+
+// inlineEmoji matches custom emoji anywhere in a message, which a quote card shows as :name:
+var inlineEmoji = regexp.MustCompile(`<a?:(\w+):\d+>`)
+
+// Quote cards are laid out on a fixed width with the avatar on the left and text beside it
+const (
+	quoteCardWidth = 800
+	quotePadding   = 32
+	quoteAvatar    = 96
+	quoteMaxLines  = 12
+)
+
+// quoteAvatarImage draws a user's avatar as a circle, or their initial on a coloured circle
+// when the avatar can't be downloaded
+func quoteAvatarImage(img *image.RGBA, user *discordgo.User, x, y int) {
+	var src image.Image
+	if user.Avatar != "" {
+		data, err := fetchAttachment(discordgo.EndpointUserAvatar(user.ID, user.Avatar) + "?size=128")
+		if err == nil {
+			src, _, err = image.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			log.Printf("Error getting avatar of %s for a quote: %v", user.ID, err)
+			src = nil
+		}
+	}
+
+	blurple := color.RGBA{0x58, 0x65, 0xf2, 0xff}
+	radius := quoteAvatar / 2
+	for py := 0; py < quoteAvatar; py++ {
+		for px := 0; px < quoteAvatar; px++ {
+			dx, dy := px-radius, py-radius
+			if dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			if src == nil {
+				img.Set(x+px, y+py, blurple)
+				continue
+			}
+			b := src.Bounds()
+			img.Set(x+px, y+py, src.At(b.Min.X+px*b.Dx()/quoteAvatar, b.Min.Y+py*b.Dy()/quoteAvatar))
+		}
+	}
+	if src == nil {
+		initial, _ := utf8.DecodeRuneInString(strings.ToUpper(user.Username))
+		drawPixelText(img, string(initial), x+radius-15, y+radius-21, 6, 0, image.White)
+	}
+}
+
+// quoteLines wraps a message for a quote card, keeping its own line breaks and cutting it
+// off after quoteMaxLines
+func quoteLines(text string, width int) []string {
+	text = inlineEmoji.ReplaceAllString(text, ":$1:")
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		wrapped := wrapCaption(paragraph, width)
+		if len(wrapped) == 0 {
+			wrapped = []string{""}
+		}
+		lines = append(lines, wrapped...)
+	}
+	if len(lines) > quoteMaxLines {
+		lines = lines[:quoteMaxLines]
+		last := []rune(lines[quoteMaxLines-1])
+		if len(last) > width-3 {
+			last = last[:width-3]
+		}
+		lines[quoteMaxLines-1] = string(last) + "..."
+	}
+	return lines
+}
+
+// renderQuoteCard draws a message as a dark card with the author's avatar, name and the
+// time it was sent, and encodes it as PNG
+func renderQuoteCard(author *discordgo.User, name string, sent time.Time, text string) ([]byte, error) {
+	textX := quotePadding + quoteAvatar + 24
+	width := (quoteCardWidth - textX - quotePadding) / 18
+	lines := quoteLines(text, width)
+
+	height := max(quotePadding*2+quoteAvatar, 110+len(lines)*30+quotePadding)
+	img := image.NewRGBA(image.Rect(0, 0, quoteCardWidth, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0x31, 0x33, 0x38, 0xff}), image.Point{}, draw.Src)
+
+	quoteAvatarImage(img, author, quotePadding, quotePadding)
+	if runes := []rune(name); len(runes) > width {
+		name = string(runes[:width-3]) + "..."
+	}
+	drawPixelText(img, name, textX, 40, 3, 0, image.White)
+	gray := image.NewUniform(color.RGBA{0x94, 0x9b, 0xa4, 0xff})
+	drawPixelText(img, sent.Format("02 Jan 2006 15:04"), textX, 76, 2, 0, gray)
+
+	light := image.NewUniform(color.RGBA{0xdb, 0xde, 0xe1, 0xff})
+	for n, line := range lines {
+		drawPixelText(img, line, textX, 110+n*30, 3, 0, light)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// handleQuoteMessageCommand handles the "Quote this" message context menu
+func handleQuoteMessageCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	msg := data.Resolved.Messages[data.TargetID]
+	if msg == nil || msg.Author == nil {
+		return
+	}
+
+	text := msg.ContentWithMentionsReplaced()
+	if strings.TrimSpace(text) == "" {
+		if len(msg.Attachments) == 0 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ That message has no text to quote.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		text = "[attachment]"
+	}
+
+	// Defer the response since downloading the avatar and drawing the card takes a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	name := msg.Author.GlobalName
+	if name == "" {
+		name = msg.Author.Username
+	}
+	if i.GuildID != "" {
+		if member, err := s.State.Member(i.GuildID, msg.Author.ID); err == nil && member.Nick != "" {
+			name = member.Nick
+		}
+	}
+	sent := msg.Timestamp.In(getGuildSettings(i.GuildID).location())
+
+	card, err := renderQuoteCard(msg.Author, name, sent, text)
+	if err != nil {
+		log.Printf("Error drawing quote of message %s: %v", msg.ID, err)
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "❌ I couldn't draw that quote, please try again later.",
+		})
+		return
+	}
+
+	guildID := i.GuildID
+	if guildID == "" {
+		guildID = "@me"
+	}
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content:         fmt.Sprintf("-# Quote from [this message](https://discord.com/channels/%s/%s/%s)", guildID, msg.ChannelID, msg.ID),
+		Files:           []*discordgo.File{{Name: "quote.png", ContentType: "image/png", Reader: bytes.NewReader(card)}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// commandsEmbed builds the embed listing all bot commands
+func commandsEmbed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "🎛️ Bot Commands",
+		Description: "All available commands for this bot",
+		Color:       0x3498db,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "🤖 **Auto-Reply Commands**",
+				Value:  "`/reply` / `/reply_edit` - Set up auto-reply rules and change yours\n`/list_replies` - Show server's auto-reply rules, filter by trigger, author or channel\n`/reply_purge` - Remove many rules at once by author, trigger text or all of them\n`/replies audit` - Find duplicate, unreachable and unused rules (Manage Server only)\n`/replies history` - Earlier versions of a rule, with rollback\n`/reply_export` / `/reply_import` - Back up rules as JSON and restore or merge them (Manage Server only)\n`/help_reply` - Help for auto-reply system",
+				Inline: false,
+			},
+			{
+				Name:   "📰 **News & Analysis Commands**",
+				Value:  "`/analisis` - Get latest financial news (restricted to specific server/channel)\n`/trendingx` - Get top 5 trending topics with links and previews\n`/feed` - Subscribe a channel to news, podcasts, BMKG earthquake alerts or free games (Manage Server only)\n`/news search` - Find an article the bot posted earlier\n`/bookmarks` - List or clear articles you saved with 🔖 Save or **Apps → Bookmark**, or your movie watchlist",
+				Inline: false,
+			},
+			{
+				Name:   "💱 **Currency Commands**",
+				Value:  "`/convert` - Convert currency amounts (e.g., `/convert $500 idr`)\n`/convert ?usd 1jt idr` - How many USD is 1jt IDR\n`/convert budget 100usd idr` - How much IDR buys 100 USD\n`/convert 0.05btc idr` - Crypto works too (BTC, ETH, SOL, ...)\n`/convert history` - Your last 10 conversions and their rates",
+				Inline: false,
+			},
+			{
+				Name:   "ℹ️ **Information Commands**",
+				Value:  "`/commands` - Show this list of all commands",
+				Inline: false,
+			},
+			{
+				Name:   "🧰 **Utility Commands**",
+				Value:  "`/qr` - Turn text, a link or a wallet address into a QR code image\n`/shorten url` / `/shorten stats` - Shorten a link and see its clicks\n`/check` / `/dns` - Website status, response time and SSL expiry, or DNS records\n`/github repo` / `/github issues` - Repository stats and issue search\n`/run go` - Run a Go snippet on the Go Playground\n`/color` - Color swatch with hex, RGB and HSL values\n`/text` - Mock case, zalgo, base64, URL encoding or binary\n`/hash` / `/uuid` - Hash text or generate UUIDs\n`/faqsearch` - Closest answers from the server FAQ\n`/summarize` - Catch up on this channel with an AI summary, only you see it\n`/transcript` - Export recent messages to a text or HTML file\n`/search` - Top web results from DuckDuckGo, when the server enabled it\n`/kbbi` / `/define` - Look up a word in KBBI or the English dictionary\n`/libur` - Indonesian national holidays, cuti bersama and long weekends\n`/slang` - Look up slang, in age-restricted channels unless `/settings slang` allows it",
+				Inline: false,
+			},
+			{
+				Name:   "🗓️ **Planning Commands**",
+				Value:  "`/remind me` / `/remind list` - Reminders, once or repeating like `every monday 9am`\n`/task add` / `/task done` - A shared task list for the channel, `/task list` posts the board",
+				Inline: false,
+			},
+			{
+				Name:   "🎲 **Fun Commands**",
+				Value:  "`/roll` - Roll dice like `2d6+3`\n`/flip` - Flip a coin\n`/choose` - Pick one of several options\n`/8ball` - Ask the magic 8-ball\n`/trivia start` / `/trivia leaderboard` - Multiple-choice quiz with server scores\n`/trivia schedule` - Weekly quiz in a channel (Manage Server only)\n`/word` - Word of the day quiz, keep your daily streak\n`/confess` - Post an anonymous confession, when the server allows it\n`/profile` - A member's card from their introduction\n`/movie` - Ratings, synopsis and where to watch, save it to your watchlist\n`/meme make` - Caption a meme template, `/meme templates` lists them\n**Apps → Quote this** - Turn any message into a quote image",
+				Inline: false,
+			},
+			{
+				Name:   "📖 **Quick Usage Examples:**",
+				Value:  "• `/reply kerja working hard!` - Create auto-reply\n• `/analisis ringkasan pasar` - Get market news (if authorized)\n• `/convert $500 idr` - Convert $500 to Indonesian Rupiah\n• `/convert 1000jpy usd` - Convert 1000 Japanese Yen to USD\n• `/list_replies` - See all server replies\n• `/help_reply` - Detailed auto-reply help",
+				Inline: false,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "💡 Tip: Use /help_reply for detailed auto-reply instructions",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// serverCommandsEmbed builds the embed listing settings and admin commands, kept apart from
+// commandsEmbed since Discord limits how much text one message's embeds hold
+func serverCommandsEmbed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "🛠️ Server Admin Commands",
+		Description: "Settings and tools for members with Manage Server",
+		Color:       0x3498db,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "⚙️ **Server Settings**",
+				Value:  "`/settings prefix` - Enable legacy `!` commands like `!convert $500 idr` (Manage Server only)\n`/settings currency` - Default target for `/convert`, so `/convert $500` just works (Manage Server only)\n`/settings apikey` - Store encrypted API keys for translation and rate providers (Manage Server only)\n`/settings api_token` - Token for posting announcements through the bot's API (Manage Server only)\n`/settings timezone` / `/settings quiet_hours` - Hold posts overnight, e.g. 00:00–06:00 (Manage Server only)\n`/settings command_channels` - Refuse commands in a channel and point members to the bot channel (Manage Server only)\n`/settings bot_channel` - Post conversion and fun command results in the bot channel (Manage Server only)\n`/settings music_links` - song.link cards for Spotify and Apple Music links in a channel (Manage Server only)",
+				Inline: false,
+			},
+			{
+				Name:   "🧩 **Feature Settings**",
+				Value:  "`/settings auto_replies` / `reply_approval` / `reply_roles` - Turn on `/reply` rules, optionally with moderator approval or only for some roles (Manage Server only)\n`/settings profanity` - Reject or mask profanity in auto-replies, with your own word list (Manage Server only)\n`/settings ocr` / `reply_blacklist` - Let images trigger auto-replies in a channel, or keep them out of one (Manage Server only)\n`/settings confessions` - Anonymous `/confess` posts, optionally approved first (Manage Server only)\n`/settings onboarding` - Welcome checklist for new members (Manage Server only)\n`/settings introductions` - Welcome threads on introductions, saved as `/profile` cards (Manage Server only)\n`/settings faq` - Upload a FAQ for `/faqsearch`, optionally auto-answering questions (Manage Server only)\n`/settings shortener` - is.gd, Bitly or your own Shlink for `/shorten` (Manage Server only)\n`/settings search` - Turn on `/search` and pick its safe search level (Manage Server only)",
+				Inline: false,
+			},
+			{
+				Name:   "🛡️ **Admin Tools**",
+				Value:  "`/customcmd` - Create server-only commands like `/faq` or `/rules` (Manage Server only)\n`/schedule` - Schedule announcements, import many from a CSV file, post long weekends, a daily word or a standup, or clean up old messages (Manage Server only)\n`/confessions author` - Who wrote a confession, for abuse reports (Manage Server only)\n`/onboarding` - How many new members finish the onboarding checklist (Manage Server only)\n`/decancer` / `/settings decancer` - Readable nicknames for fancy or hoisted names, by hand or on join (Manage Nicknames)\n`/settings watchwords` - Alert moderators about scam phrases or invites, the message stays up (Manage Server only)\n`/admin selftest` - Check that the bot's services are reachable (Manage Server only)\n`/admin cache stats` - Cache sizes and hit rates (Manage Server only)\n`/admin blocklist` - Block abusive users or servers from the bot (bot owner only)\n`/audit view` / `/audit export` - Who ran which admin commands, as a list or CSV (Manage Server only)",
+				Inline: false,
+			},
+			{
+				Name:   "📡 **Alerts**",
+				Value:  "`/monitor add` / `/monitor list` - Check a service's health URL and post when it goes down or comes back up (Manage Server only)\n`/feed live twitch` - Announce when a Twitch streamer goes live, optionally mentioning a role (Manage Server only)",
+				Inline: false,
+			},
+		},
+	}
+}
+
+// handleConvertCommand handles the /convert slash command for currency conversion
+func handleConvertCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please provide the conversion details. Examples:\n• `/convert $500 idr`\n• `/convert 1000jpy usd`\n• `/convert 100eur gbp`",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	input := options[0].StringValue()
+	if strings.EqualFold(strings.TrimSpace(input), "history") {
+		handleConvertHistory(s, i)
+		return
+	}
+
+	// Parse the input
+	amount, from, to, budget, err := parseConversionQuery(input, getGuildSettings(i.GuildID).DefaultCurrency)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("%s\n\n**Examples:**\n• `/convert $500 idr`\n• `/convert 1000jpy usd`\n• `/convert ?usd 1jt idr` - how many USD is 1jt IDR\n• `/convert budget 100usd idr` - how much IDR buys 100 USD", userErrorMessage(err)),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since currency conversion might take a moment, only the member
+	// sees it when the result goes to the bot channel
+	botChannel := botChannelFor(i)
+	var deferFlags discordgo.MessageFlags
+	if botChannel != "" {
+		deferFlags = discordgo.MessageFlagsEphemeral
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: deferFlags},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	// Perform currency conversion
+	result, err := convertCurrency(amount, from, to)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	recordConversion(interactionUserID(i), result)
+
+	embed := conversionEmbed(result)
+	if budget {
+		embed = budgetEmbed(result)
+	}
+
+	if botChannel != "" {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: postInBotChannel(s, i, botChannel, &discordgo.MessageSend{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: conversionComponents(result, budget),
+			}),
+			Flags: discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: conversionComponents(result, budget),
+	})
+}
+
+// conversionEmbed builds the embed showing a currency conversion result
+func conversionEmbed(result *CurrencyResponse) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "💱 Currency Conversion",
+		Color: 0x2ecc71,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "From",
+				Value:  fmt.Sprintf("%s %s", formatAmount(result.Query.Amount, result.Query.From), strings.ToUpper(result.Query.From)),
+				Inline: true,
+			},
+			{
+				Name:   "To",
+				Value:  fmt.Sprintf("%s %s", formatAmount(result.Result, result.Query.To), strings.ToUpper(result.Query.To)),
+				Inline: true,
+			},
+			{
+				Name:   "Exchange Rate",
+				Value:  fmt.Sprintf("1 %s = %s %s", strings.ToUpper(result.Query.From), formatRate(result.Info.Rate), strings.ToUpper(result.Query.To)),
+				Inline: false,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Exchange rates provided by " + result.Provider,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// budgetEmbed shows how much of the paying currency is needed to get the requested amount
+func budgetEmbed(result *CurrencyResponse) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "💰 Budget",
+		Color: 0x2ecc71,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "To get",
+				Value:  fmt.Sprintf("%s %s", formatAmount(result.Query.Amount, result.Query.From), strings.ToUpper(result.Query.From)),
+				Inline: true,
+			},
+			{
+				Name:   "You need",
+				Value:  fmt.Sprintf("%s %s", formatAmount(result.Result, result.Query.To), strings.ToUpper(result.Query.To)),
+				Inline: true,
+			},
+			{
+				Name:   "Exchange Rate",
+				Value:  fmt.Sprintf("1 %s = %s %s", strings.ToUpper(result.Query.From), formatRate(result.Info.Rate), strings.ToUpper(result.Query.To)),
+				Inline: false,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Exchange rates provided by " + result.Provider,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// commonCurrencies are offered in the re-convert menu under a conversion result
+var commonCurrencies = []struct {
+	Code  string
+	Label string
+}{
+	{"IDR", "🇮🇩 Indonesian Rupiah"},
+	{"USD", "🇺🇸 US Dollar"},
+	{"EUR", "🇪🇺 Euro"},
+	{"SGD", "🇸🇬 Singapore Dollar"},
+	{"MYR", "🇲🇾 Malaysian Ringgit"},
+	{"JPY", "🇯🇵 Japanese Yen"},
+	{"GBP", "🇬🇧 British Pound"},
+	{"AUD", "🇦🇺 Australian Dollar"},
+	{"CNY", "🇨🇳 Chinese Yuan"},
+	{"KRW", "🇰🇷 South Korean Won"},
+	{"SAR", "🇸🇦 Saudi Riyal"},
+	{"THB", "🇹🇭 Thai Baht"},
+}
+
+// conversionComponents builds the re-convert select menu and swap button for a conversion result.
+// The amount and pair are carried in the custom IDs so no state has to be kept.
+func conversionComponents(result *CurrencyResponse, budget bool) []discordgo.MessageComponent {
+	from := strings.ToUpper(result.Query.From)
+	to := strings.ToUpper(result.Query.To)
+	amount := strconv.FormatFloat(result.Query.Amount, 'f', -1, 64)
+	key := amount
+	if budget {
+		key += "|budget" // so the new result is shown as a budget again
+	}
+
+	var options []discordgo.SelectMenuOption
+	for _, currency := range commonCurrencies {
+		if currency.Code == from || currency.Code == to {
+			continue
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label: currency.Label,
+			Value: currency.Code,
+		})
+	}
+
+	zero := 0
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    fmt.Sprintf("convert_to:%s|%s", key, from),
+					Placeholder: fmt.Sprintf("Convert %s %s to another currency…", amount, from),
+					MinValues:   &zero,
+					MaxValues:   1,
+					Options:     options,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    fmt.Sprintf("Swap to %s → %s", to, from),
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("convert_swap:%s|%s|%s", key, to, from),
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔁"},
+				},
+			},
+		},
+	}
+}
+
+// handleConvertComponent re-runs a conversion from the select menu or swap button and updates the result in place
+func handleConvertComponent(s *discordgo.Session, i *discordgo.InteractionCreate, action, arg string) {
+	parts := strings.Split(arg, "|")
+	data := i.MessageComponentData()
+	budget := len(parts) > 1 && parts[1] == "budget"
+	if budget {
+		parts = slices.Delete(parts, 1, 2)
+	}
+
+	var from, to string
+	switch {
+	case action == "convert_to" && len(parts) == 2 && len(data.Values) == 1:
+		from, to = parts[1], data.Values[0]
+	case action == "convert_swap" && len(parts) == 3:
+		from, to = parts[1], parts[2]
+	default:
+		// Clearing the select menu needs no conversion
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		})
+		return
+	}
+
+	amount, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		log.Printf("Error parsing conversion amount %q: %v", parts[0], err)
+		return
+	}
+
+	// Defer the update since currency conversion might take a moment
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	result, err := convertCurrency(amount, from, to)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+	recordConversion(interactionUserID(i), result)
+
+	embeds := []*discordgo.MessageEmbed{conversionEmbed(result)}
+	if budget {
+		embeds = []*discordgo.MessageEmbed{budgetEmbed(result)}
+	}
+	components := conversionComponents(result, budget)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &embeds,
+		Components: &components,
+	}); err != nil {
+		log.Printf("Error updating conversion message: %v", err)
+	}
+}
+
+// loadConversionHistory loads users' recent conversions from JSON file
+func loadConversionHistory() {
+	conversionHistory = make(UserConversions)
+
+	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(historyFile)
+	if err != nil {
+		log.Printf("Error reading conversion history file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &conversionHistory); err != nil {
+		log.Printf("Error parsing conversion history file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded conversion history for %d users", len(conversionHistory))
+}
+
+// saveConversionHistory saves users' recent conversions to JSON file. The caller must hold
+// conversionsMu.
+func saveConversionHistory() {
+	data, err := json.MarshalIndent(conversionHistory, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling conversion history: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(historyFile, data, 0644); err != nil {
+		log.Printf("Error saving conversion history: %v", err)
+		return
+	}
+}
+
+// recordConversion adds a conversion to the user's history, keeping only the most recent ones
+func recordConversion(userID string, result *CurrencyResponse) {
+	if userID == "" {
+		return
+	}
+
+	conversionsMu.Lock()
+	defer conversionsMu.Unlock()
+	history := append(conversionHistory[userID], ConversionRecord{
+		Amount:    result.Query.Amount,
+		From:      result.Query.From,
+		To:        result.Query.To,
+		Result:    result.Result,
+		Rate:      result.Info.Rate,
+		Converted: time.Unix(result.Info.Timestamp, 0),
+	})
+	if len(history) > maxConversionHistory {
+		history = history[len(history)-maxConversionHistory:]
+	}
+	conversionHistory[userID] = history
+	saveConversionHistory()
+}
+
+// conversionHistoryEmbed lists a user's recent conversions, newest first
+func conversionHistoryEmbed(userID string) *discordgo.MessageEmbed {
+	conversionsMu.Lock()
+	history := slices.Clone(conversionHistory[userID])
+	conversionsMu.Unlock()
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🕘 Your Recent Conversions",
+		Description: "Rates as they were when you converted",
+		Color:       0x2ecc71,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Showing your last %d conversions", len(history)),
+		},
+	}
+
+	for idx := len(history) - 1; idx >= 0; idx-- {
+		record := history[idx]
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("%s %s → %s %s", formatAmount(record.Amount, record.From), strings.ToUpper(record.From),
+				formatAmount(record.Result, record.To), strings.ToUpper(record.To)),
+			Value:  fmt.Sprintf("1 %s = %s %s · <t:%d:R>", strings.ToUpper(record.From), formatRate(record.Rate), strings.ToUpper(record.To), record.Converted.Unix()),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// handleConvertHistory handles "/convert history"
+func handleConvertHistory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	conversionsMu.Lock()
+	empty := len(conversionHistory[userID]) == 0
+	conversionsMu.Unlock()
+	if empty {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "📝 You haven't converted anything yet. Try `/convert $500 idr`!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{conversionHistoryEmbed(userID)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleAnalisisCommand handles the /analisis slash command for RSS feeds
+func handleAnalisisCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Check if command is used in the allowed server
+	if i.GuildID != analisisServerID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ The `/analisis` command is only available in specific servers. This command is restricted to authorized servers only.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Check if command is used in the allowed channel
+	if i.ChannelID != analisisChannelID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ The `/analisis` command can only be used in the designated channel. Please use it in the correct channel.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+
+	if len(options) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please provide a topic! Example: `/analisis ringkasan pasar`",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	topic := strings.ToLower(options[0].StringValue())
+
+	// Find matching RSS URL
+	rssURL, foundTopic := findRSSTopic(topic)
+
+	if rssURL == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Topic not found! Available topics:\n• %s", strings.Join(availableRSSTopics(), "\n• ")),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since fetching RSS might take time
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	// Fetch RSS feed
+	rss, err := fetchRSSFeed(rssURL)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	if len(rss.Channel.Items) == 0 {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "📰 No news articles found for this topic.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{newsEmbed(foundTopic, rss)},
+	})
+}
+
+// findRSSTopic finds the RSS URL and topic key matching the requested topic
+func findRSSTopic(topic string) (rssURL, foundTopic string) {
+	for key, url := range rssTopics {
+		if strings.Contains(topic, key) || key == topic {
+			return url, key
+		}
+	}
+	return "", ""
+}
+
+// availableRSSTopics lists the topic names accepted by /analisis
+func availableRSSTopics() []string {
+	availableTopics := make([]string, 0, len(rssTopics))
+	for topic := range rssTopics {
+		availableTopics = append(availableTopics, topic)
+	}
+	return availableTopics
+}
+
+// cleanDescription removes HTML tags from an RSS item description and limits its length
+func cleanDescription(description string) string {
+	description = strings.ReplaceAll(description, "<![CDATA[", "")
+	description = strings.ReplaceAll(description, "]]>", "")
+	description = strings.ReplaceAll(description, "<p>", "")
+	description = strings.ReplaceAll(description, "</p>", "")
+	description = strings.ReplaceAll(description, "<br>", "\n")
+	description = strings.ReplaceAll(description, "<br/>", "\n")
+
+	if len(description) > 200 {
+		description = description[:200] + "..."
+	}
+	return description
+}
+
+// newsEmbed builds the embed with the latest news for a topic (limit to 5 articles)
+func newsEmbed(foundTopic string, rss *RSS) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📰 %s - %s", strings.ToUpper(string(foundTopic[0]))+foundTopic[1:], rss.Channel.Title),
+		Description: "Latest news from Investing.com",
+		Color:       0x1f8b4c,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Source: Investing.com",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	maxItems := 5
+	if len(rss.Channel.Items) < maxItems {
+		maxItems = len(rss.Channel.Items)
+	}
+
+	for i := 0; i < maxItems; i++ {
+		item := rss.Channel.Items[i]
+
+		description := cleanDescription(item.Description)
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   item.Title,
+			Value:  fmt.Sprintf("%s\n\n[Read More](%s)", description, item.Link),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// handleSettingsCommand handles the /settings slash command for server admins
+func handleSettingsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Settings only work in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to change bot settings.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	var content string
+	switch subcommand.Name {
+	case "prefix":
+		enabled := subcommand.Options[0].BoolValue()
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.PrefixCommands = enabled
+		})
+		if enabled {
+			content = fmt.Sprintf("✅ Prefix commands enabled for this server. Try `%sconvert $500 idr`!", commandPrefix)
+		} else {
+			content = "✅ Prefix commands disabled for this server."
+		}
+	case "timezone":
+		name := subcommand.Options[0].StringValue()
+		loc, err := parseTimezone(name)
+		if err != nil {
+			content = "❌ " + err.Error()
+			break
+		}
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.Timezone = strings.TrimSpace(name)
+		})
+		content = fmt.Sprintf("✅ Server timezone set to **%s** (currently %s).", loc.String(), time.Now().In(loc).Format("15:04"))
+	case "quiet_hours":
+		content = quietHoursSetting(i.GuildID, subcommand.Options)
+	case "apikey":
+		content = apiKeySetting(i, subcommand.Options[0])
+	case "auto_replies":
+		enabled := subcommand.Options[0].BoolValue()
+		maxRules := 0
+		for _, opt := range subcommand.Options[1:] {
+			if opt.Name == "max_rules" {
+				maxRules = int(opt.IntValue())
+			}
+		}
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.AutoReplies = enabled
+			if maxRules > 0 {
+				settings.MaxReplies = maxRules
+			}
+		})
+		if enabled {
+			content = fmt.Sprintf("✅ Auto-replies enabled. Members can create up to %d rules with `/reply`.", getGuildSettings(i.GuildID).replyLimit())
+		} else {
+			content = "✅ Auto-replies disabled. Existing rules are kept but won't fire."
+		}
+	case "reply_approval":
+		content = replyApprovalSetting(i.GuildID, subcommand.Options)
+	case "confessions":
+		content = confessionsSetting(i.GuildID, subcommand.Options)
+	case "faq":
+		handleFAQSetting(s, i, subcommand.Options)
+		return
+	case "profanity":
+		content = profanitySetting(i.GuildID, subcommand.Options[0])
+	case "reply_roles":
+		content = replyRolesSetting(i.GuildID, subcommand.Options[0])
+	case "watchwords":
+		content = watchwordsSetting(i.GuildID, subcommand.Options[0])
+	case "ocr":
+		content = ocrSetting(i.GuildID, subcommand.Options)
+	case "reply_blacklist":
+		content = replyBlacklistSetting(i.GuildID, subcommand.Options)
+	case "music_links":
+		content = musicLinksSetting(i.GuildID, subcommand.Options)
+	case "command_channels":
+		content = commandChannelsSetting(i.GuildID, subcommand.Options)
+	case "bot_channel":
+		content = botChannelSetting(i.GuildID, subcommand.Options)
+	case "onboarding":
+		content = onboardingSetting(i.GuildID, subcommand.Options)
+	case "introductions":
+		content = introductionsSetting(i.GuildID, subcommand.Options)
+	case "decancer":
+		enabled := subcommand.Options[0].BoolValue()
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.Decancer = enabled
+		})
+		if enabled {
+			content = "✅ Joining members with unreadable or hoisted names now get a clean nickname, each change goes in `/audit`."
+		} else {
+			content = "✅ Nicknames of joining members are left as they are."
+		}
+	case "shortener":
+		content = shortenerSetting(i.GuildID, subcommand.Options)
+	case "search":
+		content = searchSetting(i.GuildID, subcommand.Options)
+	case "slang":
+		enabled := subcommand.Options[0].BoolValue()
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.Slang = enabled
+		})
+		if enabled {
+			content = "✅ `/slang` now works in every channel. Definitions are user-submitted and can be explicit."
+		} else {
+			content = "✅ `/slang` is limited to age-restricted channels again."
+		}
+	case "api_token":
+		content = apiTokenSetting(i, subcommand.Options[0])
+	case "currency":
+		var code string
+		if len(subcommand.Options) > 0 {
+			code = strings.ToLower(strings.TrimSpace(subcommand.Options[0].StringValue()))
+		}
+		if code != "" && !isCryptoTicker(code) && !currencyCode.MatchString(code) {
+			content = fmt.Sprintf("❌ `%s` is not a currency code. Use a code like `idr` or `usd`.", code)
+			break
+		}
+		updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+			settings.DefaultCurrency = code
+		})
+		if code == "" {
+			content = "✅ Default currency cleared. `/convert` needs a target currency again."
+		} else {
+			content = fmt.Sprintf("✅ Default currency set to **%s**. `/convert $500` now converts to %s.", strings.ToUpper(code), strings.ToUpper(code))
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// quietHoursSetting turns quiet hours on or off for a server and returns the reply
+func quietHoursSetting(guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	var enabled bool
+	var start, end string
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "start":
+			start = opt.StringValue()
+		case "end":
+			end = opt.StringValue()
+		}
+	}
+
+	if !enabled {
+		updateGuildSettings(guildID, func(settings *GuildSettings) {
+			settings.QuietStart = ""
+			settings.QuietEnd = ""
+		})
+		return "✅ Quiet hours disabled for this server."
+	}
+
+	if start == "" {
+		start = "00:00"
+	}
+	if end == "" {
+		end = "06:00"
+	}
+	startMinute, err := parseClock(start)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	endMinute, err := parseClock(end)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if startMinute == endMinute {
+		return "❌ Quiet hours must start and end at different times."
+	}
+
+	var settings *GuildSettings
+	updateGuildSettings(guildID, func(stored *GuildSettings) {
+		stored.QuietStart = fmt.Sprintf("%02d:%02d", startMinute/60, startMinute%60)
+		stored.QuietEnd = fmt.Sprintf("%02d:%02d", endMinute/60, endMinute%60)
+		copied := *stored
+		settings = &copied
+	})
+	return fmt.Sprintf("✅ Quiet hours set to **%s–%s** (%s). Scheduled messages, news posts and digests are held and delivered when quiet hours end.",
+		settings.QuietStart, settings.QuietEnd, settings.location().String())
+}
+
+// guildAPIKeyNames are the per-server API keys admins can store with /settings apikey
+var guildAPIKeyNames = map[string]string{
+	"translate":    "Translation API",
+	"exchangerate": "exchangerate-api.com",
+	"coingecko":    "CoinGecko",
+	"llm":          "LLM provider",
+	"ocr":          "OCR.space",
+	"bitly":        "Bitly",
+	"shlink":       "Shlink",
+	"github":       "GitHub",
+	"omdb":         "OMDb",
+	"tmdb":         "TMDB",
+}
+
+// secretsKey reads the 32-byte master key from SECRETS_MASTER_KEY (base64 or hex)
+func secretsKey() (*[32]byte, error) {
+	encoded := strings.TrimSpace(os.Getenv("SECRETS_MASTER_KEY"))
+	if encoded == "" {
+		return nil, fmt.Errorf("SECRETS_MASTER_KEY is not set")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 32 {
+		raw, err = hex.DecodeString(encoded)
+	}
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("SECRETS_MASTER_KEY must be 32 bytes, base64 or hex encoded")
+	}
+
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// encryptSecret seals a secret with the master key, returning base64(nonce || box)
+func encryptSecret(plain string) (string, error) {
+	key, err := secretsKey()
+	if err != nil {
+		return "", err
+	}
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := secretbox.Seal(nonce[:], []byte(plain), &nonce, key)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret opens a secret sealed by encryptSecret
+func decryptSecret(encoded string) (string, error) {
+	key, err := secretsKey()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < 24 {
+		return "", fmt.Errorf("invalid encrypted secret")
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], sealed[:24])
+	plain, ok := secretbox.Open(nil, sealed[24:], &nonce, key)
+	if !ok {
+		return "", fmt.Errorf("failed to decrypt secret, was SECRETS_MASTER_KEY changed?")
+	}
+	return string(plain), nil
+}
+
+// maskSecret hides all but the last four characters of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "••••••••"
+	}
+	return "••••••••" + secret[len(secret)-4:]
+}
+
+// loadGuildSecrets loads encrypted per-server API keys from JSON file
+func loadGuildSecrets() {
+	guildSecrets = make(ServerSecrets)
+
+	if _, err := os.Stat(secretsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(secretsFile)
+	if err != nil {
+		log.Printf("Error reading guild secrets file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &guildSecrets); err != nil {
+		log.Printf("Error parsing guild secrets file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded API keys for %d servers", len(guildSecrets))
+}
+
+// saveGuildSecrets saves encrypted per-server API keys to JSON file, readable only by the bot user
+func saveGuildSecrets() {
+	data, err := json.MarshalIndent(guildSecrets, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling guild secrets: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(secretsFile, data, 0600); err != nil {
+		log.Printf("Error saving guild secrets: %v", err)
+		return
+	}
+}
+
+// guildAPIKey returns a server's decrypted API key for a service, if one is stored
+func guildAPIKey(guildID, name string) (string, bool) {
+	secretsMu.Lock()
+	stored, ok := guildSecrets[guildID][name]
+	secretsMu.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	key, err := decryptSecret(stored.Value)
+	if err != nil {
+		log.Printf("Error decrypting %s API key for guild %s: %v", name, guildID, err)
+		return "", false
+	}
+	return key, true
+}
+
+// apiKeySetting handles /settings apikey set|clear|list and returns the reply
+func apiKeySetting(i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	var name, value string
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "service":
+			name = opt.StringValue()
+		case "key":
+			value = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	switch subcommand.Name {
+	case "set":
+		encrypted, err := encryptSecret(value)
+		if err != nil {
+			log.Printf("Error encrypting API key: %v", err)
+			return "❌ API keys can't be stored because the bot has no encryption key configured. Ask the bot owner to set SECRETS_MASTER_KEY."
+		}
+		if guildSecrets[i.GuildID] == nil {
+			guildSecrets[i.GuildID] = make(map[string]StoredSecret)
+		}
+		guildSecrets[i.GuildID][name] = StoredSecret{
+			Value:  encrypted,
+			Masked: maskSecret(value),
+			SetBy:  i.Member.User.ID,
+			SetAt:  time.Now(),
+		}
+		saveGuildSecrets()
+		return fmt.Sprintf("✅ %s key saved (`%s`). It is stored encrypted and won't be shown again.", guildAPIKeyNames[name], maskSecret(value))
+
+	case "clear":
+		if _, ok := guildSecrets[i.GuildID][name]; !ok {
+			return fmt.Sprintf("❌ No %s key is stored for this server.", guildAPIKeyNames[name])
+		}
+		delete(guildSecrets[i.GuildID], name)
+		if len(guildSecrets[i.GuildID]) == 0 {
+			delete(guildSecrets, i.GuildID)
+		}
+		saveGuildSecrets()
+		return fmt.Sprintf("✅ %s key removed.", guildAPIKeyNames[name])
+
+	default:
+		secrets := guildSecrets[i.GuildID]
+		if len(secrets) == 0 {
+			return "📝 No API keys are stored for this server."
+		}
+		names := make([]string, 0, len(secrets))
+		for name := range secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		lines := []string{"🔐 **Stored API keys**"}
+		for _, name := range names {
+			stored := secrets[name]
+			lines = append(lines, fmt.Sprintf("• %s: `%s`, set by <@%s> <t:%d:R>", guildAPIKeyNames[name], stored.Masked, stored.SetBy, stored.SetAt.Unix()))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// loadAPITokens loads hashed inbound API tokens from JSON file
+func loadAPITokens() {
+	apiTokens = make(ServerAPITokens)
+
+	if _, err := os.Stat(tokensFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(tokensFile)
+	if err != nil {
+		log.Printf("Error reading API tokens file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &apiTokens); err != nil {
+		log.Printf("Error parsing API tokens file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded API tokens for %d servers", len(apiTokens))
+}
+
+// saveAPITokens saves hashed inbound API tokens to JSON file, readable only by the bot user
+func saveAPITokens() {
+	data, err := json.MarshalIndent(apiTokens, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling API tokens: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(tokensFile, data, 0600); err != nil {
+		log.Printf("Error saving API tokens: %v", err)
+		return
+	}
+}
+
+// hashAPIToken returns the hex SHA-256 of a token as stored in api_tokens.json
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkAPIToken reports whether token is the current API token of guildID. A token issued
+// for one server never matches another, since each server's hash is checked on its own.
+func checkAPIToken(guildID, token string) bool {
+	tokensMu.Lock()
+	stored, ok := apiTokens[guildID]
+	tokensMu.Unlock()
+	if !ok || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hashAPIToken(token))) == 1
+}
+
+// apiTokenSetting handles /settings api_token regenerate|revoke|show and returns the reply
+func apiTokenSetting(i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	switch subcommand.Name {
+	case "regenerate":
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			log.Printf("Error generating API token: %v", err)
+			return "❌ Failed to generate a token. Please try again."
+		}
+		token := "cerdas_" + base64.RawURLEncoding.EncodeToString(raw)
+		_, replaced := apiTokens[i.GuildID]
+		apiTokens[i.GuildID] = APIToken{
+			Hash:      hashAPIToken(token),
+			Masked:    maskSecret(token),
+			CreatedBy: i.Member.User.ID,
+			CreatedAt: time.Now(),
+		}
+		saveAPITokens()
+
+		content := fmt.Sprintf("🔑 New API token for this server:\n```\n%s\n```\nSend it as `Authorization: Bearer <token>` to `POST /api/guilds/%s/announce`. It only works for this server and won't be shown again.", token, i.GuildID)
+		if replaced {
+			content += "\nThe previous token no longer works."
+		}
+		if os.Getenv("API_ADDR") == "" {
+			content += "\n⚠️ The inbound API is not enabled on this bot. Ask the bot owner to set API_ADDR."
+		}
+		return content
+
+	case "revoke":
+		if _, ok := apiTokens[i.GuildID]; !ok {
+			return "❌ This server has no API token."
+		}
+		delete(apiTokens, i.GuildID)
+		saveAPITokens()
+		return "✅ API token revoked. Calls using it will be rejected."
+
+	default:
+		stored, ok := apiTokens[i.GuildID]
+		if !ok {
+			return "📝 This server has no API token. Create one with `/settings api_token regenerate`."
+		}
+		return fmt.Sprintf("🔑 API token `%s`, created by <@%s> <t:%d:R>", stored.Masked, stored.CreatedBy, stored.CreatedAt.Unix())
+	}
+}
+
+// announceRequest is the body of POST /api/guilds/{guildID}/announce
+type announceRequest struct {
+	ChannelID   string `json:"channel_id"`
+	Content     string `json:"content"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// runAPIServer serves the token-authenticated inbound API until ctx is cancelled
+func runAPIServer(ctx context.Context, s *discordgo.Session, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/guilds/{guildID}/announce", func(w http.ResponseWriter, r *http.Request) {
+		handleAnnounceAPI(s, w, r)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Inbound API listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error running inbound API: %v", err)
+	}
+}
+
+// writeAPIError writes a JSON error response
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// handleAnnounceAPI posts a message to a channel of the guild the token belongs to
+func handleAnnounceAPI(s *discordgo.Session, w http.ResponseWriter, r *http.Request) {
+	guildID := r.PathValue("guildID")
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !checkAPIToken(guildID, strings.TrimSpace(token)) {
+		writeAPIError(w, http.StatusUnauthorized, "invalid API token for this server")
+		return
+	}
+
+	var req announceRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.ChannelID == "" || (req.Content == "" && req.Title == "" && req.Description == "") {
+		writeAPIError(w, http.StatusBadRequest, "channel_id and content, title or description are required")
+		return
+	}
+	if len(req.Content) > 2000 || len(req.Title) > 256 || len(req.Description) > 4096 {
+		writeAPIError(w, http.StatusBadRequest, "message is longer than Discord allows")
+		return
+	}
+
+	// The channel must belong to the token's server
+	channel, err := s.State.Channel(req.ChannelID)
+	if err != nil {
+		channel, err = s.Channel(req.ChannelID)
+	}
+	if err != nil || channel.GuildID != guildID {
+		writeAPIError(w, http.StatusNotFound, "channel not found in this server")
+		return
+	}
+
+	message := &discordgo.MessageSend{Content: req.Content}
+	if req.Title != "" || req.Description != "" {
+		message.Embeds = []*discordgo.MessageEmbed{{
+			Title:       req.Title,
+			Description: req.Description,
+			Color:       embedColor,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}}
+	}
+
+	msg, err := queueBackgroundSend(channel.ID, func() (*discordgo.Message, error) {
+		return s.ChannelMessageSendComplex(channel.ID, message)
+	})
+	if err != nil {
+		log.Printf("Error sending API announcement to channel %s: %v", channel.ID, err)
+		writeAPIError(w, http.StatusBadGateway, "failed to send message")
+		return
+	}
+
+	log.Printf("API announcement posted to guild %s channel %s", guildID, channel.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message_id": msg.ID, "channel_id": channel.ID})
+}
+
+// customCommandName matches the names Discord accepts for slash commands
+var customCommandName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// loadCustomCommands loads custom slash commands from JSON file
+func loadCustomCommands() {
+	customCommands = make(ServerCustomCommands)
+
+	if _, err := os.Stat(commandsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(commandsFile)
+	if err != nil {
+		log.Printf("Error reading custom commands file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &customCommands); err != nil {
+		log.Printf("Error parsing custom commands file: %v", err)
+		return
+	}
+
+	total := 0
+	for _, cmds := range customCommands {
+		total += len(cmds)
+	}
+	log.Printf("Loaded %d custom commands across %d servers", total, len(customCommands))
+}
+
+// saveCustomCommands saves custom slash commands to JSON file
+func saveCustomCommands() {
+	data, err := json.MarshalIndent(customCommands, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling custom commands: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(commandsFile, data, 0644); err != nil {
+		log.Printf("Error saving custom commands: %v", err)
+		return
+	}
+}
+
+// findCustomCommand returns the custom command with the given name in a server, or nil
+func findCustomCommand(guildID, name string) *CustomCommand {
+	for i := range customCommands[guildID] {
+		if customCommands[guildID][i].Name == name {
+			return &customCommands[guildID][i]
+		}
+	}
+	return nil
+}
+
+// isBuiltinCommand checks if a name is already used by one of the bot's global commands
+func isBuiltinCommand(name string) bool {
+	for _, cmd := range slashCommands() {
+		if cmd.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCustomCommandAdmin handles the /customcmd slash command for managing server commands
+func handleCustomCommandAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Custom commands only work in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to manage custom commands.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subcommand.Options {
+		options[opt.Name] = opt
+	}
+
+	var content string
+	switch subcommand.Name {
+	case "add":
+		content = addCustomCommand(s, i, options)
+	case "remove":
+		content = removeCustomCommand(s, i.GuildID, strings.ToLower(options["name"].StringValue()))
+	case "list":
+		cmds := customCommands[i.GuildID]
+		if len(cmds) == 0 {
+			content = "📝 No custom commands set up for this server."
+			break
+		}
+		lines := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			lines = append(lines, fmt.Sprintf("• `/%s` - %s", cmd.Name, cmd.Description))
+		}
+		content = fmt.Sprintf("📋 **Custom commands (%d):**\n%s", len(cmds), strings.Join(lines, "\n"))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// addCustomCommand registers or updates a guild-scoped slash command and returns the reply text
+func addCustomCommand(s *discordgo.Session, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) string {
+	name := strings.ToLower(options["name"].StringValue())
+	response := options["response"].StringValue()
+
+	description := "Custom command for this server"
+	if opt, ok := options["description"]; ok {
+		description = opt.StringValue()
+	}
+
+	if !customCommandName.MatchString(name) {
+		return "❌ Command names must be 1-32 characters using only lowercase letters, numbers, `-` and `_`."
+	}
+	if isBuiltinCommand(name) {
+		return fmt.Sprintf("❌ `/%s` is already a built-in bot command.", name)
+	}
+
+	// Creating a command with an existing name overwrites it on Discord's side
+	created, err := s.ApplicationCommandCreate(s.State.User.ID, i.GuildID, &discordgo.ApplicationCommand{
+		Name:        name,
+		Description: description,
+	})
+	if err != nil {
+		log.Printf("Cannot create custom command %v in guild %s: %v", name, i.GuildID, err)
+		return fmt.Sprintf("❌ Failed to register `/%s`: %v", name, err)
+	}
+
+	var userID string
+	if i.Member != nil {
+		userID = i.Member.User.ID
+	}
+
+	if existing := findCustomCommand(i.GuildID, name); existing != nil {
+		existing.Description = description
+		existing.Response = response
+		existing.CommandID = created.ID
+		saveCustomCommands()
+		return fmt.Sprintf("✅ Custom command `/%s` updated!", name)
+	}
+
+	customCommands[i.GuildID] = append(customCommands[i.GuildID], CustomCommand{
+		Name:        name,
+		Description: description,
+		Response:    response,
+		CommandID:   created.ID,
+		AuthorID:    userID,
+	})
+	saveCustomCommands()
+	return fmt.Sprintf("✅ Custom command `/%s` created! It may take a moment to show up in Discord.", name)
+}
+
+// removeCustomCommand unregisters a guild-scoped slash command and returns the reply text
+func removeCustomCommand(s *discordgo.Session, guildID, name string) string {
+	for idx, cmd := range customCommands[guildID] {
+		if cmd.Name != name {
+			continue
+		}
+
+		if err := s.ApplicationCommandDelete(s.State.User.ID, guildID, cmd.CommandID); err != nil {
+			log.Printf("Cannot delete custom command %v in guild %s: %v", name, guildID, err)
+		}
+
+		customCommands[guildID] = append(customCommands[guildID][:idx], customCommands[guildID][idx+1:]...)
+		if len(customCommands[guildID]) == 0 {
+			delete(customCommands, guildID)
+		}
+
+		saveCustomCommands()
+		return fmt.Sprintf("✅ Custom command `/%s` removed!", name)
+	}
+	return fmt.Sprintf("❌ No custom command named `/%s` in this server.", name)
+}
+
+// handleCustomCommand responds to an invocation of a server's custom command
+func handleCustomCommand(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *CustomCommand) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: cmd.Response,
+		},
+	})
+}
+
+// sendPrefixReply replies to a prefix command message with text
+func sendPrefixReply(s *discordgo.Session, m *discordgo.MessageCreate, content string) {
+	_, err := s.ChannelMessageSendReply(m.ChannelID, content, &discordgo.MessageReference{
+		MessageID: m.ID,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+	})
+	if err != nil {
+		log.Printf("Error sending prefix command reply: %v", err)
+		// Fallback to regular message if reply fails
+		s.ChannelMessageSend(m.ChannelID, content)
+	}
+}
+
+// sendPrefixEmbed replies to a prefix command message with an embed
+func sendPrefixEmbed(s *discordgo.Session, m *discordgo.MessageCreate, embed *discordgo.MessageEmbed) {
+	_, err := s.ChannelMessageSendEmbedReply(m.ChannelID, embed, &discordgo.MessageReference{
+		MessageID: m.ID,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+	})
+	if err != nil {
+		log.Printf("Error sending prefix command embed: %v", err)
+		// Fallback to regular message if reply fails
+		s.ChannelMessageSendEmbed(m.ChannelID, embed)
+	}
+}
+
+// handlePrefixCommand maps legacy text commands like "!convert $500 idr" onto the slash command logic
+func handlePrefixCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	content := strings.TrimSpace(strings.TrimPrefix(m.Content, commandPrefix))
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return
+	}
+
+	name := strings.ToLower(fields[0])
+	args := strings.TrimSpace(content[len(fields[0]):])
+
+	log.Printf("Prefix command %s in guild %s from %s: %s", name, m.GuildID, m.Author.Username, args)
+
+	switch name {
+	case "reply":
+		handlePrefixReply(s, m, args)
+	case "list_replies":
+		embed, pages := listRepliesEmbed(m.GuildID, 0, replyFilter{})
+		if embed == nil {
+			sendPrefixReply(s, m, "📝 No auto-reply rules set up for this server.")
+			return
+		}
+		if pages > 1 {
+			embed.Footer.Text += " · /list_replies pages through the rest"
+		}
+		sendPrefixEmbed(s, m, embed)
+	case "help_reply":
+		sendPrefixEmbed(s, m, helpReplyEmbed())
+	case "commands":
+		sendPrefixEmbed(s, m, commandsEmbed())
+		if memberHasManageGuild(s, m.Author.ID, m.ChannelID) {
+			sendPrefixEmbed(s, m, serverCommandsEmbed())
+		}
+	case "convert":
+		handlePrefixConvert(s, m, args)
+	case "analisis":
+		handlePrefixAnalisis(s, m, args)
+	}
+}
+
+// handlePrefixReply handles "!reply <trigger> <response>" and "!reply remove <trigger>"
+func handlePrefixReply(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	settings := getGuildSettings(m.GuildID)
+	if !settings.AutoReplies {
+		sendPrefixReply(s, m, autoRepliesOffMessage)
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		sendPrefixReply(s, m, fmt.Sprintf("❌ Usage: `%sreply <trigger> <response>` or `%sreply remove <trigger>`", commandPrefix, commandPrefix))
+		return
+	}
+
+	if strings.EqualFold(fields[0], "remove") {
+		success, message, removedFor := removeAutoReply(fields[1], m.Author.ID, m.GuildID, memberHasManageGuild(s, m.Author.ID, m.ChannelID))
+		if success {
+			message = "✅ " + message
+		} else if !strings.Contains(message, "bartard") {
+			message = "❌ " + message
+		}
+		sendPrefixReply(s, m, message)
+
+		// Audited like the /reply override
+		if removedFor != "" {
+			appendAudit(m.GuildID, AuditEntry{
+				Time:    time.Now(),
+				UserID:  m.Author.ID,
+				User:    m.Author.Username,
+				Command: commandPrefix + "reply remove",
+				Options: truncate("trigger="+fields[1], 500),
+				Result:  "removed a rule by " + removedFor,
+			})
+		}
+		return
+	}
+
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	if len(settings.ReplyRoles) > 0 && !canCreateReplies(settings, roles, memberHasManageGuild(s, m.Author.ID, m.ChannelID)) {
+		sendPrefixReply(s, m, replyRolesMessage(settings))
+		return
+	}
+
+	trigger := fields[0]
+	response, ok := screenReplyResponse(m.GuildID, strings.TrimSpace(args[len(trigger):]))
+	if !ok {
+		sendPrefixReply(s, m, profanityRejectedMessage)
+		return
+	}
+
+	if settings.ReplyApproval && !memberHasManageGuild(s, m.Author.ID, m.ChannelID) {
+		sendPrefixReply(s, m, submitReplyForReview(s, settings, m.GuildID, draftRule(m.GuildID, trigger, response), m.Author.ID))
+		return
+	}
+
+	success, message, _ := addAutoReply(trigger, response, m.Author.ID, m.GuildID)
+	if !success {
+		if !strings.Contains(message, "bartard") {
+			message = "❌ " + message
+		}
+		sendPrefixReply(s, m, message)
+		return
+	}
+
+	sendPrefixEmbed(s, m, &discordgo.MessageEmbed{
+		Title:       "✅ Auto-Reply Set Up Successfully!",
+		Description: fmt.Sprintf("**Trigger:** %s\n**Response:** %s", trigger, response),
+		Color:       embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "The bot will now automatically reply when someone sends the trigger message. Only you can modify this auto-reply.",
+		},
+	})
+}
+
+// handlePrefixConvert handles "!convert $500 idr"
+func handlePrefixConvert(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	examples := fmt.Sprintf("**Examples:**\n• `%[1]sconvert $500 idr`\n• `%[1]sconvert 1000jpy usd`\n• `%[1]sconvert ?usd 1jt idr`\n• `%[1]sconvert budget 100usd idr`", commandPrefix)
+
+	if args == "" {
+		sendPrefixReply(s, m, "❌ Please provide the conversion details.\n\n"+examples)
+		return
+	}
+
+	amount, from, to, budget, err := parseConversionQuery(args, getGuildSettings(m.GuildID).DefaultCurrency)
+	if err != nil {
+		sendPrefixReply(s, m, userErrorMessage(err)+"\n\n"+examples)
+		return
+	}
+
+	result, err := convertCurrency(amount, from, to)
+	if err != nil {
+		sendPrefixReply(s, m, userErrorMessage(err))
+		return
+	}
+	recordConversion(m.Author.ID, result)
+
+	if budget {
+		sendPrefixEmbed(s, m, budgetEmbed(result))
+		return
+	}
+	sendPrefixEmbed(s, m, conversionEmbed(result))
+}
+
+// handlePrefixAnalisis handles "!analisis <topic>" with the same server/channel restriction as /analisis
+func handlePrefixAnalisis(s *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	if m.GuildID != analisisServerID || m.ChannelID != analisisChannelID {
+		sendPrefixReply(s, m, "❌ The `analisis` command is restricted to authorized servers and channels only.")
+		return
+	}
+
+	if args == "" {
+		sendPrefixReply(s, m, fmt.Sprintf("❌ Please provide a topic! Example: `%sanalisis ringkasan pasar`", commandPrefix))
+		return
+	}
+
+	rssURL, foundTopic := findRSSTopic(strings.ToLower(args))
+	if rssURL == "" {
+		sendPrefixReply(s, m, fmt.Sprintf("❌ Topic not found! Available topics:\n• %s", strings.Join(availableRSSTopics(), "\n• ")))
+		return
+	}
+
+	rss, err := fetchRSSFeed(rssURL)
+	if err != nil {
+		sendPrefixReply(s, m, userErrorMessage(err))
+		return
+	}
+
+	if len(rss.Channel.Items) == 0 {
+		sendPrefixReply(s, m, "📰 No news articles found for this topic.")
+		return
+	}
+
+	sendPrefixEmbed(s, m, newsEmbed(foundTopic, rss))
+}
+
+// loadScheduledMessages loads queued scheduled messages from JSON file
+func loadScheduledMessages() {
+	scheduledMessages = make([]ScheduledMessage, 0)
+
+	if _, err := os.Stat(scheduleFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(scheduleFile)
+	if err != nil {
+		log.Printf("Error reading scheduled messages file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &scheduledMessages); err != nil {
+		log.Printf("Error parsing scheduled messages file: %v", err)
+		return
+	}
+
+	for _, msg := range scheduledMessages {
+		if msg.ID >= nextScheduleID {
+			nextScheduleID = msg.ID + 1
+		}
+	}
+	log.Printf("Loaded %d scheduled messages", len(scheduledMessages))
+}
+
+// saveScheduledMessages saves queued scheduled messages to JSON file (caller holds scheduleMu)
+func saveScheduledMessages() {
+	data, err := json.MarshalIndent(scheduledMessages, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling scheduled messages: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(scheduleFile, data, 0644); err != nil {
+		log.Printf("Error saving scheduled messages: %v", err)
+		return
+	}
+}
+
+// scheduleMessages queues messages for delivery and assigns their IDs
+func scheduleMessages(msgs []ScheduledMessage) []ScheduledMessage {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	for idx := range msgs {
+		msgs[idx].ID = nextScheduleID
+		nextScheduleID++
+		scheduledMessages = append(scheduledMessages, msgs[idx])
+	}
+	saveScheduledMessages()
+	return msgs
+}
+
+// Markets with a holiday calendar
+const (
+	marketIDX = "idx"
+	marketUS  = "us"
+
+	holidaySkip     = "skip"
+	holidayAnnotate = "annotate"
+)
+
+// marketNames are the display names of the supported markets
+var marketNames = map[string]string{
+	marketIDX: "IDX",
+	marketUS:  "NYSE/Nasdaq",
+}
+
+// marketHolidays lists exchange holidays as YYYY-MM-DD in the market's own timezone.
+// IDX dates follow the yearly exchange announcement (national holidays and cuti bersama),
+// US dates follow the NYSE calendar. Extend this list when the next year is published.
+var marketHolidays = map[string]map[string]string{
+	marketIDX: {
+		"2025-01-01": "Tahun Baru Masehi",
+		"2025-01-27": "Isra Mikraj",
+		"2025-01-28": "Cuti Bersama Imlek",
+		"2025-01-29": "Tahun Baru Imlek",
+		"2025-03-28": "Cuti Bersama Nyepi",
+		"2025-03-31": "Idul Fitri",
+		"2025-04-01": "Idul Fitri",
+		"2025-04-02": "Cuti Bersama Idul Fitri",
+		"2025-04-03": "Cuti Bersama Idul Fitri",
+		"2025-04-04": "Cuti Bersama Idul Fitri",
+		"2025-04-07": "Cuti Bersama Idul Fitri",
+		"2025-04-18": "Wafat Isa Almasih",
+		"2025-05-01": "Hari Buruh",
+		"2025-05-12": "Waisak",
+		"2025-05-13": "Cuti Bersama Waisak",
+		"2025-05-29": "Kenaikan Isa Almasih",
+		"2025-05-30": "Cuti Bersama Kenaikan Isa Almasih",
+		"2025-06-06": "Idul Adha",
+		"2025-06-09": "Cuti Bersama Idul Adha",
+		"2025-06-27": "Tahun Baru Islam",
+		"2025-08-18": "Cuti Bersama Hari Kemerdekaan",
+		"2025-09-05": "Maulid Nabi Muhammad",
+		"2025-12-25": "Natal",
+		"2025-12-26": "Cuti Bersama Natal",
+		"2025-12-31": "Libur Bursa",
+		"2026-01-01": "Tahun Baru Masehi",
+		"2026-01-16": "Isra Mikraj",
+		"2026-02-16": "Cuti Bersama Imlek",
+		"2026-02-17": "Tahun Baru Imlek",
+		"2026-03-18": "Cuti Bersama Nyepi",
+		"2026-03-19": "Nyepi",
+		"2026-03-20": "Idul Fitri",
+		"2026-03-23": "Cuti Bersama Idul Fitri",
+		"2026-03-24": "Cuti Bersama Idul Fitri",
+		"2026-04-03": "Wafat Isa Almasih",
+		"2026-05-01": "Hari Buruh",
+		"2026-05-14": "Kenaikan Isa Almasih",
+		"2026-05-15": "Cuti Bersama Kenaikan Isa Almasih",
+		"2026-05-27": "Idul Adha",
+		"2026-05-28": "Cuti Bersama Idul Adha",
+		"2026-06-01": "Hari Lahir Pancasila",
+		"2026-06-16": "Tahun Baru Islam",
+		"2026-08-17": "Hari Kemerdekaan",
+		"2026-08-25": "Maulid Nabi Muhammad",
+		"2026-12-24": "Cuti Bersama Natal",
+		"2026-12-25": "Natal",
+		"2026-12-31": "Libur Bursa",
+	},
+	marketUS: {
+		"2025-01-01": "New Year's Day",
+		"2025-01-09": "National Day of Mourning",
+		"2025-01-20": "Martin Luther King Jr. Day",
+		"2025-02-17": "Washington's Birthday",
+		"2025-04-18": "Good Friday",
+		"2025-05-26": "Memorial Day",
+		"2025-06-19": "Juneteenth",
+		"2025-07-04": "Independence Day",
+		"2025-09-01": "Labor Day",
+		"2025-11-27": "Thanksgiving Day",
+		"2025-12-25": "Christmas Day",
+		"2026-01-01": "New Year's Day",
+		"2026-01-19": "Martin Luther King Jr. Day",
+		"2026-02-16": "Washington's Birthday",
+		"2026-04-03": "Good Friday",
+		"2026-05-25": "Memorial Day",
+		"2026-06-19": "Juneteenth",
+		"2026-07-03": "Independence Day (observed)",
+		"2026-09-07": "Labor Day",
+		"2026-11-26": "Thanksgiving Day",
+		"2026-12-25": "Christmas Day",
+		"2027-01-01": "New Year's Day",
+		"2027-01-18": "Martin Luther King Jr. Day",
+		"2027-02-15": "Washington's Birthday",
+		"2027-03-26": "Good Friday",
+		"2027-05-31": "Memorial Day",
+		"2027-06-18": "Juneteenth (observed)",
+		"2027-07-05": "Independence Day (observed)",
+		"2027-09-06": "Labor Day",
+		"2027-11-25": "Thanksgiving Day",
+		"2027-12-24": "Christmas Day (observed)",
+	},
+}
+
+// usMarketLocation is the New York timezone, falling back to a fixed UTC-5 zone
+var usMarketLocation = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// marketClosure reports why a market is closed on the trading day containing t, or "" if it is open
+func marketClosure(market string, t time.Time) string {
+	loc := botLocation
+	if market == marketUS {
+		loc = usMarketLocation
+	}
+	local := t.In(loc)
+
+	if name, ok := marketHolidays[market][local.Format("2006-01-02")]; ok {
+		return name
+	}
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return "weekend"
+	}
+	return ""
+}
+
+// holidayNotice describes the markets closed today for posts about the market
+func holidayNotice(t time.Time) string {
+	var closed []string
+	for _, market := range []string{marketIDX, marketUS} {
+		if reason := marketClosure(market, t); reason != "" && reason != "weekend" {
+			closed = append(closed, fmt.Sprintf("%s is closed today (%s)", marketNames[market], reason))
+		}
+	}
+	if len(closed) == 0 {
+		return ""
+	}
+	return "🏖️ " + strings.Join(closed, ", ") + "."
+}
+
+// marketLabel describes a scheduled message's holiday handling for /schedule list
+func marketLabel(msg ScheduledMessage) string {
+	if msg.Market == "" {
+		return ""
+	}
+	if msg.OnHoliday == holidayAnnotate {
+		return fmt.Sprintf(" · %s, noted on holidays", marketNames[msg.Market])
+	}
+	return fmt.Sprintf(" · %s, skipped on holidays", marketNames[msg.Market])
+}
+
+// Holiday is an Indonesian national holiday or cuti bersama day
+type Holiday struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Name        string `json:"name"`
+	CutiBersama bool   `json:"cuti_bersama,omitempty"`
+}
+
+// nationalHolidays lists the national holidays and cuti bersama set by the yearly SKB 3 Menteri.
+// Years missing here are fetched from HOLIDAY_API_URL. Extend this list when the next year is published.
+var nationalHolidays = []Holiday{
+	{"2025-01-01", "Tahun Baru Masehi", false},
+	{"2025-01-27", "Isra Mikraj", false},
+	{"2025-01-28", "Cuti Bersama Tahun Baru Imlek", true},
+	{"2025-01-29", "Tahun Baru Imlek", false},
+	{"2025-03-28", "Cuti Bersama Nyepi", true},
+	{"2025-03-29", "Hari Suci Nyepi", false},
+	{"2025-03-31", "Idul Fitri", false},
+	{"2025-04-01", "Idul Fitri", false},
+	{"2025-04-02", "Cuti Bersama Idul Fitri", true},
+	{"2025-04-03", "Cuti Bersama Idul Fitri", true},
+	{"2025-04-04", "Cuti Bersama Idul Fitri", true},
+	{"2025-04-07", "Cuti Bersama Idul Fitri", true},
+	{"2025-04-18", "Wafat Yesus Kristus", false},
+	{"2025-04-20", "Kebangkitan Yesus Kristus (Paskah)", false},
+	{"2025-05-01", "Hari Buruh Internasional", false},
+	{"2025-05-12", "Hari Raya Waisak", false},
+	{"2025-05-13", "Cuti Bersama Waisak", true},
+	{"2025-05-29", "Kenaikan Yesus Kristus", false},
+	{"2025-05-30", "Cuti Bersama Kenaikan Yesus Kristus", true},
+	{"2025-06-01", "Hari Lahir Pancasila", false},
+	{"2025-06-06", "Idul Adha", false},
+	{"2025-06-09", "Cuti Bersama Idul Adha", true},
+	{"2025-06-27", "Tahun Baru Islam", false},
+	{"2025-08-17", "Hari Kemerdekaan RI", false},
+	{"2025-08-18", "Cuti Bersama Hari Kemerdekaan", true},
+	{"2025-09-05", "Maulid Nabi Muhammad SAW", false},
+	{"2025-12-25", "Hari Raya Natal", false},
+	{"2025-12-26", "Cuti Bersama Natal", true},
+	{"2026-01-01", "Tahun Baru Masehi", false},
+	{"2026-01-16", "Isra Mikraj", false},
+	{"2026-02-16", "Cuti Bersama Tahun Baru Imlek", true},
+	{"2026-02-17", "Tahun Baru Imlek", false},
+	{"2026-03-18", "Cuti Bersama Nyepi", true},
+	{"2026-03-19", "Hari Suci Nyepi", false},
+	{"2026-03-20", "Cuti Bersama Idul Fitri", true},
+	{"2026-03-21", "Idul Fitri", false},
+	{"2026-03-22", "Idul Fitri", false},
+	{"2026-03-23", "Cuti Bersama Idul Fitri", true},
+	{"2026-03-24", "Cuti Bersama Idul Fitri", true},
+	{"2026-04-03", "Wafat Yesus Kristus", false},
+	{"2026-04-05", "Kebangkitan Yesus Kristus (Paskah)", false},
+	{"2026-05-01", "Hari Buruh Internasional", false},
+	{"2026-05-14", "Kenaikan Yesus Kristus", false},
+	{"2026-05-15", "Cuti Bersama Kenaikan Yesus Kristus", true},
+	{"2026-05-27", "Idul Adha", false},
+	{"2026-05-28", "Cuti Bersama Idul Adha", true},
+	{"2026-05-31", "Hari Raya Waisak", false},
+	{"2026-06-01", "Hari Lahir Pancasila", false},
+	{"2026-06-16", "Tahun Baru Islam", false},
+	{"2026-08-17", "Hari Kemerdekaan RI", false},
+	{"2026-08-25", "Maulid Nabi Muhammad SAW", false},
+	{"2026-12-24", "Cuti Bersama Natal", true},
+	{"2026-12-25", "Hari Raya Natal", false},
+}
+
+// holidaysInYear returns a year's holidays in date order, from the built-in list or the holiday API
+func holidaysInYear(year int) ([]Holiday, error) {
+	prefix := strconv.Itoa(year) + "-"
+	var holidays []Holiday
+	for _, holiday := range nationalHolidays {
+		if strings.HasPrefix(holiday.Date, prefix) {
+			holidays = append(holidays, holiday)
+		}
+	}
+	if len(holidays) > 0 {
+		return holidays, nil
+	}
+
+	if cached, ok := holidayCache.Get(prefix); ok {
+		return cached, nil
+	}
+
+	base := os.Getenv("HOLIDAY_API_URL")
+	if base == "" {
+		base = "https://dayoffapi.vercel.app/api"
+	}
+	body, err := fetchPage("The holiday calendar", fmt.Sprintf("%s?year=%d", base, year))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Date   string `json:"tanggal"`
+		Name   string `json:"keterangan"`
+		IsCuti bool   `json:"is_cuti"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, upstreamDown("The holiday calendar", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	for _, result := range results {
+		date, err := time.Parse("2006-1-2", result.Date)
+		if err != nil || date.Year() != year {
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: date.Format("2006-01-02"), Name: result.Name, CutiBersama: result.IsCuti})
+	}
+	if len(holidays) == 0 {
+		return nil, notFound("no holidays are published for %d yet", year)
+	}
+	sort.Slice(holidays, func(a, b int) bool { return holidays[a].Date < holidays[b].Date })
+
+	holidayCache.Set(prefix, holidays)
+	return holidays, nil
+}
+
+// longWeekend is a run of three or more days off in a row that includes a holiday
+type longWeekend struct {
+	Start, End time.Time
+	Names      []string
+}
+
+// findLongWeekends groups holidays with the weekends around them
+func findLongWeekends(holidays []Holiday) []longWeekend {
+	names := make(map[string]string)
+	for _, holiday := range holidays {
+		names[holiday.Date] = holiday.Name
+	}
+	dayOff := func(day time.Time) bool {
+		_, holiday := names[day.Format("2006-01-02")]
+		return holiday || day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+	}
+
+	var found []longWeekend
+	var lastEnd time.Time
+	for _, holiday := range holidays {
+		day, err := time.ParseInLocation("2006-01-02", holiday.Date, botLocation)
+		if err != nil || !day.After(lastEnd) {
+			continue
+		}
+		start, end := day, day
+		for dayOff(start.AddDate(0, 0, -1)) {
+			start = start.AddDate(0, 0, -1)
+		}
+		for dayOff(end.AddDate(0, 0, 1)) {
+			end = end.AddDate(0, 0, 1)
+		}
+		lastEnd = end
+		if end.Sub(start) < 2*24*time.Hour {
+			continue
+		}
+
+		weekend := longWeekend{Start: start, End: end}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			if name, ok := names[d.Format("2006-01-02")]; ok && !slices.Contains(weekend.Names, name) {
+				weekend.Names = append(weekend.Names, name)
+			}
+		}
+		found = append(found, weekend)
+	}
+	return found
+}
+
+// holidayLine formats a holiday for /libur, e.g. "`Mon 01 Jan` Tahun Baru Masehi"
+func holidayLine(holiday Holiday) string {
+	date, err := time.Parse("2006-01-02", holiday.Date)
+	if err != nil {
+		return holiday.Name
+	}
+	line := fmt.Sprintf("`%s` %s", date.Format("Mon 02 Jan"), holiday.Name)
+	if holiday.CutiBersama && !strings.Contains(strings.ToLower(holiday.Name), "cuti bersama") {
+		line += " *(cuti bersama)*"
+	}
+	return line
+}
+
+// longWeekendLine formats a long weekend, e.g. "Fri 28 Mar – Mon 07 Apr (11 days): Idul Fitri"
+func longWeekendLine(weekend longWeekend) string {
+	days := int(weekend.End.Sub(weekend.Start).Hours()/24+0.5) + 1
+	return fmt.Sprintf("**%s – %s** (%d days): %s", weekend.Start.Format("Mon 02 Jan"), weekend.End.Format("Mon 02 Jan"), days, strings.Join(weekend.Names, ", "))
+}
+
+// holidaysEmbed lists holidays and the long weekends they make
+func holidaysEmbed(title string, holidays []Holiday, weekends []longWeekend) *discordgo.MessageEmbed {
+	var lines []string
+	for _, holiday := range holidays {
+		lines = append(lines, holidayLine(holiday))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No national holidays or cuti bersama. 😔")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "National holidays and cuti bersama per SKB 3 Menteri",
+		},
+	}
+	if len(weekends) > 0 {
+		var weekendLines []string
+		for _, weekend := range weekends {
+			weekendLines = append(weekendLines, longWeekendLine(weekend))
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{{
+			Name:  "🏖️ Long weekends",
+			Value: truncate(strings.Join(weekendLines, "\n"), 1024),
+		}}
+	}
+	return embed
+}
+
+// handleLiburCommand handles /libur [month] [year]; without options it lists the next holidays
+func handleLiburCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	now := time.Now().In(botLocation)
+	var month, year int
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "month":
+			month = int(opt.IntValue())
+		case "year":
+			year = int(opt.IntValue())
+		}
+	}
+
+	// Defer the response since years outside the built-in list are fetched
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	if month == 0 && year == 0 {
+		embed, err = upcomingHolidaysEmbed(now)
+	} else {
+		if year == 0 {
+			year = now.Year()
+		}
+		var holidays []Holiday
+		holidays, err = holidaysInYear(year)
+		if err == nil {
+			title := fmt.Sprintf("🇮🇩 Hari Libur %d", year)
+			weekends := findLongWeekends(holidays)
+			if month != 0 {
+				prefix := fmt.Sprintf("%d-%02d-", year, month)
+				holidays = slices.DeleteFunc(holidays, func(h Holiday) bool { return !strings.HasPrefix(h.Date, prefix) })
+				weekends = slices.DeleteFunc(weekends, func(w longWeekend) bool {
+					return int(w.Start.Month()) != month && int(w.End.Month()) != month
+				})
+				title = fmt.Sprintf("🇮🇩 Hari Libur %s %d", time.Month(month), year)
+			}
+			embed = holidaysEmbed(title, holidays, weekends)
+		}
+	}
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+// upcomingHolidaysEmbed lists the next holidays from today, looking into next year when needed
+func upcomingHolidaysEmbed(now time.Time) (*discordgo.MessageEmbed, error) {
+	today := now.Format("2006-01-02")
+	var upcoming []Holiday
+	var weekends []longWeekend
+	for _, year := range []int{now.Year(), now.Year() + 1} {
+		holidays, err := holidaysInYear(year)
+		if err != nil {
+			if year == now.Year() {
+				return nil, err
+			}
+			break // next year's list is often not published yet
+		}
+		for _, holiday := range holidays {
+			if holiday.Date >= today && len(upcoming) < upcomingHolidays {
+				upcoming = append(upcoming, holiday)
+			}
+		}
+		for _, weekend := range findLongWeekends(holidays) {
+			if !weekend.End.Before(now) && len(weekends) < 3 {
+				weekends = append(weekends, weekend)
+			}
+		}
+		if len(upcoming) == upcomingHolidays {
+			break
+		}
+	}
+	return holidaysEmbed("🇮🇩 Upcoming Hari Libur", upcoming, weekends), nil
+}
+
+// announceLongWeekends posts upcoming long weekends in servers that asked for them, once per long
+// weekend, during longWeekendHour in the server's timezone
+func announceLongWeekends(s *discordgo.Session, now time.Time) {
+	settingsMu.Lock()
+	guildIDs := make([]string, 0, len(serverSettings))
+	for guildID, settings := range serverSettings {
+		if settings.LongWeekendChannel != "" {
+			guildIDs = append(guildIDs, guildID)
+		}
+	}
+	settingsMu.Unlock()
+
+	for _, guildID := range guildIDs {
+		settings := getGuildSettings(guildID)
+		local := now.In(settings.location())
+		if local.Hour() != longWeekendHour || settings.inQuietHours(now) {
+			continue
+		}
+
+		holidays, err := holidaysInYear(local.Year())
+		if err != nil {
+			log.Printf("Error loading holidays for long weekend announcements: %v", err)
+			continue
+		}
+		if next, err := holidaysInYear(local.Year() + 1); err == nil {
+			holidays = append(holidays, next...)
+		}
+
+		today := local.Format("2006-01-02")
+		for _, weekend := range findLongWeekends(holidays) {
+			start := weekend.Start.Format("2006-01-02")
+			if start <= today || weekend.Start.Sub(now) > longWeekendNotice {
+				continue
+			}
+			if start <= settings.LongWeekendNotified {
+				continue // already announced
+			}
+
+			channelID := settings.LongWeekendChannel
+			content := "🏖️ **Long weekend ahead!** " + longWeekendLine(weekend)
+			if _, err := queueBackgroundSend(channelID, func() (*discordgo.Message, error) {
+				return s.ChannelMessageSend(channelID, content)
+			}); err != nil {
+				// Tried again on the next tick, for as long as it's still the announcement hour
+				log.Printf("Error announcing long weekend in channel %s: %v", channelID, err)
+				break
+			}
+			updateGuildSettings(guildID, func(settings *GuildSettings) {
+				settings.LongWeekendNotified = start
+			})
+			break
+		}
+	}
+}
+
+// handleScheduleLongWeekends turns long weekend announcements on or off for a channel
+func handleScheduleLongWeekends(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var channelID string
+	enabled := true
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "enabled":
+			enabled = opt.BoolValue()
+		}
+	}
+
+	content := "✅ Long weekend announcements turned off."
+	updateGuildSettings(i.GuildID, func(settings *GuildSettings) {
+		if enabled {
+			settings.LongWeekendChannel = channelID
+		} else {
+			settings.LongWeekendChannel = ""
+		}
+	})
+	if enabled {
+		content = fmt.Sprintf("✅ Long weekends will be announced in <#%s> a week ahead, at %02d:00 server time.", channelID, longWeekendHour)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// runSafely runs a background job, recovering panics so one failing job cannot take the bot down.
+// With restart set, the job is started again after a growing backoff until ctx is cancelled.
+func runSafely(ctx context.Context, name string, restart bool, fn func(ctx context.Context)) {
+	backoff := minJobBackoff
+	for {
+		started := time.Now()
+		panicked := runRecovered(name, func() { fn(ctx) })
+
+		if !panicked || !restart || ctx.Err() != nil {
+			return
+		}
+
+		// A job that ran for a while before failing starts over with a short backoff
+		if time.Since(started) > maxJobBackoff {
+			backoff = minJobBackoff
+		}
+		log.Printf("Restarting background job %s in %s", name, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxJobBackoff {
+			backoff = maxJobBackoff
+		}
+	}
+}
+
+// runRecovered calls fn and reports whether it panicked, logging the panic and counting it for name
+func runRecovered(name string, fn func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			jobPanicsMu.Lock()
+			jobPanics[name]++
+			count := jobPanics[name]
+			jobPanicsMu.Unlock()
+			log.Printf("Background job %s panicked (%d total): %v\n%s", name, count, r, debug.Stack())
+		}
+	}()
+	fn()
+	return false
+}
+
+// backgroundSend is a message from a background job waiting in the send queue
+type backgroundSend struct {
+	channelID string
+	send      func() (*discordgo.Message, error)
+	result    chan backgroundSendResult
+}
+
+type backgroundSendResult struct {
+	msg *discordgo.Message
+	err error
+}
+
+// markInteractive records that a user command is being answered so background sends yield to it
+func markInteractive() {
+	lastInteractive.Store(time.Now().UnixNano())
+}
+
+// queueBackgroundSend sends a message for a background job through the send queue and waits for the result
+func queueBackgroundSend(channelID string, send func() (*discordgo.Message, error)) (*discordgo.Message, error) {
+	job := backgroundSend{
+		channelID: channelID,
+		send:      send,
+		result:    make(chan backgroundSendResult, 1),
+	}
+	backgroundSends <- job
+	result := <-job.result
+	return result.msg, result.err
+}
+
+// runSendQueue sends queued background messages one at a time, so bursts of scheduled posts
+// and feed items cannot use up the rate limits that slash command and prefix replies need
+func runSendQueue(ctx context.Context, s *discordgo.Session) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-backgroundSends:
+			waitForSendSlot(ctx, s, job.channelID)
+
+			var result backgroundSendResult
+			if runRecovered("send queue", func() { result.msg, result.err = job.send() }) {
+				result.err = fmt.Errorf("send to channel %s panicked", job.channelID)
+			}
+			job.result <- result
+		}
+	}
+}
+
+// waitForSendSlot holds a background send while user commands are being answered or the
+// channel's rate limit bucket is nearly used up, leaving room for replies in that channel.
+// It gives up after a few rounds so background posts are delayed but never starved.
+func waitForSendSlot(ctx context.Context, s *discordgo.Session, channelID string) {
+	bucket := s.Ratelimiter.GetBucket(discordgo.EndpointChannelMessages(channelID))
+	for round := 0; round < maxSendYields; round++ {
+		wait := s.Ratelimiter.GetWaitTime(bucket, backgroundSendReserve)
+		if idle := time.Since(time.Unix(0, lastInteractive.Load())); idle < interactiveQuietPeriod && wait < interactiveQuietPeriod-idle {
+			wait = interactiveQuietPeriod - idle
+		}
+		if wait <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// takeDueMessages removes the scheduled messages that are due from the queue and returns them
+func takeDueMessages(now time.Time) []ScheduledMessage {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	var due []ScheduledMessage
+	remaining := scheduledMessages[:0]
+	for _, msg := range scheduledMessages {
+		// Messages due during the server's quiet hours wait until they end
+		if !msg.SendAt.After(now) && !isQuietTime(msg.GuildID, now) {
+			due = append(due, msg)
+		} else {
+			remaining = append(remaining, msg)
+		}
+	}
+	scheduledMessages = remaining
+	if len(due) > 0 {
+		saveScheduledMessages()
+	}
+	return due
+}
+
+// runScheduler periodically sends scheduled messages that are due
+func runScheduler(ctx context.Context, s *discordgo.Session) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+
+		for _, msg := range takeDueMessages(now) {
+			content := msg.Message
+			if msg.Market != "" {
+				if reason := marketClosure(msg.Market, msg.SendAt); reason != "" {
+					if msg.OnHoliday != holidayAnnotate {
+						log.Printf("Skipping scheduled message %d: %s closed (%s)", msg.ID, marketNames[msg.Market], reason)
+						continue
+					}
+					content = fmt.Sprintf("🏖️ **%s is closed today (%s).**\n%s", marketNames[msg.Market], reason, content)
+				}
+			}
+			_, err := queueBackgroundSend(msg.ChannelID, func() (*discordgo.Message, error) {
+				return s.ChannelMessageSend(msg.ChannelID, content)
+			})
+			if err != nil {
+				log.Printf("Error sending scheduled message %d to channel %s: %v", msg.ID, msg.ChannelID, err)
+			}
+		}
+
+		announceLongWeekends(s, now)
+		startScheduledTrivia(s, now)
+		postWordOfTheDay(s, now)
+		enforceRetention(s, now)
+		deliverReminders(s, now)
+		postStandups(s, now)
+		expireAutoReplies(s, now)
+		sweepReplyDrafts(now)
+		sweepScheduleImports(now)
+		sweepReplyPurges(now)
+		remindDueTasks(s, now)
+		flushAutoReplies()
+	}
+}
+
+// parseScheduleTime parses "2006-01-02 15:04" (WIB) or RFC3339 times
+func parseScheduleTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation(scheduleTimeLayout, value, botLocation); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use format like '2025-01-31 09:00'", value)
+}
+
+// resolveGuildChannel parses a channel ID or <#mention> and checks it belongs to the server
+func resolveGuildChannel(s *discordgo.Session, guildID, value string) (string, error) {
+	channelID := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "<#"), ">")
+	if channelID == "" {
+		return "", fmt.Errorf("channel not specified")
+	}
+
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+		if err != nil {
+			return "", fmt.Errorf("channel %s not found", channelID)
+		}
+	}
+	if channel.GuildID != guildID {
+		return "", fmt.Errorf("channel %s is not in this server", channelID)
+	}
+	return channelID, nil
+}
+
+// parseScheduleCSV validates each "time,channel,message" row, returning valid messages and per-row errors
+func parseScheduleCSV(s *discordgo.Session, guildID, authorID string, data []byte) ([]ScheduledMessage, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+
+	// Skip an optional header row
+	if len(rows) > 0 && len(rows[0]) > 0 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "time") {
+		rows = rows[1:]
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("the CSV file has no rows")
+	}
+	if len(rows) > maxScheduleImportRows {
+		return nil, nil, fmt.Errorf("too many rows (%d), the limit is %d per import", len(rows), maxScheduleImportRows)
+	}
+
+	now := time.Now()
+	var msgs []ScheduledMessage
+	var rowErrors []string
+	for idx, row := range rows {
+		rowNum := idx + 1
+		if len(row) < 3 {
+			rowErrors = append(rowErrors, fmt.Sprintf("Row %d: expected time, channel and message", rowNum))
+			continue
+		}
+
+		sendAt, err := parseScheduleTime(row[0])
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("Row %d: %v", rowNum, err))
+			continue
+		}
+		if !sendAt.After(now) {
+			rowErrors = append(rowErrors, fmt.Sprintf("Row %d: time %s is in the past", rowNum, row[0]))
+			continue
+		}
+
+		channelID, err := resolveGuildChannel(s, guildID, row[1])
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("Row %d: %v", rowNum, err))
+			continue
+		}
+
+		// Allow unquoted commas in the message column
+		message := strings.TrimSpace(strings.Join(row[2:], ","))
+		if message == "" || len(message) > 2000 {
+			rowErrors = append(rowErrors, fmt.Sprintf("Row %d: message must be 1-2000 characters", rowNum))
+			continue
+		}
+
+		msgs = append(msgs, ScheduledMessage{
+			GuildID:   guildID,
+			ChannelID: channelID,
+			Message:   message,
+			SendAt:    sendAt,
+			AuthorID:  authorID,
+		})
+	}
+
+	return msgs, rowErrors, nil
+}
+
+// fetchAttachment downloads an uploaded attachment
+func fetchAttachment(url string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %v", err)
+	}
+	return body, nil
+}
+
+// handleScheduleCommand handles the /schedule slash command
+func handleScheduleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Scheduling only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to schedule messages.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "add":
+		handleScheduleAdd(s, i, subcommand.Options)
+	case "list":
+		handleScheduleList(s, i)
+	case "cancel":
+		handleScheduleCancel(s, i, int(subcommand.Options[0].IntValue()))
+	case "import":
+		handleScheduleImport(s, i, subcommand.Options)
+	case "long_weekends":
+		handleScheduleLongWeekends(s, i, subcommand.Options)
+	case "word_of_the_day":
+		handleScheduleWordOfTheDay(s, i, subcommand.Options)
+	case "standup":
+		handleScheduleStandup(s, i, subcommand.Options)
+	case "retention":
+		handleScheduleRetention(s, i, subcommand.Options)
+	}
+}
+
+// handleScheduleAdd queues a single scheduled message
+func handleScheduleAdd(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var timeValue, channelID, message, market string
+	onHoliday := holidaySkip
+	for _, opt := range options {
+		switch opt.Name {
+		case "time":
+			timeValue = opt.StringValue()
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "message":
+			message = opt.StringValue()
+		case "market":
+			market = opt.StringValue()
+		case "on_holiday":
+			onHoliday = opt.StringValue()
+		}
+	}
+	if market == "" {
+		onHoliday = ""
+	}
+
+	sendAt, err := parseScheduleTime(timeValue)
+	if err == nil && !sendAt.After(time.Now()) {
+		err = fmt.Errorf("time %s is in the past", timeValue)
+	}
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	msgs := scheduleMessages([]ScheduledMessage{{
+		GuildID:   i.GuildID,
+		ChannelID: channelID,
+		Message:   message,
+		SendAt:    sendAt,
+		AuthorID:  i.Member.User.ID,
+		Market:    market,
+		OnHoliday: onHoliday,
+	}})
+
+	content := fmt.Sprintf("✅ Message #%d scheduled for <t:%d:F> in <#%s>.", msgs[0].ID, sendAt.Unix(), channelID)
+	if market != "" {
+		if reason := marketClosure(market, sendAt); reason != "" {
+			action := "skipped"
+			if onHoliday == holidayAnnotate {
+				action = "sent with a holiday note"
+			}
+			content += fmt.Sprintf("\n⚠️ %s is closed that day (%s), so it will be %s.", marketNames[market], reason, action)
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleScheduleList shows the server's upcoming scheduled messages
+func handleScheduleList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	scheduleMu.Lock()
+	var upcoming []ScheduledMessage
+	for _, msg := range scheduledMessages {
+		if msg.GuildID == i.GuildID {
+			upcoming = append(upcoming, msg)
+		}
+	}
+	scheduleMu.Unlock()
+
+	if len(upcoming) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "📝 No scheduled messages for this server.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	sort.Slice(upcoming, func(a, b int) bool {
+		return upcoming[a].SendAt.Before(upcoming[b].SendAt)
+	})
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🗓️ Scheduled Messages",
+		Description: "Upcoming messages for this server",
+		Color:       0x3498db,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Total scheduled: %d", len(upcoming)),
+		},
+	}
+
+	for idx, msg := range upcoming {
+		if idx >= 25 {
+			break
+		}
+		preview := msg.Message
+		if len(preview) > 100 {
+			preview = preview[:100] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("#%d", msg.ID),
+			Value:  fmt.Sprintf("<t:%d:F> in <#%s>%s\n%s", msg.SendAt.Unix(), msg.ChannelID, marketLabel(msg), preview),
+			Inline: false,
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleScheduleCancel removes a scheduled message from the server's queue
+func handleScheduleCancel(s *discordgo.Session, i *discordgo.InteractionCreate, id int) {
+	content := fmt.Sprintf("❌ No scheduled message #%d in this server.", id)
+
+	scheduleMu.Lock()
+	for idx, msg := range scheduledMessages {
+		if msg.ID == id && msg.GuildID == i.GuildID {
+			scheduledMessages = append(scheduledMessages[:idx], scheduledMessages[idx+1:]...)
+			saveScheduledMessages()
+			content = fmt.Sprintf("✅ Scheduled message #%d cancelled.", id)
+			break
+		}
+	}
+	scheduleMu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleScheduleImport validates an uploaded CSV and shows a preview with Confirm/Cancel buttons
+func handleScheduleImport(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	attachmentID := options[0].Value.(string)
+	attachment := i.ApplicationCommandData().Resolved.Attachments[attachmentID]
+
+	if attachment == nil || attachment.Size > maxScheduleImportSize {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please attach a CSV file smaller than 1 MB.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Defer the response since downloading and validating might take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	data, err := fetchAttachment(attachment.URL)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("❌ %v", err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	msgs, rowErrors, err := parseScheduleCSV(s, i.GuildID, i.Member.User.ID, data)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("❌ %v\n\nExpected columns: `time,channel,message` with times like `2025-01-31 09:00` (WIB).", err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🗓️ Schedule Import Preview",
+		Description: fmt.Sprintf("**%d** valid rows, **%d** rows with errors", len(msgs), len(rowErrors)),
+		Color:       0xf1c40f,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Only valid rows will be scheduled. This preview expires in 15 minutes.",
+		},
+	}
+
+	for idx, msg := range msgs {
+		if idx >= 10 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "…",
+				Value: fmt.Sprintf("and %d more", len(msgs)-idx),
+			})
+			break
+		}
+		preview := msg.Message
+		if len(preview) > 100 {
+			preview = preview[:100] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("<t:%d:F>", msg.SendAt.Unix()),
+			Value: fmt.Sprintf("<#%s>: %s", msg.ChannelID, preview),
+		})
+	}
+
+	if len(rowErrors) > 0 {
+		errorText := strings.Join(rowErrors, "\n")
+		if len(errorText) > 1000 {
+			errorText = errorText[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "⚠️ Errors",
+			Value: errorText,
+		})
+	}
+
+	params := &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	}
+
+	if len(msgs) > 0 {
+		importID := i.ID
+		scheduleMu.Lock()
+		pendingImports[importID] = &pendingScheduleImport{
+			GuildID:   i.GuildID,
+			UserID:    i.Member.User.ID,
+			Messages:  msgs,
+			CreatedAt: time.Now(),
+		}
+		scheduleMu.Unlock()
+
+		params.Components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    fmt.Sprintf("Schedule %d messages", len(msgs)),
+						Style:    discordgo.SuccessButton,
+						CustomID: "schedule_import_confirm:" + importID,
+					},
+					discordgo.Button{
+						Label:    "Cancel",
+						Style:    discordgo.SecondaryButton,
+						CustomID: "schedule_import_cancel:" + importID,
+					},
+				},
+			},
+		}
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, params)
+}
+
+// handleScheduleImportButton commits or discards a previewed schedule import
+func handleScheduleImportButton(s *discordgo.Session, i *discordgo.InteractionCreate, importID string, confirm bool) {
+	scheduleMu.Lock()
+	pending := pendingImports[importID]
+	delete(pendingImports, importID)
+	scheduleMu.Unlock()
+
+	var content string
+	switch {
+	case pending == nil || time.Since(pending.CreatedAt) > pendingImportTTL:
+		content = "❌ This import preview has expired. Please run `/schedule import` again."
+	case !confirm:
+		content = "🗑️ Import cancelled, nothing was scheduled."
+	default:
+		msgs := scheduleMessages(pending.Messages)
+		content = fmt.Sprintf("✅ Scheduled %d messages (#%d–#%d). Use `/schedule list` to review them.", len(msgs), msgs[0].ID, msgs[len(msgs)-1].ID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// sweepScheduleImports drops import previews nobody confirmed or cancelled
+func sweepScheduleImports(now time.Time) {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	for importID, pending := range pendingImports {
+		if now.Sub(pending.CreatedAt) > pendingImportTTL {
+			delete(pendingImports, importID)
+		}
+	}
+}
+
+// loadFeedSubscriptions loads feed subscriptions from JSON file
+func loadFeedSubscriptions() {
+	feedSubscriptions = make([]*FeedSubscription, 0)
+
+	if _, err := os.Stat(feedsFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(feedsFile)
+	if err != nil {
+		log.Printf("Error reading feed subscriptions file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &feedSubscriptions); err != nil {
+		log.Printf("Error parsing feed subscriptions file: %v", err)
+		return
+	}
+
+	sealed := 0
+	for _, sub := range feedSubscriptions {
+		if sub.ID >= nextFeedID {
+			nextFeedID = sub.ID + 1
+		}
+		if sub.Webhook == nil || sub.Webhook.Token == "" {
+			continue
+		}
+		if err := sub.Webhook.seal(); err != nil {
+			log.Printf("Webhook token of feed subscription %d is still stored in plain text: %v", sub.ID, err)
+			continue
+		}
+		sealed++
+	}
+	if sealed > 0 {
+		saveFeedSubscriptions()
+		log.Printf("Encrypted %d feed webhook tokens", sealed)
+	}
+	log.Printf("Loaded %d feed subscriptions", len(feedSubscriptions))
+}
+
+// saveFeedSubscriptions saves feed subscriptions to JSON file (caller holds feedMu)
+func saveFeedSubscriptions() {
+	data, err := json.MarshalIndent(feedSubscriptions, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling feed subscriptions: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(feedsFile, data, 0644); err != nil {
+		log.Printf("Error saving feed subscriptions: %v", err)
+		return
+	}
+}
+
+// markSeen records an item as seen, keeping only the most recent links and titles
+func (sub *FeedSubscription) markSeen(item Item) {
+	sub.Seen = append(sub.Seen, canonicalLink(item.Link))
+	if len(sub.Seen) > maxSeenLinks {
+		sub.Seen = sub.Seen[len(sub.Seen)-maxSeenLinks:]
+	}
+	sub.SeenTitles = append(sub.SeenTitles, normalizeTitle(item.Title))
+	if len(sub.SeenTitles) > maxSeenLinks {
+		sub.SeenTitles = sub.SeenTitles[len(sub.SeenTitles)-maxSeenLinks:]
+	}
+}
+
+// hasSeen checks if an item link was already handled for this subscription
+func (sub *FeedSubscription) hasSeen(link string) bool {
+	link = canonicalLink(link)
+	for _, seen := range sub.Seen {
+		if canonicalLink(seen) == link {
+			return true
+		}
+	}
+	return false
+}
+
+// takeNewItems returns feed items not seen before, oldest first, and marks them seen.
+// Items already posted by another subscription in the same channel are skipped when recent is set.
+func (sub *FeedSubscription) takeNewItems(items []Item, recent *recentArticles) []Item {
+	var fresh []Item
+	for idx := len(items) - 1; idx >= 0; idx-- {
+		item := items[idx]
+		if item.Link == "" || sub.hasSeen(item.Link) {
+			continue
+		}
+		sub.markSeen(item)
+
+		if recent != nil {
+			if recent.contains(item) {
+				continue
+			}
+			recent.add(item)
+		}
+		fresh = append(fresh, item)
+	}
+	return fresh
+}
+
+// recentArticles indexes the articles already handled in one channel across all its subscriptions,
+// since Investing.com topics share articles between RSS URLs
+type recentArticles struct {
+	links  map[string]bool
+	titles []map[string]bool
+}
+
+// newRecentArticles builds the index from the seen history of a channel's subscriptions
+func newRecentArticles(subs []*FeedSubscription) *recentArticles {
+	recent := &recentArticles{links: make(map[string]bool)}
+	for _, sub := range subs {
+		for _, link := range sub.Seen {
+			recent.links[canonicalLink(link)] = true
+		}
+		for _, title := range sub.SeenTitles {
+			recent.titles = append(recent.titles, titleWords(title))
+		}
+	}
+	return recent
+}
+
+// contains checks for the same canonical link or a near-identical title
+func (r *recentArticles) contains(item Item) bool {
+	if r.links[canonicalLink(item.Link)] {
+		return true
+	}
+	words := titleWords(normalizeTitle(item.Title))
+	for _, seen := range r.titles {
+		if titleSimilarity(words, seen) >= titleSimilarityThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// add records an item in the index
+func (r *recentArticles) add(item Item) {
+	r.links[canonicalLink(item.Link)] = true
+	r.titles = append(r.titles, titleWords(normalizeTitle(item.Title)))
+}
+
+// canonicalLink normalizes an article URL so tracking parameters and host variants compare equal
+func canonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(link)
+	}
+
+	u.Scheme = "https"
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// normalizeTitle lowercases a title and strips punctuation for comparison
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// titleWords splits a normalized title into a set of words
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(title) {
+		words[word] = true
+	}
+	return words
+}
+
+// titleSimilarity returns the Jaccard similarity of two title word sets
+func titleSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// articleEmbed builds the embed for a single posted feed item
+func articleEmbed(topic string, item Item) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       item.Title,
+		URL:         item.Link,
+		Description: cleanDescription(item.Description),
+		Color:       0x1f8b4c,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Source: Investing.com • %s", topic),
+		},
+	}
+	if t, ok := parsePubDate(item.PubDate); ok {
+		embed.Timestamp = t.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// parsePubDate parses the date formats seen in RSS pubDate fields
+func parsePubDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// episodeEmbed builds the embed for a new podcast episode with its duration and audio link
+func episodeEmbed(podcast string, item Item) *discordgo.MessageEmbed {
+	notes := cleanDescription(item.Description)
+	if notes == "" {
+		notes = cleanDescription(item.Summary)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎙️ " + item.Title,
+		URL:         item.Link,
+		Description: notes,
+		Color:       0x8e44ad,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: podcast,
+		},
+	}
+
+	if item.Duration != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  formatEpisodeDuration(item.Duration),
+			Inline: true,
+		})
+	}
+	if item.Enclosure != nil && item.Enclosure.URL != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Audio",
+			Value:  fmt.Sprintf("[🎧 Listen](%s)", item.Enclosure.URL),
+			Inline: true,
+		})
+	}
+
+	if t, ok := parsePubDate(item.PubDate); ok {
+		embed.Timestamp = t.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// formatEpisodeDuration turns an itunes:duration given in seconds into h:mm:ss, leaving other formats as is
+func formatEpisodeDuration(duration string) string {
+	seconds, err := strconv.Atoi(strings.TrimSpace(duration))
+	if err != nil {
+		return duration
+	}
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// itemEmbed builds the post embed for an item of the subscription's feed kind
+func (sub *FeedSubscription) itemEmbed(item Item) *discordgo.MessageEmbed {
+	switch sub.Kind {
+	case feedKindPodcast:
+		return episodeEmbed(sub.Topic, item)
+	case feedKindQuake:
+		return quakeEmbed(item)
+	case feedKindGames:
+		return freeGameEmbed(sub.Topic, item)
+	}
+	return articleEmbed(sub.Topic, item)
+}
+
+// digestEmbed builds one consolidated embed for a channel's digest subscriptions, grouped by topic
+func digestEmbed(subs []*FeedSubscription) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "📰 Daily News Digest",
+		Color:     0x1f8b4c,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Source: Investing.com",
+		},
+	}
+
+	sort.Slice(subs, func(a, b int) bool {
+		return subs[a].Topic < subs[b].Topic
+	})
+
+	total := 0
+	for _, sub := range subs {
+		total += len(sub.Pending)
+
+		// Newest headlines first
+		var lines []string
+		for idx := len(sub.Pending) - 1; idx >= 0 && len(lines) < digestHeadlines; idx-- {
+			item := sub.Pending[idx]
+			title := item.Title
+			if len(title) > 120 {
+				title = title[:120] + "..."
+			}
+			lines = append(lines, fmt.Sprintf("• [%s](%s)", title, item.Link))
+		}
+		if more := len(sub.Pending) - len(lines); more > 0 {
+			lines = append(lines, fmt.Sprintf("…and %d more", more))
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (%d)", strings.ToUpper(string(sub.Topic[0]))+sub.Topic[1:], len(sub.Pending)),
+			Value:  strings.Join(lines, "\n"),
+			Inline: false,
+		})
+	}
+
+	embed.Description = fmt.Sprintf("%d articles since the last digest", total)
+	if notice := holidayNotice(time.Now()); notice != "" && subs[0].Kind != feedKindPodcast {
+		embed.Description += "\n" + notice
+	}
+	return embed
+}
+
+// pollBackoff doubles the poll interval for every failure in a row, up to limit
+func pollBackoff(failures int, limit time.Duration) time.Duration {
+	backoff := feedPollInterval << (failures - 1)
+	if backoff > limit || backoff <= 0 {
+		return limit
+	}
+	return backoff
+}
+
+// recordFailure counts a failed fetch, backs off polling and auto-disables the subscription
+// after too many failures. It returns a notice for the channel when one is due.
+func (sub *FeedSubscription) recordFailure(now time.Time, fetchErr error) string {
+	sub.Failures++
+	sub.RetryAt = now.Add(pollBackoff(sub.Failures, maxFeedBackoff))
+
+	switch {
+	case sub.Failures >= feedFailureLimit:
+		sub.Disabled = true
+		return fmt.Sprintf("⛔ Feed subscription #%d (**%s**) was disabled after %d failed fetches in a row (%v). An admin can re-enable it with `/feed retry %d`.", sub.ID, sub.Topic, sub.Failures, fetchErr, sub.ID)
+	case sub.Failures == feedFailureNotify:
+		return fmt.Sprintf("⚠️ Feed subscription #%d (**%s**) failed %d times in a row (%v). Polling will slow down and it will be disabled after %d failures.", sub.ID, sub.Topic, sub.Failures, fetchErr, feedFailureLimit)
+	}
+	return ""
+}
+
+// recordSuccess resets the failure tracking after a successful fetch
+func (sub *FeedSubscription) recordSuccess() {
+	sub.Failures = 0
+	sub.RetryAt = time.Time{}
+}
+
+// feedPost is an embed or notice waiting to be sent to a channel by the poller
+type feedPost struct {
+	ChannelID string
+	Embeds    []*discordgo.MessageEmbed // several article embeds when batched
+	Content   string
+	Crosspost bool
+	Articles  []PostedArticle // items covered by the post, indexed for /news search once sent
+	Buttons   bool            // attach the "🔖 Save" button
+	Thread    bool            // start a discussion thread under the post
+	Webhook   *FeedWebhook    // post with the subscription's webhook identity
+}
+
+// translateText translates text into the target language using a LibreTranslate-compatible API
+// configured with TRANSLATE_API_URL (and TRANSLATE_API_KEY if the server requires one)
+func translateText(text, target string) (string, error) {
+	apiURL := os.Getenv("TRANSLATE_API_URL")
+	if apiURL == "" {
+		return "", fmt.Errorf("translation is not configured, set TRANSLATE_API_URL")
+	}
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+
+	cacheKey := target + "\x00" + text
+	if cached, ok := translationCache.Get(cacheKey); ok {
+		return cached, nil
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  "auto",
+		"target":  target,
+		"format":  "text",
+		"api_key": os.Getenv("TRANSLATE_API_KEY"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode translation request: %v", err)
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Post(apiURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to call translation API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var result struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	translationCache.Set(cacheKey, result.TranslatedText)
+
+	return result.TranslatedText, nil
+}
+
+// translateItems translates feed item titles and descriptions, keeping the original text on errors
+func translateItems(items []Item, target string) []Item {
+	translated := make([]Item, len(items))
+	copy(translated, items)
+	for idx, item := range items {
+		title, err := translateText(item.Title, target)
+		if err != nil {
+			// Don't hammer a failing translation API for every item
+			log.Printf("Error translating feed items to %s: %v", target, err)
+			break
+		}
+		translated[idx].Title = title
+
+		if description, err := translateText(cleanDescription(item.Description), target); err == nil {
+			translated[idx].Description = description
+		}
+	}
+	return translated
+}
+
+// runFeedPoller periodically checks subscribed feeds for new items
+func runFeedPoller(ctx context.Context, s *discordgo.Session) {
+	ticker := time.NewTicker(feedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pollFeeds(s)
+			pollMonitors(s)
+			pollLiveStreams(s)
+		}
+	}
+}
+
+// pollFeeds fetches every subscribed feed once, posts new items and sends due digests
+func pollFeeds(s *discordgo.Session) {
+	now := time.Now().In(botLocation)
+
+	// Skip disabled subscriptions and those backing off after failures
+	feedMu.Lock()
+	urls := make(map[string]bool)
+	languages := make(map[string]map[string]bool) // map[url]set of target languages
+	for _, sub := range feedSubscriptions {
+		if !sub.Disabled && !now.Before(sub.RetryAt) {
+			urls[sub.URL] = true
+			if sub.Translate != "" {
+				if languages[sub.URL] == nil {
+					languages[sub.URL] = make(map[string]bool)
+				}
+				languages[sub.URL][sub.Translate] = true
+			}
+		}
+	}
+	feedMu.Unlock()
+
+	// Fetch without holding the lock, each URL only once
+	fetched := make(map[string]*RSS)
+	failed := make(map[string]error)
+	for url := range urls {
+		rss, err := fetchFeed(url)
+		if err != nil {
+			log.Printf("Error polling feed %s: %v", url, err)
+			failed[url] = err
+			continue
+		}
+		fetched[url] = rss
+	}
+
+	// Translate before taking the lock, results are cached across polls
+	translated := make(map[string][]Item) // map[url+"|"+language]
+	for url, langs := range languages {
+		if rss := fetched[url]; rss != nil {
+			for lang := range langs {
+				translated[url+"|"+lang] = translateItems(rss.Channel.Items, lang)
+			}
+		}
+	}
+
+	posts := batchFeedPosts(collectFeedPosts(now, fetched, failed, translated))
+
+	var sent []PostedArticle
+	for _, post := range posts {
+		if len(post.Embeds) == 0 {
+			_, err := queueBackgroundSend(post.ChannelID, func() (*discordgo.Message, error) {
+				return s.ChannelMessageSend(post.ChannelID, post.Content)
+			})
+			if err != nil {
+				log.Printf("Error sending feed notice to channel %s: %v", post.ChannelID, err)
+			}
+			continue
+		}
+
+		msg, err := queueBackgroundSend(post.ChannelID, func() (*discordgo.Message, error) {
+			return sendFeedPost(s, post)
+		})
+		if err != nil {
+			log.Printf("Error posting feed item to channel %s: %v", post.ChannelID, err)
+			continue
+		}
+		for _, article := range post.Articles {
+			article.MessageID = msg.ID
+			sent = append(sent, article)
+		}
+		if post.Thread {
+			name := post.Embeds[0].Title
+			if len(name) > 100 {
+				name = name[:97] + "..."
+			}
+			if _, err := s.MessageThreadStart(post.ChannelID, msg.ID, name, threadAutoArchive); err != nil {
+				log.Printf("Error starting thread for message %s in channel %s: %v", msg.ID, post.ChannelID, err)
+			}
+		}
+		if post.Crosspost && isAnnouncementChannel(s, post.ChannelID) {
+			if _, err := s.ChannelMessageCrosspost(post.ChannelID, msg.ID); err != nil {
+				log.Printf("Error crossposting message %s in channel %s: %v", msg.ID, post.ChannelID, err)
+			}
+		}
+	}
+
+	recordPostedArticles(sent)
+}
+
+// collectFeedPosts updates subscriptions with the fetched feeds and returns the posts to send.
+// The lock is released with defer so a panic here cannot leave it held.
+func collectFeedPosts(now time.Time, fetched map[string]*RSS, failed map[string]error, translated map[string][]Item) []feedPost {
+	feedMu.Lock()
+	defer feedMu.Unlock()
+
+	// Dedupe articles across all subscriptions of the same channel
+	channelSubs := make(map[string][]*FeedSubscription)
+	for _, sub := range feedSubscriptions {
+		channelSubs[sub.ChannelID] = append(channelSubs[sub.ChannelID], sub)
+	}
+	channelRecent := make(map[string]*recentArticles)
+	for channelID, subs := range channelSubs {
+		channelRecent[channelID] = newRecentArticles(subs)
+	}
+
+	var posts []feedPost
+	dueDigests := make(map[string][]*FeedSubscription) // map[channelID]
+	for _, sub := range feedSubscriptions {
+		// Earthquake alerts are urgent, so they skip quiet hours
+		quiet := isQuietTime(sub.GuildID, now) && sub.Kind != feedKindQuake
+
+		// Failure notices wait out quiet hours like articles do
+		if sub.HeldNotice != "" && !quiet {
+			posts = append(posts, feedPost{ChannelID: sub.ChannelID, Content: sub.HeldNotice})
+			sub.HeldNotice = ""
+		}
+		if sub.Disabled {
+			continue
+		}
+
+		if err, ok := failed[sub.URL]; ok && !now.Before(sub.RetryAt) {
+			switch notice := sub.recordFailure(now, err); {
+			case notice == "":
+			case quiet:
+				sub.HeldNotice = strings.TrimSpace(sub.HeldNotice + "\n" + notice)
+			default:
+				posts = append(posts, feedPost{ChannelID: sub.ChannelID, Content: notice})
+			}
+		}
+
+		if rss := fetched[sub.URL]; rss != nil {
+			sub.recordSuccess()
+			items := rss.Channel.Items
+			if sub.Translate != "" {
+				items = translated[sub.URL+"|"+sub.Translate]
+			}
+			var fresh []Item
+			switch sub.Kind {
+			case feedKindQuake:
+				fresh = slices.DeleteFunc(sub.takeNewItems(items, nil), func(item Item) bool {
+					return item.Magnitude < sub.MinMagnitude
+				})
+			case feedKindGames:
+				fresh = sub.takeNewItems(items, nil)
+			default:
+				fresh = sub.takeNewItems(items, channelRecent[sub.ChannelID])
+			}
+			if sub.Digest || quiet {
+				// Articles arriving during quiet hours are held and sent as one digest afterwards
+				sub.Pending = append(sub.Pending, fresh...)
+			} else {
+				if len(fresh) > maxItemsPerPoll {
+					fresh = fresh[len(fresh)-maxItemsPerPoll:]
+				}
+				for _, item := range fresh {
+					channelID := sub.routeChannel(item)
+					var webhook *FeedWebhook
+					if channelID == sub.ChannelID {
+						webhook = sub.Webhook
+					}
+					post := feedPost{
+						Webhook:   webhook,
+						ChannelID: channelID,
+						Embeds:    []*discordgo.MessageEmbed{sub.itemEmbed(item)},
+						Crosspost: sub.Crosspost,
+						Articles:  []PostedArticle{newPostedArticle(sub, item)},
+						Buttons:   true,
+						Thread:    sub.Threads,
+					}
+					if sub.Kind == feedKindQuake || sub.Kind == feedKindGames {
+						post.Articles = nil // alerts aren't news to search or bookmark
+						post.Buttons = false
+					}
+					posts = append(posts, post)
+				}
+			}
+		}
+
+		local := now.In(getGuildSettings(sub.GuildID).location())
+		digestDue := sub.Digest && local.Hour() == sub.DigestHour && sub.LastDigest != local.Format("2006-01-02")
+		if quiet {
+			sub.HeldDigest = sub.HeldDigest || digestDue
+		} else {
+			if len(sub.Pending) > 0 && (digestDue || sub.HeldDigest || !sub.Digest) {
+				dueDigests[sub.ChannelID] = append(dueDigests[sub.ChannelID], sub)
+			}
+			sub.HeldDigest = false
+		}
+	}
+
+	for channelID, subs := range dueDigests {
+		embed := digestEmbed(subs)
+		post := feedPost{ChannelID: channelID, Embeds: []*discordgo.MessageEmbed{embed}}
+		scheduled := false
+		for _, sub := range subs {
+			post.Crosspost = post.Crosspost || sub.Crosspost
+			if post.Webhook == nil {
+				post.Webhook = sub.Webhook
+			}
+			for _, item := range sub.Pending {
+				post.Articles = append(post.Articles, newPostedArticle(sub, item))
+			}
+			sub.Pending = nil
+			if sub.Digest {
+				scheduled = true
+				sub.LastDigest = now.In(getGuildSettings(sub.GuildID).location()).Format("2006-01-02")
+			}
+		}
+		if !scheduled {
+			embed.Title = "🌙 News Held During Quiet Hours"
+			embed.Description = strings.Replace(embed.Description, "since the last digest", "posted during quiet hours", 1)
+		}
+		posts = append(posts, post)
+	}
+	saveFeedSubscriptions()
+	return posts
+}
+
+// sendFeedPost sends a feed post through the subscription's webhook identity if set, or as the bot
+func sendFeedPost(s *discordgo.Session, post feedPost) (*discordgo.Message, error) {
+	if post.Webhook != nil {
+		token, err := post.Webhook.token()
+		var msg *discordgo.Message
+		if err == nil {
+			msg, err = s.WebhookExecute(post.Webhook.ID, token, true, &discordgo.WebhookParams{
+				Username:  post.Webhook.Name,
+				AvatarURL: post.Webhook.AvatarURL,
+				Embeds:    post.Embeds,
+			})
+		}
+		if err == nil {
+			return msg, nil
+		}
+		// The webhook may have been deleted from the channel settings, fall back to the bot
+		log.Printf("Error executing feed webhook %s in channel %s: %v", post.Webhook.ID, post.ChannelID, err)
+	}
+
+	send := &discordgo.MessageSend{
+		Embeds: post.Embeds,
+	}
+	if post.Buttons && len(post.Embeds) == 1 {
+		send.Components = saveButtonRow()
+	} else if post.Buttons {
+		send.Components = savePickerRow(post.Embeds)
+	}
+	return s.ChannelMessageSendComplex(post.ChannelID, send)
+}
+
+// batchFeedPosts merges posts for the same channel into as few messages as Discord allows
+// (up to 10 embeds and 6000 characters per message). Posts that open a thread stay on their
+// own, since each article needs its own message to start a thread from.
+func batchFeedPosts(posts []feedPost) []feedPost {
+	var batched []feedPost
+	open := make(map[string]int) // map[batch key]index in batched
+
+	for _, post := range posts {
+		if len(post.Embeds) == 0 || post.Thread {
+			batched = append(batched, post)
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%t|%t", post.ChannelID, post.Crosspost, post.Buttons)
+		if post.Webhook != nil {
+			key += "|" + post.Webhook.ID
+		}
+
+		if idx, ok := open[key]; ok {
+			batch := &batched[idx]
+			if len(batch.Embeds)+len(post.Embeds) <= maxEmbedsPerMessage &&
+				embedsLength(batch.Embeds)+embedsLength(post.Embeds) <= maxEmbedChars {
+				batch.Embeds = append(batch.Embeds, post.Embeds...)
+				batch.Articles = append(batch.Articles, post.Articles...)
+				continue
+			}
+		}
+
+		open[key] = len(batched)
+		batched = append(batched, post)
+	}
+
+	return batched
+}
+
+// embedsLength counts the characters Discord counts towards the per-message embed limit
+func embedsLength(embeds []*discordgo.MessageEmbed) int {
+	total := 0
+	for _, embed := range embeds {
+		total += len(embed.Title) + len(embed.Description)
+		for _, field := range embed.Fields {
+			total += len(field.Name) + len(field.Value)
+		}
+		if embed.Footer != nil {
+			total += len(embed.Footer.Text)
+		}
+		if embed.Author != nil {
+			total += len(embed.Author.Name)
+		}
+	}
+	return total
+}
+
+// isAnnouncementChannel checks if a channel is an Announcement (news) channel that followers can receive
+func isAnnouncementChannel(s *discordgo.Session, channelID string) bool {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		channel, err = s.Channel(channelID)
+		if err != nil {
+			return false
+		}
+	}
+	return channel.Type == discordgo.ChannelTypeGuildNews
+}
+
+// handleFeedCommand handles the /feed slash command
+func handleFeedCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Feed subscriptions only work in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to manage feed subscriptions.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "subscribe":
+		handleFeedSubscribe(s, i, subcommand.Options)
+	case "unsubscribe":
+		handleFeedUnsubscribe(s, i, int(subcommand.Options[0].IntValue()))
+	case "list":
+		handleFeedList(s, i)
+	case "retry":
+		handleFeedRetry(s, i, int(subcommand.Options[0].IntValue()))
+	case "route":
+		handleFeedRoute(s, i, subcommand.Options[0])
+	case "podcast":
+		handleFeedPodcast(s, i, subcommand.Options)
+	case "quake":
+		handleFeedQuake(s, i, subcommand.Options)
+	case "free_games":
+		handleFeedFreeGames(s, i, subcommand.Options)
+	case "webhook":
+		handleFeedWebhook(s, i, subcommand.Options)
+	case "live":
+		handleFeedLive(s, i, subcommand.Options[0])
+	}
+}
+
+// handleFeedWebhook switches a subscription between posting as the bot and posting through a
+// bot-managed channel webhook with a custom name and avatar
+func handleFeedWebhook(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var id int
+	var enabled bool
+	var name, avatarURL string
+	for _, opt := range options {
+		switch opt.Name {
+		case "id":
+			id = int(opt.IntValue())
+		case "enabled":
+			enabled = opt.BoolValue()
+		case "name":
+			name = strings.TrimSpace(opt.StringValue())
+		case "avatar_url":
+			avatarURL = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	feedMu.Lock()
+	var sub *FeedSubscription
+	for _, candidate := range feedSubscriptions {
+		if candidate.ID == id && candidate.GuildID == i.GuildID {
+			sub = candidate
+			break
+		}
+	}
+	if sub == nil {
+		feedMu.Unlock()
+		respond(fmt.Sprintf("❌ No feed subscription #%d in this server.", id))
+		return
+	}
+	channelID := sub.ChannelID
+	existing := sub.Webhook
+	topic := sub.Topic
+	feedMu.Unlock()
+
+	if !enabled {
+		if existing == nil {
+			respond(fmt.Sprintf("✅ Subscription #%d already posts as the bot.", id))
+			return
+		}
+		if err := s.WebhookDelete(existing.ID); err != nil {
+			log.Printf("Error deleting feed webhook %s: %v", existing.ID, err)
+		}
+
+		feedMu.Lock()
+		sub.Webhook = nil
+		saveFeedSubscriptions()
+		feedMu.Unlock()
+
+		respond(fmt.Sprintf("✅ Subscription #%d will post as the bot again.", id))
+		return
+	}
+
+	if name == "" {
+		name = defaultWebhookName
+		if existing != nil {
+			name = existing.Name
+		}
+	}
+	if len(name) > 80 {
+		respond("❌ Webhook names can be at most 80 characters.")
+		return
+	}
+	if avatarURL != "" {
+		if u, err := url.Parse(avatarURL); err != nil || u.Scheme != "https" {
+			respond("❌ The avatar must be an https image URL.")
+			return
+		}
+	} else if existing != nil {
+		avatarURL = existing.AvatarURL
+	}
+
+	// The token is stored encrypted, so there's no point creating a webhook without a key
+	if _, err := secretsKey(); err != nil {
+		log.Printf("Error setting up feed webhook: %v", err)
+		respond("❌ Webhook identities can't be stored because the bot has no encryption key configured. Ask the bot owner to set SECRETS_MASTER_KEY.")
+		return
+	}
+
+	webhook := existing
+	if webhook == nil {
+		created, err := s.WebhookCreate(channelID, "Cerdas Feeds", "")
+		if err != nil {
+			log.Printf("Error creating feed webhook in channel %s: %v", channelID, err)
+			respond(fmt.Sprintf("❌ Failed to create a webhook in <#%s>. Make sure the bot has the Manage Webhooks permission there.", channelID))
+			return
+		}
+		webhook = &FeedWebhook{ID: created.ID, Token: created.Token}
+	}
+	updated := &FeedWebhook{
+		ID:        webhook.ID,
+		Token:     webhook.Token,
+		Sealed:    webhook.Sealed,
+		Name:      name,
+		AvatarURL: avatarURL,
+	}
+	if err := updated.seal(); err != nil {
+		log.Printf("Error encrypting feed webhook token: %v", err)
+		respond("❌ Failed to store the webhook, please try again.")
+		return
+	}
+
+	feedMu.Lock()
+	sub.Webhook = updated
+	saveFeedSubscriptions()
+	feedMu.Unlock()
+
+	respond(fmt.Sprintf("✅ Subscription #%d (**%s**) will now post in <#%s> as **%s**.", id, topic, channelID, name))
+}
+
+// handleFeedPodcast subscribes a channel to a podcast RSS feed, posting each new episode
+func handleFeedPodcast(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelID := i.ChannelID
+	var feedURL string
+	for _, opt := range options {
+		switch opt.Name {
+		case "url":
+			feedURL = strings.TrimSpace(opt.StringValue())
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		}
+	}
+
+	if u, err := url.Parse(feedURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Please provide a valid http(s) podcast RSS URL.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ChannelID == channelID && sub.URL == feedURL {
+			feedMu.Unlock()
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ <#%s> is already subscribed to this podcast (#%d).", channelID, sub.ID),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+	feedMu.Unlock()
+
+	// Defer the response since fetching RSS might take time
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	rss, err := fetchRSSFeed(feedURL)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	hasAudio := false
+	for _, item := range rss.Channel.Items {
+		if item.Enclosure != nil && item.Enclosure.URL != "" && (item.Enclosure.Type == "" || strings.HasPrefix(item.Enclosure.Type, "audio/")) {
+			hasAudio = true
+			break
+		}
+	}
+	if !hasAudio {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "❌ This feed has no audio episodes, is it a podcast feed?",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	podcast := strings.TrimSpace(rss.Channel.Title)
+	if podcast == "" {
+		podcast = "Podcast"
+	}
+
+	sub := &FeedSubscription{
+		GuildID:   i.GuildID,
+		ChannelID: channelID,
+		Topic:     podcast,
+		URL:       feedURL,
+		Kind:      feedKindPodcast,
+		CreatedBy: i.Member.User.ID,
+	}
+	sub.takeNewItems(rss.Channel.Items, nil)
+
+	feedMu.Lock()
+	sub.ID = nextFeedID
+	nextFeedID++
+	feedSubscriptions = append(feedSubscriptions, sub)
+	saveFeedSubscriptions()
+	feedMu.Unlock()
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprintf("✅ Subscription #%d created: <#%s> will get new episodes of **%s**.", sub.ID, channelID, podcast),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// bmkgQuake is one earthquake in the BMKG open data feeds
+type bmkgQuake struct {
+	DateTime  string `json:"DateTime"` // RFC3339 in UTC
+	Magnitude string `json:"Magnitude"`
+	Depth     string `json:"Kedalaman"`
+	Latitude  string `json:"Lintang"`
+	Longitude string `json:"Bujur"`
+	Region    string `json:"Wilayah"`
+	Potential string `json:"Potensi"`   // tsunami potential, M5+ feed only
+	Felt      string `json:"Dirasakan"` // MMI scale per area, felt feed only
+}
+
+// fetchFeed fetches a subscription's feed, RSS for everything except the BMKG quake and
+// free games feeds
+func fetchFeed(feedURL string) (*RSS, error) {
+	switch feedURL {
+	case bmkgQuakeFeed:
+		return fetchQuakes()
+	case epicFreeGamesFeed:
+		return fetchEpicFreeGames()
+	case steamFreeGamesFeed:
+		return fetchSteamFreeGames()
+	}
+	return fetchRSSFeed(feedURL)
+}
+
+// fetchQuakes merges BMKG's latest M5+ and felt earthquakes into feed items, newest first.
+// Each item links to the quake's time so the usual seen-link dedupe applies.
+func fetchQuakes() (*RSS, error) {
+	if rss, ok := rssCache.Get(bmkgQuakeFeed); ok {
+		return rss, nil
+	}
+
+	quakes := make(map[string]bmkgQuake)
+	for _, feedURL := range []string{bmkgQuakeFeed, bmkgFeltFeed} {
+		body, err := fetchPage("BMKG", feedURL)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Infogempa struct {
+				Gempa []bmkgQuake `json:"gempa"`
+			} `json:"Infogempa"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, upstreamDown("BMKG", fmt.Errorf("failed to parse JSON: %v", err))
+		}
+		for _, quake := range result.Infogempa.Gempa {
+			if known, ok := quakes[quake.DateTime]; ok {
+				// The same quake in both feeds, keep the tsunami potential and where it was felt
+				quake.Potential = known.Potential
+			}
+			quakes[quake.DateTime] = quake
+		}
+	}
+
+	times := make([]string, 0, len(quakes))
+	for t := range quakes {
+		times = append(times, t)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(times)))
+
+	var rss RSS
+	rss.Channel.Title = "BMKG Gempabumi"
+	for _, t := range times {
+		quake := quakes[t]
+		magnitude, _ := strconv.ParseFloat(quake.Magnitude, 64)
+		item := Item{
+			Title:     fmt.Sprintf("Gempa M%s · %s", quake.Magnitude, quake.Region),
+			Link:      "https://www.bmkg.go.id/gempabumi/gempabumi-terkini.bmkg?event=" + url.QueryEscape(quake.DateTime),
+			Magnitude: magnitude,
+		}
+		if t, err := time.Parse(time.RFC3339, quake.DateTime); err == nil {
+			item.PubDate = t.Format(time.RFC1123Z)
+		}
+
+		lines := []string{
+			fmt.Sprintf("**Magnitude:** %s", quake.Magnitude),
+			fmt.Sprintf("**Depth:** %s", quake.Depth),
+			fmt.Sprintf("**Location:** %s %s", quake.Latitude, quake.Longitude),
+		}
+		if quake.Felt != "" {
+			lines = append(lines, "**Felt:** "+quake.Felt)
+		}
+		if quake.Potential != "" {
+			lines = append(lines, "**Tsunami:** "+quake.Potential)
+		}
+		item.Description = strings.Join(lines, "\n")
+		rss.Channel.Items = append(rss.Channel.Items, item)
+	}
+
+	rssCache.Set(bmkgQuakeFeed, &rss)
+	return &rss, nil
+}
+
+// quakeEmbed builds the alert embed for an earthquake, colored by magnitude
+func quakeEmbed(item Item) *discordgo.MessageEmbed {
+	color := 0xf1c40f
+	switch {
+	case item.Magnitude >= 7:
+		color = 0x8b0000
+	case item.Magnitude >= 6:
+		color = 0xe74c3c
+	case item.Magnitude >= 5:
+		color = 0xe67e22
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🌋 " + item.Title,
+		URL:         item.Link,
+		Description: item.Description,
+		Color:       color,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Source: BMKG",
+		},
+	}
+	if t, ok := parsePubDate(item.PubDate); ok {
+		embed.Timestamp = t.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// handleFeedQuake subscribes a channel to BMKG earthquake alerts above a magnitude
+func handleFeedQuake(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelID := i.ChannelID
+	minMagnitude := defaultQuakeMagnitude
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "min_magnitude":
+			minMagnitude = opt.FloatValue()
+		}
+	}
+
+	// An existing subscription in the channel just gets the new threshold
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ChannelID == channelID && sub.Kind == feedKindQuake {
+			sub.MinMagnitude = minMagnitude
+			saveFeedSubscriptions()
+			feedMu.Unlock()
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("✅ Subscription #%d now posts earthquakes of magnitude %.1f and up in <#%s>.", sub.ID, minMagnitude, channelID),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+	feedMu.Unlock()
+
+	// Defer the response since fetching BMKG might take time
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	rss, err := fetchQuakes()
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	sub := &FeedSubscription{
+		GuildID:      i.GuildID,
+		ChannelID:    channelID,
+		Topic:        "Gempabumi BMKG",
+		URL:          bmkgQuakeFeed,
+		Kind:         feedKindQuake,
+		MinMagnitude: minMagnitude,
+		CreatedBy:    i.Member.User.ID,
+	}
+	sub.takeNewItems(rss.Channel.Items, nil) // only quakes from now on
+
+	feedMu.Lock()
+	sub.ID = nextFeedID
+	nextFeedID++
+	feedSubscriptions = append(feedSubscriptions, sub)
+	saveFeedSubscriptions()
+	feedMu.Unlock()
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprintf("✅ Subscription #%d created: <#%s> will get BMKG alerts for earthquakes of magnitude %.1f and up. Alerts are posted during quiet hours too.", sub.ID, channelID, minMagnitude),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// epicPromotions is the part of the Epic Games Store free games response the bot uses
+type epicPromotions struct {
+	Data struct {
+		Catalog struct {
+			SearchStore struct {
+				Elements []struct {
+					Title       string `json:"title"`
+					ID          string `json:"id"`
+					Description string `json:"description"`
+					ProductSlug string `json:"productSlug"`
+					KeyImages   []struct {
+						Type string `json:"type"`
+						URL  string `json:"url"`
+					} `json:"keyImages"`
+					CatalogNs struct {
+						Mappings []struct {
+							PageSlug string `json:"pageSlug"`
+						} `json:"mappings"`
+					} `json:"catalogNs"`
+					Price struct {
+						TotalPrice struct {
+							DiscountPrice int `json:"discountPrice"`
+							OriginalPrice int `json:"originalPrice"`
+							FmtPrice      struct {
+								OriginalPrice string `json:"originalPrice"`
+							} `json:"fmtPrice"`
+						} `json:"totalPrice"`
+					} `json:"price"`
+					Promotions *struct {
+						PromotionalOffers []struct {
+							PromotionalOffers []struct {
+								StartDate time.Time `json:"startDate"`
+								EndDate   time.Time `json:"endDate"`
+							} `json:"promotionalOffers"`
+						} `json:"promotionalOffers"`
+					} `json:"promotions"`
+				} `json:"elements"`
+			} `json:"searchStore"`
+		} `json:"Catalog"`
+	} `json:"data"`
+}
+
+// fetchEpicFreeGames turns the Epic Games Store games that are free right now into feed
+// items. Each item links to the store page with the promotion in the query, so the
+// seen-link dedupe posts a game again when it comes back in a later promotion.
+func fetchEpicFreeGames() (*RSS, error) {
+	if rss, ok := rssCache.Get(epicFreeGamesFeed); ok {
+		return rss, nil
+	}
+
+	body, err := fetchPage("Epic Games Store", epicFreeGamesFeed+"?locale=en-US&country=ID&allowCountries=ID")
+	if err != nil {
+		return nil, err
+	}
+	var result epicPromotions
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, upstreamDown("Epic Games Store", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	now := time.Now()
+	var rss RSS
+	rss.Channel.Title = "Epic Games Store"
+	for _, game := range result.Data.Catalog.SearchStore.Elements {
+		if game.Promotions == nil || game.Price.TotalPrice.DiscountPrice != 0 || game.Price.TotalPrice.OriginalPrice == 0 {
+			continue // not discounted to free, or always free
+		}
+		for _, group := range game.Promotions.PromotionalOffers {
+			for _, offer := range group.PromotionalOffers {
+				if now.Before(offer.StartDate) || !now.Before(offer.EndDate) {
+					continue
+				}
+				slug := game.ProductSlug
+				if len(game.CatalogNs.Mappings) > 0 {
+					slug = game.CatalogNs.Mappings[0].PageSlug
+				}
+				page := "https://store.epicgames.com/free-games"
+				if slug != "" {
+					page = "https://store.epicgames.com/p/" + strings.TrimSuffix(slug, "/home")
+				}
+				item := Item{
+					Title:       game.Title,
+					Link:        fmt.Sprintf("%s?promo=%s-%d", page, game.ID, offer.StartDate.Unix()),
+					Description: game.Description,
+					PubDate:     offer.StartDate.Format(time.RFC1123Z),
+					EndsAt:      offer.EndDate,
+					Worth:       game.Price.TotalPrice.FmtPrice.OriginalPrice,
+				}
+				for _, image := range game.KeyImages {
+					if image.Type == "OfferImageWide" || (item.Image == "" && image.Type == "Thumbnail") {
+						item.Image = image.URL
+					}
+				}
+				rss.Channel.Items = append(rss.Channel.Items, item)
+			}
+		}
+	}
+
+	rssCache.Set(epicFreeGamesFeed, &rss)
+	return &rss, nil
+}
+
+// fetchSteamFreeGames turns GamerPower's list of Steam games given away for free into
+// feed items, linked by giveaway ID for the seen-link dedupe. Steam has no public API
+// for its promotions.
+func fetchSteamFreeGames() (*RSS, error) {
+	if rss, ok := rssCache.Get(steamFreeGamesFeed); ok {
+		return rss, nil
+	}
+
+	body, err := fetchPage("GamerPower", steamFreeGamesFeed)
+	if err != nil {
+		return nil, err
+	}
+	var giveaways []struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Worth       string `json:"worth"`
+		Image       string `json:"image"`
+		Description string `json:"description"`
+		EndDate     string `json:"end_date"` // "2026-10-20 23:59:00" or "N/A"
+		URL         string `json:"open_giveaway_url"`
+		PublishedAt string `json:"published_date"`
+	}
+	if err := json.Unmarshal(body, &giveaways); err != nil {
+		return nil, upstreamDown("GamerPower", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+
+	var rss RSS
+	rss.Channel.Title = "Steam"
+	for _, giveaway := range giveaways {
+		link, err := url.Parse(giveaway.URL)
+		if err != nil || link.Host == "" {
+			continue
+		}
+		query := link.Query()
+		query.Set("promo", strconv.Itoa(giveaway.ID))
+		link.RawQuery = query.Encode()
+
+		item := Item{
+			Title:       strings.TrimSuffix(strings.TrimSuffix(giveaway.Title, " Giveaway"), " (Steam)"),
+			Link:        link.String(),
+			Description: giveaway.Description,
+			Image:       giveaway.Image,
+		}
+		if giveaway.Worth != "N/A" {
+			item.Worth = giveaway.Worth
+		}
+		// GamerPower dates are UTC
+		if ends, err := time.Parse(time.DateTime, giveaway.EndDate); err == nil {
+			item.EndsAt = ends
+		}
+		if published, err := time.Parse(time.DateTime, giveaway.PublishedAt); err == nil {
+			item.PubDate = published.Format(time.RFC1123Z)
+		}
+		rss.Channel.Items = append(rss.Channel.Items, item)
+	}
+
+	rssCache.Set(steamFreeGamesFeed, &rss)
+	return &rss, nil
+}
+
+// freeGameEmbed builds the announcement for a free game, counting down to the end of the
+// promotion
+func freeGameEmbed(store string, item Item) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎮 Free on " + store + ": " + truncate(item.Title, 200),
+		URL:         item.Link,
+		Description: truncate(item.Description, 400),
+		Color:       0x2ecc71,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Source: " + store,
+		},
+	}
+	if item.Image != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: item.Image}
+	}
+	if !item.EndsAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Free until",
+			Value:  fmt.Sprintf("<t:%d:f> (<t:%d:R>)", item.EndsAt.Unix(), item.EndsAt.Unix()),
+			Inline: true,
+		})
+	}
+	if item.Worth != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Usually", Value: "~~" + item.Worth + "~~", Inline: true})
+	}
+	return embed
+}
+
+// handleFeedFreeGames subscribes a channel to free games from the Epic Games Store or Steam
+func handleFeedFreeGames(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelID := i.ChannelID
+	feedURL, store := epicFreeGamesFeed, "Epic Games Store"
+	for _, opt := range options {
+		switch opt.Name {
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "store":
+			if opt.StringValue() == "steam" {
+				feedURL, store = steamFreeGamesFeed, "Steam"
+			}
+		}
+	}
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ChannelID == channelID && sub.URL == feedURL {
+			feedMu.Unlock()
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ <#%s> already gets free games from %s (subscription #%d).", channelID, store, sub.ID),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+	feedMu.Unlock()
+
+	// Defer the response since fetching the store might take time
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	rss, err := fetchFeed(feedURL)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	// The games free right now are posted on the next poll, they're still news to the channel
+	sub := &FeedSubscription{
+		GuildID:   i.GuildID,
+		ChannelID: channelID,
+		Topic:     store,
+		URL:       feedURL,
+		Kind:      feedKindGames,
+		CreatedBy: i.Member.User.ID,
+	}
+
+	feedMu.Lock()
+	sub.ID = nextFeedID
+	nextFeedID++
+	feedSubscriptions = append(feedSubscriptions, sub)
+	saveFeedSubscriptions()
+	feedMu.Unlock()
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprintf("✅ Subscription #%d created: <#%s> will get free games from %s. %d are free right now and will be posted shortly.", sub.ID, channelID, store, len(rss.Channel.Items)),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// routeChannel returns the channel an item should be posted to, based on the subscription's keyword routes
+func (sub *FeedSubscription) routeChannel(item Item) string {
+	text := " " + normalizeTitle(item.Title+" "+item.Description) + " "
+	for _, route := range sub.Routes {
+		if strings.Contains(text, " "+normalizeTitle(route.Keyword)+" ") {
+			return route.ChannelID
+		}
+	}
+	return sub.ChannelID
+}
+
+// handleFeedRoute handles /feed route add|remove for keyword routing rules
+func handleFeedRoute(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) {
+	var id int
+	var keyword, channelID string
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "id":
+			id = int(opt.IntValue())
+		case "keyword":
+			keyword = strings.TrimSpace(opt.StringValue())
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		}
+	}
+
+	content := fmt.Sprintf("❌ No feed subscription #%d in this server.", id)
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ID != id || sub.GuildID != i.GuildID {
+			continue
+		}
+
+		switch subcommand.Name {
+		case "add":
+			if normalizeTitle(keyword) == "" {
+				content = "❌ Please provide a keyword with letters or numbers."
+				break
+			}
+
+			updated := false
+			for idx := range sub.Routes {
+				if strings.EqualFold(sub.Routes[idx].Keyword, keyword) {
+					sub.Routes[idx].ChannelID = channelID
+					updated = true
+				}
+			}
+			if !updated && len(sub.Routes) >= maxFeedRoutes {
+				content = fmt.Sprintf("❌ Subscription #%d already has %d routes, remove one first.", id, maxFeedRoutes)
+				break
+			}
+			if !updated {
+				sub.Routes = append(sub.Routes, FeedRoute{Keyword: keyword, ChannelID: channelID})
+			}
+			saveFeedSubscriptions()
+			content = fmt.Sprintf("✅ Items of subscription #%d mentioning **%s** will be posted in <#%s>.", id, keyword, channelID)
+			if sub.Digest {
+				content += "\n⚠️ Routes only apply to per-article posts, this subscription is in digest mode."
+			}
+		case "remove":
+			content = fmt.Sprintf("❌ Subscription #%d has no route for **%s**.", id, keyword)
+			for idx, route := range sub.Routes {
+				if strings.EqualFold(route.Keyword, keyword) {
+					sub.Routes = append(sub.Routes[:idx], sub.Routes[idx+1:]...)
+					saveFeedSubscriptions()
+					content = fmt.Sprintf("✅ Route for **%s** removed from subscription #%d.", keyword, id)
+					break
+				}
+			}
+		}
+		break
+	}
+	feedMu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleFeedRetry re-enables a subscription that was disabled or is backing off after failures
+func handleFeedRetry(s *discordgo.Session, i *discordgo.InteractionCreate, id int) {
+	content := fmt.Sprintf("❌ No feed subscription #%d in this server.", id)
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ID == id && sub.GuildID == i.GuildID {
+			sub.Disabled = false
+			sub.recordSuccess()
+			saveFeedSubscriptions()
+			content = fmt.Sprintf("✅ Feed subscription #%d re-enabled, it will be fetched on the next poll.", id)
+			break
+		}
+	}
+	feedMu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleFeedSubscribe subscribes a channel to a topic, or updates an existing subscription
+func handleFeedSubscribe(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelID := i.ChannelID
+	var topic string
+	var digest bool
+	digestHour := defaultDigestHour
+	crosspost := true
+	crosspostSet := false
+	var threads, threadsSet bool
+	var translate string
+	translateSet := false
+	for _, opt := range options {
+		switch opt.Name {
+		case "topic":
+			topic = opt.StringValue()
+		case "channel":
+			channelID = opt.ChannelValue(nil).ID
+		case "digest":
+			digest = opt.BoolValue()
+		case "digest_hour":
+			digestHour = int(opt.IntValue())
+		case "crosspost":
+			crosspost = opt.BoolValue()
+			crosspostSet = true
+		case "threads":
+			threads = opt.BoolValue()
+			threadsSet = true
+		case "translate":
+			translate = opt.StringValue()
+			if translate == "off" {
+				translate = ""
+			}
+			translateSet = true
+		}
+	}
+
+	rssURL, foundTopic := findRSSTopic(topic)
+	if rssURL == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Topic not found! Available topics:\n• %s", strings.Join(availableRSSTopics(), "\n• ")),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	mode := "every new article"
+	if digest {
+		mode = fmt.Sprintf("a daily digest at %02d:00 server time", digestHour)
+	}
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.ChannelID == channelID && sub.Topic == foundTopic {
+			sub.Digest = digest
+			sub.DigestHour = digestHour
+			if crosspostSet {
+				sub.Crosspost = crosspost
+			}
+			if threadsSet {
+				sub.Threads = threads
+			}
+			if translateSet {
+				sub.Translate = translate
+			}
+			saveFeedSubscriptions()
+			feedMu.Unlock()
+
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("✅ Subscription #%d updated: <#%s> now gets %s for **%s**.", sub.ID, channelID, mode, foundTopic),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+	}
+	feedMu.Unlock()
+
+	// Defer the response since fetching RSS might take time
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	// Mark the current items as seen so only articles published from now on are posted
+	rss, err := fetchRSSFeed(rssURL)
+	if err != nil {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: userErrorMessage(err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	sub := &FeedSubscription{
+		GuildID:    i.GuildID,
+		ChannelID:  channelID,
+		Topic:      foundTopic,
+		URL:        rssURL,
+		Digest:     digest,
+		DigestHour: digestHour,
+		Crosspost:  crosspost,
+		Threads:    threads,
+		Translate:  translate,
+		CreatedBy:  i.Member.User.ID,
+	}
+	sub.takeNewItems(rss.Channel.Items, nil)
+
+	feedMu.Lock()
+	sub.ID = nextFeedID
+	nextFeedID++
+	feedSubscriptions = append(feedSubscriptions, sub)
+	saveFeedSubscriptions()
+	feedMu.Unlock()
+
+	content := fmt.Sprintf("✅ Subscription #%d created: <#%s> will get %s for **%s**.", sub.ID, channelID, mode, foundTopic)
+	if translate != "" && os.Getenv("TRANSLATE_API_URL") == "" {
+		content += "\n⚠️ Translation is not configured on this bot, items will be posted untranslated."
+	}
+
+	s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// handleFeedUnsubscribe removes one of the server's feed subscriptions
+func handleFeedUnsubscribe(s *discordgo.Session, i *discordgo.InteractionCreate, id int) {
+	content := fmt.Sprintf("❌ No feed subscription #%d in this server.", id)
+
+	feedMu.Lock()
+	for idx, sub := range feedSubscriptions {
+		if sub.ID == id && sub.GuildID == i.GuildID {
+			feedSubscriptions = append(feedSubscriptions[:idx], feedSubscriptions[idx+1:]...)
+			saveFeedSubscriptions()
+			content = fmt.Sprintf("✅ Feed subscription #%d removed.", id)
+			break
+		}
+	}
+	feedMu.Unlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleFeedList shows the server's feed subscriptions
+func handleFeedList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	embed := &discordgo.MessageEmbed{
+		Title:       "📰 Feed Subscriptions",
+		Description: "News feeds posted in this server",
+		Color:       0x1f8b4c,
+	}
+
+	feedMu.Lock()
+	for _, sub := range feedSubscriptions {
+		if sub.GuildID != i.GuildID || len(embed.Fields) >= 25 {
+			continue
+		}
+		mode := "Every new article"
+		switch sub.Kind {
+		case feedKindPodcast:
+			mode = "Every new podcast episode"
+		case feedKindQuake:
+			mode = fmt.Sprintf("Earthquakes of magnitude %.1f and up", sub.MinMagnitude)
+		case feedKindGames:
+			mode = "Every new free game"
+		}
+		if sub.Digest {
+			mode = fmt.Sprintf("Daily digest at %02d:00 server time (%d queued)", sub.DigestHour, len(sub.Pending))
+		}
+		if sub.Crosspost {
+			mode += ", crossposted in Announcement channels"
+		}
+		if sub.Threads && !sub.Digest {
+			mode += ", with a discussion thread per article"
+		}
+		if sub.Translate != "" {
+			mode += fmt.Sprintf(", translated to `%s`", sub.Translate)
+		}
+		if sub.Webhook != nil {
+			mode += fmt.Sprintf(", posted as **%s**", sub.Webhook.Name)
+		}
+		for _, route := range sub.Routes {
+			mode += fmt.Sprintf("\n↪️ **%s** → <#%s>", route.Keyword, route.ChannelID)
+		}
+		if sub.Disabled {
+			mode += fmt.Sprintf("\n⛔ Disabled after %d failed fetches, use `/feed retry %d`", sub.Failures, sub.ID)
+		} else if sub.Failures > 0 {
+			mode += fmt.Sprintf("\n⚠️ %d failed fetches in a row, next try <t:%d:R>", sub.Failures, sub.RetryAt.Unix())
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("#%d • %s", sub.ID, sub.Topic),
+			Value:  fmt.Sprintf("<#%s>\n%s", sub.ChannelID, mode),
+			Inline: false,
+		})
+	}
+	feedMu.Unlock()
+
+	if len(embed.Fields) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "📝 No feed subscriptions for this server.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// loadPostedArticles loads the index of posted feed items from JSON file
+func loadPostedArticles() {
+	postedArticles = make([]PostedArticle, 0)
+
+	if _, err := os.Stat(articlesFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(articlesFile)
+	if err != nil {
+		log.Printf("Error reading posted articles file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &postedArticles); err != nil {
+		log.Printf("Error parsing posted articles file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded %d posted articles", len(postedArticles))
+}
+
+// savePostedArticles saves the index of posted feed items to JSON file (caller holds articleMu)
+func savePostedArticles() {
+	data, err := json.MarshalIndent(postedArticles, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling posted articles: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(articlesFile, data, 0644); err != nil {
+		log.Printf("Error saving posted articles: %v", err)
+		return
+	}
+}
+
+// newPostedArticle builds the index entry for a feed item posted by a subscription
+func newPostedArticle(sub *FeedSubscription, item Item) PostedArticle {
+	article := PostedArticle{
+		GuildID:     sub.GuildID,
+		ChannelID:   sub.ChannelID,
+		Topic:       sub.Topic,
+		Title:       item.Title,
+		Description: cleanDescription(item.Description),
+		Link:        item.Link,
+		PostedAt:    time.Now(),
+	}
+	if t, ok := parsePubDate(item.PubDate); ok {
+		article.PublishedAt = t
+	}
+	return article
+}
+
+// recordPostedArticles adds posted items to the search index, dropping entries past the retention window
+func recordPostedArticles(articles []PostedArticle) {
+	if len(articles) == 0 {
+		return
+	}
+
+	articleMu.Lock()
+	defer articleMu.Unlock()
+
+	cutoff := time.Now().Add(-articleRetention)
+	kept := postedArticles[:0]
+	for _, article := range postedArticles {
+		if article.PostedAt.After(cutoff) {
+			kept = append(kept, article)
+		}
+	}
+	postedArticles = append(kept, articles...)
+	savePostedArticles()
+}
+
+// searchPostedArticles finds a server's posted articles matching every query word, best matches first
+func searchPostedArticles(guildID, query string, days int) []PostedArticle {
+	terms := strings.Fields(normalizeTitle(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	type scored struct {
+		article PostedArticle
+		score   int
+	}
+	var matches []scored
+
+	articleMu.Lock()
+	for _, article := range postedArticles {
+		if article.GuildID != guildID || article.PostedAt.Before(cutoff) {
+			continue
+		}
+
+		title := normalizeTitle(article.Title)
+		description := normalizeTitle(article.Description)
+		score := 0
+		for _, term := range terms {
+			switch {
+			case strings.Contains(title, term):
+				score += 2
+			case strings.Contains(description, term):
+				score++
+			default:
+				score = -1
+			}
+			if score < 0 {
+				break
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{article, score})
+		}
+	}
+	articleMu.Unlock()
+
+	// Title matches rank higher, then newer articles
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		return matches[a].article.PostedAt.After(matches[b].article.PostedAt)
+	})
+
+	results := make([]PostedArticle, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, match.article)
+	}
+	return results
+}
+
+// handleNewsCommand handles the /news slash command
+func handleNewsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ News search only works in servers, not in DMs!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "search":
+		handleNewsSearch(s, i, subcommand.Options)
+	}
+}
+
+// handleNewsSearch searches articles the bot posted in this server
+func handleNewsSearch(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	var query string
+	days := defaultSearchDays
+	for _, opt := range options {
+		switch opt.Name {
+		case "query":
+			query = opt.StringValue()
+		case "days":
+			days = int(opt.IntValue())
+		}
+	}
+
+	results := searchPostedArticles(i.GuildID, query, days)
+	if len(results) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("🔍 No posted articles matching **%s** in the last %d days.", query, days),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔍 News matching \"%s\"", query),
+		Description: fmt.Sprintf("Articles posted in this server in the last %d days", days),
+		Color:       0x1f8b4c,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d results", len(results)),
+		},
+	}
+
+	for idx, article := range results {
+		if idx >= maxSearchResults {
+			break
+		}
+		value := fmt.Sprintf("%s • <t:%d:d>\n[Read More](%s)", article.Topic, article.PostedAt.Unix(), article.Link)
+		if article.MessageID != "" {
+			value += fmt.Sprintf(" • [Jump to post](https://discord.com/channels/%s/%s/%s)", article.GuildID, article.ChannelID, article.MessageID)
+		}
+		title := article.Title
+		if len(title) > 250 {
+			title = title[:250] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   title,
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// loadBookmarks loads saved bookmarks from JSON file
+func loadBookmarks() {
+	userBookmarks = make(UserBookmarks)
+
+	if _, err := os.Stat(bookmarksFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(bookmarksFile)
+	if err != nil {
+		log.Printf("Error reading bookmarks file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &userBookmarks); err != nil {
+		log.Printf("Error parsing bookmarks file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded bookmarks for %d users", len(userBookmarks))
+}
+
+// saveBookmarks saves bookmarks to JSON file. The caller must hold bookmarksMu.
+func saveBookmarks() {
+	data, err := json.MarshalIndent(userBookmarks, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling bookmarks: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(bookmarksFile, data, 0644); err != nil {
+		log.Printf("Error saving bookmarks: %v", err)
+		return
+	}
+}
+
+// saveButtonRow returns the "🔖 Save" button attached to posted news articles
+func saveButtonRow() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Save",
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔖"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: "bookmark_save",
+				},
+			},
+		},
+	}
+}
+
+// savePickerRow returns a select menu for saving one article from a message with several article embeds
+func savePickerRow(embeds []*discordgo.MessageEmbed) []discordgo.MessageComponent {
+	var options []discordgo.SelectMenuOption
+	for idx, embed := range embeds {
+		// Discord rejects the whole menu if one option has no label
+		title := strings.TrimSpace(embed.Title)
+		if title == "" {
+			title = fmt.Sprintf("Article %d", idx+1)
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncate(title, 100),
+			Value: strconv.Itoa(idx),
+			Emoji: &discordgo.ComponentEmoji{Name: "🔖"},
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "bookmark_pick",
+					Placeholder: "Save an article for later…",
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// bookmarkFromMessage builds a bookmark from a message, preferring its first embed's title and link
+func bookmarkFromMessage(guildID string, msg *discordgo.Message) Bookmark {
+	bookmark := Bookmark{
+		JumpLink: fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, msg.ChannelID, msg.ID),
+		SavedAt:  time.Now(),
+	}
+	if guildID == "" {
+		bookmark.JumpLink = fmt.Sprintf("https://discord.com/channels/@me/%s/%s", msg.ChannelID, msg.ID)
+	}
+
+	if len(msg.Embeds) > 0 {
+		bookmark.Title = msg.Embeds[0].Title
+		bookmark.Link = msg.Embeds[0].URL
+	}
+	if bookmark.Title == "" {
+		bookmark.Title = msg.Content
+	}
+	if len(bookmark.Title) > 100 {
+		bookmark.Title = bookmark.Title[:100] + "..."
+	}
+	if bookmark.Title == "" {
+		bookmark.Title = "Saved message"
+	}
+	return bookmark
+}
+
+// addBookmark stores a bookmark for a user and returns the reply text
+func addBookmark(userID string, bookmark Bookmark) string {
+	bookmarksMu.Lock()
+	defer bookmarksMu.Unlock()
+
+	for _, existing := range userBookmarks[userID] {
+		if bookmark.Watchlist && existing.Watchlist && existing.Link == bookmark.Link {
+			return "🍿 That's already on your watchlist! Use `/bookmarks watchlist` to see it."
+		}
+		if existing.JumpLink == bookmark.JumpLink && existing.Link == bookmark.Link {
+			return "🔖 You already saved this one! Use `/bookmarks` to see your saved items."
+		}
+	}
+
+	if len(userBookmarks[userID]) >= maxBookmarks {
+		return fmt.Sprintf("❌ You have reached the limit of %d bookmarks. Use `/bookmarks clear` to make room.", maxBookmarks)
+	}
+
+	userBookmarks[userID] = append(userBookmarks[userID], bookmark)
+	saveBookmarks()
+	if bookmark.Watchlist {
+		return fmt.Sprintf("🍿 Added **%s** to your watchlist! Use `/bookmarks watchlist` to see it.", bookmark.Title)
+	}
+	return fmt.Sprintf("🔖 Saved **%s**! Use `/bookmarks` to see your saved items.", bookmark.Title)
+}
+
+// interactionUserID returns the ID of the user behind an interaction, in servers or DMs
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// handleBookmarkButton saves the news article the "🔖 Save" button is attached to
+func handleBookmarkButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	content := addBookmark(interactionUserID(i), bookmarkFromMessage(i.GuildID, i.Message))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleBookmarkPick saves the article picked from the select menu under a batched feed post
+func handleBookmarkPick(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	bookmark := bookmarkFromMessage(i.GuildID, i.Message)
+	if values := i.MessageComponentData().Values; len(values) == 1 {
+		if idx, err := strconv.Atoi(values[0]); err == nil && idx >= 0 && idx < len(i.Message.Embeds) {
+			bookmark.Title = i.Message.Embeds[idx].Title
+			bookmark.Link = i.Message.Embeds[idx].URL
+			if len(bookmark.Title) > 100 {
+				bookmark.Title = bookmark.Title[:100] + "..."
+			}
+		}
+	}
+	content := addBookmark(interactionUserID(i), bookmark)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleBookmarkMessageCommand handles the "Bookmark" message context menu
+func handleBookmarkMessageCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	msg := data.Resolved.Messages[data.TargetID]
+	if msg == nil {
+		return
+	}
+
+	content := addBookmark(interactionUserID(i), bookmarkFromMessage(i.GuildID, msg))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleBookmarksCommand handles the /bookmarks slash command
+func handleBookmarksCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+
+	action := "list"
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		action = options[0].StringValue()
+	}
+
+	if action == "clear" {
+		bookmarksMu.Lock()
+		delete(userBookmarks, userID)
+		saveBookmarks()
+		bookmarksMu.Unlock()
+
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🗑️ All your bookmarks were cleared.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	bookmarksMu.Lock()
+	bookmarks := slices.Clone(userBookmarks[userID])
+	bookmarksMu.Unlock()
+	title := "🔖 Your Bookmarks"
+	if action == "watchlist" {
+		bookmarks = slices.DeleteFunc(bookmarks, func(bookmark Bookmark) bool {
+			return !bookmark.Watchlist
+		})
+		if len(bookmarks) == 0 {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "🍿 Your watchlist is empty. Look a title up with `/movie` and press **Watchlist**.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		title = "🍿 Your Watchlist"
+	}
+	if len(bookmarks) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "🔖 You have no bookmarks yet. Press **Save** on a news post or use **Apps → Bookmark** on any message.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: "Newest first",
+		Color:       0x3498db,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Total bookmarks: %d/%d", len(bookmarks), maxBookmarks),
+		},
+	}
+
+	for idx := len(bookmarks) - 1; idx >= 0 && len(embed.Fields) < 25; idx-- {
+		bookmark := bookmarks[idx]
+		value := fmt.Sprintf("<t:%d:d> • [Jump to message](%s)", bookmark.SavedAt.Unix(), bookmark.JumpLink)
+		if bookmark.Link != "" {
+			value = fmt.Sprintf("[Open link](%s) • ", bookmark.Link) + value
+		}
+		name := bookmark.Title
+		if bookmark.Watchlist && action != "watchlist" {
+			name = "🍿 " + name
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// selfTestResult is the outcome of one startup or /admin selftest check
+type selfTestResult struct {
+	Name     string
+	Err      error
+	Detail   string
+	Duration time.Duration
+}
+
+// dataFiles are the JSON stores checked by the self-test
+var dataFiles = []string{
+	dataFile, settingsFile, commandsFile, scheduleFile, feedsFile,
+	articlesFile, bookmarksFile, historyFile, secretsFile, tokensFile,
+	auditFile, blocklistFile, reviewsFile, triviaFile, streaksFile,
+	confessFile, faqFile, onboardFile, profilesFile, remindersFile,
+	tasksFile, monitorsFile, liveFile, memesFile,
+}
+
+// runSelfTest checks Discord, the exchange rate API, a sample feed and the data stores
+func runSelfTest(s *discordgo.Session) []selfTestResult {
+	checks := []struct {
+		name string
+		fn   func() (string, error)
+	}{
+		{"Discord REST", func() (string, error) {
+			user, err := s.User("@me")
+			if err != nil {
+				return "", err
+			}
+			return "logged in as " + user.Username, nil
+		}},
+		{"Exchange rates", func() (string, error) {
+			if os.Getenv("EXCHANGERATE_API_KEY") == "" {
+				return "", fmt.Errorf("EXCHANGERATE_API_KEY is not set")
+			}
+			result, err := convertCurrency(1, "usd", "idr")
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("1 USD = %s IDR", formatRate(result.Info.Rate)), nil
+		}},
+		{"News feed", func() (string, error) {
+			rss, err := fetchRSSFeed(rssTopics["berita"])
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d items", len(rss.Channel.Items)), nil
+		}},
+		{"Data stores", checkDataStores},
+	}
+
+	var results []selfTestResult
+	for _, check := range checks {
+		started := time.Now()
+		detail, err := check.fn()
+		results = append(results, selfTestResult{
+			Name:     check.name,
+			Err:      err,
+			Detail:   detail,
+			Duration: time.Since(started).Round(time.Millisecond),
+		})
+	}
+	return results
+}
+
+// checkDataStores verifies the stored JSON files parse and the data directory is writable
+func checkDataStores() (string, error) {
+	found := 0
+	for _, file := range dataFiles {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %v", file, err)
+		}
+		if !json.Valid(data) {
+			return "", fmt.Errorf("%s is not valid JSON", file)
+		}
+		found++
+	}
+
+	probe := ".selftest"
+	if err := os.WriteFile(probe, []byte("ok"), 0644); err != nil {
+		return "", fmt.Errorf("data directory is not writable: %v", err)
+	}
+	os.Remove(probe)
+
+	return fmt.Sprintf("%d files readable, directory writable", found), nil
+}
+
+// logSelfTest runs the self-test at startup and logs a readiness summary
+func logSelfTest(s *discordgo.Session) {
+	results := runSelfTest(s)
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			log.Printf("Self-test %s failed after %s: %v", result.Name, result.Duration, result.Err)
+		} else {
+			log.Printf("Self-test %s ok in %s: %s", result.Name, result.Duration, result.Detail)
+		}
+	}
+
+	if failed == 0 {
+		log.Printf("Ready: all %d self-test checks passed", len(results))
+	} else {
+		log.Printf("Degraded: %d of %d self-test checks failed", failed, len(results))
+	}
+}
+
+// selfTestEmbed shows self-test results along with recovered background job panics
+func selfTestEmbed(results []selfTestResult) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "🩺 Self-Test",
+		Color:     0x2ecc71,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	failed := 0
+	for _, result := range results {
+		value := fmt.Sprintf("✅ %s (%s)", result.Detail, result.Duration)
+		if result.Err != nil {
+			failed++
+			value = fmt.Sprintf("❌ %v (%s)", result.Err, result.Duration)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   result.Name,
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	jobPanicsMu.Lock()
+	var panics []string
+	for name, count := range jobPanics {
+		panics = append(panics, fmt.Sprintf("%s: %d", name, count))
+	}
+	jobPanicsMu.Unlock()
+	if len(panics) > 0 {
+		sort.Strings(panics)
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Recovered panics",
+			Value:  strings.Join(panics, "\n"),
+			Inline: false,
+		})
+	}
+
+	if failed == 0 {
+		embed.Description = fmt.Sprintf("All %d checks passed", len(results))
+	} else {
+		embed.Description = fmt.Sprintf("%d of %d checks failed", failed, len(results))
+		embed.Color = 0xe74c3c
+	}
+	return embed
+}
+
+// cacheStatsEmbed shows the size and hit rate of every shared cache
+func cacheStatsEmbed() *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "🗃️ Cache Stats",
+		Color:     0x3498db,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	for _, cache := range caches {
+		stats := cache.stats()
+		hitRate := 0.0
+		if total := stats.Hits + stats.Misses; total > 0 {
+			hitRate = float64(stats.Hits) / float64(total) * 100
+		}
+		ttl := "no expiry"
+		if stats.TTL > 0 {
+			ttl = "TTL " + stats.TTL.String()
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: stats.Name,
+			Value: fmt.Sprintf("%d/%d entries, %s\n%d hits, %d misses (%.0f%% hit rate), %d evicted",
+				stats.Size, stats.MaxSize, ttl, stats.Hits, stats.Misses, hitRate, stats.Evictions),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// handleAdminCommand handles the /admin slash command for bot diagnostics
+func handleAdminCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// The blocklist is checked against the bot owners instead, so it also works in DMs
+	if group := i.ApplicationCommandData().Options[0]; group.Name == "blocklist" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: blocklistCommand(s, i, group.Options[0]),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Cache stats and the self-test report on the whole bot, not just this server
+	if !isBotOwner(interactionUserID(i)) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Only the bot owner can run diagnostics.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	switch i.ApplicationCommandData().Options[0].Name {
+	case "cache":
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{cacheStatsEmbed()},
+				Flags:  discordgo.MessageFlagsEphemeral,
+			},
+		})
+	case "selftest":
+		// Defer the response since the checks call external services
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			log.Printf("Error deferring interaction: %v", err)
+			return
+		}
+
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{selfTestEmbed(runSelfTest(s))},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		})
+	}
+}
+
+// loadAuditLogs loads the privileged command audit trail from JSON file
+func loadAuditLogs() {
+	auditLogs = make(ServerAuditLogs)
+
+	if _, err := os.Stat(auditFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(auditFile)
+	if err != nil {
+		log.Printf("Error reading audit log file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &auditLogs); err != nil {
+		log.Printf("Error parsing audit log file: %v", err)
+		return
+	}
+
+	log.Printf("Loaded audit logs for %d servers", len(auditLogs))
+}
+
+// saveAuditLogs saves the privileged command audit trail to JSON file
+func saveAuditLogs() {
+	data, err := json.MarshalIndent(auditLogs, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling audit logs: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(auditFile, data, 0644); err != nil {
+		log.Printf("Error saving audit logs: %v", err)
+		return
+	}
+}
+
+// privilegedCommands are the slash commands that need Manage Server, built from slashCommands
+var (
+	privilegedCommands     map[string]bool
+	privilegedCommandsOnce sync.Once
+)
+
+// isPrivilegedCommand reports whether a slash command is restricted to server managers
+func isPrivilegedCommand(name string) bool {
+	privilegedCommandsOnce.Do(func() {
+		privilegedCommands = make(map[string]bool)
+		for _, cmd := range slashCommands() {
+			if cmd.DefaultMemberPermissions != nil {
+				privilegedCommands[cmd.Name] = true
+			}
+		}
+	})
+	return privilegedCommands[name]
+}
+
+// auditCommandLine flattens an interaction into "/name group sub" and "opt=value" parts,
+// masking values that look like secrets
+func auditCommandLine(data discordgo.ApplicationCommandInteractionData) (string, string) {
+	command := "/" + data.Name
+	var options []string
+
+	var walk func(opts []*discordgo.ApplicationCommandInteractionDataOption)
+	walk = func(opts []*discordgo.ApplicationCommandInteractionDataOption) {
+		for _, opt := range opts {
+			switch opt.Type {
+			case discordgo.ApplicationCommandOptionSubCommandGroup, discordgo.ApplicationCommandOptionSubCommand:
+				command += " " + opt.Name
+				walk(opt.Options)
+			default:
+				value := fmt.Sprint(opt.Value)
+				if opt.Name == "key" || opt.Name == "token" {
+					value = maskSecret(value)
+				}
+				options = append(options, opt.Name+"="+value)
+			}
+		}
+	}
+	walk(data.Options)
+
+	return command, strings.Join(options, " ")
+}
+
+// truncate shortens text to at most limit bytes, marking the cut with "..."
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit - 3
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut-- // don't split a multi-byte character
+	}
+	return text[:cut] + "..."
+}
+
+// interactionResult summarizes the reply a handler sent, for the audit trail
+func interactionResult(s *discordgo.Session, i *discordgo.InteractionCreate) string {
+	msg, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		return "no reply"
+	}
+
+	result := msg.Content
+	if result == "" && len(msg.Embeds) > 0 {
+		result = msg.Embeds[0].Title
+	}
+	if result == "" {
+		return "deferred"
+	}
+	result, _, _ = strings.Cut(result, "\n")
+	return truncate(result, 150)
+}
+
+// recordAudit appends a privileged command use to the server's audit trail
+func recordAudit(i *discordgo.InteractionCreate, result string) {
+	command, options := auditCommandLine(i.ApplicationCommandData())
+	entry := AuditEntry{
+		Time:    time.Now(),
+		UserID:  interactionUserID(i),
+		Command: command,
+		Options: truncate(options, 500),
+		Result:  result,
+	}
+	if i.Member != nil && i.Member.User != nil {
+		entry.User = i.Member.User.Username
+	}
+	appendAudit(i.GuildID, entry)
+}
+
+// appendAudit adds an entry to the server's audit trail, also for changes the bot makes
+// on its own
+func appendAudit(guildID string, entry AuditEntry) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	entries := append(auditLogs[guildID], entry)
+	if len(entries) > maxAuditEntries {
+		entries = entries[len(entries)-maxAuditEntries:]
+	}
+	auditLogs[guildID] = entries
+	saveAuditLogs()
+}
+
+// filterAudit returns a server's audit entries, newest first, optionally for one user or command
+func filterAudit(guildID, userID, command string) []AuditEntry {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	command = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(command)), "/")
+	var matched []AuditEntry
+	entries := auditLogs[guildID]
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		entry := entries[idx]
+		if userID != "" && entry.UserID != userID {
+			continue
+		}
+		if command != "" && !strings.HasPrefix(strings.TrimPrefix(entry.Command, "/"), command) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	return matched
+}
+
+// auditEmbed lists the most recent audit entries
+func auditEmbed(entries []AuditEntry) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "🛡️ Audit Trail",
+		Color: embedColor,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Showing %d of %d entries · /audit export for the full log", min(len(entries), auditPageSize), len(entries)),
+		},
+	}
+
+	var lines []string
+	for _, entry := range entries[:min(len(entries), auditPageSize)] {
+		line := fmt.Sprintf("<t:%d:R> <@%s> `%s`", entry.Time.Unix(), entry.UserID, entry.Command)
+		if entry.Options != "" {
+			line += " " + truncate(entry.Options, 80)
+		}
+		line += "\n↳ " + entry.Result
+		lines = append(lines, line)
+	}
+	embed.Description = truncate(strings.Join(lines, "\n"), 4000)
+	return embed
+}
+
+// auditCSV renders audit entries as CSV, oldest first
+func auditCSV(entries []AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"time", "user_id", "user", "command", "options", "result"})
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		entry := entries[idx]
+		writer.Write([]string{entry.Time.UTC().Format(time.RFC3339), entry.UserID, entry.User, entry.Command, entry.Options, entry.Result})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// handleAuditCommand handles /audit view|export
+func handleAuditCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || !hasManageGuild(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ You need the Manage Server permission to view the audit trail.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	var userID, command string
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "user":
+			userID = opt.UserValue(nil).ID
+		case "command":
+			command = opt.StringValue()
+		}
+	}
+
+	entries := filterAudit(i.GuildID, userID, command)
+	if len(entries) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "📝 No privileged commands have been recorded for this filter.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	if subcommand.Name == "export" {
+		data, err := auditCSV(entries)
+		if err != nil {
+			log.Printf("Error exporting audit log: %v", err)
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "❌ Failed to export the audit trail.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("📄 %d audit entries", len(entries)),
+				Files: []*discordgo.File{{
+					Name:        fmt.Sprintf("audit-%s-%s.csv", i.GuildID, time.Now().Format("20060102")),
+					ContentType: "text/csv",
+					Reader:      bytes.NewReader(data),
+				}},
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{auditEmbed(entries)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// loadBlocklist loads the bot-wide blocklist from JSON file
+func loadBlocklist() {
+	blocklist = Blocklist{Users: make(map[string]BlockEntry), Guilds: make(map[string]BlockEntry)}
+
+	if _, err := os.Stat(blocklistFile); os.IsNotExist(err) {
+		return
+	}
+
+	data, err := os.ReadFile(blocklistFile)
+	if err != nil {
+		log.Printf("Error reading blocklist file: %v", err)
+		return
+	}
+
+	if err := json.Unmarshal(data, &blocklist); err != nil {
+		log.Printf("Error parsing blocklist file: %v", err)
+		return
+	}
+	if blocklist.Users == nil {
+		blocklist.Users = make(map[string]BlockEntry)
+	}
+	if blocklist.Guilds == nil {
+		blocklist.Guilds = make(map[string]BlockEntry)
+	}
+
+	log.Printf("Loaded blocklist with %d users and %d servers", len(blocklist.Users), len(blocklist.Guilds))
+}
+
+// saveBlocklist saves the bot-wide blocklist to JSON file
+func saveBlocklist() {
+	data, err := json.MarshalIndent(blocklist, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling blocklist: %v", err)
+		return
+	}
+
+	if err := os.WriteFile(blocklistFile, data, 0644); err != nil {
+		log.Printf("Error saving blocklist: %v", err)
+		return
+	}
+}
+
+// isBlocked reports whether a user or the server they are in is on the blocklist
+func isBlocked(userID, guildID string) bool {
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+
+	_, userBlocked := blocklist.Users[userID]
+	_, guildBlocked := blocklist.Guilds[guildID]
+	return userBlocked || guildBlocked
+}
+
+// leaveIfBlocked leaves a server that is on the blocklist
+func leaveIfBlocked(s *discordgo.Session, guildID string) {
+	blocklistMu.Lock()
+	_, blocked := blocklist.Guilds[guildID]
+	blocklistMu.Unlock()
+	if !blocked {
+		return
+	}
+
+	if err := s.GuildLeave(guildID); err != nil {
+		log.Printf("Error leaving blocked guild %s: %v", guildID, err)
+		return
+	}
+	log.Printf("Left blocked guild %s", guildID)
+}
+
+// loadBotOwners records the application owner, or its team members, plus any IDs in BOT_OWNER_IDS
+func loadBotOwners(s *discordgo.Session) {
+	owners := make(map[string]bool)
+	for _, id := range strings.Split(os.Getenv("BOT_OWNER_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			owners[id] = true
+		}
+	}
+
+	app, err := s.Application("@me")
+	if err != nil {
+		log.Printf("Error fetching application info: %v", err)
+	} else {
+		if app.Owner != nil {
+			owners[app.Owner.ID] = true
+		}
+		if app.Team != nil {
+			for _, member := range app.Team.Members {
+				if member.User != nil {
+					owners[member.User.ID] = true
+				}
+			}
+		}
+	}
+
+	blocklistMu.Lock()
+	botOwners = owners
+	blocklistMu.Unlock()
+	log.Printf("Bot owners: %d", len(owners))
+}
+
+// isBotOwner reports whether a user owns the bot's application or is on its team
+func isBotOwner(userID string) bool {
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+	return botOwners[userID]
+}
+
+// blocklistCommand handles /admin blocklist add|remove|list and returns the reply
+func blocklistCommand(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) string {
+	userID := interactionUserID(i)
+
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+
+	if !botOwners[userID] {
+		return "❌ Only the bot owner can manage the blocklist."
+	}
+
+	var kind, id, reason string
+	for _, opt := range subcommand.Options {
+		switch opt.Name {
+		case "type":
+			kind = opt.StringValue()
+		case "id":
+			id = strings.Trim(strings.TrimSpace(opt.StringValue()), "<@!>")
+		case "reason":
+			reason = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	entries, label := blocklist.Users, "User"
+	if kind == "guild" {
+		entries, label = blocklist.Guilds, "Server"
+	}
+
+	switch subcommand.Name {
+	case "add":
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return fmt.Sprintf("❌ `%s` is not a Discord ID.", id)
+		}
+		if kind == "user" && botOwners[id] {
+			return "❌ Bot owners can't be blocked."
+		}
+		entries[id] = BlockEntry{Reason: reason, AddedBy: userID, AddedAt: time.Now()}
+		saveBlocklist()
+		log.Printf("Blocked %s %s: %s", kind, id, reason)
+
+		if kind == "guild" {
+			if err := s.GuildLeave(id); err == nil {
+				return fmt.Sprintf("✅ Server `%s` blocked and the bot has left it.", id)
+			}
+			return fmt.Sprintf("✅ Server `%s` blocked. The bot will leave it if it joins.", id)
+		}
+		return fmt.Sprintf("✅ User <@%s> blocked. The bot will ignore their messages and commands.", id)
+
+	case "remove":
+		if _, ok := entries[id]; !ok {
+			return fmt.Sprintf("❌ %s `%s` is not blocked.", label, id)
+		}
+		delete(entries, id)
+		saveBlocklist()
+		log.Printf("Unblocked %s %s", kind, id)
+		return fmt.Sprintf("✅ %s `%s` unblocked.", label, id)
+
+	default:
+		if len(blocklist.Users) == 0 && len(blocklist.Guilds) == 0 {
+			return "📝 The blocklist is empty."
+		}
+		lines := []string{"⛔ **Blocklist**"}
+		add := func(label string, entries map[string]BlockEntry) {
+			ids := make([]string, 0, len(entries))
+			for id := range entries {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				line := fmt.Sprintf("• %s `%s`, by <@%s> <t:%d:R>", label, id, entries[id].AddedBy, entries[id].AddedAt.Unix())
+				if entries[id].Reason != "" {
+					line += ": " + entries[id].Reason
+				}
+				lines = append(lines, line)
+			}
+		}
+		add("User", blocklist.Users)
+		add("Server", blocklist.Guilds)
+		return truncate(strings.Join(lines, "\n"), 2000)
+	}
+}
+
+// handleComponent routes button and select menu interactions by their custom ID prefix
+func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action, arg, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+
+	switch action {
+	case "schedule_import_confirm":
+		handleScheduleImportButton(s, i, arg, true)
+	case "schedule_import_cancel":
+		handleScheduleImportButton(s, i, arg, false)
+	case "bookmark_save":
+		handleBookmarkButton(s, i)
+	case "bookmark_pick":
+		handleBookmarkPick(s, i)
+	case "watchlist_add":
+		handleWatchlistButton(s, i)
+	case "convert_to", "convert_swap":
+		handleConvertComponent(s, i, action, arg)
+	case "reply_review":
+		handleReplyReviewButton(s, i, arg)
+	case "trivia":
+		handleTriviaAnswer(s, i, arg)
+	case "word_quiz":
+		handleWordQuizButton(s, i, arg)
+	case "confess_review":
+		handleConfessReviewButton(s, i, arg)
+	case "replies_cleanup":
+		handleRepliesCleanup(s, i, arg)
+	case "reply_rollback":
+		handleReplyRollback(s, i, arg)
+	case "reply_confirm":
+		handleReplyDraftButton(s, i, arg, true)
+	case "reply_cancel":
+		handleReplyDraftButton(s, i, arg, false)
+	case "reply_purge_confirm":
+		handleReplyPurgeButton(s, i, arg, true)
+	case "reply_purge_cancel":
+		handleReplyPurgeButton(s, i, arg, false)
+	case "onboarding":
+		handleOnboardingButton(s, i, arg)
+	case "list_replies":
+		handleListRepliesPage(s, i, arg)
+	case "remind_snooze":
+		handleReminderButton(s, i, arg, true)
+	case "remind_done":
+		handleReminderButton(s, i, arg, false)
+	}
+}
+
+// messageCreate handles incoming messages for auto-replies and manual bot triggers
+func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// Ignore bot messages and blocked users
+	if m.Author.Bot || isBlocked(m.Author.ID, m.GuildID) {
+		return
+	}
+
+	// Only work in servers, not DMs
+	if m.GuildID == "" {
+		return
+	}
+
+	log.Printf("Received message in guild %s from %s: %s", m.GuildID, m.Author.Username, m.Content)
+
+	// Watchwords look at every message, whatever else the bot does with it
+	checkWatchwords(s, m, getGuildSettings(m.GuildID))
+
+	// Legacy prefix commands, only for servers that enabled them with /settings prefix
+	if settings := getGuildSettings(m.GuildID); strings.HasPrefix(m.Content, commandPrefix) && settings.PrefixCommands {
+		// Channels without commands stay quiet, there is no way to answer only the author
+		if commandsRefused(settings, m.ChannelID) {
+			return
+		}
+		markInteractive()
+		handlePrefixCommand(s, m)
+		return
+	}
+
+	// Blacklisted channels never get auto-replies, so no trigger is looked at there
+	repliesAllowed := !slices.Contains(getGuildSettings(m.GuildID).NoReplyChannels, m.ChannelID)
+
+	// Check for manual bot trigger when user replies to a message and mentions the bot
+	if repliesAllowed && m.ReferencedMessage != nil && len(m.Mentions) > 0 {
+		// Check if the bot is mentioned
+		for _, mention := range m.Mentions {
+			if mention.ID == s.State.User.ID {
+				// Get the ORIGINAL message content to check for specific trigger words
+				originalMessageContent := strings.ToLower(strings.TrimSpace(m.ReferencedMessage.Content))
+				log.Printf("Manual trigger detected, checking original message: %s", originalMessageContent)
+				var response string
+
+				// Split original message into words to check for exact matches
+				words := strings.Fields(originalMessageContent)
+				triggerFound := false
+
+				log.Printf("Original message words: %v", words)
+				for _, word := range words {
+					// Remove common punctuation from the word
+					cleanWord := strings.Trim(word, ".,!?;:\"'()[]{}*<>@")
+
+					log.Printf("Checking word from original message: %s", cleanWord)
+
+					switch cleanWord {
+					case "ai":
+						response = "AI IS DUMB BRO!"
+						triggerFound = true
+					case "kerja":
+						response = "cerdas"
+						triggerFound = true
+					case "btc":
+						response = "SIAP, ALL IN BTC!!!!!"
+						triggerFound = true
+					case "aji":
+						response = "ganteng"
+						triggerFound = true
+					}
+
+					if triggerFound {
+						break
+					}
+				}
+
+				// Only respond if a trigger was found in the original message
+				if triggerFound {
+					// Reply to the original message with the appropriate response
+					_, err := s.ChannelMessageSendReply(m.ChannelID, response, &discordgo.MessageReference{
+						MessageID: m.ReferencedMessage.ID,
+						ChannelID: m.ChannelID,
+						GuildID:   m.GuildID,
+					})
+					if err != nil {
+						log.Printf("Error sending manual trigger reply: %v", err)
+						// Fallback to regular message if reply fails
+						s.ChannelMessageSend(m.ChannelID, response)
+					}
+				} else {
+					// No trigger found in original message, send default response
+					log.Printf("No trigger found in original message")
+					s.ChannelMessageSend(m.ChannelID, "belum ada yang pas ganteng, coba izin dulu ke kak aji ganteng!")
+				}
+				return // Exit early after handling manual trigger
+			}
+		}
+	}
+
+	// Introductions get a welcome thread and become the member's /profile
+	settings := getGuildSettings(m.GuildID)
+	if settings.IntroThreads && m.ChannelID == settings.IntroChannel {
+		handleIntroduction(s, m, settings)
+		return
+	}
+
+	// Music links get a card with the song on other streaming services, alongside any auto-reply
+	if slices.Contains(settings.MusicChannels, m.ChannelID) {
+		expandMusicLinks(s, m)
+	}
+
+	// FAQ auto-answers come first, a question gets one reply rather than two
+	if settings.FAQAutoAnswer && answerFromFAQ(s, m) {
+		return
+	}
+
+	// Auto-replies only fire in servers that turned them on with /settings auto_replies
+	if settings.AutoReplies && repliesAllowed {
+		handleAutoReplies(s, m)
+	}
+}
+
+// messageUpdate gives edited messages the same auto-replies as new ones, so a trigger
+// can't be slipped past the bot by editing it in afterwards
+func messageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Link previews update messages too, only edits by members count
+	if m.Author == nil || m.Author.Bot || m.GuildID == "" || m.EditedTimestamp == nil || isBlocked(m.Author.ID, m.GuildID) {
+		return
+	}
+	if time.Since(m.Timestamp) > editedReplyWindow || answeredMessages.Held(m.ID, time.Now()) {
+		return
+	}
+
+	// The same messages messageCreate keeps away from auto-replies are skipped here
+	settings := getGuildSettings(m.GuildID)
+	switch {
+	case !settings.AutoReplies, slices.Contains(settings.NoReplyChannels, m.ChannelID):
+		return
+	case settings.PrefixCommands && strings.HasPrefix(m.Content, commandPrefix):
+		return
+	case settings.IntroThreads && m.ChannelID == settings.IntroChannel:
+		return
+	}
+
+	log.Printf("Message %s edited in guild %s by %s", m.ID, m.GuildID, m.Author.Username)
+	handleAutoReplies(s, &discordgo.MessageCreate{Message: m.Message})
+}
+
+// allowOCR reports whether another image may be read in a server, at most ocrPerGuildHour
+// per server and one per ocrUserCooldown per member, and records the call if so
+func allowOCR(guildID, userID string, now time.Time) bool {
+	ocrMu.Lock()
+	defer ocrMu.Unlock()
+
+	userKey := guildID + "|" + userID
+	if now.Sub(ocrLastUser[userKey]) < ocrUserCooldown {
+		return false
+	}
+
+	var recent []time.Time
+	for _, t := range ocrRecent[guildID] {
+		if now.Sub(t) < time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= ocrPerGuildHour {
+		ocrRecent[guildID] = recent
+		return false
+	}
+
+	ocrRecent[guildID] = append(recent, now)
+	ocrLastUser[userKey] = now
+	return true
+}
+
+// ocrSpaceResponse is the part of the OCR.space parse response the bot uses
+type ocrSpaceResponse struct {
+	ParsedResults []struct {
+		ParsedText string `json:"ParsedText"`
+	} `json:"ParsedResults"`
+	IsErroredOnProcessing bool            `json:"IsErroredOnProcessing"`
+	ErrorMessage          json.RawMessage `json:"ErrorMessage"` // a string or a list of strings
+}
+
+// ocrImage extracts the text of an image by URL with OCR.space
+func ocrImage(apiKey, imageURL string) (string, error) {
+	form := url.Values{
+		"url":       {imageURL},
+		"OCREngine": {"2"}, // detects the language, so Indonesian and English both work
+		"scale":     {"true"},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.ocr.space/parse/image", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("apikey", apiKey)
+
+	client := &http.Client{
+		Timeout: 20 * time.Second,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", upstreamDown("OCR.space", fmt.Errorf("failed to read image: %v", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", httpStatusError("OCR.space", resp.StatusCode)
+	}
+
+	var result ocrSpaceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", upstreamDown("OCR.space", fmt.Errorf("failed to parse JSON: %v", err))
+	}
+	if result.IsErroredOnProcessing {
+		return "", upstreamDown("OCR.space", fmt.Errorf("processing failed: %s", result.ErrorMessage))
+	}
+
+	var texts []string
+	for _, parsed := range result.ParsedResults {
+		texts = append(texts, parsed.ParsedText)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// imageText returns the text found in a message's first image, in servers that enabled
+// OCR for the channel and have an OCR.space key, subject to the OCR rate limits
+func imageText(m *discordgo.MessageCreate) string {
+	if len(m.Attachments) == 0 || !slices.Contains(getGuildSettings(m.GuildID).OCRChannels, m.ChannelID) {
+		return ""
+	}
+
+	apiKey, ok := guildAPIKey(m.GuildID, "ocr")
+	if !ok {
+		apiKey = os.Getenv("OCR_SPACE_API_KEY")
+	}
+	if apiKey == "" {
+		return ""
+	}
+
+	var texts []string
+	for _, attachment := range m.Attachments {
+		if len(texts) == ocrImagesPerMsg {
+			break
+		}
+		if !strings.HasPrefix(attachment.ContentType, "image/") || attachment.Size > ocrMaxImageSize {
+			continue
+		}
+		if !allowOCR(m.GuildID, m.Author.ID, time.Now()) {
+			break
+		}
+
+		text, err := ocrImage(apiKey, attachment.URL)
+		if err != nil {
+			log.Printf("Error reading image text in guild %s: %v", m.GuildID, err)
+			break
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// memberIdentity returns the lowercased display name of a message's author and their role
+// names joined by newlines, for nickname and role rules
+func memberIdentity(s *discordgo.Session, m *discordgo.MessageCreate) (string, string) {
+	name := m.Author.GlobalName
+	if name == "" {
+		name = m.Author.Username
+	}
+	if m.Member == nil {
+		return strings.ToLower(name), ""
+	}
+	if m.Member.Nick != "" {
+		name = m.Member.Nick
+	}
+
+	var roles []string
+	for _, roleID := range m.Member.Roles {
+		if role, err := s.State.Role(m.GuildID, roleID); err == nil {
+			roles = append(roles, role.Name)
+		}
+	}
+	return strings.ToLower(name), strings.ToLower(strings.Join(roles, "\n"))
+}
+
+// handleAutoReplies sends the response of the first rule whose trigger the message contains
+func handleAutoReplies(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// Check if this server has any auto-replies set up
+	repliesMu.Lock()
+	ruleCount := len(serverAutoReplies[m.GuildID])
+	repliesMu.Unlock()
+	if ruleCount == 0 {
+		return
+	}
+
+	// Note: If MESSAGE_CONTENT_INTENT is not enabled, m.Content will be empty
+	// for messages from users who are not the bot owner
+	originalContent := strings.TrimSpace(m.Content)
+
+	// Text in images counts too in channels with OCR enabled
+	if text := imageText(m); text != "" {
+		originalContent = strings.TrimSpace(originalContent + "\n" + text)
+	}
+	messageContent := strings.ToLower(originalContent)
+
+	// Nickname and role rules look at who is talking rather than what they said
+	nickname, roleNames := memberIdentity(s, m)
+	var memberRoles []string
+	if m.Member != nil {
+		memberRoles = m.Member.Roles
+	}
+
+	// Check for matching triggers - search for whole word matches only
+	repliesMu.Lock()
+	var response, responseType string
+	var attachments []ReplyAttachment
+	matched, deleteTrigger := false, false
+rules:
+	for idx, reply := range serverAutoReplies[m.GuildID] {
+		rule := &serverAutoReplies[m.GuildID][idx]
+		if (len(reply.ChannelIDs) > 0 && !slices.Contains(reply.ChannelIDs, m.ChannelID)) || reply.expired(time.Now()) {
+			continue
+		}
+		if !reply.firesFor(m.Author.ID, memberRoles) {
+			continue
+		}
+		switch reply.Match {
+		case "nickname", "role":
+			target := nickname
+			if reply.Match == "role" {
+				target = roleNames
+			}
+			if !strings.Contains(target, reply.Trigger) || slices.Contains(reply.Greeted, m.Author.ID) {
+				continue
+			}
+			rule.Greeted = append(rule.Greeted, m.Author.ID)
+			if len(rule.Greeted) > maxGreetedMembers {
+				rule.Greeted = slices.Clone(rule.Greeted[len(rule.Greeted)-maxGreetedMembers:])
+			}
+		default:
+			if !matchesTrigger(reply, originalContent, messageContent) {
+				continue
+			}
+			// A rule that just fired stays quiet, so repeated messages get one reply
+			cooldown := replyCooldown(reply)
+			cooldownKey := m.GuildID + "|" + strings.ToLower(reply.Trigger)
+			if cooldown > 0 && replyCooldowns.Held(cooldownKey, time.Now()) {
+				break rules
+			}
+			// A member setting off rule after rule is ignored for a while. That's checked
+			// before the cooldown is claimed, so it doesn't silence the rule for everyone.
+			if !replyUserLimits.Allow(m.GuildID+"|"+m.Author.ID, time.Now()) {
+				break rules
+			}
+			if cooldown > 0 && !replyCooldowns.Claim(cooldownKey, cooldown, time.Now()) {
+				break rules
+			}
+		}
+
+		response = pickResponse(reply)
+		responseType = reply.ResponseType
+		attachments = reply.Attachments
+		deleteTrigger = reply.DeleteTrigger
+		matched = true
+		rule.LastFired = time.Now()
+		rule.FireCount++
+		// Saved by the scheduler rather than on every reply
+		repliesDirty = true
+		break // Only respond to the first matching trigger
+	}
+	repliesMu.Unlock()
+	if !matched {
+		return
+	}
+	// Editing the message afterwards doesn't get it a second reply
+	answeredMessages.Claim(m.ID, editedReplyWindow, time.Now())
+
+	// Reaction rules acknowledge the message without sending anything
+	if responseType == "reaction" {
+		emoji, err := parseReactions(response)
+		if err != nil {
+			log.Printf("Skipped reaction auto-reply in guild %s: %v", m.GuildID, err)
+			return
+		}
+		for _, e := range emoji {
+			if err := s.MessageReactionAdd(m.ChannelID, m.ID, e); err != nil {
+				log.Printf("Error adding auto-reply reaction %s: %v", e, err)
+			}
+		}
+		return
+	}
+
+	// Rules saved before a word was blocked are screened again when they fire
+	response, ok := screenReplyResponse(m.GuildID, response)
+	if !ok {
+		log.Printf("Skipped auto-reply in guild %s: response contains a blocked word", m.GuildID)
+		return
+	}
+
+	// Moderation rules remove the message once the reply is out
+	if deleteTrigger {
+		defer deleteTriggerMessage(s, m)
+	}
+
+	response = expandReplyTemplate(s, response, m.Author, m.GuildID, m.ChannelID)
+
+	// DM rules answer privately, and in the channel when the member's DMs are closed
+	if responseType == "dm" {
+		err := sendReplyDM(s, m, response, attachments)
+		if err == nil {
+			return
+		}
+		log.Printf("Error sending auto-reply DM to %s, replying in the channel instead: %v", m.Author.ID, err)
+		response = truncate("📭 I couldn't DM you, so here it is:\n"+response, 2000)
+	}
+
+	if len(attachments) > 0 {
+		reference := &discordgo.MessageReference{MessageID: m.ID, ChannelID: m.ChannelID, GuildID: m.GuildID}
+		if err := sendReplyWithMedia(s, m.ChannelID, reference, response, attachments); err != nil {
+			log.Printf("Error sending auto-reply with media: %v", err)
+		}
+		return
+	}
+
+	// Send reply immediately with message reference to show "replying to" context
+	_, err := s.ChannelMessageSendReply(m.ChannelID, response, &discordgo.MessageReference{
+		MessageID: m.ID,
+		ChannelID: m.ChannelID,
+		GuildID:   m.GuildID,
+	})
+	if err != nil {
+		log.Printf("Error sending auto-reply: %v", err)
+		// Fallback to regular message if reply fails
+		s.ChannelMessageSend(m.ChannelID, response)
+	}
+}
+
+// deleteTriggerMessage deletes the message that set off a delete_trigger rule. Without
+// Manage Messages in the channel the rule just replies, which is logged.
+func deleteTriggerMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	perms, err := s.State.UserChannelPermissions(s.State.User.ID, m.ChannelID)
+	if err != nil || perms&discordgo.PermissionManageMessages == 0 {
+		log.Printf("Not deleting message %s in channel %s: missing Manage Messages", m.ID, m.ChannelID)
+		return
+	}
+	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		log.Printf("Error deleting auto-reply trigger message %s: %v", m.ID, err)
+	}
+}
+
+// interactionCreate handles slash command interactions
+func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	markInteractive()
+
+	if isBlocked(interactionUserID(i), i.GuildID) {
+		return
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		handleComponent(s, i)
+		return
+	}
+
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		handleAutocomplete(s, i)
+		return
+	}
+
+	if i.Type == discordgo.InteractionModalSubmit {
+		if i.ModalSubmitData().CustomID == "run_go" {
+			handleRunModal(s, i)
+		}
+		return
+	}
+
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	if refuseCommandHere(s, i) {
+		return
+	}
+	defer auditInteraction(s, i)
+
+	switch i.ApplicationCommandData().Name {
+	case "reply", "reply_edit":
+		handleReplyCommand(s, i)
+	case "list_replies":
+		handleListRepliesCommand(s, i)
+	case "replies":
+		handleRepliesCommand(s, i)
+	case "reply_purge":
+		handleReplyPurgeCommand(s, i)
+	case "reply_export":
+		handleReplyExportCommand(s, i)
+	case "reply_import":
+		handleReplyImportCommand(s, i)
+	case "help_reply":
+		handleHelpCommand(s, i)
+	case "analisis":
+		handleAnalisisCommand(s, i)
+	case "commands":
+		handleCommandsCommand(s, i)
+	case "convert":
+		handleConvertCommand(s, i)
+	case "settings":
+		handleSettingsCommand(s, i)
+	case "customcmd":
+		handleCustomCommandAdmin(s, i)
+	case "schedule":
+		handleScheduleCommand(s, i)
+	case "feed":
+		handleFeedCommand(s, i)
+	case "monitor":
+		handleMonitorCommand(s, i)
+	case "news":
+		handleNewsCommand(s, i)
+	case "bookmarks":
+		handleBookmarksCommand(s, i)
+	case "Bookmark":
+		handleBookmarkMessageCommand(s, i)
+	case "Quote this":
+		handleQuoteMessageCommand(s, i)
+	case "admin":
+		handleAdminCommand(s, i)
+	case "audit":
+		handleAuditCommand(s, i)
+	case "qr":
+		handleQRCommand(s, i)
+	case "shorten":
+		handleShortenCommand(s, i)
+	case "kbbi":
+		handleDictionaryCommand(s, i, lookupKBBI)
+	case "define":
+		handleDictionaryCommand(s, i, lookupEnglish)
+	case "slang":
+		handleSlangCommand(s, i)
+	case "libur":
+		handleLiburCommand(s, i)
+	case "check":
+		handleCheckCommand(s, i)
+	case "dns":
+		handleDNSCommand(s, i)
+	case "github":
+		handleGitHubCommand(s, i)
+	case "movie":
+		handleMovieCommand(s, i)
+	case "meme":
+		handleMemeCommand(s, i)
+	case "run":
+		handleRunCommand(s, i)
+	case "search":
+		handleSearchCommand(s, i)
+	case "color":
+		handleColorCommand(s, i)
+	case "text":
+		handleTextCommand(s, i)
+	case "hash", "uuid":
+		handleDevCommand(s, i)
+	case "roll", "flip", "choose", "8ball":
+		handleFunCommand(s, i)
+	case "trivia":
+		handleTriviaCommand(s, i)
+	case "word":
+		handleWordCommand(s, i)
+	case "confess":
+		handleConfessCommand(s, i)
+	case "confessions":
+		handleConfessionsCommand(s, i)
+	case "faqsearch":
+		handleFAQSearchCommand(s, i)
+	case "summarize":
+		handleSummarizeCommand(s, i)
+	case "transcript":
+		handleTranscriptCommand(s, i)
+	case "onboarding":
+		handleOnboardingCommand(s, i)
+	case "profile":
+		handleProfileCommand(s, i)
+	case "remind":
+		handleRemindCommand(s, i)
+	case "task":
+		handleTaskCommand(s, i)
+	case "decancer":
+		handleDecancerCommand(s, i)
+	default:
+		if cmd := findCustomCommand(i.GuildID, i.ApplicationCommandData().Name); cmd != nil {
+			handleCustomCommand(s, i, cmd)
+		}
+	}
+}
+
+// auditInteraction records privileged commands once their handler has replied
+func auditInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || !isPrivilegedCommand(i.ApplicationCommandData().Name) {
+		return
+	}
+	recordAudit(i, interactionResult(s, i))
+}
+
+// ready handles the ready event
+func ready(s *discordgo.Session, event *discordgo.Ready) {
+	log.Printf("Bot is ready! Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
+	log.Printf("Bot is in %d servers", len(event.Guilds))
+
+	// Register slash commands
+	commands := slashCommands()
+	for _, cmd := range commands {
+		_, err := s.ApplicationCommandCreate(s.State.User.ID, "", cmd)
+		if err != nil {
+			log.Printf("Cannot create command %v: %v", cmd.Name, err)
+		}
+	}
+
+	log.Printf("Registered %d slash commands", len(commands))
+
+	loadBotOwners(s)
+	for _, guild := range event.Guilds {
+		leaveIfBlocked(s, guild.ID)
+	}
+}
+
+// guildCreate leaves blocked servers as soon as the bot joins or reconnects to them
+func guildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	leaveIfBlocked(s, g.ID)
+}
+
+// apiKeyChoices returns the services that accept a per-server API key as slash command choices
+func apiKeyChoices() []*discordgo.ApplicationCommandOptionChoice {
+	names := make([]string, 0, len(guildAPIKeyNames))
+	for name := range guildAPIKeyNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range names {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: guildAPIKeyNames[name], Value: name})
+	}
+	return choices
+}
+
+// blocklistTargetOptions are the options shared by /admin blocklist add and remove
+func blocklistTargetOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "type",
+			Description: "Block a user or a whole server",
+			Required:    true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "User", Value: "user"},
+				{Name: "Server", Value: "guild"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "id",
+			Description: "User or server ID",
+			Required:    true,
+			MaxLength:   32,
+		},
+	}
+}
+
+// auditFilterOptions are the filters shared by /audit view and /audit export
+func auditFilterOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "Only show commands run by this member",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "command",
+			Description: "Only show this command, e.g. 'settings' or 'feed add'",
+			Required:    false,
+		},
+	}
+}
+
+// rssTopicChoices returns the RSS topics as slash command choices
+func rssTopicChoices() []*discordgo.ApplicationCommandOptionChoice {
+	return []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Ringkasan Pasar", Value: "ringkasan pasar"},
+		{Name: "Analisis Teknikal", Value: "analisis teknikal"},
+		{Name: "Analisis Fundamental", Value: "analisis fundamental"},
+		{Name: "Opini", Value: "opini"},
+		{Name: "Ide Investasi", Value: "ide investasi"},
+		{Name: "Mata Uang Kripto", Value: "mata uang kripto"},
+		{Name: "Forex", Value: "forex"},
+		{Name: "Saham", Value: "saham"},
+		{Name: "Komoditas", Value: "komoditas"},
+		{Name: "Berita", Value: "berita"},
+		{Name: "Breaking News", Value: "breaking news"},
+	}
+}
+
+// slashCommands returns the global slash commands registered on startup
+func slashCommands() []*discordgo.ApplicationCommand {
+	manageGuild := int64(discordgo.PermissionManageGuild)
+	manageNicknames := int64(discordgo.PermissionManageNicknames)
+	zero := 0.0
+	one := 1.0
+	minHolidayYear := 2000.0
+	minMovieYear := 1888.0
+	ten := 10.0
+
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "reply",
+			Description: "Set up auto-reply for specific messages",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "trigger",
+					Description:  "The message that will trigger the reply, pick mode first to choose an existing rule",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "response",
+					Description: "The response message to send",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Choose 'add' to create a rule, 'append' a response to yours, or 'remove' it; edit with /reply_edit",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "add",
+							Value: "add",
+						},
+						{
+							Name:  "append",
+							Value: "append",
+						},
+						{
+							Name:  "remove",
+							Value: "remove",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "match",
+					Description: "Look for the trigger in the message (default), or greet members by nickname or role",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "message text",
+							Value: "text",
+						},
+						{
+							Name:  "nickname",
+							Value: "nickname",
+						},
+						{
+							Name:  "role name",
+							Value: "role",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "regex",
+					Description: "Treat the trigger as a regular expression, like go+d morning",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "case_sensitive",
+					Description: "Match the regex trigger's capital letters exactly",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "channels",
+					Description: "Only fire in these channels, like #general #memes ('all' for every channel)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "roles",
+					Description: "Only fire for members with one of these roles, like @Member @VIP ('all' for everyone)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "users",
+					Description: "Only fire for these members, like @alice @bob ('none' to clear)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ignore_users",
+					Description: "Never fire for these members ('none' to clear)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "attachment",
+					Description: "An image or file to send with the response",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "media_url",
+					Description: "A link to an image or file to send with the response, or 'none' to remove the media",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "response_type",
+					Description: "Reply with the response (default), react with the emoji in it, or DM it to the member",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "message", Value: "message"},
+						{Name: "reaction", Value: "reaction"},
+						{Name: "dm", Value: "dm"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "cooldown",
+					Description: "Seconds before the rule fires again in this server (default 30, 0 for none)",
+					Required:    false,
+					MinValue:    &zero,
+					MaxValue:    maxReplyCooldown,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "expires",
+					Description: "Remove the rule after a while, like 7d or 2026-11-01, or 'never'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "expiry_dm",
+					Description: "DM you when the rule expires (default on)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "delete_trigger",
+					Description: "Delete the message that set the rule off after replying (moderators only)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "reply_edit",
+			Description: "Change one of your auto-reply rules, options you leave out stay as they are",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "trigger",
+					Description:  "The rule to change, start typing to pick one of yours",
+					Required:     true,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "response",
+					Description: "The new response message",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "match",
+					Description: "Look for the trigger in the message, or greet members by nickname or role",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "message text",
+							Value: "text",
+						},
+						{
+							Name:  "nickname",
+							Value: "nickname",
+						},
+						{
+							Name:  "role name",
+							Value: "role",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "regex",
+					Description: "Treat the trigger as a regular expression",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "case_sensitive",
+					Description: "Match capitals exactly, for regex triggers",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "channels",
+					Description: "Channels the rule fires in, like #general #memes, or 'all'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "roles",
+					Description: "Roles the rule fires for, like @Member @VIP, or 'all'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "users",
+					Description: "Members the rule fires for, like @alice @bob, or 'none'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ignore_users",
+					Description: "Members the rule never fires for, or 'none'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "attachment",
+					Description: "A new image or file to send with the response",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "media_url",
+					Description: "A link to an image or file to send with the response, or 'none' to remove the media",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "response_type",
+					Description: "Reply with the response, react with the emoji in it, or DM it",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "message", Value: "message"},
+						{Name: "reaction", Value: "reaction"},
+						{Name: "dm", Value: "dm"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "cooldown",
+					Description: "Seconds before the rule fires again in this server, 0 for none",
+					Required:    false,
+					MinValue:    &zero,
+					MaxValue:    maxReplyCooldown,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "expires",
+					Description: "Remove the rule after a while, like 7d or 2026-11-01, or 'never'",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "expiry_dm",
+					Description: "DM you when the rule expires (default on)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "delete_trigger",
+					Description: "Delete the message that set the rule off after replying (moderators only)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "reply_purge",
+			Description: "Remove many auto-reply rules at once, after a preview",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "author",
+					Description: "Rules created by this member (Manage Server for others' rules)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "contains",
+					Description: "Rules whose trigger contains this text",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "all",
+					Description: "Every rule in the server (Manage Server only)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "list_replies",
+			Description: "List all global auto-reply rules",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "trigger",
+					Description: "Only rules whose trigger contains this text",
+					Required:    false,
+					MaxLength:   40,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "author",
+					Description: "Only rules this member created",
+					Required:    false,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "Only rules limited to this channel",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+				},
+			},
+		},
+		{
+			Name:                     "decancer",
+			Description:              "Give a member with an unreadable or hoisted name a clean nickname",
+			DefaultMemberPermissions: &manageNicknames,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Member to rename",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "task",
+			Description: "A shared task list for this channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Add a task to this channel's list",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "title",
+							Description: "What needs doing",
+							Required:    true,
+							MaxLength:   200,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "assignee",
+							Description: "Who is doing it, pinged when it is due",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "due",
+							Description: "e.g. tomorrow 5pm, in 2h or 2026-11-01 17:00",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Post this channel's task board",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "done",
+					Description: "Mark a task as done",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "Task number from the board",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "remind",
+			Description: "Personal reminders, once or repeating",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "me",
+					Description: "Set a reminder, delivered in this channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "when",
+							Description: "e.g. in 30m, tomorrow 9am, 2026-11-01 08:00, every monday 9am, every 1st of month",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "What to remind you of",
+							Required:    true,
+							MaxLength:   500,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show your reminders",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancel one of your reminders",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "Reminder number from /remind list",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "timezone",
+					Description: "Set the timezone your reminders use, the server's by default",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "zone",
+							Description: "e.g. Asia/Jakarta, WITA or UTC+8",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "profile",
+			Description: "Show a member's profile card from their introduction",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Whose profile to show, yours by default",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "onboarding",
+			Description:              "See how far new members get through the onboarding checklist",
+			DefaultMemberPermissions: &manageGuild,
+		},
+		{
+			Name:                     "reply_export",
+			Description:              "Download this server's auto-reply rules as a JSON file",
+			DefaultMemberPermissions: &manageGuild,
+		},
+		{
+			Name:                     "reply_import",
+			Description:              "Restore auto-reply rules from a /reply_export file",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "file",
+					Description: "The JSON file from /reply_export",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Add the file's rules to the server's (default), or replace them all",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "merge", Value: "merge"},
+						{Name: "replace", Value: "replace"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "replies",
+			Description: "Maintain this server's auto-reply rules",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "audit",
+					Description: "Find duplicate, unreachable and unused rules (Manage Server)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "Show earlier versions of a rule and roll back",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "trigger",
+							Description: "The rule's trigger",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "help_reply",
+			Description: "Show help information for the auto-reply bot",
+		},
+		{
+			Name:        "analisis",
+			Description: "Fetch latest news and analysis from Investing.com",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "topic",
+					Description: "Topic to get news for",
+					Required:    true,
+					Choices:     rssTopicChoices(),
+				},
+			},
+		},
+		{
+			Name:        "commands",
+			Description: "Show all available bot commands",
+		},
+		{
+			Name:        "convert",
+			Description: "Convert currency amounts between different currencies",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "amount_and_currencies",
+					Description: "Amount and currencies to convert (e.g., '$500 idr', '1,5jt idr usd', '500kjpy usd')",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "settings",
+			Description:              "Configure the bot for this server",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "prefix",
+					Description: "Enable or disable legacy prefix commands like !convert",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether prefix commands are enabled",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "apikey",
+					Description: "Store API keys this server's features use",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "set",
+							Description: "Save an API key (stored encrypted)",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "service",
+									Description: "Which service the key is for",
+									Required:    true,
+									Choices:     apiKeyChoices(),
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "key",
+									Description: "The API key",
+									Required:    true,
+									MaxLength:   512,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "clear",
+							Description: "Remove a stored API key",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "service",
+									Description: "Which service's key to remove",
+									Required:    true,
+									Choices:     apiKeyChoices(),
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show which API keys are stored (masked)",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "auto_replies",
+					Description: "Enable or disable /reply auto-reply rules",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether auto-replies are enabled",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "max_rules",
+							Description: "Most rules this server can have (default 100)",
+							MinValue:    &one,
+							MaxValue:    maxMaxReplies,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reply_approval",
+					Description: "Require a moderator to approve new auto-replies",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether new rules need approval",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel where moderators review new rules",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "faq",
+					Description: "Upload the server FAQ for /faqsearch and auto-answers",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "Text or Markdown with Q: lines or ## headings for questions, answers below",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "embeddings",
+							Description: "How questions are compared (default: API if an LLM key is set, else local)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "local", Value: "local"},
+								{Name: "API (LLM key)", Value: "api"},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "auto_answer",
+							Description: "Answer question-like messages that closely match a FAQ entry",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "confessions",
+					Description: "Let members post anonymous confessions with /confess",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether /confess is available",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel where confessions are posted",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "review_channel",
+							Description:  "Channel where moderators approve confessions first (leave out to post right away)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "ocr",
+					Description: "Match auto-reply triggers against text in images in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel whose images are read",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether images in the channel are read",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "music_links",
+					Description: "Expand Spotify and Apple Music links in a channel into song.link cards",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel whose music links are expanded",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether music links in the channel are expanded",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reply_blacklist",
+					Description: "Keep auto-replies out of a channel, or list the channels they're kept out of",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to change, leave out to see the list",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "blocked",
+							Description: "Whether auto-replies are blocked in the channel (default true)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "command_channels",
+					Description: "Refuse the bot's commands in a channel and point members to the bot channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to change",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "allowed",
+							Description: "Whether members can use commands in the channel",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "bot_channel",
+							Description:  "Channel members are pointed to instead",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "onboarding",
+					Description: "Give new members a checklist: read the rules, pick roles, introduce themselves",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether new members get the checklist",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "delivery",
+							Description: "Send it by DM (default) or post it in a channel",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "dm", Value: "dm"},
+								{Name: "channel", Value: "channel"},
+							},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel the checklist is posted in, for delivery:channel",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "rules_channel",
+							Description:  "Channel with the server rules",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "roles_channel",
+							Description:  "Channel where members pick roles",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "intro_channel",
+							Description:  "Channel where members introduce themselves",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "Welcome text shown above the steps",
+							Required:    false,
+							MaxLength:   500,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "decancer",
+					Description: "Clean up unreadable or hoisted nicknames of members as they join",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether joining members' nicknames are cleaned up",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "introductions",
+					Description: "Welcome introductions with a thread and keep them as /profile cards",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether introductions get a thread and a reaction",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The introductions channel",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "emoji",
+							Description: "Reaction on new introductions (default 👋)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "bot_channel",
+					Description: "Set the bot channel and optionally post command results there",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "The bot channel",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "redirect",
+							Description: "Post conversion and fun command results there, with a link for the member",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "search",
+					Description: "Enable /search web searches and choose safe search",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether /search is available",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "safe_search",
+							Description: "How strictly explicit results are filtered (default moderate)",
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Strict", Value: "strict"},
+								{Name: "Moderate", Value: "moderate"},
+								{Name: "Off", Value: "off"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "slang",
+					Description: "Allow /slang outside age-restricted channels",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether /slang works in every channel",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "shortener",
+					Description: "Choose the link shortener behind /shorten",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "provider",
+							Description: "is.gd needs no key, Bitly and Shlink also report clicks",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "is.gd", Value: "isgd"},
+								{Name: "Bitly", Value: "bitly"},
+								{Name: "Shlink (self-hosted)", Value: "shlink"},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "endpoint",
+							Description: "Address of your Shlink server, e.g. https://s.example.com",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "watchwords",
+					Description: "Alert moderators about messages with scam phrases or other watched words",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Watch for a word or phrase, like a scam line or another server's invite",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "word",
+									Description: "The word or phrase, matched anywhere in a message ignoring case",
+									Required:    true,
+									MaxLength:   100,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Stop watching for a word or phrase",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "word",
+									Description: "The word or phrase to stop watching for",
+									Required:    true,
+									MaxLength:   100,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show the watched words and where alerts go",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "channel",
+							Description: "Pick the moderator channel alerts go to",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:         discordgo.ApplicationCommandOptionChannel,
+									Name:         "channel",
+									Description:  "The moderator channel",
+									Required:     true,
+									ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+								},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "reply_roles",
+					Description: "Limit creating auto-replies to members with certain roles",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Let members with a role create auto-replies",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionRole,
+									Name:        "role",
+									Description: "Role that may create auto-replies",
+									Required:    true,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Take a role off the list, everyone may create rules once it's empty",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionRole,
+									Name:        "role",
+									Description: "Role to remove",
+									Required:    true,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show which roles may create auto-replies",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "profanity",
+					Description: "Filter profanity from auto-reply responses",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "mode",
+							Description: "Reject or mask responses with blocked words",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "mode",
+									Description: "What to do with blocked words",
+									Required:    true,
+									Choices: []*discordgo.ApplicationCommandOptionChoice{
+										{Name: "Reject the rule", Value: "reject"},
+										{Name: "Mask the words", Value: "mask"},
+										{Name: "Off", Value: "off"},
+									},
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Block an extra word in this server",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "word",
+									Description: "The word to block",
+									Required:    true,
+									MaxLength:   50,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Unblock a word this server added",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "word",
+									Description: "The word to unblock",
+									Required:    true,
+									MaxLength:   50,
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show the filter mode and this server's words",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "api_token",
+					Description: "Manage the token for posting through the bot's API",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "regenerate",
+							Description: "Create a new API token, replacing the old one",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "revoke",
+							Description: "Delete this server's API token",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "show",
+							Description: "Show whether an API token exists (masked)",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "currency",
+					Description: "Set the default currency /convert converts to when no target is given",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "code",
+							Description: "Currency code, e.g. 'idr'. Leave empty to clear",
+							Required:    false,
+							MaxLength:   5,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "timezone",
+					Description: "Set the server timezone used for quiet hours",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Timezone, e.g. 'Asia/Jakarta', 'WITA', 'UTC+8'",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "quiet_hours",
+					Description: "Hold scheduled messages and news posts during the night",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Whether quiet hours are enabled",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "start",
+							Description: "Start time, e.g. '00:00' (default 00:00)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "end",
+							Description: "End time, e.g. '06:00' (default 06:00)",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "customcmd",
+			Description:              "Manage custom slash commands for this server",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Create or update a custom command like /faq or /rules",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Command name (lowercase letters, numbers, - and _)",
+							Required:    true,
+							MaxLength:   32,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "response",
+							Description: "The message the command replies with",
+							Required:    true,
+							MaxLength:   2000,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "description",
+							Description: "Description shown in Discord's command picker",
+							Required:    false,
+							MaxLength:   100,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Delete a custom command",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Name of the command to delete",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List this server's custom commands",
+				},
+			},
+		},
+		{
+			Name:                     "schedule",
+			Description:              "Schedule announcements for this server",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Schedule a single message",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "time",
+							Description: "When to send it in WIB, e.g. '2025-01-31 09:00'",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to post in",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "message",
+							Description: "The message to send",
+							Required:    true,
+							MaxLength:   2000,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "market",
+							Description: "Market this post is about, checked against its holiday calendar",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "IDX", Value: marketIDX},
+								{Name: "NYSE/Nasdaq", Value: marketUS},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "on_holiday",
+							Description: "What to do when the market is closed that day (default skip)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Skip the post", Value: holidaySkip},
+								{Name: "Post with a holiday note", Value: holidayAnnotate},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show upcoming scheduled messages",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancel a scheduled message",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "ID shown in /schedule list",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "long_weekends",
+					Description: "Announce upcoming long weekends a week ahead",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel for the announcements",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Turn the announcements on or off (default on)",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "word_of_the_day",
+					Description: "Post an Indonesian/English word with a quiz every day",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel for the daily word",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "hour",
+							Description: "Hour to post, in the server timezone (0-23, default 7)",
+							MinValue:    &zero,
+							MaxValue:    23,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Turn the daily word on or off (default on)",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "standup",
+					Description: "Daily standup prompt, with the thread's answers summarized in one post",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel for the prompt and summary",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "hour",
+							Description: "Hour to post, in the server timezone (0-23, default 9)",
+							MinValue:    &zero,
+							MaxValue:    23,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "window",
+							Description: "Hours to collect answers before the summary (default 4)",
+							MinValue:    &one,
+							MaxValue:    maxStandupWindow,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "weekdays_only",
+							Description: "Skip Saturday and Sunday (default on)",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Turn the standup on or off (default on)",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "retention",
+					Description: "Delete a channel's old messages, or archive them to a file monthly",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to clean up",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "days",
+							Description: "Keep messages this many days",
+							MinValue:    &one,
+							MaxValue:    maxRetentionDays,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "archive_channel",
+							Description:  "Post old messages here as a file on the 1st of the month before deleting them",
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Set to false to stop cleaning up the channel",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "import",
+					Description: "Schedule many messages from a CSV file (time, channel, message)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "CSV with columns time,channel,message",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "monitor",
+			Description:              "Watch external services and post when they go down or come back",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Check a health URL every few minutes",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Service name used in the alerts",
+							Required:    true,
+							MaxLength:   60,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "url",
+							Description: "Health or status URL, an error or HTTP 4xx/5xx counts as down",
+							Required:    true,
+							MaxLength:   500,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Status channel for the up/down posts",
+							Required:     true,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop checking a service",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "Monitor number from /monitor list",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show this server's monitors and their status",
+				},
+			},
+		},
+		{
+			Name:                     "feed",
+			Description:              "Subscribe channels to Investing.com news",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "live",
+					Description: "Announce when streamers go live",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "twitch",
+							Description: "Announce a Twitch channel's streams",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "streamer",
+									Description: "Twitch channel name",
+									Required:    true,
+									MaxLength:   60,
+								},
+								{
+									Type:         discordgo.ApplicationCommandOptionChannel,
+									Name:         "channel",
+									Description:  "Where to announce (default: this channel)",
+									ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionRole,
+									Name:        "role",
+									Description: "Role to mention when the stream starts",
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show the streamers this server follows",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Stop announcing a streamer",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "id",
+									Description: "Subscription number from /feed live list",
+									Required:    true,
+								},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "subscribe",
+					Description: "Post new articles of a topic in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "topic",
+							Description: "Topic to subscribe to",
+							Required:    true,
+							Choices:     rssTopicChoices(),
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to post in (defaults to this channel)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "digest",
+							Description: "Collect the day's articles into one daily embed instead of posting each one",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "digest_hour",
+							Description: "Hour to post the digest (server time, 0-23, default 8)",
+							Required:    false,
+							MinValue:    &zero,
+							MaxValue:    23,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "crosspost",
+							Description: "Publish posts to following servers when the channel is an Announcement channel (default on)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "threads",
+							Description: "Open a discussion thread under each article, auto-archived after 24h",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "translate",
+							Description: "Machine-translate titles and descriptions before posting",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Indonesian", Value: "id"},
+								{Name: "English", Value: "en"},
+								{Name: "Off", Value: "off"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "podcast",
+					Description: "Post new episodes of a podcast RSS feed in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "url",
+							Description: "Podcast RSS feed URL",
+							Required:    true,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to post in (defaults to this channel)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "quake",
+					Description: "Post BMKG earthquake alerts in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to post in (defaults to this channel)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "min_magnitude",
+							Description: "Smallest magnitude to post (default 5.0)",
+							Required:    false,
+							MinValue:    &one,
+							MaxValue:    9,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "free_games",
+					Description: "Announce limited-time free games in a channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "store",
+							Description: "Store to watch",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Epic Games Store (weekly free games)", Value: "epic"},
+								{Name: "Steam (free-to-keep promotions)", Value: "steam"},
+							},
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel to post in (defaults to this channel)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "unsubscribe",
+					Description: "Remove a feed subscription",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "ID shown in /feed list",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show this server's feed subscriptions",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "route",
+					Description: "Send items mentioning a keyword to another channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Route items mentioning a keyword (e.g. BTC) to a channel",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "id",
+									Description: "Subscription ID shown in /feed list",
+									Required:    true,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "keyword",
+									Description: "Keyword to look for in the title or description",
+									Required:    true,
+								},
+								{
+									Type:         discordgo.ApplicationCommandOptionChannel,
+									Name:         "channel",
+									Description:  "Channel to post matching items in",
+									Required:     true,
+									ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Remove a keyword route",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:        discordgo.ApplicationCommandOptionInteger,
+									Name:        "id",
+									Description: "Subscription ID shown in /feed list",
+									Required:    true,
+								},
+								{
+									Type:        discordgo.ApplicationCommandOptionString,
+									Name:        "keyword",
+									Description: "Keyword of the route to remove",
+									Required:    true,
+								},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "webhook",
+					Description: "Post a subscription through a webhook with a custom name and avatar",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "Subscription ID shown in /feed list",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Use a webhook (true) or post as the bot (false)",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Display name, e.g. 'Investing.com News'",
+							Required:    false,
+							MaxLength:   80,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "avatar_url",
+							Description: "https URL of the avatar image",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "retry",
+					Description: "Re-enable a feed subscription that was disabled after failures",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "id",
+							Description: "ID shown in /feed list",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "news",
+			Description: "Search news the bot posted in this server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "search",
+					Description: "Find a posted article by title or description",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "query",
+							Description: "Words to search for",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "days",
+							Description: "How many days back to search (default 7)",
+							Required:    false,
+							MinValue:    &one,
+							MaxValue:    90,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "bookmarks",
+			Description: "List or clear the messages and articles you saved",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Choose 'list' to show your bookmarks, 'watchlist' for saved movies or 'clear' to delete them all",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  "list",
+							Value: "list",
+						},
+						{
+							Name:  "watchlist",
+							Value: "watchlist",
+						},
+						{
+							Name:  "clear",
+							Value: "clear",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "Bookmark",
+			Type: discordgo.MessageApplicationCommand,
+		},
+		{
+			Name: "Quote this",
+			Type: discordgo.MessageApplicationCommand,
+		},
+		{
+			Name:                     "admin",
+			Description:              "Bot diagnostics",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "selftest",
+					Description: "Check Discord, the exchange rate API, news feeds and data files",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "blocklist",
+					Description: "Block users or servers from the bot (bot owner only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Block a user or server",
+							Options: append(blocklistTargetOptions(), &discordgo.ApplicationCommandOption{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "reason",
+								Description: "Why they are blocked",
+								Required:    false,
+								MaxLength:   200,
+							}),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Unblock a user or server",
+							Options:     blocklistTargetOptions(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "list",
+							Description: "Show blocked users and servers",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "cache",
+					Description: "Inspect the bot's caches",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "stats",
+							Description: "Show cache sizes and hit rates",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "audit",
+			Description:              "Review who used the bot's admin commands",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "view",
+					Description: "Show recent admin command use",
+					Options:     auditFilterOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "export",
+					Description: "Download the audit trail as CSV",
+					Options:     auditFilterOptions(),
+				},
+			},
+		},
+		{
+			Name:        "qr",
+			Description: "Turn text or a link into a QR code image",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "Text, link, payment link or wallet address to encode",
+					Required:    true,
+					MaxLength:   200,
+				},
+			},
+		},
+		{
+			Name:        "shorten",
+			Description: "Shorten a link or see how often it was opened",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "url",
+					Description: "Shorten a link",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "url",
+							Description: "The link to shorten, with https://",
+							Required:    true,
+							MaxLength:   1800,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stats",
+					Description: "Show how often a short link was opened (Bitly and Shlink only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "link",
+							Description: "The short link",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "kbbi",
+			Description: "Look up a word in the Kamus Besar Bahasa Indonesia",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "word",
+					Description: "Kata yang dicari, e.g. makan",
+					Required:    true,
+					MaxLength:   50,
+				},
+			},
+		},
+		{
+			Name:        "define",
+			Description: "Look up an English word in the dictionary",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "word",
+					Description: "The word to define",
+					Required:    true,
+					MaxLength:   50,
+				},
+			},
+		},
 		{
-			Name:        "reply",
-			Description: "Set up auto-reply for specific messages",
+			Name:        "slang",
+			Description: "Look up slang on Urban Dictionary (age-restricted channels only by default)",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "trigger",
-					Description: "The message that will trigger the reply",
+					Name:        "term",
+					Description: "The slang term, e.g. gabut",
 					Required:    true,
+					MaxLength:   50,
+				},
+			},
+		},
+		{
+			Name:        "libur",
+			Description: "List Indonesian national holidays and cuti bersama",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "month",
+					Description: "Only this month (1-12)",
+					MinValue:    &one,
+					MaxValue:    12,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "year",
+					Description: "Year to list, this year by default",
+					MinValue:    &minHolidayYear,
+					MaxValue:    2100,
 				},
+			},
+		},
+		{
+			Name:        "check",
+			Description: "Check if a website is up, how fast it answers and when its SSL expires",
+			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "response",
-					Description: "The response message to send",
-					Required:    false,
+					Name:        "url",
+					Description: "Website or URL, e.g. example.com",
+					Required:    true,
+					MaxLength:   500,
 				},
+			},
+		},
+		{
+			Name:        "dns",
+			Description: "Look up the DNS records of a domain",
+			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "mode",
-					Description: "Choose 'add' to create new rule or 'remove' to delete existing rule",
+					Name:        "domain",
+					Description: "Domain, e.g. example.com",
+					Required:    true,
+					MaxLength:   253,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "type",
+					Description: "Only this record type (default: all)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "A", Value: "A"},
+						{Name: "AAAA", Value: "AAAA"},
+						{Name: "CNAME", Value: "CNAME"},
+						{Name: "MX", Value: "MX"},
+						{Name: "NS", Value: "NS"},
+						{Name: "TXT", Value: "TXT"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "meme",
+			Description: "Make a meme from a template, or manage this server's templates",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "make",
+					Description: "Put your captions on a meme template",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionString,
+							Name:         "template",
+							Description:  "Template to use, start typing to pick one",
+							Required:     true,
+							Autocomplete: true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "top",
+							Description: "Caption at the top",
+							Required:    true,
+							MaxLength:   maxMemeCaption,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "bottom",
+							Description: "Caption at the bottom",
+							Required:    false,
+							MaxLength:   maxMemeCaption,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "templates",
+					Description: "List the built-in and server templates",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Add a template for this server (Manage Server only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Template name, like kucing-kaget",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "image",
+							Description: "PNG or JPEG image of the template",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Remove one of this server's templates (Manage Server only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Template name, see /meme templates",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "movie",
+			Description: "Look up a movie or series: poster, ratings, synopsis and where to watch",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "title",
+					Description: "Movie or series title",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "type",
+					Description: "Only look for movies or series",
 					Required:    false,
 					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Movie", Value: "movie"},
+						{Name: "Series", Value: "series"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "year",
+					Description: "Release year, to tell remakes apart",
+					Required:    false,
+					MinValue:    &minMovieYear,
+					MaxValue:    2100,
+				},
+			},
+		},
+		{
+			Name:        "github",
+			Description: "Look up GitHub repositories and issues",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "repo",
+					Description: "Stars, language and latest release of a repository",
+					Options: []*discordgo.ApplicationCommandOption{
 						{
-							Name:  "add",
-							Value: "add",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "repo",
+							Description: "Repository as owner/name, e.g. bwmarrin/discordgo",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "issues",
+					Description: "Search a repository's issues and pull requests",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "repo",
+							Description: "Repository as owner/name",
+							Required:    true,
 						},
 						{
-							Name:  "remove",
-							Value: "remove",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "query",
+							Description: "Words to search for, GitHub qualifiers like is:open work too",
+							Required:    true,
+							MaxLength:   200,
 						},
 					},
 				},
 			},
 		},
 		{
-			Name:        "list_replies",
-			Description: "List all global auto-reply rules",
+			Name:        "run",
+			Description: "Run a code snippet",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "go",
+					Description: "Run Go code on the Go Playground",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "code",
+							Description: "A one-liner like fmt.Println(1 << 10), leave empty to paste a full program",
+							MaxLength:   maxRunCodeSize,
+						},
+					},
+				},
+			},
 		},
 		{
-			Name:        "help_reply",
-			Description: "Show help information for the auto-reply bot",
+			Name:        "search",
+			Description: "Search the web with DuckDuckGo",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "query",
+					Description: "What to search for, e.g. goroutine leak site:stackoverflow.com",
+					Required:    true,
+					MaxLength:   300,
+				},
+			},
 		},
 		{
-			Name:        "analisis",
-			Description: "Fetch latest news and analysis from Investing.com",
+			Name:        "color",
+			Description: "Preview a color and convert between hex, RGB and HSL",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "topic",
-					Description: "Topic to get news for",
+					Name:        "color",
+					Description: "Hex like #5865f2, RGB like 88,101,242, or a name like orange",
+					Required:    true,
+					MaxLength:   30,
+				},
+			},
+		},
+		{
+			Name:        "text",
+			Description: "Transform or encode some text",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "What to do with the text",
 					Required:    true,
 					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{Name: "Ringkasan Pasar", Value: "ringkasan pasar"},
-						{Name: "Analisis Teknikal", Value: "analisis teknikal"},
-						{Name: "Analisis Fundamental", Value: "analisis fundamental"},
-						{Name: "Opini", Value: "opini"},
-						{Name: "Ide Investasi", Value: "ide investasi"},
-						{Name: "Mata Uang Kripto", Value: "mata uang kripto"},
-						{Name: "Forex", Value: "forex"},
-						{Name: "Saham", Value: "saham"},
-						{Name: "Komoditas", Value: "komoditas"},
-						{Name: "Berita", Value: "berita"},
-						{Name: "Breaking News", Value: "breaking news"},
+						{Name: "UPPERCASE", Value: "upper"},
+						{Name: "mOcK cAsE", Value: "mock"},
+						{Name: "Zalgo", Value: "zalgo"},
+						{Name: "Base64 encode", Value: "base64_encode"},
+						{Name: "Base64 decode", Value: "base64_decode"},
+						{Name: "URL encode", Value: "url_encode"},
+						{Name: "Binary", Value: "binary"},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "content",
+					Description: "The text to transform",
+					Required:    true,
+					MaxLength:   maxTextInput,
+				},
 			},
 		},
 		{
-			Name:        "commands",
-			Description: "Show all available bot commands",
+			Name:        "hash",
+			Description: "Hash some text with md5, sha1 or sha256",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "algo",
+					Description: "Hash algorithm",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "md5", Value: "md5"},
+						{Name: "sha1", Value: "sha1"},
+						{Name: "sha256", Value: "sha256"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "The text to hash",
+					Required:    true,
+				},
+			},
 		},
 		{
-			Name:        "convert",
-			Description: "Convert currency amounts between different currencies",
+			Name:        "uuid",
+			Description: "Generate random UUIDs",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "How many (default 1)",
+					Required:    false,
+					MinValue:    &one,
+					MaxValue:    maxUUIDs,
+				},
+			},
+		},
+		{
+			Name:        "roll",
+			Description: "Roll dice",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "amount_and_currencies",
-					Description: "Amount and currencies to convert (e.g., '$500 idr', '1000jpy usd', '100eur gbp')",
+					Name:        "dice",
+					Description: "Dice like d20, 2d6 or 3d8+2 (default d6)",
+					MaxLength:   20,
+				},
+			},
+		},
+		{
+			Name:        "flip",
+			Description: "Flip a coin",
+		},
+		{
+			Name:        "choose",
+			Description: "Let the bot pick one of your options",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "options",
+					Description: "Options separated by commas, e.g. nasi goreng, mie ayam, bakso",
 					Required:    true,
 				},
 			},
 		},
+		{
+			Name:        "8ball",
+			Description: "Ask the magic 8-ball a yes or no question",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "question",
+					Description: "Your question",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "summarize",
+			Description: "Privately summarize this channel's recent messages to catch up",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "How many recent messages (default 50)",
+					Required:    false,
+					MinValue:    &ten,
+					MaxValue:    maxSummaryMessages,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "since",
+					Description: "Messages from this long ago, like 30m, 2h or 1d",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "transcript",
+			Description: "Export this channel's recent messages to a text or HTML file",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "How many recent messages (default 100)",
+					Required:    false,
+					MinValue:    &one,
+					MaxValue:    maxTranscriptMessages,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "since",
+					Description: "Messages from this long ago, like 30m, 2h or 1d",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "File format (default text)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "text", Value: "text"},
+						{Name: "HTML", Value: "html"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "faqsearch",
+			Description: "Search the server FAQ",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "question",
+					Description: "Your question",
+					Required:    true,
+					MaxLength:   300,
+				},
+			},
+		},
+		{
+			Name:        "confess",
+			Description: "Post an anonymous confession in this server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "text",
+					Description: "Your confession, posted without your name",
+					Required:    true,
+					MaxLength:   2000,
+				},
+			},
+		},
+		{
+			Name:                     "confessions",
+			Description:              "Look up who wrote a confession, for abuse reports",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "author",
+					Description: "Show the author of a confession (logged in the audit log)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "number",
+							Description: "Confession number",
+							Required:    true,
+							MinValue:    &one,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "word",
+			Description: "Today's Indonesian/English word of the day and quiz",
+		},
+		{
+			Name:        "trivia",
+			Description: "Multiple-choice trivia quiz with a server leaderboard",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Start a quiz in this channel",
+					Options: []*discordgo.ApplicationCommandOption{
+						triviaCategoryOption(),
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "rounds",
+							Description: "Number of questions (default 5)",
+							Required:    false,
+							MinValue:    &one,
+							MaxValue:    maxTriviaRounds,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "leaderboard",
+					Description: "Top trivia players in this server",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "schedule",
+					Description: "Start a quiz automatically every week (Manage Server only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Channel for the weekly quiz",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "day",
+							Description: "Day of the week",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Sunday", Value: 0},
+								{Name: "Monday", Value: 1},
+								{Name: "Tuesday", Value: 2},
+								{Name: "Wednesday", Value: 3},
+								{Name: "Thursday", Value: 4},
+								{Name: "Friday", Value: 5},
+								{Name: "Saturday", Value: 6},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "hour",
+							Description: "Hour to start, in the server timezone (0-23, default 20)",
+							Required:    false,
+							MinValue:    &zero,
+							MaxValue:    23,
+						},
+						triviaCategoryOption(),
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "enabled",
+							Description: "Set to false to stop the weekly quiz",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
 	}
-
-	for _, cmd := range commands {
-		_, err := s.ApplicationCommandCreate(s.State.User.ID, "", cmd)
-		if err != nil {
-			log.Printf("Cannot create command %v: %v", cmd.Name, err)
-		}
-	}
-
-	log.Printf("Registered %d slash commands", len(commands))
 }
 
 func main() {
@@ -1105,8 +22981,32 @@ func main() {
 		log.Fatal("Please set DISCORD_BOT_TOKEN environment variable")
 	}
 
-	// Load existing auto-replies
+	// Upgrade old data files, then load existing auto-replies and server settings
+	runMigrations()
 	loadAutoReplies()
+	loadPendingReplies()
+	loadGuildSettings()
+	loadGuildSecrets()
+	loadAPITokens()
+	loadAuditLogs()
+	loadBlocklist()
+	loadTriviaScores()
+	loadWordStreaks()
+	loadConfessions()
+	loadFAQs()
+	loadOnboarding()
+	loadProfiles()
+	loadReminders()
+	loadTasks()
+	loadMemeTemplates()
+	loadCustomCommands()
+	loadScheduledMessages()
+	loadFeedSubscriptions()
+	loadMonitors()
+	loadLiveSubscriptions()
+	loadPostedArticles()
+	loadBookmarks()
+	loadConversionHistory()
 
 	// Create Discord session
 	var err error
@@ -1118,10 +23018,17 @@ func main() {
 	// Set up event handlers
 	session.AddHandler(ready)
 	session.AddHandler(messageCreate)
+	session.AddHandler(messageUpdate)
 	session.AddHandler(interactionCreate)
+	session.AddHandler(guildCreate)
+	session.AddHandler(guildMemberAdd)
 
 	// Set intents (only use privileged intents if enabled in Discord Developer Portal)
-	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+	if os.Getenv("SERVER_MEMBERS_INTENT") != "" {
+		// Member joins, for the onboarding checklist
+		session.Identify.Intents |= discordgo.IntentsGuildMembers
+	}
 
 	// Open connection
 	err = session.Open()
@@ -1130,6 +23037,17 @@ func main() {
 	}
 	defer session.Close()
 
+	// Start background jobs, restarting them if they panic
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runSafely(ctx, "send queue", true, func(ctx context.Context) { runSendQueue(ctx, session) })
+	go runSafely(ctx, "scheduler", true, func(ctx context.Context) { runScheduler(ctx, session) })
+	go runSafely(ctx, "feed poller", true, func(ctx context.Context) { runFeedPoller(ctx, session) })
+	go runSafely(ctx, "self-test", false, func(ctx context.Context) { logSelfTest(session) })
+	if addr := os.Getenv("API_ADDR"); addr != "" {
+		go runSafely(ctx, "inbound API", true, func(ctx context.Context) { runAPIServer(ctx, session, addr) })
+	}
+
 	// Wait for interrupt signal
 	log.Println("Bot is running. Press CTRL+C to exit.")
 	c := make(chan os.Signal, 1)
@@ -1137,4 +23055,6 @@ func main() {
 	<-c
 
 	log.Println("Bot shutting down...")
+	cancel()
+	flushAutoReplies()
 }